@@ -0,0 +1,100 @@
+// Package main imports existing family data (kids, caregivers and historical star
+// transactions) from a JSON document or CSV files into the database. The report,
+// including row-level errors and the IDs generated for every external ID, is
+// printed as JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/importer"
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+)
+
+func main() {
+	file := flag.String("json", "", "JSON document with kids, caregivers and transactions arrays")
+	kids := flag.String("kids", "", "CSV file of kids (external_id,name,birthdate)")
+	caregivers := flag.String("caregivers", "", "CSV file of caregivers (external_id,name,email,relationship)")
+	transactions := flag.String("transactions", "", "CSV file of transactions (external_id,kid_id,type,amount,description[,created_at])")
+	dryRun := flag.Bool("dry-run", false, "validate everything, including database constraints, without importing")
+	flag.Parse()
+
+	data, err := readData(*file, map[string]string{
+		importer.Kids:         *kids,
+		importer.Caregivers:   *caregivers,
+		importer.Transactions: *transactions,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read import: %v\n", err)
+		os.Exit(2)
+	}
+
+	report, err := run(data, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to import: %v\n", err)
+		os.Exit(2)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readData reads the JSON document, or the CSV file of every entity given
+func readData(file string, csvFiles map[string]string) (*importer.Data, error) {
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return importer.ReadJSON(f)
+	}
+
+	data := &importer.Data{}
+	read := false
+	for _, entity := range []string{importer.Kids, importer.Caregivers, importer.Transactions} {
+		path := csvFiles[entity]
+		if path == "" {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		err = data.ReadCSV(entity, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		read = true
+	}
+	if !read {
+		return nil, fmt.Errorf("nothing to import, pass -json or at least one of -kids, -caregivers and -transactions")
+	}
+
+	return data, nil
+}
+
+// run connects to the database from the DB_* environment variables and imports the data
+func run(data *importer.Data, dryRun bool) (*importer.Report, error) {
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer repoManager.Close()
+
+	// Imported records are attributed to the importer in the audit log
+	ctx := audit.NewContext(context.Background(), audit.Source{Actor: "astras-import"})
+	return importer.Import(ctx, repoManager.Imports(), data, dryRun)
+}
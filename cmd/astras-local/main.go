@@ -0,0 +1,124 @@
+// Package main runs the kid, caregiver, star, family and migration services as a single
+// net/http server for local development and end-to-end tests. Routes match
+// template.yaml, so clients can use it in place of sam local start-api.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/httpadapter"
+	"github.com/lukasz/astras-mono-api/internal/middleware"
+	"github.com/lukasz/astras-mono-api/internal/notifications"
+	"github.com/lukasz/astras-mono-api/internal/openapi"
+	"github.com/lukasz/astras-mono-api/internal/services/audit"
+	"github.com/lukasz/astras-mono-api/internal/services/caregivers"
+	"github.com/lukasz/astras-mono-api/internal/services/families"
+	"github.com/lukasz/astras-mono-api/internal/services/kids"
+	"github.com/lukasz/astras-mono-api/internal/services/migrations"
+	"github.com/lukasz/astras-mono-api/internal/services/stars"
+)
+
+// service describes one service mounted on the local server
+type service struct {
+	name      string
+	routes    []handler.Route
+	handle    middleware.HandlerFunc
+	versioned bool // Whether the service is also served under the API version prefixes
+}
+
+func main() {
+	addr := flag.String("addr", defaultAddr(), "address to listen on")
+	flag.Parse()
+
+	if err := run(*addr); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to run local server: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run connects to the database once, mounts every service and serves HTTP on addr
+func run(addr string) error {
+	if err := handler.LoadSchedule(); err != nil {
+		return err
+	}
+
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer repoManager.Close()
+
+	if err := repoManager.Ping(context.Background()); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	sender, err := notifications.NewSenderFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize email sender: %w", err)
+	}
+
+	services := []service{
+		{kids.ServiceName, kids.Routes, kids.NewKidHandler(repoManager.Kids()).Handle, true},
+		{caregivers.ServiceName, caregivers.Routes, caregivers.NewCaregiverHandler(repoManager.Caregivers(), repoManager.Notifications()).Handle, true},
+		{stars.ServiceName, stars.Routes, stars.NewTransactionHandler(repoManager.Transactions()).Handle, true},
+		{families.ServiceName, families.Routes, families.NewFamilyHandler(repoManager.Families(), repoManager.Webhooks(), repoManager.Invitations(), repoManager.Categories(), sender).Handle, true},
+		{audit.ServiceName, audit.Routes, audit.NewAuditHandler(repoManager.Audit()).Handle, true},
+		{migrations.ServiceName, migrations.Routes, migrations.Handle, false},
+	}
+
+	mux := http.NewServeMux()
+	var all []handler.Route
+	for _, svc := range services {
+		logging, err := middleware.SetupLocalLogging(svc.name)
+		if err != nil {
+			return fmt.Errorf("failed to set up logging for %s: %w", svc.name, err)
+		}
+		defer logging.Close()
+
+		// Every service serves its own OpenAPI document, the local server
+		// serves a single document for all of them instead
+		var routes []handler.Route
+		for _, route := range svc.routes {
+			if route.Path != openapi.Path {
+				routes = append(routes, route)
+			}
+		}
+
+		mounted := routes
+		if svc.versioned {
+			mounted = handler.VersionedRoutes(routes)
+		}
+
+		httpadapter.Mount(mux, mounted, logging.WrapHandler(svc.handle))
+		for _, route := range mounted {
+			log.Printf("%-20s %-7s %s", svc.name, route.Method, route.Path)
+		}
+		all = append(all, routes...)
+	}
+
+	all = append(all, openapi.SpecRoute)
+	spec := []handler.Route{openapi.SpecRoute}
+	httpadapter.Mount(mux, handler.VersionedRoutes(spec), func(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+		return handler.ServeVersion(ctx, request, spec, handler.DefaultSchedule, func(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+			return openapi.Serve("astras-local", all, request.Version)
+		})
+	})
+
+	log.Printf("Listening on %s", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// defaultAddr listens on PORT when set, otherwise on port 3000 like sam local start-api
+func defaultAddr() string {
+	if port := os.Getenv("PORT"); port != "" {
+		return ":" + port
+	}
+	return ":3000"
+}
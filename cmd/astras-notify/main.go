@@ -0,0 +1,103 @@
+// Package main sends the scheduled notification emails to the caregivers who opted in:
+// weekly summaries, reminders of deletion requests waiting for confirmation and upcoming
+// birthdays. Run it daily, e.g. from cron: a weekly summary covers Monday to Sunday and
+// goes out with the first run of the next week, and every email is sent once, so running
+// it more often is safe. Large spend emails are sent by astras-relay -sink notifications.
+// The sender is configured by the NOTIFY_* and SMTP_* environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/notifications"
+	"github.com/lukasz/astras-mono-api/internal/reports"
+)
+
+// jobs are the scheduled notifications, by name
+var jobs = []string{"weekly-summary", "pending-approvals", "birthdays"}
+
+func main() {
+	jobNames := flag.String("jobs", strings.Join(jobs, ","), "notifications to send: "+strings.Join(jobs, ", ")+", comma-separated")
+	birthdayDays := flag.Int("birthday-days", notifications.DefaultBirthdayLeadDays, "announce birthdays this many days ahead")
+	flag.Parse()
+
+	selected := strings.Split(*jobNames, ",")
+	for i, name := range selected {
+		selected[i] = strings.TrimSpace(name)
+		if !contains(jobs, selected[i]) {
+			fmt.Fprintf(os.Stderr, "unknown job %q, use %s\n", name, strings.Join(jobs, ", "))
+			os.Exit(2)
+		}
+	}
+	if *birthdayDays < 0 {
+		fmt.Fprintln(os.Stderr, "birthday-days cannot be negative")
+		os.Exit(2)
+	}
+
+	if err := run(selected, *birthdayDays); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to send notifications: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run connects to the database from the DB_* environment variables and runs the jobs.
+// A failing job does not stop the others.
+func run(jobNames []string, birthdayDays int) error {
+	sender, err := notifications.NewSenderFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to configure email: %w", err)
+	}
+
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer repoManager.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	notifier := notifications.NewNotifier(repoManager.Notifications(), sender, reports.NewReporter(repoManager.Transactions()))
+
+	var failed []string
+	for _, name := range jobNames {
+		var sent int
+		var err error
+		switch name {
+		case "weekly-summary":
+			sent, err = notifier.SendWeeklySummaries(ctx)
+		case "pending-approvals":
+			sent, err = notifier.SendPendingApprovals(ctx)
+		case "birthdays":
+			sent, err = notifier.SendBirthdayReminders(ctx, birthdayDays)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: sent %d emails\n", name, sent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			failed = append(failed, name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%s failed", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// contains reports whether names includes name
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Package main permanently removes kids and caregivers that were soft deleted
+// longer ago than the retention period. Purging a kid also removes their star
+// transactions. Run it periodically, e.g. daily from cron or a scheduled task;
+// the counts of purged records are printed as JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+)
+
+// DefaultRetentionDays is how long soft-deleted records can be restored
+const DefaultRetentionDays = 30
+
+// Report lists what a purge removed
+type Report struct {
+	DeletedBefore time.Time `json:"deleted_before"` // Records soft deleted before this time were purged
+	Kids          int       `json:"kids"`
+	Caregivers    int       `json:"caregivers"`
+}
+
+func main() {
+	retentionDays := flag.Int("retention-days", DefaultRetentionDays, "keep soft-deleted records restorable for this many days")
+	flag.Parse()
+
+	if *retentionDays < 0 {
+		fmt.Fprintln(os.Stderr, "retention-days cannot be negative")
+		os.Exit(2)
+	}
+
+	report, err := run(time.Now().AddDate(0, 0, -*retentionDays))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to purge: %v\n", err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}
+
+// run connects to the database from the DB_* environment variables and purges
+// the records soft deleted before deletedBefore
+func run(deletedBefore time.Time) (*Report, error) {
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer repoManager.Close()
+
+	// Purged records are attributed to the purge job in the audit log
+	ctx := audit.NewContext(context.Background(), audit.Source{Actor: "astras-purge"})
+	report := &Report{DeletedBefore: deletedBefore.UTC()}
+
+	if report.Kids, err = repoManager.Kids().Purge(ctx, deletedBefore); err != nil {
+		return nil, err
+	}
+	if report.Caregivers, err = repoManager.Caregivers().Purge(ctx, deletedBefore); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,66 @@
+// Package main checks the materialized star totals of every kid (kid_balances) against
+// the transaction ledger they are derived from. Kids whose totals drifted are printed as
+// JSON; with -fix their totals are recomputed from the ledger. Run it periodically, e.g.
+// nightly from cron or a scheduled task. It exits with status 3 when drift was found and
+// not fixed, so the scheduler can alert.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+)
+
+// Report lists the kids whose totals drifted from the ledger
+type Report struct {
+	CheckedAt time.Time                  `json:"checked_at"`
+	Fixed     bool                       `json:"fixed"` // The drifted totals were overwritten with the ledger's
+	Drifts    []*interfaces.BalanceDrift `json:"drifts"`
+}
+
+func main() {
+	fix := flag.Bool("fix", false, "overwrite drifted totals with the ones recomputed from the ledger")
+	flag.Parse()
+
+	report, err := run(*fix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reconcile balances: %v\n", err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if len(report.Drifts) > 0 && !report.Fixed {
+		os.Exit(3)
+	}
+}
+
+// run connects to the database from the DB_* environment variables and compares, and
+// with fix repairs, the kids' totals
+func run(fix bool) (*Report, error) {
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer repoManager.Close()
+
+	ctx := audit.NewContext(context.Background(), audit.Source{Actor: "astras-reconcile"})
+	report := &Report{CheckedAt: time.Now().UTC(), Fixed: fix, Drifts: []*interfaces.BalanceDrift{}}
+
+	drifts, err := repoManager.Transactions().ReconcileBalances(ctx, fix)
+	if err != nil {
+		return nil, err
+	}
+	report.Drifts = append(report.Drifts, drifts...)
+
+	return report, nil
+}
@@ -0,0 +1,100 @@
+// Package main publishes the domain events of the outbox table to sinks: JSON lines
+// on stdout (-sink log), one webhook (-sink webhook -webhook-url ...) or the webhook
+// deliveries of the families' subscriptions (-sink webhooks, sent by astras-webhooks)
+// or large spend emails to caregivers (-sink notifications, see NOTIFY_* variables).
+// Several sinks are separated by commas. It polls until interrupted, or publishes the
+// pending events once with -once. Delivery is at least once; a failing sink is retried
+// on the next poll without skipping events, until the event is parked after
+// -max-attempts failures.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/notifications"
+	"github.com/lukasz/astras-mono-api/internal/outbox"
+	"github.com/lukasz/astras-mono-api/internal/webhooks"
+)
+
+func main() {
+	sinkNames := flag.String("sink", "log", "where to publish events: log, webhook, webhooks or notifications, comma-separated")
+	webhookURL := flag.String("webhook-url", "", "URL the webhook sink posts events to")
+	interval := flag.Duration("interval", outbox.DefaultInterval, "how often to poll the outbox")
+	batchSize := flag.Int("batch-size", outbox.DefaultBatchSize, "events claimed per batch")
+	maxAttempts := flag.Int("max-attempts", outbox.DefaultMaxAttempts, "failed attempts after which an event is parked, 0 to retry forever")
+	once := flag.Bool("once", false, "publish the pending events and exit")
+	flag.Parse()
+
+	for _, name := range strings.Split(*sinkNames, ",") {
+		switch strings.TrimSpace(name) {
+		case "log", "webhooks", "notifications":
+		case "webhook":
+			if *webhookURL == "" {
+				fmt.Fprintln(os.Stderr, "-webhook-url is required with -sink webhook")
+				os.Exit(2)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "unknown sink %q, use log, webhook, webhooks or notifications\n", name)
+			os.Exit(2)
+		}
+	}
+
+	if err := run(strings.Split(*sinkNames, ","), *webhookURL, *batchSize, *maxAttempts, *interval, *once); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to relay events: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run connects to the database from the DB_* environment variables and relays events
+// to the named sinks until interrupted
+func run(sinkNames []string, webhookURL string, batchSize, maxAttempts int, interval time.Duration, once bool) error {
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer repoManager.Close()
+
+	var sink outbox.Sinks
+	for _, name := range sinkNames {
+		switch strings.TrimSpace(name) {
+		case "log":
+			sink = append(sink, outbox.NewLogSink(os.Stdout))
+		case "webhook":
+			sink = append(sink, outbox.NewWebhookSink(webhookURL))
+		case "webhooks":
+			sink = append(sink, webhooks.NewSink(repoManager.Webhooks()))
+		case "notifications":
+			sender, err := notifications.NewSenderFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to configure email: %w", err)
+			}
+			sink = append(sink, notifications.NewNotifier(repoManager.Notifications(), sender))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	relay := outbox.NewRelay(repoManager.Outbox(), sink, batchSize).SetMaxAttempts(maxAttempts)
+	if once {
+		n, err := relay.Publish(ctx)
+		fmt.Fprintf(os.Stderr, "Published %d events\n", n)
+		return err
+	}
+
+	err = relay.Run(ctx, interval, func(err error) {
+		fmt.Fprintf(os.Stderr, "Failed to publish events, retrying: %v\n", err)
+	})
+	if err == context.Canceled {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+// Package main snapshots the star totals of every kid with transactions since their
+// previous snapshot, so balances at a point in time are computed from the latest
+// snapshot instead of the whole ledger. Run it periodically, e.g. daily from cron or a
+// scheduled task; by default it snapshots at the start of the current UTC day. The
+// number of snapshots taken is printed as JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+)
+
+// Report lists what a snapshot run took
+type Report struct {
+	TakenAt   time.Time `json:"taken_at"` // Snapshots count the transactions created before this time
+	Snapshots int       `json:"snapshots"`
+}
+
+func main() {
+	at := flag.String("at", "", "RFC 3339 time to snapshot at, in the past (default: start of the current UTC day)")
+	flag.Parse()
+
+	takenAt := time.Now().UTC().Truncate(24 * time.Hour)
+	if *at != "" {
+		parsed, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid at: %s\n", *at)
+			os.Exit(2)
+		}
+		takenAt = parsed.UTC()
+	}
+
+	report, err := run(takenAt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to snapshot balances: %v\n", err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}
+
+// run connects to the database from the DB_* environment variables and snapshots the
+// kids' totals at takenAt
+func run(takenAt time.Time) (*Report, error) {
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer repoManager.Close()
+
+	ctx := audit.NewContext(context.Background(), audit.Source{Actor: "astras-snapshot"})
+	report := &Report{TakenAt: takenAt}
+
+	if report.Snapshots, err = repoManager.Transactions().TakeBalanceSnapshots(ctx, takenAt); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
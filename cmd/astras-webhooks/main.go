@@ -0,0 +1,79 @@
+// Package main sends the webhook deliveries created by astras-relay -sink webhooks.
+// Every delivery is a JSON POST signed with the webhook's secret; failures are retried
+// with exponential backoff and webhooks that keep failing are disabled (see
+// webhooks.DefaultRetryPolicy). It polls until interrupted, or sends the due
+// deliveries once with -once.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/webhooks"
+)
+
+func main() {
+	interval := flag.Duration("interval", 5*time.Second, "how often to look for due deliveries")
+	batchSize := flag.Int("batch-size", 50, "deliveries claimed at once")
+	once := flag.Bool("once", false, "send the due deliveries and exit")
+	flag.Parse()
+
+	if *batchSize <= 0 {
+		fmt.Fprintln(os.Stderr, "batch-size must be greater than 0")
+		os.Exit(2)
+	}
+
+	if err := run(*batchSize, *interval, *once); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to send webhooks: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run connects to the database from the DB_* environment variables and sends due
+// deliveries until interrupted
+func run(batchSize int, interval time.Duration, once bool) error {
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer repoManager.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dispatcher := webhooks.NewDispatcher(repoManager.Webhooks(), webhooks.DefaultRetryPolicy)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep claiming while full batches come back
+		for {
+			n, err := dispatcher.Dispatch(ctx, batchSize)
+			if err != nil {
+				if once || ctx.Err() != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Failed to send webhooks, retrying: %v\n", err)
+				break
+			}
+			if n < batchSize {
+				break
+			}
+		}
+
+		if once {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
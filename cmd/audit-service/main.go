@@ -0,0 +1,52 @@
+// Package main implements the Audit Service AWS Lambda function.
+// This service lists the audit log of changes to kids, caregivers and transactions.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/lambdaadapter"
+	"github.com/lukasz/astras-mono-api/internal/services/audit"
+)
+
+var auditHandler *audit.AuditHandler
+
+// initHandler initializes the audit handler with database connection
+func initHandler() error {
+	// Read the API version deprecation and sunset dates before serving requests
+	if err := handler.LoadSchedule(); err != nil {
+		return err
+	}
+
+	// Create PostgreSQL repository manager from environment variables
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	// Test database connection
+	if err := repoManager.Ping(context.Background()); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// Create audit handler with repository
+	auditHandler = audit.NewAuditHandler(repoManager.Audit())
+	return nil
+}
+
+// main initializes the database connection and starts the AWS Lambda function handler.
+// This function is called when the Lambda container starts up.
+func main() {
+	// Initialize handler with database connection
+	if err := initHandler(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize audit service: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Start Lambda handler
+	lambdaadapter.Start(handler.VersionedRoutes(audit.Routes), auditHandler.Handle)
+}
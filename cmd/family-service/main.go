@@ -0,0 +1,60 @@
+// Package main implements the Family Service AWS Lambda function.
+// This service groups kids and caregivers into families, invites caregivers by
+// email and exports or erases a family's personal data on request.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/lukasz/astras-mono-api/internal/database/postgres"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/lambdaadapter"
+	"github.com/lukasz/astras-mono-api/internal/notifications"
+	"github.com/lukasz/astras-mono-api/internal/services/families"
+)
+
+var familyHandler *families.FamilyHandler
+
+// initHandler initializes the family handler with database connection
+func initHandler() error {
+	// Read the API version deprecation and sunset dates before serving requests
+	if err := handler.LoadSchedule(); err != nil {
+		return err
+	}
+
+	// Create PostgreSQL repository manager from environment variables
+	repoManager, err := postgres.NewRepositoryManagerFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	// Test database connection
+	if err := repoManager.Ping(context.Background()); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// Invitation and deletion confirmation tokens are emailed to their recipients
+	sender, err := notifications.NewSenderFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to initialize email sender: %w", err)
+	}
+
+	// Create family handler with repository
+	familyHandler = families.NewFamilyHandler(repoManager.Families(), repoManager.Webhooks(), repoManager.Invitations(), repoManager.Categories(), sender)
+	return nil
+}
+
+// main initializes the database connection and starts the AWS Lambda function handler.
+// This function is called when the Lambda container starts up.
+func main() {
+	// Initialize handler with database connection
+	if err := initHandler(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize family service: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Start Lambda handler
+	lambdaadapter.Start(handler.VersionedRoutes(families.Routes), familyHandler.Handle)
+}
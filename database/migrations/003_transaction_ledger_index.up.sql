@@ -0,0 +1,3 @@
+-- Ledger reads walk a kid's transactions in (created_at, id) order with keyset
+-- pagination; this index serves them without sorting the whole history
+CREATE INDEX idx_transactions_kid_ledger ON transactions(kid_id, created_at, id);
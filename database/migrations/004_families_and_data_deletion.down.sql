@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_data_deletions_family_id;
+DROP TABLE IF EXISTS data_deletions;
+
+DROP TRIGGER IF EXISTS update_families_updated_at ON families;
+
+DROP INDEX IF EXISTS idx_caregivers_family_id;
+DROP INDEX IF EXISTS idx_kids_family_id;
+
+ALTER TABLE caregivers DROP COLUMN IF EXISTS family_id;
+ALTER TABLE kids DROP COLUMN IF EXISTS family_id;
+
+DROP TABLE IF EXISTS families;
+
+DROP TYPE IF EXISTS deletion_status;
+DROP TYPE IF EXISTS deletion_mode;
@@ -0,0 +1,41 @@
+-- Families group the kids and caregivers of one household, so their data can be
+-- exported and erased together
+
+CREATE TYPE deletion_mode AS ENUM ('delete', 'anonymize');
+CREATE TYPE deletion_status AS ENUM ('pending', 'completed');
+
+CREATE TABLE families (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL CHECK (length(trim(name)) >= 2),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE kids ADD COLUMN family_id INTEGER REFERENCES families(id) ON DELETE SET NULL;
+ALTER TABLE caregivers ADD COLUMN family_id INTEGER REFERENCES families(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_kids_family_id ON kids(family_id);
+CREATE INDEX idx_caregivers_family_id ON caregivers(family_id);
+
+CREATE TRIGGER update_families_updated_at
+    BEFORE UPDATE ON families
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Audit record of every data deletion request. It holds no personal data and has
+-- no foreign key, so it outlives the family it describes.
+CREATE TABLE data_deletions (
+    id SERIAL PRIMARY KEY,
+    family_id INTEGER NOT NULL,
+    mode deletion_mode NOT NULL,
+    status deletion_status NOT NULL DEFAULT 'pending',
+    token_hash CHAR(64) NOT NULL,
+    kids INTEGER NOT NULL DEFAULT 0,
+    caregivers INTEGER NOT NULL DEFAULT 0,
+    transactions INTEGER NOT NULL DEFAULT 0,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_data_deletions_family_id ON data_deletions(family_id);
@@ -0,0 +1,12 @@
+-- Soft-deleted rows would come back as live records, so they are purged first
+DELETE FROM kids WHERE deleted_at IS NOT NULL;
+DELETE FROM caregivers WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS caregivers_email_key;
+ALTER TABLE caregivers ADD CONSTRAINT caregivers_email_key UNIQUE (email);
+
+DROP INDEX IF EXISTS idx_caregivers_deleted_at;
+DROP INDEX IF EXISTS idx_kids_deleted_at;
+
+ALTER TABLE caregivers DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE kids DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,15 @@
+-- Kids and caregivers are soft deleted: deleted_at hides them from every read
+-- while keeping the row, and a kid's transaction history, so they can be restored.
+-- Rows deleted longer ago than the retention period are purged by astras-purge.
+
+ALTER TABLE kids ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE caregivers ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- The purge job and the ?deleted=true listings only look at deleted rows
+CREATE INDEX idx_kids_deleted_at ON kids(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_caregivers_deleted_at ON caregivers(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- An email only has to be unique among caregivers that are not deleted, so a
+-- deleted caregiver can be added again
+ALTER TABLE caregivers DROP CONSTRAINT caregivers_email_key;
+CREATE UNIQUE INDEX caregivers_email_key ON caregivers(email) WHERE deleted_at IS NULL;
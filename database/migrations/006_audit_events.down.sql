@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS audit_transactions ON transactions;
+DROP TRIGGER IF EXISTS audit_caregivers ON caregivers;
+DROP TRIGGER IF EXISTS audit_kids ON kids;
+
+DROP FUNCTION IF EXISTS record_audit_event();
+
+DROP INDEX IF EXISTS idx_audit_events_created_at;
+DROP INDEX IF EXISTS idx_audit_events_actor;
+DROP INDEX IF EXISTS idx_audit_events_entity;
+DROP TABLE IF EXISTS audit_events;
+
+DROP TYPE IF EXISTS audit_action;
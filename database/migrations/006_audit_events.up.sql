@@ -0,0 +1,84 @@
+-- Audit log of every change to kids, caregivers and transactions. Rows are written
+-- by triggers, so no write path can skip them. The repositories tag each database
+-- transaction with the authenticated actor, the actor claimed by the client and the
+-- request ID of the API request (astras.actor, astras.claimed_actor and astras.request_id);
+-- other writes, e.g. from psql, are attributed to the database user.
+
+CREATE TYPE audit_action AS ENUM ('create', 'update', 'delete', 'restore', 'purge');
+
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    claimed_actor VARCHAR(255), -- Actor named by the client (X-Actor), not verified
+    action audit_action NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    before JSONB, -- Row before the change, NULL for create
+    after JSONB,  -- Row after the change, NULL for hard deletes
+    request_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events(entity, entity_id, created_at);
+CREATE INDEX idx_audit_events_actor ON audit_events(actor, created_at);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+
+-- record_audit_event writes one audit event per changed row. TG_ARGV[0] names the
+-- entity. Setting or clearing deleted_at is recorded as delete or restore, and
+-- hard-deleting a soft-deleted row as purge.
+CREATE OR REPLACE FUNCTION record_audit_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    event_action audit_action;
+    old_row JSONB;
+    new_row JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW);
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        event_action := 'create';
+    ELSIF TG_OP = 'DELETE' THEN
+        event_action := CASE WHEN old_row ->> 'deleted_at' IS NOT NULL THEN 'purge' ELSE 'delete' END;
+    ELSIF old_row ->> 'deleted_at' IS NULL AND new_row ->> 'deleted_at' IS NOT NULL THEN
+        event_action := 'delete';
+    ELSIF old_row ->> 'deleted_at' IS NOT NULL AND new_row ->> 'deleted_at' IS NULL THEN
+        event_action := 'restore';
+    ELSE
+        event_action := 'update';
+    END IF;
+
+    INSERT INTO audit_events (actor, claimed_actor, action, entity, entity_id, before, after, request_id)
+    VALUES (
+        COALESCE(NULLIF(current_setting('astras.actor', true), ''), current_user),
+        NULLIF(current_setting('astras.claimed_actor', true), ''),
+        event_action,
+        TG_ARGV[0],
+        (COALESCE(new_row, old_row) ->> 'id')::integer,
+        old_row,
+        new_row,
+        NULLIF(current_setting('astras.request_id', true), '')
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_kids
+    AFTER INSERT OR UPDATE OR DELETE ON kids
+    FOR EACH ROW
+    EXECUTE FUNCTION record_audit_event('kid');
+
+CREATE TRIGGER audit_caregivers
+    AFTER INSERT OR UPDATE OR DELETE ON caregivers
+    FOR EACH ROW
+    EXECUTE FUNCTION record_audit_event('caregiver');
+
+CREATE TRIGGER audit_transactions
+    AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION record_audit_event('transaction');
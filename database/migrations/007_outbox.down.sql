@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_outbox_entity;
+DROP INDEX IF EXISTS idx_outbox_pending;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,22 @@
+-- Transactional outbox of domain events. The repositories insert an event in the
+-- database transaction of the change it describes; the relay (cmd/astras-relay)
+-- publishes pending events in id order and marks them published. The relay claims
+-- events with a lease instead of holding row locks while it publishes them, and parks
+-- events that keep failing so they stop blocking later ones.
+
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP WITH TIME ZONE, -- NULL until the relay has published the event
+    attempts INTEGER NOT NULL DEFAULT 0,   -- Failed publish attempts
+    last_error TEXT,
+    locked_until TIMESTAMP WITH TIME ZONE, -- Claimed by a relay until then
+    parked_at TIMESTAMP WITH TIME ZONE     -- Set when the event ran out of attempts
+);
+
+CREATE INDEX idx_outbox_pending ON outbox(id) WHERE published_at IS NULL AND parked_at IS NULL;
+CREATE INDEX idx_outbox_entity ON outbox(entity, entity_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TRIGGER IF EXISTS update_webhooks_updated_at ON webhooks;
+DROP INDEX IF EXISTS idx_webhooks_family_id;
+DROP TABLE IF EXISTS webhooks;
+
+DROP TYPE IF EXISTS webhook_delivery_status;
@@ -0,0 +1,28 @@
+-- Email notification preferences of caregivers and the log of notifications sent.
+-- Caregivers without preferences receive no email: every notification is opt-in.
+
+CREATE TABLE notification_preferences (
+    caregiver_id INTEGER PRIMARY KEY REFERENCES caregivers(id) ON DELETE CASCADE,
+    weekly_summary BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_approvals BOOLEAN NOT NULL DEFAULT FALSE,
+    large_spends BOOLEAN NOT NULL DEFAULT FALSE,
+    large_spend_threshold INTEGER NOT NULL DEFAULT 20 CHECK (large_spend_threshold > 0),
+    upcoming_birthdays BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_notification_preferences_updated_at
+    BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE notifications_sent (
+    id BIGSERIAL PRIMARY KEY,
+    caregiver_id INTEGER NOT NULL REFERENCES caregivers(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    key VARCHAR(100) NOT NULL, -- What the notification was about, e.g. the week or the transaction
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    -- Scheduled jobs may run more than once, a caregiver is notified once
+    UNIQUE (caregiver_id, kind, key)
+);
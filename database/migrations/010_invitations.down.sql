@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS invitations;
+DROP INDEX IF EXISTS idx_caregivers_status;
+ALTER TABLE caregivers DROP COLUMN IF EXISTS status;
+DROP TYPE IF EXISTS invitation_status;
+DROP TYPE IF EXISTS caregiver_status;
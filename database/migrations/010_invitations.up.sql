@@ -0,0 +1,30 @@
+-- Caregivers prove they own their email address by accepting an invitation. Existing
+-- caregivers are kept active; new ones are invited until they accept.
+
+CREATE TYPE caregiver_status AS ENUM ('invited', 'active', 'revoked');
+CREATE TYPE invitation_status AS ENUM ('pending', 'accepted', 'revoked');
+
+ALTER TABLE caregivers ADD COLUMN status caregiver_status NOT NULL DEFAULT 'active';
+ALTER TABLE caregivers ALTER COLUMN status SET DEFAULT 'invited';
+
+CREATE INDEX idx_caregivers_status ON caregivers(status);
+
+CREATE TABLE invitations (
+    id SERIAL PRIMARY KEY,
+    family_id INTEGER NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL CHECK (length(trim(name)) >= 2),
+    relationship relationship_type NOT NULL,
+    invited_by INTEGER REFERENCES caregivers(id) ON DELETE SET NULL,
+    caregiver_id INTEGER REFERENCES caregivers(id) ON DELETE SET NULL, -- Set when accepted
+    status invitation_status NOT NULL DEFAULT 'pending',
+    token_hash CHAR(64) NOT NULL UNIQUE, -- SHA-256 of the token emailed to the invitee
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_invitations_family_id ON invitations(family_id);
+-- An email address has at most one pending invitation per family
+CREATE UNIQUE INDEX idx_invitations_pending_email ON invitations(family_id, lower(email)) WHERE status = 'pending';
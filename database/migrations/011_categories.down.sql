@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transactions_tags;
+DROP INDEX IF EXISTS idx_transactions_category_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tags;
+ALTER TABLE transactions DROP COLUMN IF EXISTS category_id;
+
+DROP TRIGGER IF EXISTS update_categories_updated_at ON categories;
+DROP INDEX IF EXISTS idx_categories_family_name;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,26 @@
+-- Categories defined by each family, e.g. homework or chores, and free-form tags on
+-- transactions, so stars can be counted by what they were for.
+
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    family_id INTEGER NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL CHECK (length(trim(name)) > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Category names are unique within a family, ignoring case
+CREATE UNIQUE INDEX idx_categories_family_name ON categories(family_id, lower(name));
+
+CREATE TRIGGER update_categories_updated_at
+    BEFORE UPDATE ON categories
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Deleting a category leaves its transactions uncategorized
+ALTER TABLE transactions
+    ADD COLUMN category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    ADD COLUMN tags JSONB NOT NULL DEFAULT '[]'; -- Array of lowercase tags
+
+CREATE INDEX idx_transactions_category_id ON transactions(category_id);
+CREATE INDEX idx_transactions_tags ON transactions USING GIN (tags);
@@ -0,0 +1,48 @@
+DROP INDEX IF EXISTS idx_caregivers_search;
+DROP INDEX IF EXISTS idx_kids_search;
+DROP INDEX IF EXISTS idx_transactions_search;
+ALTER TABLE caregivers DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE kids DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE transactions DROP COLUMN IF EXISTS search_vector;
+
+CREATE OR REPLACE FUNCTION record_audit_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    event_action audit_action;
+    old_row JSONB;
+    new_row JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW);
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        event_action := 'create';
+    ELSIF TG_OP = 'DELETE' THEN
+        event_action := CASE WHEN old_row ->> 'deleted_at' IS NOT NULL THEN 'purge' ELSE 'delete' END;
+    ELSIF old_row ->> 'deleted_at' IS NULL AND new_row ->> 'deleted_at' IS NOT NULL THEN
+        event_action := 'delete';
+    ELSIF old_row ->> 'deleted_at' IS NOT NULL AND new_row ->> 'deleted_at' IS NULL THEN
+        event_action := 'restore';
+    ELSE
+        event_action := 'update';
+    END IF;
+
+    INSERT INTO audit_events (actor, claimed_actor, action, entity, entity_id, before, after, request_id)
+    VALUES (
+        COALESCE(NULLIF(current_setting('astras.actor', true), ''), current_user),
+        NULLIF(current_setting('astras.claimed_actor', true), ''),
+        event_action,
+        TG_ARGV[0],
+        (COALESCE(new_row, old_row) ->> 'id')::integer,
+        old_row,
+        new_row,
+        NULLIF(current_setting('astras.request_id', true), '')
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
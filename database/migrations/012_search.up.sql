@@ -0,0 +1,58 @@
+-- Full-text search over transaction descriptions and kid and caregiver names.
+-- Descriptions are stemmed as English ("cleaned the garage" matches "clean garage");
+-- names are not stemmed. The vectors are generated columns, so every write path,
+-- including anonymization, keeps them current.
+
+ALTER TABLE transactions
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', description)) STORED;
+ALTER TABLE kids
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', name)) STORED;
+ALTER TABLE caregivers
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', name)) STORED;
+
+CREATE INDEX idx_transactions_search ON transactions USING GIN (search_vector);
+CREATE INDEX idx_kids_search ON kids USING GIN (search_vector);
+CREATE INDEX idx_caregivers_search ON caregivers USING GIN (search_vector);
+
+-- The search vectors are derived data, keep them out of the audit log
+CREATE OR REPLACE FUNCTION record_audit_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    event_action audit_action;
+    old_row JSONB;
+    new_row JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD) - 'search_vector';
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW) - 'search_vector';
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        event_action := 'create';
+    ELSIF TG_OP = 'DELETE' THEN
+        event_action := CASE WHEN old_row ->> 'deleted_at' IS NOT NULL THEN 'purge' ELSE 'delete' END;
+    ELSIF old_row ->> 'deleted_at' IS NULL AND new_row ->> 'deleted_at' IS NOT NULL THEN
+        event_action := 'delete';
+    ELSIF old_row ->> 'deleted_at' IS NOT NULL AND new_row ->> 'deleted_at' IS NULL THEN
+        event_action := 'restore';
+    ELSE
+        event_action := 'update';
+    END IF;
+
+    INSERT INTO audit_events (actor, claimed_actor, action, entity, entity_id, before, after, request_id)
+    VALUES (
+        COALESCE(NULLIF(current_setting('astras.actor', true), ''), current_user),
+        NULLIF(current_setting('astras.claimed_actor', true), ''),
+        event_action,
+        TG_ARGV[0],
+        (COALESCE(new_row, old_row) ->> 'id')::integer,
+        old_row,
+        new_row,
+        NULLIF(current_setting('astras.request_id', true), '')
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS transactions_kid_balance ON transactions;
+DROP FUNCTION IF EXISTS apply_kid_balance();
+DROP TABLE IF EXISTS kid_balances;
@@ -0,0 +1,69 @@
+-- Materialized star totals of each kid, so balances are read without summing the whole
+-- ledger. A trigger applies every insert, update and delete of a transaction in the same
+-- database transaction, so no write path can skip it; cmd/astras-reconcile compares the
+-- totals with the ledger. Kids without transactions have no row.
+
+CREATE TABLE kid_balances (
+    kid_id INTEGER PRIMARY KEY REFERENCES kids(id) ON DELETE CASCADE,
+    total_earned INTEGER NOT NULL DEFAULT 0,
+    total_spent INTEGER NOT NULL DEFAULT 0,
+    balance INTEGER GENERATED ALWAYS AS (total_earned - total_spent) STORED,
+    earn_count INTEGER NOT NULL DEFAULT 0,
+    spend_count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- apply_kid_balance removes the old row's stars from its kid's totals and adds the new
+-- row's. The old kid's row is only updated: when the kid itself is being deleted, its
+-- totals are deleted with it.
+CREATE OR REPLACE FUNCTION apply_kid_balance()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE kid_balances SET
+            total_earned = total_earned - CASE WHEN OLD.type = 'earn' THEN OLD.amount ELSE 0 END,
+            total_spent = total_spent - CASE WHEN OLD.type = 'spend' THEN OLD.amount ELSE 0 END,
+            earn_count = earn_count - CASE WHEN OLD.type = 'earn' THEN 1 ELSE 0 END,
+            spend_count = spend_count - CASE WHEN OLD.type = 'spend' THEN 1 ELSE 0 END,
+            updated_at = NOW()
+        WHERE kid_id = OLD.kid_id;
+    END IF;
+
+    IF TG_OP <> 'DELETE' THEN
+        INSERT INTO kid_balances AS b (kid_id, total_earned, total_spent, earn_count, spend_count)
+        VALUES (
+            NEW.kid_id,
+            CASE WHEN NEW.type = 'earn' THEN NEW.amount ELSE 0 END,
+            CASE WHEN NEW.type = 'spend' THEN NEW.amount ELSE 0 END,
+            CASE WHEN NEW.type = 'earn' THEN 1 ELSE 0 END,
+            CASE WHEN NEW.type = 'spend' THEN 1 ELSE 0 END
+        )
+        ON CONFLICT (kid_id) DO UPDATE SET
+            total_earned = b.total_earned + EXCLUDED.total_earned,
+            total_spent = b.total_spent + EXCLUDED.total_spent,
+            earn_count = b.earn_count + EXCLUDED.earn_count,
+            spend_count = b.spend_count + EXCLUDED.spend_count,
+            updated_at = NOW();
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Writes to the ledger wait until the existing totals are in place
+LOCK TABLE transactions IN SHARE MODE;
+
+CREATE TRIGGER transactions_kid_balance
+    AFTER INSERT OR UPDATE OF kid_id, type, amount OR DELETE ON transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION apply_kid_balance();
+
+INSERT INTO kid_balances (kid_id, total_earned, total_spent, earn_count, spend_count)
+SELECT
+    kid_id,
+    COALESCE(SUM(amount) FILTER (WHERE type = 'earn'), 0),
+    COALESCE(SUM(amount) FILTER (WHERE type = 'spend'), 0),
+    COUNT(*) FILTER (WHERE type = 'earn'),
+    COUNT(*) FILTER (WHERE type = 'spend')
+FROM transactions
+GROUP BY kid_id;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS transactions_balance_snapshots ON transactions;
+DROP FUNCTION IF EXISTS invalidate_balance_snapshots();
+DROP TABLE IF EXISTS kid_balance_snapshots;
@@ -0,0 +1,36 @@
+-- Periodic snapshots of each kid's star totals, so the balance at a point in time is the
+-- latest snapshot before it plus the few transactions since, however long the ledger.
+-- cmd/astras-snapshot takes them. A snapshot at taken_at covers the transactions created
+-- before it; writing a transaction created earlier invalidates the kid's later snapshots.
+
+CREATE TABLE kid_balance_snapshots (
+    kid_id INTEGER NOT NULL REFERENCES kids(id) ON DELETE CASCADE,
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    total_earned INTEGER NOT NULL,
+    total_spent INTEGER NOT NULL,
+    balance INTEGER GENERATED ALWAYS AS (total_earned - total_spent) STORED,
+    earn_count INTEGER NOT NULL,
+    spend_count INTEGER NOT NULL,
+    PRIMARY KEY (kid_id, taken_at)
+);
+
+-- invalidate_balance_snapshots drops the snapshots that counted, or should have counted,
+-- the changed transaction
+CREATE OR REPLACE FUNCTION invalidate_balance_snapshots()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        DELETE FROM kid_balance_snapshots WHERE kid_id = OLD.kid_id AND taken_at > OLD.created_at;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        DELETE FROM kid_balance_snapshots WHERE kid_id = NEW.kid_id AND taken_at > NEW.created_at;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_balance_snapshots
+    AFTER INSERT OR UPDATE OF kid_id, type, amount, created_at OR DELETE ON transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION invalidate_balance_snapshots();
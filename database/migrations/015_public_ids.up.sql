@@ -0,0 +1,98 @@
+-- Public identifiers of families, kids, caregivers and transactions. The API addresses records by
+-- these random UUIDs so sequential IDs, which reveal record counts and can be enumerated,
+-- stay internal. Adding a column with a volatile default rewrites the table and gives
+-- every existing row its own UUID, without firing the update and audit triggers.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+ALTER TABLE families ADD COLUMN public_id UUID NOT NULL DEFAULT uuid_generate_v4();
+ALTER TABLE kids ADD COLUMN public_id UUID NOT NULL DEFAULT uuid_generate_v4();
+ALTER TABLE caregivers ADD COLUMN public_id UUID NOT NULL DEFAULT uuid_generate_v4();
+ALTER TABLE transactions ADD COLUMN public_id UUID NOT NULL DEFAULT uuid_generate_v4();
+
+CREATE UNIQUE INDEX idx_families_public_id ON families(public_id);
+CREATE UNIQUE INDEX idx_kids_public_id ON kids(public_id);
+CREATE UNIQUE INDEX idx_caregivers_public_id ON caregivers(public_id);
+CREATE UNIQUE INDEX idx_transactions_public_id ON transactions(public_id);
+
+-- Audit events expose public IDs only. entity_public_id identifies the changed record
+-- and the before/after rows carry public IDs instead of internal ones; entity_id keeps
+-- the internal ID, which the family data deletion scrubs the log by.
+
+ALTER TABLE audit_events ADD COLUMN entity_public_id UUID;
+
+CREATE INDEX idx_audit_events_public_entity ON audit_events(entity, entity_public_id, created_at);
+
+-- public_audit_row replaces the internal IDs of an audited row: id becomes the row's
+-- public_id, kid_id the kid's public ID, and family_id is dropped. The derived search
+-- vector is left out too.
+CREATE OR REPLACE FUNCTION public_audit_row(row_data JSONB)
+RETURNS JSONB AS $$
+BEGIN
+    IF row_data IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    row_data := jsonb_set(row_data - 'search_vector' - 'family_id', '{id}', COALESCE(row_data -> 'public_id', 'null'));
+    row_data := row_data - 'public_id';
+    IF row_data ? 'kid_id' THEN
+        row_data := jsonb_set(row_data, '{kid_id}',
+            COALESCE(to_jsonb((SELECT public_id FROM kids WHERE id = (row_data ->> 'kid_id')::integer)), 'null'));
+    END IF;
+
+    RETURN row_data;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+CREATE OR REPLACE FUNCTION record_audit_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    event_action audit_action;
+    old_row JSONB;
+    new_row JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW);
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        event_action := 'create';
+    ELSIF TG_OP = 'DELETE' THEN
+        event_action := CASE WHEN old_row ->> 'deleted_at' IS NOT NULL THEN 'purge' ELSE 'delete' END;
+    ELSIF old_row ->> 'deleted_at' IS NULL AND new_row ->> 'deleted_at' IS NOT NULL THEN
+        event_action := 'delete';
+    ELSIF old_row ->> 'deleted_at' IS NOT NULL AND new_row ->> 'deleted_at' IS NULL THEN
+        event_action := 'restore';
+    ELSE
+        event_action := 'update';
+    END IF;
+
+    INSERT INTO audit_events (actor, claimed_actor, action, entity, entity_id, entity_public_id, before, after, request_id)
+    VALUES (
+        COALESCE(NULLIF(current_setting('astras.actor', true), ''), current_user),
+        NULLIF(current_setting('astras.claimed_actor', true), ''),
+        event_action,
+        TG_ARGV[0],
+        (COALESCE(new_row, old_row) ->> 'id')::integer,
+        (COALESCE(new_row, old_row) ->> 'public_id')::uuid,
+        public_audit_row(old_row),
+        public_audit_row(new_row),
+        NULLIF(current_setting('astras.request_id', true), '')
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Existing events of records that still exist get their public ID; events of purged
+-- records have none and keep a null id in their rows
+UPDATE audit_events a SET entity_public_id = k.public_id FROM kids k WHERE a.entity = 'kid' AND k.id = a.entity_id;
+UPDATE audit_events a SET entity_public_id = c.public_id FROM caregivers c WHERE a.entity = 'caregiver' AND c.id = a.entity_id;
+UPDATE audit_events a SET entity_public_id = t.public_id FROM transactions t WHERE a.entity = 'transaction' AND t.id = a.entity_id;
+
+UPDATE audit_events SET
+    before = public_audit_row(before || jsonb_build_object('public_id', entity_public_id)),
+    after = public_audit_row(after || jsonb_build_object('public_id', entity_public_id));
@@ -7,51 +7,144 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 -- Create enum types
 CREATE TYPE relationship_type AS ENUM ('parent', 'guardian', 'grandparent', 'relative', 'caregiver');
 CREATE TYPE transaction_type AS ENUM ('earn', 'spend');
+CREATE TYPE deletion_mode AS ENUM ('delete', 'anonymize');
+CREATE TYPE deletion_status AS ENUM ('pending', 'completed');
+CREATE TYPE audit_action AS ENUM ('create', 'update', 'delete', 'restore', 'purge');
+CREATE TYPE caregiver_status AS ENUM ('invited', 'active', 'revoked');
+CREATE TYPE invitation_status AS ENUM ('pending', 'accepted', 'revoked');
+
+-- Families table (households whose data is exported and erased together)
+CREATE TABLE families (
+    id SERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT uuid_generate_v4(), -- Identifier exposed by the API, the serial ID stays internal
+    name VARCHAR(100) NOT NULL CHECK (length(trim(name)) >= 2),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Categories of transactions, defined by each family
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    family_id INTEGER NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL CHECK (length(trim(name)) > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
 
 -- Kids table
 CREATE TABLE kids (
     id SERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT uuid_generate_v4(), -- Identifier exposed by the API, the serial ID stays internal
     name VARCHAR(100) NOT NULL CHECK (length(trim(name)) >= 2),
     birthdate DATE NOT NULL,
+    family_id INTEGER REFERENCES families(id) ON DELETE SET NULL,
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', name)) STORED, -- Full-text search
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE -- Soft deletion, purged after the retention period
 );
 
 -- Caregivers table
 CREATE TABLE caregivers (
     id SERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT uuid_generate_v4(), -- Identifier exposed by the API, the serial ID stays internal
     name VARCHAR(100) NOT NULL CHECK (length(trim(name)) >= 2),
-    email VARCHAR(255) NOT NULL UNIQUE,
+    email VARCHAR(255) NOT NULL,
     relationship relationship_type NOT NULL,
+    family_id INTEGER REFERENCES families(id) ON DELETE SET NULL,
+    status caregiver_status NOT NULL DEFAULT 'invited', -- Active once the email is verified by accepting an invitation
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', name)) STORED, -- Full-text search
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE -- Soft deletion, purged after the retention period
 );
 
 -- Star transactions table
 CREATE TABLE transactions (
     id SERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT uuid_generate_v4(), -- Identifier exposed by the API, the serial ID stays internal
     kid_id INTEGER NOT NULL REFERENCES kids(id) ON DELETE CASCADE,
     type transaction_type NOT NULL,
     amount INTEGER NOT NULL CHECK (amount >= 1 AND amount <= 100),
     description VARCHAR(255) NOT NULL CHECK (length(trim(description)) > 0),
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL, -- Uncategorized when NULL
+    tags JSONB NOT NULL DEFAULT '[]', -- Array of lowercase tags
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', description)) STORED, -- Full-text search, stemmed
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Data deletion audit records (no personal data, no foreign key: they outlive the family)
+CREATE TABLE data_deletions (
+    id SERIAL PRIMARY KEY,
+    family_id INTEGER NOT NULL,
+    mode deletion_mode NOT NULL,
+    status deletion_status NOT NULL DEFAULT 'pending',
+    token_hash CHAR(64) NOT NULL,
+    kids INTEGER NOT NULL DEFAULT 0,
+    caregivers INTEGER NOT NULL DEFAULT 0,
+    transactions INTEGER NOT NULL DEFAULT 0,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Audit log of every change to kids, caregivers and transactions (written by triggers)
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    claimed_actor VARCHAR(255), -- Actor named by the client (X-Actor), not verified
+    action audit_action NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL, -- Internal ID, the API shows entity_public_id
+    entity_public_id UUID,      -- NULL only for events of records purged before public IDs existed
+    before JSONB, -- Row before the change with public IDs, NULL for create
+    after JSONB,  -- Row after the change with public IDs, NULL for hard deletes
+    request_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
 -- Indexes for better query performance
+CREATE UNIQUE INDEX idx_families_public_id ON families(public_id);
+CREATE UNIQUE INDEX idx_kids_public_id ON kids(public_id);
 CREATE INDEX idx_kids_name ON kids(name);
 CREATE INDEX idx_kids_birthdate ON kids(birthdate);
 CREATE INDEX idx_kids_created_at ON kids(created_at);
+CREATE INDEX idx_kids_family_id ON kids(family_id);
+CREATE INDEX idx_kids_deleted_at ON kids(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_kids_search ON kids USING GIN (search_vector);
 
+CREATE UNIQUE INDEX idx_caregivers_public_id ON caregivers(public_id);
 CREATE INDEX idx_caregivers_name ON caregivers(name);
 CREATE INDEX idx_caregivers_email ON caregivers(email);
 CREATE INDEX idx_caregivers_relationship ON caregivers(relationship);
 CREATE INDEX idx_caregivers_created_at ON caregivers(created_at);
+CREATE INDEX idx_caregivers_family_id ON caregivers(family_id);
+CREATE INDEX idx_caregivers_status ON caregivers(status);
+CREATE INDEX idx_caregivers_deleted_at ON caregivers(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_caregivers_search ON caregivers USING GIN (search_vector);
+-- Emails are unique among caregivers that are not deleted
+CREATE UNIQUE INDEX caregivers_email_key ON caregivers(email) WHERE deleted_at IS NULL;
 
+CREATE UNIQUE INDEX idx_transactions_public_id ON transactions(public_id);
 CREATE INDEX idx_transactions_kid_id ON transactions(kid_id);
 CREATE INDEX idx_transactions_type ON transactions(type);
 CREATE INDEX idx_transactions_created_at ON transactions(created_at);
 CREATE INDEX idx_transactions_kid_type ON transactions(kid_id, type);
+CREATE INDEX idx_transactions_kid_ledger ON transactions(kid_id, created_at, id);
+CREATE INDEX idx_transactions_category_id ON transactions(category_id);
+CREATE INDEX idx_transactions_tags ON transactions USING GIN (tags);
+CREATE INDEX idx_transactions_search ON transactions USING GIN (search_vector);
+
+-- Category names are unique within a family, ignoring case
+CREATE UNIQUE INDEX idx_categories_family_name ON categories(family_id, lower(name));
+
+CREATE INDEX idx_data_deletions_family_id ON data_deletions(family_id);
+
+CREATE INDEX idx_audit_events_entity ON audit_events(entity, entity_id, created_at);
+CREATE INDEX idx_audit_events_public_entity ON audit_events(entity, entity_public_id, created_at);
+CREATE INDEX idx_audit_events_actor ON audit_events(actor, created_at);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
 
 -- Function to automatically update updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -63,6 +156,16 @@ END;
 $$ language 'plpgsql';
 
 -- Triggers to automatically update updated_at
+CREATE TRIGGER update_families_updated_at 
+    BEFORE UPDATE ON families 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_categories_updated_at
+    BEFORE UPDATE ON categories
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
 CREATE TRIGGER update_kids_updated_at 
     BEFORE UPDATE ON kids 
     FOR EACH ROW 
@@ -78,16 +181,296 @@ CREATE TRIGGER update_transactions_updated_at
     FOR EACH ROW 
     EXECUTE FUNCTION update_updated_at_column();
 
+-- public_audit_row replaces the internal IDs of an audited row: id becomes the row's
+-- public_id, kid_id the kid's public ID, and family_id is dropped. The derived search
+-- vector is left out too.
+CREATE OR REPLACE FUNCTION public_audit_row(row_data JSONB)
+RETURNS JSONB AS $$
+BEGIN
+    IF row_data IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    row_data := jsonb_set(row_data - 'search_vector' - 'family_id', '{id}', COALESCE(row_data -> 'public_id', 'null'));
+    row_data := row_data - 'public_id';
+    IF row_data ? 'kid_id' THEN
+        row_data := jsonb_set(row_data, '{kid_id}',
+            COALESCE(to_jsonb((SELECT public_id FROM kids WHERE id = (row_data ->> 'kid_id')::integer)), 'null'));
+    END IF;
+
+    RETURN row_data;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- record_audit_event writes one audit event per changed row. TG_ARGV[0] names the
+-- entity. Setting or clearing deleted_at is recorded as delete or restore, and
+-- hard-deleting a soft-deleted row as purge. Rows are stored with public IDs only.
+CREATE OR REPLACE FUNCTION record_audit_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    event_action audit_action;
+    old_row JSONB;
+    new_row JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW);
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        event_action := 'create';
+    ELSIF TG_OP = 'DELETE' THEN
+        event_action := CASE WHEN old_row ->> 'deleted_at' IS NOT NULL THEN 'purge' ELSE 'delete' END;
+    ELSIF old_row ->> 'deleted_at' IS NULL AND new_row ->> 'deleted_at' IS NOT NULL THEN
+        event_action := 'delete';
+    ELSIF old_row ->> 'deleted_at' IS NOT NULL AND new_row ->> 'deleted_at' IS NULL THEN
+        event_action := 'restore';
+    ELSE
+        event_action := 'update';
+    END IF;
+
+    INSERT INTO audit_events (actor, claimed_actor, action, entity, entity_id, entity_public_id, before, after, request_id)
+    VALUES (
+        COALESCE(NULLIF(current_setting('astras.actor', true), ''), current_user),
+        NULLIF(current_setting('astras.claimed_actor', true), ''),
+        event_action,
+        TG_ARGV[0],
+        (COALESCE(new_row, old_row) ->> 'id')::integer,
+        (COALESCE(new_row, old_row) ->> 'public_id')::uuid,
+        public_audit_row(old_row),
+        public_audit_row(new_row),
+        NULLIF(current_setting('astras.request_id', true), '')
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_kids
+    AFTER INSERT OR UPDATE OR DELETE ON kids
+    FOR EACH ROW
+    EXECUTE FUNCTION record_audit_event('kid');
+
+CREATE TRIGGER audit_caregivers
+    AFTER INSERT OR UPDATE OR DELETE ON caregivers
+    FOR EACH ROW
+    EXECUTE FUNCTION record_audit_event('caregiver');
+
+CREATE TRIGGER audit_transactions
+    AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION record_audit_event('transaction');
+
+-- Transactional outbox of domain events, published by cmd/astras-relay
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP WITH TIME ZONE, -- NULL until the relay has published the event
+    attempts INTEGER NOT NULL DEFAULT 0,   -- Failed publish attempts
+    last_error TEXT,
+    locked_until TIMESTAMP WITH TIME ZONE, -- Claimed by a relay until then
+    parked_at TIMESTAMP WITH TIME ZONE     -- Set when the event ran out of attempts
+);
+
+CREATE INDEX idx_outbox_pending ON outbox(id) WHERE published_at IS NULL AND parked_at IS NULL;
+CREATE INDEX idx_outbox_entity ON outbox(entity, entity_id);
+
+-- Webhook subscriptions of families and the log of deliveries to them
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'failed');
+
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    family_id INTEGER NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    url VARCHAR(2048) NOT NULL,
+    event_types JSONB NOT NULL, -- Array of subscribed event types
+    secret VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    disabled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhooks_family_id ON webhooks(family_id);
+
+CREATE TRIGGER update_webhooks_updated_at
+    BEFORE UPDATE ON webhooks
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL, -- Outbox ID of the event
+    event_type VARCHAR(100) NOT NULL,
+    body TEXT NOT NULL,       -- JSON posted, kept byte for byte so retries carry the same body
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE, -- NULL once the delivery succeeded or failed
+    last_status_code INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    -- The relay publishes at least once, an event is delivered to a webhook once
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at);
+
+-- Email notification preferences of caregivers, opt-in, and the notifications sent
+CREATE TABLE notification_preferences (
+    caregiver_id INTEGER PRIMARY KEY REFERENCES caregivers(id) ON DELETE CASCADE,
+    weekly_summary BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_approvals BOOLEAN NOT NULL DEFAULT FALSE,
+    large_spends BOOLEAN NOT NULL DEFAULT FALSE,
+    large_spend_threshold INTEGER NOT NULL DEFAULT 20 CHECK (large_spend_threshold > 0),
+    upcoming_birthdays BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_notification_preferences_updated_at
+    BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE notifications_sent (
+    id BIGSERIAL PRIMARY KEY,
+    caregiver_id INTEGER NOT NULL REFERENCES caregivers(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    key VARCHAR(100) NOT NULL, -- What the notification was about, e.g. the week or the transaction
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    -- Scheduled jobs may run more than once, a caregiver is notified once
+    UNIQUE (caregiver_id, kind, key)
+);
+
+-- Invitations of email addresses to a family; accepting one creates or links the caregiver
+CREATE TABLE invitations (
+    id SERIAL PRIMARY KEY,
+    family_id INTEGER NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL CHECK (length(trim(name)) >= 2),
+    relationship relationship_type NOT NULL,
+    invited_by INTEGER REFERENCES caregivers(id) ON DELETE SET NULL,
+    caregiver_id INTEGER REFERENCES caregivers(id) ON DELETE SET NULL, -- Set when accepted
+    status invitation_status NOT NULL DEFAULT 'pending',
+    token_hash CHAR(64) NOT NULL UNIQUE, -- SHA-256 of the token emailed to the invitee
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_invitations_family_id ON invitations(family_id);
+-- An email address has at most one pending invitation per family
+CREATE UNIQUE INDEX idx_invitations_pending_email ON invitations(family_id, lower(email)) WHERE status = 'pending';
+
+-- Materialized star totals of each kid, kept current by a trigger on transactions and
+-- checked against the ledger by cmd/astras-reconcile. Kids without transactions have no row.
+CREATE TABLE kid_balances (
+    kid_id INTEGER PRIMARY KEY REFERENCES kids(id) ON DELETE CASCADE,
+    total_earned INTEGER NOT NULL DEFAULT 0,
+    total_spent INTEGER NOT NULL DEFAULT 0,
+    balance INTEGER GENERATED ALWAYS AS (total_earned - total_spent) STORED,
+    earn_count INTEGER NOT NULL DEFAULT 0,
+    spend_count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- apply_kid_balance removes the old row's stars from its kid's totals and adds the new
+-- row's. The old kid's row is only updated: when the kid itself is being deleted, its
+-- totals are deleted with it.
+CREATE OR REPLACE FUNCTION apply_kid_balance()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE kid_balances SET
+            total_earned = total_earned - CASE WHEN OLD.type = 'earn' THEN OLD.amount ELSE 0 END,
+            total_spent = total_spent - CASE WHEN OLD.type = 'spend' THEN OLD.amount ELSE 0 END,
+            earn_count = earn_count - CASE WHEN OLD.type = 'earn' THEN 1 ELSE 0 END,
+            spend_count = spend_count - CASE WHEN OLD.type = 'spend' THEN 1 ELSE 0 END,
+            updated_at = NOW()
+        WHERE kid_id = OLD.kid_id;
+    END IF;
+
+    IF TG_OP <> 'DELETE' THEN
+        INSERT INTO kid_balances AS b (kid_id, total_earned, total_spent, earn_count, spend_count)
+        VALUES (
+            NEW.kid_id,
+            CASE WHEN NEW.type = 'earn' THEN NEW.amount ELSE 0 END,
+            CASE WHEN NEW.type = 'spend' THEN NEW.amount ELSE 0 END,
+            CASE WHEN NEW.type = 'earn' THEN 1 ELSE 0 END,
+            CASE WHEN NEW.type = 'spend' THEN 1 ELSE 0 END
+        )
+        ON CONFLICT (kid_id) DO UPDATE SET
+            total_earned = b.total_earned + EXCLUDED.total_earned,
+            total_spent = b.total_spent + EXCLUDED.total_spent,
+            earn_count = b.earn_count + EXCLUDED.earn_count,
+            spend_count = b.spend_count + EXCLUDED.spend_count,
+            updated_at = NOW();
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_kid_balance
+    AFTER INSERT OR UPDATE OF kid_id, type, amount OR DELETE ON transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION apply_kid_balance();
+
+-- Periodic snapshots of each kid's totals taken by cmd/astras-snapshot, so balances at a
+-- point in time do not sum the whole ledger. Writing a transaction created before a
+-- snapshot invalidates it.
+CREATE TABLE kid_balance_snapshots (
+    kid_id INTEGER NOT NULL REFERENCES kids(id) ON DELETE CASCADE,
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    total_earned INTEGER NOT NULL,
+    total_spent INTEGER NOT NULL,
+    balance INTEGER GENERATED ALWAYS AS (total_earned - total_spent) STORED,
+    earn_count INTEGER NOT NULL,
+    spend_count INTEGER NOT NULL,
+    PRIMARY KEY (kid_id, taken_at)
+);
+
+-- invalidate_balance_snapshots drops the snapshots that counted, or should have counted,
+-- the changed transaction
+CREATE OR REPLACE FUNCTION invalidate_balance_snapshots()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        DELETE FROM kid_balance_snapshots WHERE kid_id = OLD.kid_id AND taken_at > OLD.created_at;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        DELETE FROM kid_balance_snapshots WHERE kid_id = NEW.kid_id AND taken_at > NEW.created_at;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_balance_snapshots
+    AFTER INSERT OR UPDATE OF kid_id, type, amount, created_at OR DELETE ON transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION invalidate_balance_snapshots();
+
 -- Sample data for development/testing
 INSERT INTO kids (name, birthdate) VALUES 
     ('Alice Johnson', '2015-03-15'),
     ('Bob Smith', '2012-07-22'),
     ('Emma Wilson', '2017-11-08');
 
-INSERT INTO caregivers (name, email, relationship) VALUES 
-    ('Sarah Johnson', 'sarah.johnson@example.com', 'parent'),
-    ('Mike Smith', 'mike.smith@example.com', 'guardian'),
-    ('Grace Wilson', 'grace.wilson@example.com', 'grandparent');
+INSERT INTO caregivers (name, email, relationship, status) VALUES 
+    ('Sarah Johnson', 'sarah.johnson@example.com', 'parent', 'active'),
+    ('Mike Smith', 'mike.smith@example.com', 'guardian', 'active'),
+    ('Grace Wilson', 'grace.wilson@example.com', 'grandparent', 'active');
 
 INSERT INTO transactions (kid_id, type, amount, description) VALUES 
     (1, 'earn', 5, 'Completed homework perfectly'),
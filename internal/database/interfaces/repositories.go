@@ -5,12 +5,59 @@ package interfaces
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
 	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/category"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+	"github.com/lukasz/astras-mono-api/internal/models/invitation"
 	"github.com/lukasz/astras-mono-api/internal/models/kid"
+	"github.com/lukasz/astras-mono-api/internal/models/notification"
 	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+	"github.com/lukasz/astras-mono-api/internal/models/webhook"
 )
 
+// ErrNotFound is wrapped by the errors of lookups and writes whose record does not exist
+// (or is soft deleted), e.g. "kid with id 3 not found"; check it with errors.Is.
+var ErrNotFound = errors.New("not found")
+
+// ErrVersionConflict is returned by conditional writes when the stored record no longer
+// has any of the versions the caller expected, i.e. it was modified concurrently.
+var ErrVersionConflict = errors.New("resource has been modified")
+
+// ErrNotDeleted is returned by Restore when the record exists but is not soft deleted
+var ErrNotDeleted = errors.New("resource is not deleted")
+
+// ErrDeletionNotPending is returned when a data deletion request cannot be confirmed
+// because it was already carried out, has expired or the confirmation token is wrong.
+var ErrDeletionNotPending = errors.New("deletion request is not pending")
+
+// ErrInvitationNotPending is returned when an invitation cannot be accepted or revoked
+// because it was already accepted or revoked, has expired or the token is wrong.
+var ErrInvitationNotPending = errors.New("invitation is not pending")
+
+// ErrInviterNotAllowed is returned when the caregiver sending an invitation is not an
+// active parent or guardian of the family.
+var ErrInviterNotAllowed = errors.New("only active parents and guardians of the family can invite caregivers")
+
+// ErrAlreadyInvited is returned when the email address already has a pending invitation to the family
+var ErrAlreadyInvited = errors.New("email address has already been invited to the family")
+
+// ErrCategoryExists is returned when a family already has a category with the name
+var ErrCategoryExists = errors.New("family already has a category with this name")
+
+// ErrCaregiverInOtherFamily is returned when accepting an invitation would take an
+// active caregiver away from another family.
+var ErrCaregiverInOtherFamily = errors.New("caregiver is active in another family")
+
+// ErrBatchRolledBack is returned by atomic batch writes when an item failed and
+// the whole batch was rolled back. The item results say which items failed.
+var ErrBatchRolledBack = errors.New("batch has been rolled back")
+
 // KidRepository defines the interface for Kid data persistence operations.
 // Implementations should handle database interactions, error handling, and data validation.
 type KidRepository interface {
@@ -20,17 +67,32 @@ type KidRepository interface {
 	// GetByID retrieves a kid by their unique identifier
 	GetByID(ctx context.Context, id int) (*kid.Kid, error)
 	
+	// ResolveID returns the internal ID of the kid with the given public ID, including soft-deleted kids
+	ResolveID(ctx context.Context, publicID string) (int, error)
+	
 	// GetAll retrieves all kids from the repository
 	GetAll(ctx context.Context) ([]*kid.Kid, error)
 	
-	// Update modifies an existing kid's information
-	Update(ctx context.Context, kid *kid.Kid) (*kid.Kid, error)
+	// Update modifies an existing kid's information. When ifMatch versions (updated_at
+	// values) are given, the update only applies if the stored kid still has one of them,
+	// otherwise ErrVersionConflict is returned.
+	Update(ctx context.Context, kid *kid.Kid, ifMatch ...time.Time) (*kid.Kid, error)
+	
+	// Delete soft deletes a kid, subject to the same ifMatch precondition as Update. The kid
+	// is hidden from every other method until it is restored or purged.
+	Delete(ctx context.Context, id int, ifMatch ...time.Time) error
+	
+	// Restore brings back a soft-deleted kid. It returns ErrNotDeleted when the kid is not deleted.
+	Restore(ctx context.Context, id int) (*kid.Kid, error)
 	
-	// Delete removes a kid from the repository
-	Delete(ctx context.Context, id int) error
+	// Purge permanently removes kids soft deleted before the given time and returns how many were removed
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
 	
 	// GetByAgeRange retrieves kids within a specific age range
 	GetByAgeRange(ctx context.Context, minAge, maxAge int) ([]*kid.Kid, error)
+	
+	// Find retrieves kids matching the filter, ordered as the filter requests
+	Find(ctx context.Context, filter KidFilter) ([]*kid.Kid, error)
 }
 
 // CaregiverRepository defines the interface for Caregiver data persistence operations.
@@ -42,20 +104,35 @@ type CaregiverRepository interface {
 	// GetByID retrieves a caregiver by their unique identifier
 	GetByID(ctx context.Context, id int) (*caregiver.Caregiver, error)
 	
+	// ResolveID returns the internal ID of the caregiver with the given public ID, including soft-deleted caregivers
+	ResolveID(ctx context.Context, publicID string) (int, error)
+	
 	// GetAll retrieves all caregivers from the repository
 	GetAll(ctx context.Context) ([]*caregiver.Caregiver, error)
 	
-	// Update modifies an existing caregiver's information
-	Update(ctx context.Context, caregiver *caregiver.Caregiver) (*caregiver.Caregiver, error)
+	// Update modifies an existing caregiver's information. When ifMatch versions (updated_at
+	// values) are given, the update only applies if the stored caregiver still has one of them,
+	// otherwise ErrVersionConflict is returned.
+	Update(ctx context.Context, caregiver *caregiver.Caregiver, ifMatch ...time.Time) (*caregiver.Caregiver, error)
+	
+	// Delete soft deletes a caregiver, subject to the same ifMatch precondition as Update. The caregiver
+	// is hidden from every other method until it is restored or purged.
+	Delete(ctx context.Context, id int, ifMatch ...time.Time) error
+	
+	// Restore brings back a soft-deleted caregiver. It returns ErrNotDeleted when the caregiver is not deleted.
+	Restore(ctx context.Context, id int) (*caregiver.Caregiver, error)
 	
-	// Delete removes a caregiver from the repository
-	Delete(ctx context.Context, id int) error
+	// Purge permanently removes caregivers soft deleted before the given time and returns how many were removed
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
 	
 	// GetByEmail retrieves a caregiver by their email address
 	GetByEmail(ctx context.Context, email string) (*caregiver.Caregiver, error)
 	
 	// GetByRelationship retrieves all caregivers with a specific relationship type
 	GetByRelationship(ctx context.Context, relationship caregiver.RelationshipType) ([]*caregiver.Caregiver, error)
+	
+	// Find retrieves caregivers matching the filter, ordered as the filter requests
+	Find(ctx context.Context, filter CaregiverFilter) ([]*caregiver.Caregiver, error)
 }
 
 // TransactionRepository defines the interface for Transaction data persistence operations.
@@ -64,17 +141,34 @@ type TransactionRepository interface {
 	// Create adds a new transaction to the repository and returns the transaction with generated ID
 	Create(ctx context.Context, transaction *transaction.Transaction) (*transaction.Transaction, error)
 	
+	// CreateBatch adds transactions in a single database transaction and returns one result per
+	// item, in order. In atomic mode a failing item rolls back the whole batch and ErrBatchRolledBack
+	// is returned; otherwise failing items are skipped and the others are committed.
+	CreateBatch(ctx context.Context, transactions []*transaction.Transaction, atomic bool) ([]BatchResult, error)
+	
 	// GetByID retrieves a transaction by its unique identifier
 	GetByID(ctx context.Context, id int) (*transaction.Transaction, error)
 	
+	// ResolveID returns the internal ID of the transaction with the given public ID
+	ResolveID(ctx context.Context, publicID string) (int, error)
+	
+	// ResolveKidID returns the internal ID of the kid with the given public ID, so the
+	// star endpoints can take kids by their public IDs
+	ResolveKidID(ctx context.Context, publicID string) (int, error)
+	
+	// ResolveFamilyID returns the internal ID of the family with the given public ID
+	ResolveFamilyID(ctx context.Context, publicID string) (int, error)
+	
 	// GetAll retrieves all transactions from the repository
 	GetAll(ctx context.Context) ([]*transaction.Transaction, error)
 	
-	// Update modifies an existing transaction's information
-	Update(ctx context.Context, transaction *transaction.Transaction) (*transaction.Transaction, error)
+	// Update modifies an existing transaction's information. When ifMatch versions (updated_at
+	// values) are given, the update only applies if the stored transaction still has one of them,
+	// otherwise ErrVersionConflict is returned.
+	Update(ctx context.Context, transaction *transaction.Transaction, ifMatch ...time.Time) (*transaction.Transaction, error)
 	
-	// Delete removes a transaction from the repository
-	Delete(ctx context.Context, id int) error
+	// Delete removes a transaction from the repository, subject to the same ifMatch precondition as Update
+	Delete(ctx context.Context, id int, ifMatch ...time.Time) error
 	
 	// GetByKidID retrieves all transactions for a specific kid
 	GetByKidID(ctx context.Context, kidID int) ([]*transaction.Transaction, error)
@@ -85,21 +179,418 @@ type TransactionRepository interface {
 	// GetByKidIDAndType retrieves transactions for a specific kid and type
 	GetByKidIDAndType(ctx context.Context, kidID int, transactionType transaction.TransactionType) ([]*transaction.Transaction, error)
 	
-	// GetKidBalance calculates the current star balance for a kid
+	// GetKidBalance returns the current star balance for a kid from its materialized totals
 	GetKidBalance(ctx context.Context, kidID int) (int, error)
 	
-	// GetKidTransactionStats returns transaction statistics for a kid (total earned, spent, balance)
+	// GetKidBalanceAt returns the star balance of a kid at a point in time: the stars of
+	// the transactions created before it, counted from the latest snapshot before it
+	GetKidBalanceAt(ctx context.Context, kidID int, at time.Time) (int, error)
+	
+	// TakeBalanceSnapshots snapshots, at a past point in time, the totals of every kid with
+	// transactions since their previous snapshot, and returns the number of snapshots taken
+	TakeBalanceSnapshots(ctx context.Context, at time.Time) (int, error)
+	
+	// GetKidTransactionStats returns transaction statistics for a kid (total earned, spent, balance);
+	// the totals are read from the kid's materialized totals
 	GetKidTransactionStats(ctx context.Context, kidID int) (*TransactionStats, error)
+	
+	// ReconcileBalances recomputes every kid's totals from the ledger and returns the kids
+	// whose materialized totals disagree. With fix, their totals are overwritten with the
+	// ledger's; writes to the ledger wait until it is done.
+	ReconcileBalances(ctx context.Context, fix bool) ([]*BalanceDrift, error)
+	
+	// Find retrieves transactions matching the filter, ordered as the filter requests, each
+	// with the kid's running balance after it over the kid's whole ledger
+	Find(ctx context.Context, filter TransactionFilter) ([]*transaction.Transaction, error)
+	
+	// FindAfter retrieves up to limit transactions matching the filter in ledger order
+	// (created_at, then id) that come after the cursor; the zero Cursor starts at the
+	// beginning. The filter's Sort is ignored. Used to page through long histories.
+	FindAfter(ctx context.Context, filter TransactionFilter, after Cursor, limit int) ([]*transaction.Transaction, error)
+	
+	// GetBalance calculates the star balance (earned minus spent) of the transactions matching the filter
+	GetBalance(ctx context.Context, filter TransactionFilter) (int, error)
+	
+	// GetSeries returns the stars earned and spent per day, week or month of the filter's
+	// range, with the balance at the end of each, for one kid or all kids of a family.
+	// Buckets without transactions are included.
+	GetSeries(ctx context.Context, filter SeriesFilter) ([]*SeriesPoint, error)
+}
+
+// FamilyRepository defines the interface for Family data persistence operations,
+// including family membership and the export and erasure of a family's personal data.
+type FamilyRepository interface {
+	// Create adds a new family to the repository and returns the family with generated ID
+	Create(ctx context.Context, family *family.Family) (*family.Family, error)
+	
+	// GetByID retrieves a family by its unique identifier
+	GetByID(ctx context.Context, id int) (*family.Family, error)
+	
+	// ResolveID returns the internal ID of the family with the given public ID
+	ResolveID(ctx context.Context, publicID string) (int, error)
+	
+	// GetAll retrieves all families from the repository
+	GetAll(ctx context.Context) ([]*family.Family, error)
+	
+	// Update modifies an existing family's information, subject to the same ifMatch
+	// precondition as KidRepository.Update
+	Update(ctx context.Context, family *family.Family, ifMatch ...time.Time) (*family.Family, error)
+	
+	// SetKidFamily moves the kid with the given public ID into the family, or out of any family when familyID is 0
+	SetKidFamily(ctx context.Context, kidID string, familyID int) error
+	
+	// SetCaregiverFamily moves the caregiver with the given public ID into the family, or out of any family when familyID is 0
+	SetCaregiverFamily(ctx context.Context, caregiverID string, familyID int) error
+	
+	// GetCaregivers retrieves the active caregivers of the family that are not soft deleted
+	GetCaregivers(ctx context.Context, familyID int) ([]*caregiver.Caregiver, error)
+	
+	// Export retrieves the family with all its kids, caregivers and their transactions
+	Export(ctx context.Context, id int) (*FamilyExport, error)
+	
+	// RequestDeletion records a pending deletion request for the family, counting the
+	// records it would erase, and returns it with generated ID
+	RequestDeletion(ctx context.Context, request *family.DeletionRequest) (*family.DeletionRequest, error)
+	
+	// GetDeletion retrieves a deletion request of a family
+	GetDeletion(ctx context.Context, familyID, id int) (*family.DeletionRequest, error)
+	
+	// CompleteDeletion erases the family's data as the request's mode says and marks the
+	// request completed, in one database transaction. It returns ErrDeletionNotPending
+	// unless the request is pending, unexpired and tokenHash matches.
+	CompleteDeletion(ctx context.Context, familyID, id int, tokenHash string) (*family.DeletionRequest, error)
+	
+	// Search finds the transactions, kids and caregivers of a family matching a full-text
+	// query, best matches first
+	Search(ctx context.Context, filter SearchFilter) (*SearchResults, error)
+}
+
+// WebhookRepository defines the interface for webhook subscriptions and their deliveries
+type WebhookRepository interface {
+	// Create adds a webhook to its family and returns it with generated ID
+	Create(ctx context.Context, w *webhook.Webhook) (*webhook.Webhook, error)
+	
+	// GetByID retrieves a webhook of a family
+	GetByID(ctx context.Context, familyID, id int) (*webhook.Webhook, error)
+	
+	// GetByFamily retrieves the webhooks of a family
+	GetByFamily(ctx context.Context, familyID int) ([]*webhook.Webhook, error)
+	
+	// Update changes the URL, event types and active flag of a webhook. Activating a
+	// webhook resets its failure count and disabled_at.
+	Update(ctx context.Context, w *webhook.Webhook) (*webhook.Webhook, error)
+	
+	// Delete removes a webhook of a family together with its deliveries
+	Delete(ctx context.Context, familyID, id int) error
+	
+	// Enqueue creates a pending delivery of the event for every active webhook of the
+	// family of the kid with the given public ID subscribed to its type, and returns how
+	// many were created. Enqueuing an event twice does not deliver it twice.
+	Enqueue(ctx context.Context, e *event.Event, kidID string) (int, error)
+	
+	// ClaimDue locks up to limit pending deliveries of active webhooks that are due,
+	// oldest first, for lease: they are not claimed again before it ends, so concurrent
+	// dispatchers do not send them twice and a crashed dispatcher's claims expire.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*DueDelivery, error)
+	
+	// RecordAttempt stores the outcome of an attempt of a claimed delivery. Failures count
+	// towards the webhook's consecutive failures; reaching disableAfter deactivates the
+	// webhook. Reports whether the webhook was disabled.
+	RecordAttempt(ctx context.Context, delivery *DueDelivery, attempt webhook.Attempt, disableAfter int) (bool, error)
+	
+	// Deliveries retrieves the delivery log of a webhook, newest first
+	Deliveries(ctx context.Context, webhookID int, limit int) ([]*webhook.Delivery, error)
+}
+
+// CategoryRepository defines the interface for the transaction categories of families
+type CategoryRepository interface {
+	// Create adds a category to its family and returns it with generated ID. It returns
+	// ErrCategoryExists when the family already has a category with the name, ignoring case.
+	Create(ctx context.Context, category *category.Category) (*category.Category, error)
+	
+	// GetByID retrieves a category of a family
+	GetByID(ctx context.Context, familyID, id int) (*category.Category, error)
+	
+	// GetByFamily retrieves the categories of a family, ordered by name
+	GetByFamily(ctx context.Context, familyID int) ([]*category.Category, error)
+	
+	// Update renames a category, subject to the same rule as Create
+	Update(ctx context.Context, category *category.Category) (*category.Category, error)
+	
+	// Delete removes a category of a family; its transactions become uncategorized
+	Delete(ctx context.Context, familyID, id int) error
+}
+
+// InvitationRepository defines the interface for inviting caregivers to a family and
+// for the caregivers' invitation status
+type InvitationRepository interface {
+	// Create records a pending invitation and returns it with generated ID. The inviter,
+	// given by InvitedByPublicID, must be an active parent or guardian of the family, otherwise ErrInviterNotAllowed
+	// is returned; ErrAlreadyInvited is returned while the email has a pending invitation.
+	Create(ctx context.Context, invitation *invitation.Invitation) (*invitation.Invitation, error)
+	
+	// GetByID retrieves an invitation of a family
+	GetByID(ctx context.Context, familyID, id int) (*invitation.Invitation, error)
+	
+	// GetByFamily retrieves the invitations of a family, newest first
+	GetByFamily(ctx context.Context, familyID int) ([]*invitation.Invitation, error)
+	
+	// Accept creates the invited caregiver, or links the caregiver with the invited email,
+	// to the family as an active caregiver and marks the invitation accepted, in one
+	// database transaction. It returns ErrInvitationNotPending unless the invitation is
+	// pending, unexpired and tokenHash matches, and ErrCaregiverInOtherFamily when the
+	// caregiver is active in another family.
+	Accept(ctx context.Context, familyID, id int, tokenHash string) (*invitation.Invitation, *caregiver.Caregiver, error)
+	
+	// Revoke withdraws a pending invitation, otherwise it returns ErrInvitationNotPending
+	Revoke(ctx context.Context, familyID, id int) (*invitation.Invitation, error)
+	
+	// RevokeCaregiver takes a caregiver's access to the family away; the caregiver stays
+	// in the family with the revoked status until invited again
+	RevokeCaregiver(ctx context.Context, familyID int, caregiverID string) (*caregiver.Caregiver, error)
+}
+
+// DueDelivery is a claimed delivery with the webhook to send it to
+type DueDelivery struct {
+	Delivery *webhook.Delivery
+	Webhook  *webhook.Webhook
+}
+
+// NotificationRepository defines the interface for caregivers' notification preferences
+// and the data notification emails are made of
+type NotificationRepository interface {
+	// GetPreferences retrieves the preferences of a caregiver, the defaults when none were saved
+	GetPreferences(ctx context.Context, caregiverID int) (*notification.Preferences, error)
+		
+	// SetPreferences saves the preferences of a caregiver and returns them
+	SetPreferences(ctx context.Context, preferences *notification.Preferences) (*notification.Preferences, error)
+		
+	// Recipients retrieves the caregivers of a family, or of every family when familyID is
+	// omitted, who opted in to the kind of notification
+	Recipients(ctx context.Context, kind notification.Kind, familyID ...int) ([]*notification.Recipient, error)
+		
+	// KidSummaries retrieves the stars of the kids of every family, or of the kids with
+	// the given public IDs only, earned and spent between from and to, with their balance at to
+	KidSummaries(ctx context.Context, from, to time.Time, kidIDs ...string) ([]*notification.KidSummary, error)
+		
+	// PendingDeletions retrieves the family deletion requests waiting for confirmation
+	PendingDeletions(ctx context.Context) ([]*family.DeletionRequest, error)
+		
+	// MarkSent records that a caregiver is being notified about key, e.g. a week or a
+	// transaction. It returns false when the caregiver was already notified.
+	MarkSent(ctx context.Context, caregiverID int, kind notification.Kind, key string) (bool, error)
+		
+	// UnmarkSent forgets a notification that could not be sent, so it is tried again
+	UnmarkSent(ctx context.Context, caregiverID int, kind notification.Kind, key string) error
+}
+
+// ImportRepository defines the interface for loading existing family data in bulk,
+// e.g. when a family moves over from paper charts or another app.
+type ImportRepository interface {
+	// Import inserts every record of the batch in a single database transaction and returns
+	// the generated IDs, in batch order. A row the database rejects fails the whole import
+	// with an *ImportRowError. In dry-run mode the inserts are rolled back, so constraint
+	// violations are reported without changing any data and no IDs are returned.
+	// Imported rows publish the same created events as rows created through the API.
+	Import(ctx context.Context, batch *ImportBatch, dryRun bool) (*ImportResult, error)
+}
+
+// AuditRepository defines the interface for reading the audit log. Events are written
+// by the database itself whenever a kid, caregiver or transaction changes.
+type AuditRepository interface {
+	// Find retrieves the audit events matching the filter, newest first
+	Find(ctx context.Context, filter AuditFilter) ([]*audit.Event, error)
+}
+
+// OutboxRepository defines the interface for publishing the domain events the other
+// repositories write to the outbox together with the changes they describe.
+type OutboxRepository interface {
+	// Dispatch claims up to options.Limit pending events, hands them to publish, oldest
+	// first, and marks them published. It stops at the first event publish fails for,
+	// records the error on the event and returns it, so the event is retried by the next
+	// Dispatch and later events are not sent before it. An event that has failed
+	// options.MaxAttempts times is parked instead: it is no longer retried and stops
+	// holding up the events after it.
+	// Claimed events are leased for options.Lease, without holding database locks while
+	// they are published, so concurrent relays skip them until the lease ends. An event
+	// may be published again if marking it fails or the lease runs out, i.e. delivery is
+	// at least once. Returns the number of events published.
+	Dispatch(ctx context.Context, options DispatchOptions, publish func(ctx context.Context, e *event.Event) error) (int, error)
+}
+
+// DispatchOptions says how OutboxRepository.Dispatch claims and retries events
+type DispatchOptions struct {
+	Limit       int           // Events claimed at a time
+	Lease       time.Duration // How long claimed events are hidden from other relays, longer than publishing them takes
+	MaxAttempts int           // Failed attempts after which an event is parked, 0 to retry forever
+}
+
+// AuditFilter describes which audit events Find should return.
+// Zero values leave the corresponding criterion unconstrained.
+type AuditFilter struct {
+	Entity   string     // kid, caregiver or transaction
+	EntityID string     // Public ID of the changed record, used together with Entity
+	Actor    string     // Who made the change
+	From     *time.Time // Earliest created_at to include (inclusive)
+	To       *time.Time // Latest created_at to include (exclusive)
+	Limit    int        // Maximum number of events, 0 for all
+}
+
+// KidFilter describes which kids Find should return.
+// Zero values leave the corresponding criterion unconstrained.
+type KidFilter struct {
+	MinAge  *int   // Youngest age to include (inclusive)
+	MaxAge  *int   // Oldest age to include (inclusive)
+	Deleted bool   // Only soft-deleted kids instead of the others
+	Sort    string // Comma-separated sort keys, "-" prefix for descending (e.g. "-created_at,name")
+}
+
+// CaregiverFilter describes which caregivers Find should return.
+// Zero values leave the corresponding criterion unconstrained.
+type CaregiverFilter struct {
+	Relationship caregiver.RelationshipType // Exact relationship type
+	Email        string                     // Exact email address
+	Deleted      bool                       // Only soft-deleted caregivers instead of the others
+	Sort         string                     // Comma-separated sort keys, "-" prefix for descending
+}
+
+// TransactionFilter describes which transactions Find should return.
+// Zero values leave the corresponding criterion unconstrained.
+type TransactionFilter struct {
+	KidID      int                         // Owning kid
+	Type       transaction.TransactionType // earn or spend
+	CategoryID int                         // Only transactions in this category
+	Tag        string                      // Only transactions with this (normalized) tag
+	From       *time.Time                  // Earliest created_at to include (inclusive)
+	To         *time.Time                  // Latest created_at to include (exclusive)
+	Sort       string                      // Comma-separated sort keys, "-" prefix for descending
+}
+
+// BatchResult is the outcome of one item of a batch write
+type BatchResult struct {
+	Transaction *transaction.Transaction // Created transaction, nil when the item was not created
+	Err         error                    // Why the item failed, nil when it succeeded or was rolled back with the batch
+}
+
+// FamilyExport bundles all personal data held about a family
+type FamilyExport struct {
+	Family       *family.Family             `json:"family"`
+	Kids         []*kid.Kid                 `json:"kids"`
+	Caregivers   []*caregiver.Caregiver     `json:"caregivers"`
+	Transactions []*transaction.Transaction `json:"transactions"` // Transactions of the family's kids, in ledger order
+	Categories   []*category.Category       `json:"categories"`
+}
+
+// ImportBatch is a validated set of records to insert together
+type ImportBatch struct {
+	Kids         []*kid.Kid
+	Caregivers   []*caregiver.Caregiver
+	Transactions []ImportedTransaction
+}
+
+// ImportedTransaction is a historical transaction of a kid created by the same import.
+// The kid has no ID yet, so it is referenced by its position in ImportBatch.Kids;
+// the transaction's CreatedAt is kept when set.
+type ImportedTransaction struct {
+	Transaction *transaction.Transaction
+	Kid         int // Index in ImportBatch.Kids of the owning kid
+}
+
+// ImportResult holds the public IDs generated by an import, in the order of the batch
+type ImportResult struct {
+	KidIDs         []string
+	CaregiverIDs   []string
+	TransactionIDs []string
+}
+
+// ImportRowError is returned by Import when the database rejects one row of the batch
+type ImportRowError struct {
+	Entity string // kids, caregivers or transactions
+	Index  int    // Position of the row in the batch
+	Err    error  // Why the row was rejected
+}
+
+func (e *ImportRowError) Error() string {
+	return fmt.Sprintf("%s row %d: %v", e.Entity, e.Index+1, e.Err)
+}
+
+func (e *ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+// Cursor is a keyset pagination position in ledger order: the created_at and id
+// of the last transaction read
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
 }
 
 // TransactionStats represents aggregated transaction statistics for a kid
 type TransactionStats struct {
-	KidID        int `json:"kid_id"`
+	KidID        int    `json:"-"`      // Internal ID of the kid
+	KidPublicID  string `json:"kid_id"` // Public ID of the kid, set by the API
 	TotalEarned  int `json:"total_earned"`
 	TotalSpent   int `json:"total_spent"`
 	Balance      int `json:"balance"`
 	EarnCount    int `json:"earn_count"`
 	SpendCount   int `json:"spend_count"`
+	Categories   []CategoryStats `json:"categories"` // Breakdown by category, uncategorized transactions last
+}
+
+// BalanceTotals are the star totals of a kid
+type BalanceTotals struct {
+	TotalEarned int `json:"total_earned"`
+	TotalSpent  int `json:"total_spent"`
+	Balance     int `json:"balance"`
+	EarnCount   int `json:"earn_count"`
+	SpendCount  int `json:"spend_count"`
+}
+
+// BalanceDrift is a kid whose materialized totals disagree with its ledger
+type BalanceDrift struct {
+	KidID  int           `json:"kid_id"`
+	Stored BalanceTotals `json:"stored"` // Materialized totals, zero when the kid has none
+	Ledger BalanceTotals `json:"ledger"` // Totals recomputed from the transactions
+}
+
+// CategoryStats represents the transaction statistics of a kid within one category
+type CategoryStats struct {
+	CategoryID  *int   `json:"category_id" db:"category_id"` // nil for uncategorized transactions
+	Name        string `json:"name" db:"name"`               // Empty for uncategorized transactions
+	TotalEarned int    `json:"total_earned" db:"total_earned"`
+	TotalSpent  int    `json:"total_spent" db:"total_spent"`
+	EarnCount   int    `json:"earn_count" db:"earn_count"`
+	SpendCount  int    `json:"spend_count" db:"spend_count"`
+}
+
+// SeriesInterval is the length of the buckets of a transaction series
+type SeriesInterval string
+
+const (
+	SeriesDay   SeriesInterval = "day"
+	SeriesWeek  SeriesInterval = "week" // Weeks start on Monday
+	SeriesMonth SeriesInterval = "month"
+)
+
+// SeriesFilter describes the transaction series GetSeries should return.
+// Exactly one of KidID and FamilyID is set.
+type SeriesFilter struct {
+	KidID    int            // Transactions of this kid
+	FamilyID int            // Transactions of the kids in this family
+	Interval SeriesInterval // Length of the buckets
+	Location *time.Location // Where buckets start at midnight, UTC when nil
+	From     time.Time      // Start of the range, inclusive
+	To       time.Time      // End of the range, exclusive
+}
+
+// SeriesPoint holds the stars of one bucket of a transaction series. The first and
+// last buckets only count the transactions within the range.
+type SeriesPoint struct {
+	Start   time.Time `json:"start" db:"start"`     // Start of the bucket
+	Earned  int       `json:"earned" db:"earned"`   // Stars earned in the bucket
+	Spent   int       `json:"spent" db:"spent"`     // Stars spent in the bucket
+	Net     int       `json:"net" db:"net"`         // Earned minus spent
+	Balance int       `json:"balance" db:"balance"` // Balance at the end of the bucket, including everything before the range
 }
 
 // RepositoryManager provides access to all repository interfaces.
@@ -114,9 +605,67 @@ type RepositoryManager interface {
 	// Transactions returns the transaction repository
 	Transactions() TransactionRepository
 	
+	// Families returns the family repository
+	Families() FamilyRepository
+	
+	// Imports returns the bulk import repository
+	Imports() ImportRepository
+	
+	// Audit returns the audit log repository
+	Audit() AuditRepository
+	
+	// Outbox returns the domain event outbox repository
+	Outbox() OutboxRepository
+	
+	// Webhooks returns the webhook repository
+	Webhooks() WebhookRepository
+	
+	// Notifications returns the notification repository
+	Notifications() NotificationRepository
+	
+	// Invitations returns the caregiver invitation repository
+	Invitations() InvitationRepository
+	
+	// Categories returns the transaction category repository
+	Categories() CategoryRepository
+	
 	// Close closes all database connections and cleans up resources
 	Close() error
 	
 	// Ping tests the database connection
 	Ping(ctx context.Context) error
-}
\ No newline at end of file
+}
+
+// SearchKind is the kind of record a search result is
+type SearchKind string
+
+const (
+	SearchTransaction SearchKind = "transaction"
+	SearchKid         SearchKind = "kid"
+	SearchCaregiver   SearchKind = "caregiver"
+)
+
+// SearchFilter describes the search Search should run
+type SearchFilter struct {
+	FamilyID int    // Family whose records are searched
+	Query    string // Web search syntax: words, "quoted phrases", or and -excluded words
+	Limit    int    // Maximum number of results
+	Offset   int    // Number of results to skip
+}
+
+// SearchResult is a record matching a search
+type SearchResult struct {
+	Kind      SearchKind `json:"kind" db:"kind"`
+	ID        string     `json:"id" db:"public_id"`                   // Public ID of the transaction, kid or caregiver
+	KidID     *string    `json:"kid_id,omitempty" db:"kid_public_id"` // Public ID of the kid of a transaction, or of the kid itself
+	Text      string     `json:"text" db:"text"`               // Description or name that matched
+	Highlight string     `json:"highlight" db:"highlight"`     // HTML-escaped text with the matching words in <mark> tags
+	Rank      float64    `json:"rank" db:"rank"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SearchResults is one page of search results
+type SearchResults struct {
+	Total   int             `json:"total"` // Number of matches across all pages, 0 past the last page
+	Results []*SearchResult `json:"results"`
+}
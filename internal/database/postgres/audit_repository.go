@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+)
+
+// AuditRepository implements the interfaces.AuditRepository interface for PostgreSQL.
+// The events are written by the record_audit_event trigger.
+type AuditRepository struct {
+	db *sqlx.DB
+}
+
+// Find retrieves the audit events matching the filter, newest first
+func (r *AuditRepository) Find(ctx context.Context, filter interfaces.AuditFilter) ([]*audit.Event, error) {
+	f := NewFilter()
+
+	if filter.Entity != "" {
+		f.Equal("entity", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		f.Equal("entity_public_id", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		f.Equal("actor", filter.Actor)
+	}
+	if filter.From != nil {
+		f.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		f.Where("created_at < ?", *filter.To)
+	}
+	if err := f.Sort("", nil, "created_at DESC, id DESC"); err != nil {
+		return nil, err
+	}
+	if filter.Limit > 0 {
+		f.Limit(filter.Limit)
+	}
+
+	query, args := f.Build(`SELECT id, actor, claimed_actor, action, entity, entity_id, entity_public_id::text, before::text, after::text, request_id, created_at FROM audit_events`)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*audit.Event
+	for rows.Next() {
+		var e audit.Event
+		var action string
+		var claimedActor, before, after, requestID *string
+
+		err := rows.Scan(&e.ID, &e.Actor, &claimedActor, &action, &e.Entity, &e.EntityID, &e.PublicID, &before, &after, &requestID, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+
+		e.Action = audit.Action(action)
+		if claimedActor != nil {
+			e.ClaimedActor = *claimedActor
+		}
+		if before != nil {
+			e.Before = json.RawMessage(*before)
+		}
+		if after != nil {
+			e.After = json.RawMessage(*after)
+		}
+		if requestID != nil {
+			e.RequestID = *requestID
+		}
+		events = append(events, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate audit events: %w", err)
+	}
+
+	return events, nil
+}
@@ -3,17 +3,22 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
 	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
 )
 
 // CaregiverRepository implements the interfaces.CaregiverRepository interface for PostgreSQL
 type CaregiverRepository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	withTx func(ctx context.Context, fn func(*sqlx.Tx) error) error // Runs fn in a database transaction (see RepositoryManager.withTx)
 }
 
 // Create adds a new caregiver to the database and returns the caregiver with generated ID
@@ -26,41 +31,37 @@ func (r *CaregiverRepository) Create(ctx context.Context, c *caregiver.Caregiver
 	query := `
 		INSERT INTO caregivers (name, email, relationship, created_at, updated_at)
 		VALUES ($1, $2, $3, NOW(), NOW())
-		RETURNING id, created_at, updated_at`
+		RETURNING id, public_id, status, created_at, updated_at`
 
-	var id int
-	var createdAt, updatedAt time.Time
-	err := r.db.QueryRowContext(ctx, query, c.Name, c.Email, string(c.Relationship)).Scan(&id, &createdAt, &updatedAt)
+	createdCaregiver := &caregiver.Caregiver{Name: c.Name, Email: c.Email, Relationship: c.Relationship}
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowContext(ctx, query, c.Name, c.Email, string(c.Relationship)).
+			Scan(&createdCaregiver.ID, &createdCaregiver.PublicID, &createdCaregiver.Status, &createdCaregiver.CreatedAt, &createdCaregiver.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, event.CaregiverCreated, createdCaregiver.ID, createdCaregiver)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create caregiver: %w", err)
 	}
 
-	// Return the created caregiver with all data
-	createdCaregiver := &caregiver.Caregiver{
-		ID:           id,
-		Name:         c.Name,
-		Email:        c.Email,
-		Relationship: c.Relationship,
-		CreatedAt:    createdAt,
-		UpdatedAt:    updatedAt,
-	}
-
 	return createdCaregiver, nil
 }
 
-// GetByID retrieves a caregiver by their unique identifier
+// GetByID retrieves a caregiver by their unique identifier, unless the caregiver is soft deleted
 func (r *CaregiverRepository) GetByID(ctx context.Context, id int) (*caregiver.Caregiver, error) {
-	query := `SELECT id, name, email, relationship, created_at, updated_at FROM caregivers WHERE id = $1`
+	query := `SELECT id, public_id, name, email, relationship, status, created_at, updated_at FROM caregivers WHERE id = $1 AND deleted_at IS NULL`
 
 	var c caregiver.Caregiver
 	var relationshipStr string
 	
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&c.ID, &c.Name, &c.Email, &relationshipStr, &c.CreatedAt, &c.UpdatedAt,
+		&c.ID, &c.PublicID, &c.Name, &c.Email, &relationshipStr, &c.Status, &c.CreatedAt, &c.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("caregiver with id %d not found", id)
+			return nil, fmt.Errorf("caregiver with id %d %w", id, interfaces.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get caregiver: %w", err)
 	}
@@ -69,9 +70,14 @@ func (r *CaregiverRepository) GetByID(ctx context.Context, id int) (*caregiver.C
 	return &c, nil
 }
 
-// GetAll retrieves all caregivers from the database
+// ResolveID returns the internal ID of the caregiver with the given public ID, even if the caregiver is soft deleted
+func (r *CaregiverRepository) ResolveID(ctx context.Context, publicID string) (int, error) {
+	return resolvePublicID(ctx, r.db, "caregivers", publicID, fmt.Errorf("caregiver with id %s %w", publicID, interfaces.ErrNotFound))
+}
+
+// GetAll retrieves all caregivers that are not soft deleted from the database
 func (r *CaregiverRepository) GetAll(ctx context.Context) ([]*caregiver.Caregiver, error) {
-	query := `SELECT id, name, email, relationship, created_at, updated_at FROM caregivers ORDER BY created_at DESC`
+	query := `SELECT id, public_id, name, email, relationship, status, created_at, updated_at FROM caregivers WHERE deleted_at IS NULL ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -84,7 +90,7 @@ func (r *CaregiverRepository) GetAll(ctx context.Context) ([]*caregiver.Caregive
 		var c caregiver.Caregiver
 		var relationshipStr string
 		
-		err := rows.Scan(&c.ID, &c.Name, &c.Email, &relationshipStr, &c.CreatedAt, &c.UpdatedAt)
+		err := rows.Scan(&c.ID, &c.PublicID, &c.Name, &c.Email, &relationshipStr, &c.Status, &c.CreatedAt, &c.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan caregiver: %w", err)
 		}
@@ -100,8 +106,9 @@ func (r *CaregiverRepository) GetAll(ctx context.Context) ([]*caregiver.Caregive
 	return caregivers, nil
 }
 
-// Update modifies an existing caregiver's information
-func (r *CaregiverRepository) Update(ctx context.Context, c *caregiver.Caregiver) (*caregiver.Caregiver, error) {
+// Update modifies an existing caregiver's information.
+// The version check is part of the UPDATE's WHERE clause, so it is atomic.
+func (r *CaregiverRepository) Update(ctx context.Context, c *caregiver.Caregiver, ifMatch ...time.Time) (*caregiver.Caregiver, error) {
 	// Validate the caregiver before saving
 	if err := c.Validate(); err != nil {
 		return nil, fmt.Errorf("caregiver validation failed: %w", err)
@@ -109,62 +116,150 @@ func (r *CaregiverRepository) Update(ctx context.Context, c *caregiver.Caregiver
 
 	query := `
 		UPDATE caregivers 
-		SET name = $2, email = $3, relationship = $4, updated_at = NOW()
-		WHERE id = $1
-		RETURNING id, name, email, relationship, created_at, updated_at`
+		SET name = $2, email = $3, relationship = $4, updated_at = NOW(),
+			-- A new email address has to be verified again
+			status = CASE WHEN email = $3 OR status = 'revoked' THEN status ELSE 'invited' END
+		WHERE id = $1 AND deleted_at IS NULL`
+	args := []any{c.ID, c.Name, c.Email, string(c.Relationship)}
+
+	if len(ifMatch) > 0 {
+		query += ` AND updated_at = ANY($5)`
+		args = append(args, ifMatch)
+	}
+	query += `
+		RETURNING id, public_id, name, email, relationship, status, created_at, updated_at`
 
 	var updatedCaregiver caregiver.Caregiver
 	var relationshipStr string
 	
-	err := r.db.QueryRowContext(ctx, query, c.ID, c.Name, c.Email, string(c.Relationship)).Scan(
-		&updatedCaregiver.ID, &updatedCaregiver.Name, &updatedCaregiver.Email, 
-		&relationshipStr, &updatedCaregiver.CreatedAt, &updatedCaregiver.UpdatedAt,
-	)
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowContext(ctx, query, args...).Scan(
+			&updatedCaregiver.ID, &updatedCaregiver.PublicID, &updatedCaregiver.Name, &updatedCaregiver.Email, 
+			&relationshipStr, &updatedCaregiver.Status, &updatedCaregiver.CreatedAt, &updatedCaregiver.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		updatedCaregiver.Relationship = caregiver.RelationshipType(relationshipStr)
+		return enqueue(ctx, tx, event.CaregiverUpdated, updatedCaregiver.ID, updatedCaregiver)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("caregiver with id %d not found", c.ID)
+			return nil, conditionalWriteError(ctx, r.db, "caregivers", c.ID, fmt.Errorf("caregiver with id %d %w", c.ID, interfaces.ErrNotFound))
 		}
 		return nil, fmt.Errorf("failed to update caregiver: %w", err)
 	}
 
-	updatedCaregiver.Relationship = caregiver.RelationshipType(relationshipStr)
 	return &updatedCaregiver, nil
 }
 
-// Delete removes a caregiver from the database
-func (r *CaregiverRepository) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM caregivers WHERE id = $1`
+// Delete soft deletes a caregiver; the row is kept until it is restored or purged
+func (r *CaregiverRepository) Delete(ctx context.Context, id int, ifMatch ...time.Time) error {
+	query := `UPDATE caregivers SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	args := []any{id}
+
+	if len(ifMatch) > 0 {
+		query += ` AND updated_at = ANY($2)`
+		args = append(args, ifMatch)
+	}
+
+	query += `
+		RETURNING id, public_id, name, email, relationship, status, created_at, updated_at, deleted_at`
+
+	var deletedCaregiver caregiver.Caregiver
+	var relationshipStr string
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowContext(ctx, query, args...).Scan(
+			&deletedCaregiver.ID, &deletedCaregiver.PublicID, &deletedCaregiver.Name, &deletedCaregiver.Email,
+			&relationshipStr, &deletedCaregiver.Status, &deletedCaregiver.CreatedAt, &deletedCaregiver.UpdatedAt, &deletedCaregiver.DeletedAt,
+		)
+		if err != nil {
+			return err
+		}
+		deletedCaregiver.Relationship = caregiver.RelationshipType(relationshipStr)
+		return enqueue(ctx, tx, event.CaregiverDeleted, id, deletedCaregiver)
+	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return conditionalWriteError(ctx, r.db, "caregivers", id, fmt.Errorf("caregiver with id %d %w", id, interfaces.ErrNotFound))
+		}
 		return fmt.Errorf("failed to delete caregiver: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return nil
+}
+
+// Restore brings back a soft-deleted caregiver. It fails when another caregiver
+// has taken the email address in the meantime.
+func (r *CaregiverRepository) Restore(ctx context.Context, id int) (*caregiver.Caregiver, error) {
+	query := `
+		UPDATE caregivers SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, public_id, name, email, relationship, status, created_at, updated_at`
+
+	var restoredCaregiver caregiver.Caregiver
+	var relationshipStr string
+
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowContext(ctx, query, id).Scan(
+			&restoredCaregiver.ID, &restoredCaregiver.PublicID, &restoredCaregiver.Name, &restoredCaregiver.Email,
+			&relationshipStr, &restoredCaregiver.Status, &restoredCaregiver.CreatedAt, &restoredCaregiver.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		restoredCaregiver.Relationship = caregiver.RelationshipType(relationshipStr)
+		return enqueue(ctx, tx, event.CaregiverRestored, id, restoredCaregiver)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, restoreError(ctx, r.db, "caregivers", id, fmt.Errorf("caregiver with id %d %w", id, interfaces.ErrNotFound))
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			return nil, fmt.Errorf("cannot restore caregiver %d: its email is used by another caregiver", id)
+		}
+		return nil, fmt.Errorf("failed to restore caregiver: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("caregiver with id %d not found", id)
+	return &restoredCaregiver, nil
+}
+
+// Purge permanently removes caregivers soft deleted before the given time
+func (r *CaregiverRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged []purgedRow
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.SelectContext(ctx, &purged, `DELETE FROM caregivers WHERE deleted_at < $1 RETURNING id, public_id`, deletedBefore); err != nil {
+			return err
+		}
+		for _, p := range purged {
+			if err := enqueue(ctx, tx, event.CaregiverPurged, p.ID, event.Removed{ID: p.PublicID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge caregivers: %w", err)
 	}
 
-	return nil
+	return len(purged), nil
 }
 
 // GetByEmail retrieves a caregiver by their email address
 func (r *CaregiverRepository) GetByEmail(ctx context.Context, email string) (*caregiver.Caregiver, error) {
-	query := `SELECT id, name, email, relationship, created_at, updated_at FROM caregivers WHERE email = $1`
+	query := `SELECT id, public_id, name, email, relationship, status, created_at, updated_at FROM caregivers WHERE email = $1 AND deleted_at IS NULL`
 
 	var c caregiver.Caregiver
 	var relationshipStr string
 	
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&c.ID, &c.Name, &c.Email, &relationshipStr, &c.CreatedAt, &c.UpdatedAt,
+		&c.ID, &c.PublicID, &c.Name, &c.Email, &relationshipStr, &c.Status, &c.CreatedAt, &c.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("caregiver with email %s not found", email)
+			return nil, fmt.Errorf("caregiver with email %s %w", email, interfaces.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get caregiver by email: %w", err)
 	}
@@ -173,18 +268,47 @@ func (r *CaregiverRepository) GetByEmail(ctx context.Context, email string) (*ca
 	return &c, nil
 }
 
+// caregiverSortColumns maps client sort keys to caregiver columns
+var caregiverSortColumns = map[string]string{
+	"id":           "id",
+	"name":         "name",
+	"email":        "email",
+	"relationship": "relationship",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+}
+
 // GetByRelationship retrieves all caregivers with a specific relationship type
 func (r *CaregiverRepository) GetByRelationship(ctx context.Context, relationship caregiver.RelationshipType) ([]*caregiver.Caregiver, error) {
-	query := `
-		SELECT id, name, email, relationship, created_at, updated_at 
-		FROM caregivers 
-		WHERE relationship = $1 
-		ORDER BY name ASC`
-
-	rows, err := r.db.QueryContext(ctx, query, string(relationship))
+	caregivers, err := r.Find(ctx, interfaces.CaregiverFilter{Relationship: relationship, Sort: "name"})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get caregivers by relationship: %w", err)
 	}
+
+	return caregivers, nil
+}
+
+// Find retrieves caregivers matching the filter
+func (r *CaregiverRepository) Find(ctx context.Context, filter interfaces.CaregiverFilter) ([]*caregiver.Caregiver, error) {
+	f := NewFilter().Deleted(filter.Deleted)
+
+	if filter.Relationship != "" {
+		f.Equal("relationship", string(filter.Relationship))
+	}
+	if filter.Email != "" {
+		f.Equal("email", filter.Email)
+	}
+
+	if err := f.Sort(filter.Sort, caregiverSortColumns, "created_at DESC"); err != nil {
+		return nil, err
+	}
+
+	query, args := f.Build(`SELECT id, public_id, name, email, relationship, status, created_at, updated_at, deleted_at FROM caregivers`)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find caregivers: %w", err)
+	}
 	defer rows.Close()
 
 	var caregivers []*caregiver.Caregiver
@@ -192,7 +316,7 @@ func (r *CaregiverRepository) GetByRelationship(ctx context.Context, relationshi
 		var c caregiver.Caregiver
 		var relationshipStr string
 		
-		err := rows.Scan(&c.ID, &c.Name, &c.Email, &relationshipStr, &c.CreatedAt, &c.UpdatedAt)
+		err := rows.Scan(&c.ID, &c.PublicID, &c.Name, &c.Email, &relationshipStr, &c.Status, &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan caregiver: %w", err)
 		}
@@ -206,4 +330,4 @@ func (r *CaregiverRepository) GetByRelationship(ctx context.Context, relationshi
 	}
 
 	return caregivers, nil
-}
\ No newline at end of file
+}
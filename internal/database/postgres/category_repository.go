@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/category"
+)
+
+// CategoryRepository implements the interfaces.CategoryRepository interface for PostgreSQL
+type CategoryRepository struct {
+	db *sqlx.DB
+}
+
+// categoryColumns lists the categories columns read into category.Category
+const categoryColumns = `id, family_id, name, created_at, updated_at`
+
+// Create adds a category to its family
+func (r *CategoryRepository) Create(ctx context.Context, c *category.Category) (*category.Category, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("category validation failed: %w", err)
+	}
+
+	var created category.Category
+	err := r.db.GetContext(ctx, &created, `
+		INSERT INTO categories (family_id, name, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		RETURNING `+categoryColumns, c.FamilyID, c.Name)
+	if err != nil {
+		return nil, categoryWriteError(err, c.FamilyID)
+	}
+
+	return &created, nil
+}
+
+// GetByID retrieves a category of a family
+func (r *CategoryRepository) GetByID(ctx context.Context, familyID, id int) (*category.Category, error) {
+	var c category.Category
+	err := r.db.GetContext(ctx, &c, `SELECT `+categoryColumns+` FROM categories WHERE id = $1 AND family_id = $2`, id, familyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("category with id %d %w", id, interfaces.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	return &c, nil
+}
+
+// GetByFamily retrieves the categories of a family, ordered by name
+func (r *CategoryRepository) GetByFamily(ctx context.Context, familyID int) ([]*category.Category, error) {
+	if _, err := getFamily(ctx, r.db, familyID); err != nil {
+		return nil, err
+	}
+
+	categories := []*category.Category{}
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE family_id = $1 ORDER BY lower(name), id`
+	if err := r.db.SelectContext(ctx, &categories, query, familyID); err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// Update renames a category of a family
+func (r *CategoryRepository) Update(ctx context.Context, c *category.Category) (*category.Category, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("category validation failed: %w", err)
+	}
+
+	var updated category.Category
+	err := r.db.GetContext(ctx, &updated, `
+		UPDATE categories SET name = $3, updated_at = NOW()
+		WHERE id = $1 AND family_id = $2
+		RETURNING `+categoryColumns, c.ID, c.FamilyID, c.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("category with id %d %w", c.ID, interfaces.ErrNotFound)
+		}
+		return nil, categoryWriteError(err, c.FamilyID)
+	}
+
+	return &updated, nil
+}
+
+// Delete removes a category of a family; the foreign key leaves its transactions uncategorized
+func (r *CategoryRepository) Delete(ctx context.Context, familyID, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM categories WHERE id = $1 AND family_id = $2`, id, familyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("category with id %d %w", id, interfaces.ErrNotFound)
+	}
+
+	return nil
+}
+
+// categoryWriteError explains why the database rejected a category
+func categoryWriteError(err error, familyID int) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23503": // foreign_key_violation
+			return fmt.Errorf("family with id %d %w", familyID, interfaces.ErrNotFound)
+		case pgErr.ConstraintName == "idx_categories_family_name": // unique_violation
+			return interfaces.ErrCategoryExists
+		}
+	}
+	return fmt.Errorf("failed to save category: %w", err)
+}
@@ -3,13 +3,16 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver (pgx)
 
+	"github.com/lukasz/astras-mono-api/internal/database"
 	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
 )
 
 // Config holds the PostgreSQL database configuration
@@ -54,6 +57,14 @@ type RepositoryManager struct {
 	kidRepo      *KidRepository
 	caregiverRepo *CaregiverRepository
 	transactionRepo *TransactionRepository
+	familyRepo   *FamilyRepository
+	importRepo   *ImportRepository
+	auditRepo    *AuditRepository
+	outboxRepo   *OutboxRepository
+	webhookRepo  *WebhookRepository
+	notificationRepo *NotificationRepository
+	invitationRepo *InvitationRepository
+	categoryRepo *CategoryRepository
 }
 
 // NewRepositoryManager creates a new PostgreSQL repository manager
@@ -79,13 +90,39 @@ func NewRepositoryManager(config *Config) (*RepositoryManager, error) {
 		db: db,
 	}
 	
-	rm.kidRepo = &KidRepository{db: db}
-	rm.caregiverRepo = &CaregiverRepository{db: db}
-	rm.transactionRepo = &TransactionRepository{db: db}
+	rm.kidRepo = &KidRepository{db: db, withTx: rm.withTx}
+	rm.caregiverRepo = &CaregiverRepository{db: db, withTx: rm.withTx}
+	rm.transactionRepo = &TransactionRepository{db: db, withTx: rm.withTx}
+	rm.familyRepo = &FamilyRepository{db: db, withTx: rm.withTx}
+	rm.importRepo = &ImportRepository{withTx: rm.withTx}
+	rm.auditRepo = &AuditRepository{db: db}
+	rm.outboxRepo = &OutboxRepository{db: db}
+	rm.webhookRepo = &WebhookRepository{db: db, withTx: rm.withTx}
+	rm.notificationRepo = &NotificationRepository{db: db}
+	rm.invitationRepo = &InvitationRepository{db: db, withTx: rm.withTx}
+	rm.categoryRepo = &CategoryRepository{db: db}
 
 	return rm, nil
 }
 
+// NewRepositoryManagerFromEnv creates a repository manager configured from the
+// DB_* environment variables (see database.LoadConfigFromEnv)
+func NewRepositoryManagerFromEnv() (*RepositoryManager, error) {
+	config := database.LoadConfigFromEnv()
+
+	return NewRepositoryManager(&Config{
+		Host:         config.Host,
+		Port:         config.Port,
+		Database:     config.Database,
+		Username:     config.Username,
+		Password:     config.Password,
+		SSLMode:      config.SSLMode,
+		MaxOpenConns: config.MaxOpenConns,
+		MaxIdleConns: config.MaxIdleConns,
+		MaxLifetime:  config.MaxLifetime,
+	})
+}
+
 // Kids returns the kid repository
 func (rm *RepositoryManager) Kids() interfaces.KidRepository {
 	return rm.kidRepo
@@ -101,6 +138,46 @@ func (rm *RepositoryManager) Transactions() interfaces.TransactionRepository {
 	return rm.transactionRepo
 }
 
+// Families returns the family repository
+func (rm *RepositoryManager) Families() interfaces.FamilyRepository {
+	return rm.familyRepo
+}
+
+// Imports returns the bulk import repository
+func (rm *RepositoryManager) Imports() interfaces.ImportRepository {
+	return rm.importRepo
+}
+
+// Audit returns the audit log repository
+func (rm *RepositoryManager) Audit() interfaces.AuditRepository {
+	return rm.auditRepo
+}
+
+// Outbox returns the domain event outbox repository
+func (rm *RepositoryManager) Outbox() interfaces.OutboxRepository {
+	return rm.outboxRepo
+}
+
+// Webhooks returns the webhook repository
+func (rm *RepositoryManager) Webhooks() interfaces.WebhookRepository {
+	return rm.webhookRepo
+}
+
+// Notifications returns the notification repository
+func (rm *RepositoryManager) Notifications() interfaces.NotificationRepository {
+	return rm.notificationRepo
+}
+
+// Invitations returns the caregiver invitation repository
+func (rm *RepositoryManager) Invitations() interfaces.InvitationRepository {
+	return rm.invitationRepo
+}
+
+// Categories returns the transaction category repository
+func (rm *RepositoryManager) Categories() interfaces.CategoryRepository {
+	return rm.categoryRepo
+}
+
 // Close closes the database connection
 func (rm *RepositoryManager) Close() error {
 	if rm.db != nil {
@@ -119,7 +196,9 @@ func (rm *RepositoryManager) GetDB() *sqlx.DB {
 	return rm.db
 }
 
-// withTx executes a function within a database transaction
+// withTx executes a function within a database transaction.
+// The audit source of ctx, if any, is set for the transaction so the audit
+// triggers record who made the changes (see audit_events).
 func (rm *RepositoryManager) withTx(ctx context.Context, fn func(*sqlx.Tx) error) error {
 	tx, err := rm.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -133,6 +212,15 @@ func (rm *RepositoryManager) withTx(ctx context.Context, fn func(*sqlx.Tx) error
 		}
 	}()
 
+	if source, ok := audit.FromContext(ctx); ok {
+		_, err := tx.ExecContext(ctx, `SELECT set_config('astras.actor', $1, true), set_config('astras.claimed_actor', $2, true), set_config('astras.request_id', $3, true)`,
+			source.Actor, source.ClaimedActor, source.RequestID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to set audit source: %w", err)
+		}
+	}
+
 	if err := fn(tx); err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
 			return fmt.Errorf("failed to rollback transaction: %v (original error: %w)", rbErr, err)
@@ -145,4 +233,68 @@ func (rm *RepositoryManager) withTx(ctx context.Context, fn func(*sqlx.Tx) error
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// purgedRow identifies a row removed permanently, for its purge event
+type purgedRow struct {
+	ID       int    `db:"id"`
+	PublicID string `db:"public_id"`
+}
+
+// resolvePublicID returns the internal ID of the row of table with the given public ID.
+// Soft-deleted rows are resolved too; the operation using the ID decides whether they count.
+func resolvePublicID(ctx context.Context, db *sqlx.DB, table, publicID string, notFound error) (int, error) {
+	var id int
+	err := db.QueryRowContext(ctx, `SELECT id FROM `+table+` WHERE public_id = $1`, publicID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, notFound
+		}
+		return 0, fmt.Errorf("failed to resolve %s public id: %w", table, err)
+	}
+
+	return id, nil
+}
+
+// visibleRows maps tables to the condition of their visible rows: kids and caregivers are
+// soft deleted with deleted_at, transactions are hidden with their kid
+var visibleRows = map[string]string{
+	"kids":         "deleted_at IS NULL",
+	"caregivers":   "deleted_at IS NULL",
+	"transactions": kidNotDeleted,
+}
+
+// conditionalWriteError explains why a conditional UPDATE or DELETE matched no rows:
+// either the row does not exist (or is hidden), or it exists but its version no longer matches.
+func conditionalWriteError(ctx context.Context, db *sqlx.DB, table string, id int, notFound error) error {
+	query := `SELECT EXISTS(SELECT 1 FROM ` + table + ` WHERE id = $1`
+	if condition, ok := visibleRows[table]; ok {
+		query += ` AND ` + condition
+	}
+
+	var exists bool
+	err := db.QueryRowContext(ctx, query+`)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check %s existence: %w", table, err)
+	}
+
+	if exists {
+		return interfaces.ErrVersionConflict
+	}
+	return notFound
+}
+
+// restoreError explains why restoring a soft-deleted row matched no rows:
+// either the row does not exist, or it is not deleted.
+func restoreError(ctx context.Context, db *sqlx.DB, table string, id int, notFound error) error {
+	var exists bool
+	err := db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM `+table+` WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check %s existence: %w", table, err)
+	}
+
+	if exists {
+		return interfaces.ErrNotDeleted
+	}
+	return notFound
+}
@@ -0,0 +1,451 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/category"
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+	"github.com/lukasz/astras-mono-api/internal/models/kid"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// FamilyRepository implements the interfaces.FamilyRepository interface for PostgreSQL
+type FamilyRepository struct {
+	db     *sqlx.DB
+	withTx func(ctx context.Context, fn func(*sqlx.Tx) error) error // Runs fn in a database transaction (see RepositoryManager.withTx)
+}
+
+// Create adds a new family to the database and returns the family with generated ID
+func (r *FamilyRepository) Create(ctx context.Context, f *family.Family) (*family.Family, error) {
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("family validation failed: %w", err)
+	}
+
+	query := `
+		INSERT INTO families (name, created_at, updated_at)
+		VALUES ($1, NOW(), NOW())
+		RETURNING id, public_id, name, created_at, updated_at`
+
+	var created family.Family
+	if err := r.db.QueryRowxContext(ctx, query, f.Name).StructScan(&created); err != nil {
+		return nil, fmt.Errorf("failed to create family: %w", err)
+	}
+
+	return &created, nil
+}
+
+// GetByID retrieves a family by its unique identifier
+func (r *FamilyRepository) GetByID(ctx context.Context, id int) (*family.Family, error) {
+	return getFamily(ctx, r.db, id)
+}
+
+// ResolveID returns the internal ID of the family with the given public ID
+func (r *FamilyRepository) ResolveID(ctx context.Context, publicID string) (int, error) {
+	return resolvePublicID(ctx, r.db, "families", publicID, fmt.Errorf("family with id %s %w", publicID, interfaces.ErrNotFound))
+}
+
+// getFamily retrieves a family using db or a database transaction
+func getFamily(ctx context.Context, q sqlx.QueryerContext, id int) (*family.Family, error) {
+	query := `SELECT id, public_id, name, created_at, updated_at FROM families WHERE id = $1`
+
+	var f family.Family
+	if err := sqlx.GetContext(ctx, q, &f, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("family with id %d %w", id, interfaces.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get family: %w", err)
+	}
+
+	return &f, nil
+}
+
+// GetAll retrieves all families from the database
+func (r *FamilyRepository) GetAll(ctx context.Context) ([]*family.Family, error) {
+	query := `SELECT id, public_id, name, created_at, updated_at FROM families ORDER BY created_at DESC`
+
+	var families []*family.Family
+	if err := r.db.SelectContext(ctx, &families, query); err != nil {
+		return nil, fmt.Errorf("failed to get all families: %w", err)
+	}
+
+	return families, nil
+}
+
+// Update modifies an existing family's information.
+// The version check is part of the UPDATE's WHERE clause, so it is atomic.
+func (r *FamilyRepository) Update(ctx context.Context, f *family.Family, ifMatch ...time.Time) (*family.Family, error) {
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("family validation failed: %w", err)
+	}
+
+	query := `
+		UPDATE families
+		SET name = $2, updated_at = NOW()
+		WHERE id = $1`
+	args := []any{f.ID, f.Name}
+
+	if len(ifMatch) > 0 {
+		query += ` AND updated_at = ANY($3)`
+		args = append(args, ifMatch)
+	}
+	query += `
+		RETURNING id, public_id, name, created_at, updated_at`
+
+	var updated family.Family
+	if err := r.db.QueryRowxContext(ctx, query, args...).StructScan(&updated); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, conditionalWriteError(ctx, r.db, "families", f.ID, fmt.Errorf("family with id %d %w", f.ID, interfaces.ErrNotFound))
+		}
+		return nil, fmt.Errorf("failed to update family: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// SetKidFamily moves the kid with the given public ID into the family, or out of any family when familyID is 0
+func (r *FamilyRepository) SetKidFamily(ctx context.Context, kidID string, familyID int) error {
+	return r.setFamily(ctx, "kids", "kid", kidID, familyID)
+}
+
+// SetCaregiverFamily moves the caregiver with the given public ID into the family, or out of any family when familyID is 0
+func (r *FamilyRepository) SetCaregiverFamily(ctx context.Context, caregiverID string, familyID int) error {
+	return r.setFamily(ctx, "caregivers", "caregiver", caregiverID, familyID)
+}
+
+// setFamily sets the family_id of a kid or caregiver, given by public ID
+func (r *FamilyRepository) setFamily(ctx context.Context, table, entity string, publicID string, familyID int) error {
+	var family any
+	if familyID > 0 {
+		if _, err := r.GetByID(ctx, familyID); err != nil {
+			return err
+		}
+		family = familyID
+	}
+
+	var result sql.Result
+	err := r.withTx(ctx, func(tx *sqlx.Tx) (err error) {
+		result, err = tx.ExecContext(ctx, `UPDATE `+table+` SET family_id = $2, updated_at = NOW() WHERE public_id = $1 AND deleted_at IS NULL`, publicID, family)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set family of %s: %w", entity, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s with id %s %w", entity, publicID, interfaces.ErrNotFound)
+	}
+
+	return nil
+}
+
+// GetCaregivers retrieves the active caregivers of the family that are not soft deleted.
+// Invited caregivers have not proven they own their email address, revoked ones lost access.
+func (r *FamilyRepository) GetCaregivers(ctx context.Context, familyID int) ([]*caregiver.Caregiver, error) {
+	query := `
+		SELECT id, public_id, name, email, relationship, status, created_at, updated_at
+		FROM caregivers WHERE family_id = $1 AND status = 'active' AND deleted_at IS NULL ORDER BY id`
+
+	var caregivers []*caregiver.Caregiver
+	if err := r.db.SelectContext(ctx, &caregivers, query, familyID); err != nil {
+		return nil, fmt.Errorf("failed to get family caregivers: %w", err)
+	}
+
+	return caregivers, nil
+}
+
+// Export retrieves the family with all its kids, caregivers and their transactions.
+// Soft-deleted kids and caregivers are still held, so they are included with deleted_at.
+// The reads share one database transaction, so the bundle is consistent.
+func (r *FamilyRepository) Export(ctx context.Context, id int) (*interfaces.FamilyExport, error) {
+	export := &interfaces.FamilyExport{
+		Kids:         []*kid.Kid{},
+		Caregivers:   []*caregiver.Caregiver{},
+		Transactions: []*transaction.Transaction{},
+		Categories:   []*category.Category{},
+	}
+
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		f, err := getFamily(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		export.Family = f
+
+		err = tx.SelectContext(ctx, &export.Kids, `
+			SELECT id, public_id, name, birthdate, created_at, updated_at, deleted_at
+			FROM kids WHERE family_id = $1 ORDER BY id`, id)
+		if err != nil {
+			return fmt.Errorf("failed to export kids: %w", err)
+		}
+
+		err = tx.SelectContext(ctx, &export.Caregivers, `
+			SELECT id, public_id, name, email, relationship, status, created_at, updated_at, deleted_at
+			FROM caregivers WHERE family_id = $1 ORDER BY id`, id)
+		if err != nil {
+			return fmt.Errorf("failed to export caregivers: %w", err)
+		}
+
+		transactions, err := queryTransactions(ctx, tx, `
+			SELECT `+transactionColumns+` FROM transactions
+			WHERE kid_id IN (SELECT id FROM kids WHERE family_id = $1)
+			ORDER BY kid_id, created_at, id`, id)
+		if err != nil {
+			return fmt.Errorf("failed to export transactions: %w", err)
+		}
+		if transactions != nil {
+			export.Transactions = transactions
+		}
+
+		err = tx.SelectContext(ctx, &export.Categories, `
+			SELECT id, family_id, name, created_at, updated_at
+			FROM categories WHERE family_id = $1 ORDER BY id`, id)
+		if err != nil {
+			return fmt.Errorf("failed to export categories: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// RequestDeletion records a pending deletion request, counting the records it would erase
+func (r *FamilyRepository) RequestDeletion(ctx context.Context, request *family.DeletionRequest) (*family.DeletionRequest, error) {
+	if _, err := r.GetByID(ctx, request.FamilyID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO data_deletions (family_id, mode, status, token_hash, kids, caregivers, transactions, requested_at, expires_at)
+		SELECT $1, $2, 'pending', $3,
+			(SELECT COUNT(*) FROM kids WHERE family_id = $1),
+			(SELECT COUNT(*) FROM caregivers WHERE family_id = $1),
+			(SELECT COUNT(*) FROM transactions t JOIN kids k ON k.id = t.kid_id WHERE k.family_id = $1),
+			NOW(), $4
+		RETURNING ` + deletionColumns
+
+	var created family.DeletionRequest
+	err := r.db.QueryRowxContext(ctx, query, request.FamilyID, string(request.Mode), request.TokenHash, request.ExpiresAt).StructScan(&created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deletion request: %w", err)
+	}
+
+	return &created, nil
+}
+
+// deletionColumns lists the data_deletions columns read into family.DeletionRequest
+const deletionColumns = `id, family_id, mode, status, token_hash, kids, caregivers, transactions, requested_at, expires_at, completed_at`
+
+// GetDeletion retrieves a deletion request of a family
+func (r *FamilyRepository) GetDeletion(ctx context.Context, familyID, id int) (*family.DeletionRequest, error) {
+	query := `SELECT ` + deletionColumns + ` FROM data_deletions WHERE id = $1 AND family_id = $2`
+
+	var request family.DeletionRequest
+	if err := r.db.GetContext(ctx, &request, query, id, familyID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deletion request with id %d %w", id, interfaces.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get deletion request: %w", err)
+	}
+
+	return &request, nil
+}
+
+// CompleteDeletion erases the family's data, also from the audit log and the outbox, and
+// completes the request in one database transaction. Claiming the request first makes a
+// second confirmation fail instead of erasing twice. The counts are updated to what was
+// actually erased.
+func (r *FamilyRepository) CompleteDeletion(ctx context.Context, familyID, id int, tokenHash string) (*family.DeletionRequest, error) {
+	var completed family.DeletionRequest
+
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		var mode string
+		err := tx.QueryRowxContext(ctx, `
+			UPDATE data_deletions SET status = 'completed', completed_at = NOW()
+			WHERE id = $1 AND family_id = $2 AND status = 'pending' AND expires_at > NOW() AND token_hash = $3
+			RETURNING mode`, id, familyID, tokenHash).Scan(&mode)
+		if err == sql.ErrNoRows {
+			return interfaces.ErrDeletionNotPending
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim deletion request: %w", err)
+		}
+
+		// Erasing writes audit events too, so the audit log is scrubbed afterwards
+		var kidIDs, caregiverIDs, transactionIDs []int64
+		if err := tx.SelectContext(ctx, &kidIDs, `SELECT id FROM kids WHERE family_id = $1`, familyID); err != nil {
+			return fmt.Errorf("failed to get family kids: %w", err)
+		}
+		if err := tx.SelectContext(ctx, &caregiverIDs, `SELECT id FROM caregivers WHERE family_id = $1`, familyID); err != nil {
+			return fmt.Errorf("failed to get family caregivers: %w", err)
+		}
+		if err := tx.SelectContext(ctx, &transactionIDs, `SELECT id FROM transactions WHERE kid_id = ANY($1)`, kidIDs); err != nil {
+			return fmt.Errorf("failed to get family transactions: %w", err)
+		}
+
+		statements := anonymizeFamily
+		if family.DeletionMode(mode) == family.DeletionModeDelete {
+			statements = deleteFamily
+		}
+
+		var counts [3]int64
+		for i, statement := range statements {
+			result, err := tx.ExecContext(ctx, statement, familyID)
+			if err != nil {
+				return fmt.Errorf("failed to erase family data: %w", err)
+			}
+			if i < len(counts) {
+				if counts[i], err = result.RowsAffected(); err != nil {
+					return fmt.Errorf("failed to get rows affected: %w", err)
+				}
+			}
+		}
+
+		// The audit log keeps that changes happened, but not the personal data they contained
+		_, err = tx.ExecContext(ctx, `
+			UPDATE audit_events SET before = NULL, after = NULL
+			WHERE (entity = 'kid' AND entity_id = ANY($1))
+				OR (entity = 'caregiver' AND entity_id = ANY($2))
+				OR (entity = 'transaction' AND entity_id = ANY($3))`, kidIDs, caregiverIDs, transactionIDs)
+		if err != nil {
+			return fmt.Errorf("failed to scrub audit log: %w", err)
+		}
+
+		// Unpublished events would still carry the erased data to subscribers
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM outbox
+			WHERE (entity = 'kid' AND entity_id = ANY($1))
+				OR (entity = 'caregiver' AND entity_id = ANY($2))
+				OR (entity = 'transaction' AND entity_id = ANY($3))`, kidIDs, caregiverIDs, transactionIDs)
+		if err != nil {
+			return fmt.Errorf("failed to scrub outbox: %w", err)
+		}
+
+		return tx.QueryRowxContext(ctx, `
+			UPDATE data_deletions SET transactions = $2, kids = $3, caregivers = $4
+			WHERE id = $1
+			RETURNING `+deletionColumns, id, counts[0], counts[1], counts[2]).StructScan(&completed)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &completed, nil
+}
+
+// deleteFamily removes a family's transactions, kids, caregivers and the family itself.
+// The first three statements report the transaction, kid and caregiver counts.
+var deleteFamily = []string{
+	`DELETE FROM transactions WHERE kid_id IN (SELECT id FROM kids WHERE family_id = $1)`,
+	`DELETE FROM kids WHERE family_id = $1`,
+	`DELETE FROM caregivers WHERE family_id = $1`,
+	`DELETE FROM families WHERE id = $1`,
+}
+
+// anonymizeFamily replaces a family's personal data with placeholders. Amounts, types and
+// dates of transactions are kept, so balances and statistics stay correct; birthdates keep
+// the year only. Webhooks are removed with their deliveries, whose bodies hold personal data,
+// caregivers no longer receive notifications and invitations, which hold the invitees'
+// emails, are removed. The first three statements report the transaction, kid and
+// caregiver counts.
+var anonymizeFamily = []string{
+	`UPDATE transactions SET description = 'Redacted'
+		WHERE kid_id IN (SELECT id FROM kids WHERE family_id = $1)`,
+	`UPDATE kids SET name = 'Deleted kid ' || id, birthdate = date_trunc('year', birthdate)::date
+		WHERE family_id = $1`,
+	`UPDATE caregivers SET name = 'Deleted caregiver ' || id, email = 'deleted-' || id || '@anonymized.invalid'
+		WHERE family_id = $1`,
+	`UPDATE families SET name = 'Deleted family ' || id WHERE id = $1`,
+	`DELETE FROM webhooks WHERE family_id = $1`,
+	`DELETE FROM notification_preferences WHERE caregiver_id IN (SELECT id FROM caregivers WHERE family_id = $1)`,
+	`DELETE FROM notifications_sent WHERE caregiver_id IN (SELECT id FROM caregivers WHERE family_id = $1)`,
+	`DELETE FROM invitations WHERE family_id = $1`,
+}
+
+// searchMatches finds the family's transactions (English stemming) and its kids and
+// caregivers (names, no stemming) matching $2
+const searchMatches = `
+	WITH matches AS (
+		SELECT 'transaction' AS kind, t.id, t.public_id, k.public_id AS kid_public_id, t.description AS text, 'english'::regconfig AS config,
+			ts_rank(t.search_vector, websearch_to_tsquery('english', $2)) AS rank, t.created_at
+		FROM transactions t
+		JOIN kids k ON k.id = t.kid_id
+		WHERE k.family_id = $1 AND k.deleted_at IS NULL
+			AND t.search_vector @@ websearch_to_tsquery('english', $2)
+		UNION ALL
+		SELECT 'kid', k.id, k.public_id, k.public_id, k.name, 'simple'::regconfig,
+			ts_rank(k.search_vector, websearch_to_tsquery('simple', $2)), k.created_at
+		FROM kids k
+		WHERE k.family_id = $1 AND k.deleted_at IS NULL
+			AND k.search_vector @@ websearch_to_tsquery('simple', $2)
+		UNION ALL
+		SELECT 'caregiver', c.id, c.public_id, NULL, c.name, 'simple'::regconfig,
+			ts_rank(c.search_vector, websearch_to_tsquery('simple', $2)), c.created_at
+		FROM caregivers c
+		WHERE c.family_id = $1 AND c.deleted_at IS NULL
+			AND c.search_vector @@ websearch_to_tsquery('simple', $2)
+	)`
+
+// searchQuery ranks the matches, counts all of them and highlights the page's results
+// only. The text is HTML-escaped before ts_headline adds the <mark> tags, so the
+// highlight can be rendered as HTML; the parser skips the entities when matching words.
+const searchQuery = searchMatches + `, page AS (
+		SELECT *
+		FROM matches
+		ORDER BY rank DESC, created_at DESC, kind, id
+		LIMIT $3 OFFSET $4
+	)
+	SELECT kind, public_id, kid_public_id, text, rank, created_at, (SELECT COUNT(*) FROM matches) AS total,
+		ts_headline(config,
+			replace(replace(replace(replace(replace(text, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+			websearch_to_tsquery(config, $2), 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS highlight
+	FROM page
+	ORDER BY rank DESC, created_at DESC, kind, id`
+
+// searchCountQuery counts the matches when the page is past the last one
+const searchCountQuery = searchMatches + `
+	SELECT COUNT(*) FROM matches`
+
+// Search finds the transactions, kids and caregivers of a family matching a full-text
+// query, best matches first. Soft-deleted kids and caregivers, and the transactions of
+// soft-deleted kids, are not searched.
+func (r *FamilyRepository) Search(ctx context.Context, filter interfaces.SearchFilter) (*interfaces.SearchResults, error) {
+	if _, err := getFamily(ctx, r.db, filter.FamilyID); err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		interfaces.SearchResult
+		Total int `db:"total"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, searchQuery, filter.FamilyID, filter.Query, filter.Limit, filter.Offset); err != nil {
+		return nil, fmt.Errorf("failed to search family: %w", err)
+	}
+
+	results := &interfaces.SearchResults{Results: make([]*interfaces.SearchResult, len(rows))}
+	for i := range rows {
+		results.Results[i] = &rows[i].SearchResult
+		results.Total = rows[i].Total
+	}
+	if len(rows) == 0 && filter.Offset > 0 {
+		if err := r.db.GetContext(ctx, &results.Total, searchCountQuery, filter.FamilyID, filter.Query); err != nil {
+			return nil, fmt.Errorf("failed to count search results: %w", err)
+		}
+	}
+
+	return results, nil
+}
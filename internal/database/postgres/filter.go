@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter composes the WHERE and ORDER BY clauses of a SELECT statement.
+// Column names and expressions always come from repository code; client input
+// only reaches the query as bound parameters, and client-supplied sort keys are
+// resolved through a whitelist, so a Filter cannot be used for SQL injection.
+type Filter struct {
+	conditions []string
+	args       []any
+	orderBy    []string
+	limit      int
+}
+
+// NewFilter creates an empty filter that matches every row
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// Where adds a condition joined with AND to the previous ones.
+// Every '?' in expr is replaced with the next positional placeholder ($1, $2, ...)
+// and bound to the corresponding value in args.
+func (f *Filter) Where(expr string, args ...any) *Filter {
+	if strings.Count(expr, "?") != len(args) {
+		panic(fmt.Sprintf("postgres filter: %q expects %d arguments, got %d", expr, strings.Count(expr, "?"), len(args)))
+	}
+
+	var b strings.Builder
+	for _, r := range expr {
+		if r == '?' {
+			f.args = append(f.args, args[0])
+			args = args[1:]
+			fmt.Fprintf(&b, "$%d", len(f.args))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	f.conditions = append(f.conditions, b.String())
+	return f
+}
+
+// Equal adds a "column = value" condition
+func (f *Filter) Equal(column string, value any) *Filter {
+	return f.Where(column+" = ?", value)
+}
+
+// Deleted restricts the rows to soft-deleted ones, or to the others when deleted is false
+func (f *Filter) Deleted(deleted bool) *Filter {
+	if deleted {
+		return f.Where("deleted_at IS NOT NULL")
+	}
+	return f.Where("deleted_at IS NULL")
+}
+
+// Sort parses a client sort expression such as "-created_at,name" into ORDER BY terms.
+// A leading '-' sorts descending, a leading '+' or no prefix sorts ascending.
+// Keys are looked up in columns (client key -> SQL column); unknown keys are rejected.
+// When sort is empty, fallback is used verbatim as the ORDER BY clause.
+func (f *Filter) Sort(sort string, columns map[string]string, fallback string) error {
+	seen := make(map[string]bool)
+
+	for _, key := range strings.Split(sort, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		direction := "ASC"
+		switch key[0] {
+		case '-':
+			direction = "DESC"
+			key = key[1:]
+		case '+':
+			key = key[1:]
+		}
+
+		column, ok := columns[key]
+		if !ok {
+			return fmt.Errorf("invalid sort field %q", key)
+		}
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+
+		f.orderBy = append(f.orderBy, column+" "+direction)
+	}
+
+	if len(f.orderBy) == 0 && fallback != "" {
+		f.orderBy = append(f.orderBy, fallback)
+	}
+
+	return nil
+}
+
+// Limit caps the number of rows returned, zero means no limit
+func (f *Filter) Limit(n int) *Filter {
+	f.limit = n
+	return f
+}
+
+// Outer returns an empty filter for a query that selects from f's query as a subquery.
+// Its placeholders are numbered after those of f, and its Build returns the arguments of both.
+func (f *Filter) Outer() *Filter {
+	return &Filter{args: append([]any(nil), f.args...)}
+}
+
+// Build appends the composed clauses to base and returns the query with its arguments
+func (f *Filter) Build(base string) (string, []any) {
+	var b strings.Builder
+	b.WriteString(base)
+
+	if len(f.conditions) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(f.conditions, " AND "))
+	}
+
+	if len(f.orderBy) > 0 {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(strings.Join(f.orderBy, ", "))
+	}
+
+	if f.limit > 0 {
+		fmt.Fprintf(&b, " LIMIT %d", f.limit)
+	}
+
+	return b.String(), f.args
+}
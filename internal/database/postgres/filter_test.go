@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+)
+
+func TestFilterBuild(t *testing.T) {
+	columns := map[string]string{
+		"name":       "name",
+		"created_at": "created_at",
+	}
+
+	tests := []struct {
+		name          string
+		build         func(f *Filter)
+		sort          string
+		expectedQuery string
+		expectedArgs  []any
+	}{
+		{
+			name:          "no conditions uses fallback order",
+			build:         func(f *Filter) {},
+			expectedQuery: "SELECT * FROM kids ORDER BY created_at DESC",
+		},
+		{
+			name: "conditions are numbered in order",
+			build: func(f *Filter) {
+				f.Equal("kid_id", 1).Where("created_at >= ? AND created_at < ?", "a", "b")
+			},
+			sort:          "-created_at",
+			expectedQuery: "SELECT * FROM kids WHERE kid_id = $1 AND created_at >= $2 AND created_at < $3 ORDER BY created_at DESC",
+			expectedArgs:  []any{1, "a", "b"},
+		},
+		{
+			name:          "multiple sort keys keep their direction",
+			build:         func(f *Filter) {},
+			sort:          "name, -created_at, +name",
+			expectedQuery: "SELECT * FROM kids ORDER BY name ASC, created_at DESC",
+		},
+		{
+			name: "client values are never interpolated",
+			build: func(f *Filter) {
+				f.Equal("name", "x'; DROP TABLE kids; --")
+			},
+			expectedQuery: "SELECT * FROM kids WHERE name = $1 ORDER BY created_at DESC",
+			expectedArgs:  []any{"x'; DROP TABLE kids; --"},
+		},
+		{
+			name: "soft-deleted rows only",
+			build: func(f *Filter) {
+				f.Deleted(true).Equal("name", "Alice")
+			},
+			expectedQuery: "SELECT * FROM kids WHERE deleted_at IS NOT NULL AND name = $1 ORDER BY created_at DESC",
+			expectedArgs:  []any{"Alice"},
+		},
+		{
+			name: "limit follows the order",
+			build: func(f *Filter) {
+				f.Where("(created_at, id) > (?, ?)", "a", 7).Limit(100)
+			},
+			expectedQuery: "SELECT * FROM kids WHERE (created_at, id) > ($1, $2) ORDER BY created_at DESC LIMIT 100",
+			expectedArgs:  []any{"a", 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFilter()
+			tt.build(f)
+			if err := f.Sort(tt.sort, columns, "created_at DESC"); err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			query, args := f.Build("SELECT * FROM kids")
+			if query != tt.expectedQuery {
+				t.Errorf("expected query %q, got %q", tt.expectedQuery, query)
+			}
+			if len(args) != 0 || len(tt.expectedArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.expectedArgs) {
+					t.Errorf("expected args %v, got %v", tt.expectedArgs, args)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterSortRejectsUnknownFields(t *testing.T) {
+	columns := map[string]string{"name": "name"}
+
+	for _, sort := range []string{"password", "-name;DROP TABLE kids", "name DESC"} {
+		t.Run(sort, func(t *testing.T) {
+			if err := NewFilter().Sort(sort, columns, ""); err == nil {
+				t.Errorf("expected error for sort %q but got none", sort)
+			}
+		})
+	}
+}
+
+func TestTransactionConditionsHideDeletedKids(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        interfaces.TransactionFilter
+		expectedQuery string
+	}{
+		{"no filter", interfaces.TransactionFilter{}, "SELECT * FROM transactions WHERE " + kidNotDeleted},
+		{"one kid", interfaces.TransactionFilter{KidID: 3}, "SELECT * FROM transactions WHERE " + kidNotDeleted + " AND kid_id = $1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := transactionConditions(NewFilter(), tt.filter).Build("SELECT * FROM transactions")
+			if query != tt.expectedQuery {
+				t.Errorf("expected query %q, got %q", tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestLedgerWithBalancesPushesKidAndEndIntoWindow(t *testing.T) {
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	ledger, f := ledgerWithBalances(interfaces.TransactionFilter{KidID: 3, To: &to})
+	query, args := f.Where("type = ?", "earn").Build("SELECT * FROM " + ledger)
+
+	expectedQuery := "SELECT * FROM (SELECT *, SUM(CASE WHEN type = 'earn' THEN amount ELSE -amount END) OVER (PARTITION BY kid_id ORDER BY created_at, id) AS balance FROM transactions WHERE kid_id = $1 AND created_at < $2) transactions WHERE type = $3"
+	if query != expectedQuery {
+		t.Errorf("expected query %q, got %q", expectedQuery, query)
+	}
+	if expectedArgs := []any{3, to, "earn"}; !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
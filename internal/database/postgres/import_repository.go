@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+	"github.com/lukasz/astras-mono-api/internal/models/kid"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// errDryRun rolls back the database transaction of a dry-run import
+var errDryRun = errors.New("dry run")
+
+// ImportRepository implements the interfaces.ImportRepository interface for PostgreSQL
+type ImportRepository struct {
+	withTx func(ctx context.Context, fn func(*sqlx.Tx) error) error // Runs fn in a database transaction (see RepositoryManager.withTx)
+}
+
+// Import inserts kids, then caregivers, then the transactions of the new kids in
+// one database transaction. Transactions keep their CreatedAt, so imported history
+// shows up at the right place in the ledger. Every row publishes the same event as
+// when it is created through the API.
+func (r *ImportRepository) Import(ctx context.Context, batch *interfaces.ImportBatch, dryRun bool) (*interfaces.ImportResult, error) {
+	result := &interfaces.ImportResult{
+		KidIDs:         make([]string, len(batch.Kids)),
+		CaregiverIDs:   make([]string, len(batch.Caregivers)),
+		TransactionIDs: make([]string, len(batch.Transactions)),
+	}
+	kidIDs := make([]int, len(batch.Kids)) // Internal IDs the transactions refer to
+
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		for i, k := range batch.Kids {
+			created := &kid.Kid{Name: k.Name, Birthdate: k.Birthdate}
+			err := tx.QueryRowxContext(ctx, `
+				INSERT INTO kids (name, birthdate, created_at, updated_at)
+				VALUES ($1, $2, NOW(), NOW())
+				RETURNING id, public_id, created_at, updated_at`, k.Name, k.Birthdate).
+				Scan(&created.ID, &created.PublicID, &created.CreatedAt, &created.UpdatedAt)
+			if err != nil {
+				return importRowError("kids", i, err)
+			}
+			if err := enqueue(ctx, tx, event.KidCreated, created.ID, created); err != nil {
+				return err
+			}
+			kidIDs[i], result.KidIDs[i] = created.ID, created.PublicID
+		}
+
+		for i, c := range batch.Caregivers {
+			created := &caregiver.Caregiver{Name: c.Name, Email: c.Email, Relationship: c.Relationship}
+			err := tx.QueryRowxContext(ctx, `
+				INSERT INTO caregivers (name, email, relationship, created_at, updated_at)
+				VALUES ($1, $2, $3, NOW(), NOW())
+				RETURNING id, public_id, created_at, updated_at`, c.Name, c.Email, string(c.Relationship)).
+				Scan(&created.ID, &created.PublicID, &created.CreatedAt, &created.UpdatedAt)
+			if err != nil {
+				return importRowError("caregivers", i, err)
+			}
+			if err := enqueue(ctx, tx, event.CaregiverCreated, created.ID, created); err != nil {
+				return err
+			}
+			result.CaregiverIDs[i] = created.PublicID
+		}
+
+		for i, item := range batch.Transactions {
+			if item.Kid < 0 || item.Kid >= len(kidIDs) {
+				return &interfaces.ImportRowError{Entity: "transactions", Index: i, Err: fmt.Errorf("kid %d is not part of the import", item.Kid)}
+			}
+
+			t := item.Transaction
+			var createdAt any
+			if !t.CreatedAt.IsZero() {
+				createdAt = t.CreatedAt
+			}
+			created := &transaction.Transaction{KidID: kidIDs[item.Kid], KidPublicID: result.KidIDs[item.Kid], Type: t.Type, Amount: t.Amount, Description: t.Description}
+			err := tx.QueryRowxContext(ctx, `
+				INSERT INTO transactions (kid_id, type, amount, description, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, COALESCE($5, NOW()), NOW())
+				RETURNING id, public_id, created_at, updated_at`, created.KidID, string(t.Type), t.Amount, t.Description, createdAt).
+				Scan(&created.ID, &created.PublicID, &created.CreatedAt, &created.UpdatedAt)
+			if err != nil {
+				return importRowError("transactions", i, err)
+			}
+			if err := enqueue(ctx, tx, createdEvent(created), created.ID, created); err != nil {
+				return err
+			}
+			result.TransactionIDs[i] = created.PublicID
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		// Sequences are not rolled back, so the IDs would differ on a real import
+		return &interfaces.ImportResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// importRowError explains why the database rejected a row of an import
+func importRowError(entity string, index int, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			err = fmt.Errorf("already exists (%s)", pgErr.ConstraintName)
+		case "23514": // check_violation
+			err = fmt.Errorf("violates %s", pgErr.ConstraintName)
+		}
+	}
+	return &interfaces.ImportRowError{Entity: entity, Index: index, Err: err}
+}
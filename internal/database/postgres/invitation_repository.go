@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+	"github.com/lukasz/astras-mono-api/internal/models/invitation"
+)
+
+// InvitationRepository implements the interfaces.InvitationRepository interface for PostgreSQL
+type InvitationRepository struct {
+	db     *sqlx.DB
+	withTx func(ctx context.Context, fn func(*sqlx.Tx) error) error // Runs fn in a database transaction (see RepositoryManager.withTx)
+}
+
+// invitationColumns lists the invitations columns read into invitation.Invitation, with the
+// public IDs of the inviter and of the caregiver created or linked on acceptance
+const invitationColumns = `id, family_id, email, name, relationship, invited_by, caregiver_id,
+	(SELECT public_id FROM caregivers WHERE caregivers.id = invited_by) AS invited_by_public_id,
+	(SELECT public_id FROM caregivers WHERE caregivers.id = caregiver_id) AS caregiver_public_id,
+	status, token_hash, expires_at, created_at, accepted_at, revoked_at`
+
+// caregiverColumns lists the caregivers columns read into caregiver.Caregiver
+const caregiverColumns = `id, public_id, name, email, relationship, status, created_at, updated_at`
+
+// Create records a pending invitation once the inviter is known to be an active parent
+// or guardian of the family
+func (r *InvitationRepository) Create(ctx context.Context, i *invitation.Invitation) (*invitation.Invitation, error) {
+	if err := i.Validate(); err != nil {
+		return nil, fmt.Errorf("invitation validation failed: %w", err)
+	}
+	if i.InvitedByPublicID == nil {
+		return nil, interfaces.ErrInviterNotAllowed
+	}
+
+	var created invitation.Invitation
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := getFamily(ctx, tx, i.FamilyID); err != nil {
+			return err
+		}
+
+		var inviterID int
+		err := tx.QueryRowContext(ctx, `
+			SELECT id FROM caregivers
+			WHERE public_id = $1 AND family_id = $2 AND status = 'active' AND relationship IN ('parent', 'guardian') AND deleted_at IS NULL`,
+			*i.InvitedByPublicID, i.FamilyID).Scan(&inviterID)
+		if err == sql.ErrNoRows {
+			return interfaces.ErrInviterNotAllowed
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check inviter: %w", err)
+		}
+
+		err = tx.QueryRowxContext(ctx, `
+			INSERT INTO invitations (family_id, email, name, relationship, invited_by, token_hash, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING `+invitationColumns,
+			i.FamilyID, i.Email, i.Name, string(i.Relationship), inviterID, i.TokenHash, i.ExpiresAt).StructScan(&created)
+		if err != nil {
+			return fmt.Errorf("failed to create invitation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "idx_invitations_pending_email" { // unique_violation
+			return nil, interfaces.ErrAlreadyInvited
+		}
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// GetByID retrieves an invitation of a family
+func (r *InvitationRepository) GetByID(ctx context.Context, familyID, id int) (*invitation.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE id = $1 AND family_id = $2`
+
+	var i invitation.Invitation
+	if err := r.db.GetContext(ctx, &i, query, id, familyID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invitation with id %d %w", id, interfaces.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return &i, nil
+}
+
+// GetByFamily retrieves the invitations of a family, newest first
+func (r *InvitationRepository) GetByFamily(ctx context.Context, familyID int) ([]*invitation.Invitation, error) {
+	if _, err := getFamily(ctx, r.db, familyID); err != nil {
+		return nil, err
+	}
+
+	invitations := []*invitation.Invitation{}
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE family_id = $1 ORDER BY created_at DESC, id DESC`
+	if err := r.db.SelectContext(ctx, &invitations, query, familyID); err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// Accept claims the pending invitation, then creates the invited caregiver or links the
+// caregiver who already has the invited email, so a caregiver created with POST
+// /caregivers becomes active once they prove they own the address
+func (r *InvitationRepository) Accept(ctx context.Context, familyID, id int, tokenHash string) (*invitation.Invitation, *caregiver.Caregiver, error) {
+	var accepted invitation.Invitation
+	var c caregiver.Caregiver
+
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, `
+			SELECT `+invitationColumns+` FROM invitations
+			WHERE id = $1 AND family_id = $2 AND status = 'pending' AND expires_at > NOW() AND token_hash = $3
+			FOR UPDATE`, id, familyID, tokenHash).StructScan(&accepted)
+		if err == sql.ErrNoRows {
+			return interfaces.ErrInvitationNotPending
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim invitation: %w", err)
+		}
+
+		var existingID int
+		var existingFamily sql.NullInt64
+		var existingStatus string
+		err = tx.QueryRowContext(ctx, `
+			SELECT id, family_id, status FROM caregivers
+			WHERE lower(email) = lower($1) AND deleted_at IS NULL
+			ORDER BY id LIMIT 1
+			FOR UPDATE`, accepted.Email).Scan(&existingID, &existingFamily, &existingStatus)
+		switch {
+		case err == sql.ErrNoRows:
+			err = tx.QueryRowxContext(ctx, `
+				INSERT INTO caregivers (name, email, relationship, family_id, status, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, 'active', NOW(), NOW())
+				RETURNING `+caregiverColumns,
+				accepted.Name, accepted.Email, string(accepted.Relationship), familyID).StructScan(&c)
+			if err != nil {
+				return fmt.Errorf("failed to create caregiver: %w", err)
+			}
+			if err := enqueue(ctx, tx, event.CaregiverCreated, c.ID, c); err != nil {
+				return err
+			}
+		case err != nil:
+			return fmt.Errorf("failed to get caregiver by email: %w", err)
+		default:
+			if existingFamily.Valid && int(existingFamily.Int64) != familyID && caregiver.Status(existingStatus) == caregiver.StatusActive {
+				return interfaces.ErrCaregiverInOtherFamily
+			}
+			err = tx.QueryRowxContext(ctx, `
+				UPDATE caregivers SET family_id = $2, status = 'active', updated_at = NOW()
+				WHERE id = $1
+				RETURNING `+caregiverColumns, existingID, familyID).StructScan(&c)
+			if err != nil {
+				return fmt.Errorf("failed to link caregiver: %w", err)
+			}
+			if err := enqueue(ctx, tx, event.CaregiverUpdated, c.ID, c); err != nil {
+				return err
+			}
+		}
+
+		return tx.QueryRowxContext(ctx, `
+			UPDATE invitations SET status = 'accepted', caregiver_id = $2, accepted_at = NOW()
+			WHERE id = $1
+			RETURNING `+invitationColumns, id, c.ID).StructScan(&accepted)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &accepted, &c, nil
+}
+
+// Revoke withdraws a pending invitation, so its token can no longer be used
+func (r *InvitationRepository) Revoke(ctx context.Context, familyID, id int) (*invitation.Invitation, error) {
+	var revoked invitation.Invitation
+	err := r.db.GetContext(ctx, &revoked, `
+		UPDATE invitations SET status = 'revoked', revoked_at = NOW()
+		WHERE id = $1 AND family_id = $2 AND status = 'pending'
+		RETURNING `+invitationColumns, id, familyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, err := r.GetByID(ctx, familyID, id); err != nil {
+				return nil, err
+			}
+			return nil, interfaces.ErrInvitationNotPending
+		}
+		return nil, fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	return &revoked, nil
+}
+
+// RevokeCaregiver sets the status of a caregiver of the family, given by public ID, to revoked
+func (r *InvitationRepository) RevokeCaregiver(ctx context.Context, familyID int, caregiverID string) (*caregiver.Caregiver, error) {
+	var c caregiver.Caregiver
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, `
+			UPDATE caregivers SET status = 'revoked', updated_at = NOW()
+			WHERE public_id = $1 AND family_id = $2 AND deleted_at IS NULL
+			RETURNING `+caregiverColumns, caregiverID, familyID).StructScan(&c)
+		if err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, event.CaregiverUpdated, c.ID, c)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("caregiver with id %s %w in family %d", caregiverID, interfaces.ErrNotFound, familyID)
+		}
+		return nil, fmt.Errorf("failed to revoke caregiver: %w", err)
+	}
+
+	return &c, nil
+}
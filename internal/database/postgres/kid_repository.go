@@ -8,12 +8,15 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
 	"github.com/lukasz/astras-mono-api/internal/models/kid"
 )
 
 // KidRepository implements the interfaces.KidRepository interface for PostgreSQL
 type KidRepository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	withTx func(ctx context.Context, fn func(*sqlx.Tx) error) error // Runs fn in a database transaction (see RepositoryManager.withTx)
 }
 
 // Create adds a new kid to the database and returns the kid with generated ID
@@ -26,36 +29,32 @@ func (r *KidRepository) Create(ctx context.Context, k *kid.Kid) (*kid.Kid, error
 	query := `
 		INSERT INTO kids (name, birthdate, created_at, updated_at)
 		VALUES ($1, $2, NOW(), NOW())
-		RETURNING id, created_at, updated_at`
+		RETURNING id, public_id, created_at, updated_at`
 
-	var id int
-	var createdAt, updatedAt time.Time
-	err := r.db.QueryRowContext(ctx, query, k.Name, k.Birthdate).Scan(&id, &createdAt, &updatedAt)
+	createdKid := &kid.Kid{Name: k.Name, Birthdate: k.Birthdate}
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowContext(ctx, query, k.Name, k.Birthdate).Scan(&createdKid.ID, &createdKid.PublicID, &createdKid.CreatedAt, &createdKid.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, event.KidCreated, createdKid.ID, createdKid)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kid: %w", err)
 	}
 
-	// Return the created kid with all data
-	createdKid := &kid.Kid{
-		ID:        id,
-		Name:      k.Name,
-		Birthdate: k.Birthdate,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-	}
-
 	return createdKid, nil
 }
 
-// GetByID retrieves a kid by their unique identifier
+// GetByID retrieves a kid by their unique identifier, unless the kid is soft deleted
 func (r *KidRepository) GetByID(ctx context.Context, id int) (*kid.Kid, error) {
-	query := `SELECT id, name, birthdate, created_at, updated_at FROM kids WHERE id = $1`
+	query := `SELECT id, public_id, name, birthdate, created_at, updated_at FROM kids WHERE id = $1 AND deleted_at IS NULL`
 
 	var k kid.Kid
 	err := r.db.GetContext(ctx, &k, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("kid with id %d not found", id)
+			return nil, fmt.Errorf("kid with id %d %w", id, interfaces.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get kid: %w", err)
 	}
@@ -63,9 +62,14 @@ func (r *KidRepository) GetByID(ctx context.Context, id int) (*kid.Kid, error) {
 	return &k, nil
 }
 
-// GetAll retrieves all kids from the database
+// ResolveID returns the internal ID of the kid with the given public ID, even if the kid is soft deleted
+func (r *KidRepository) ResolveID(ctx context.Context, publicID string) (int, error) {
+	return resolvePublicID(ctx, r.db, "kids", publicID, fmt.Errorf("kid with id %s %w", publicID, interfaces.ErrNotFound))
+}
+
+// GetAll retrieves all kids that are not soft deleted from the database
 func (r *KidRepository) GetAll(ctx context.Context) ([]*kid.Kid, error) {
-	query := `SELECT id, name, birthdate, created_at, updated_at FROM kids ORDER BY created_at DESC`
+	query := `SELECT id, public_id, name, birthdate, created_at, updated_at FROM kids WHERE deleted_at IS NULL ORDER BY created_at DESC`
 
 	var kids []kid.Kid
 	err := r.db.SelectContext(ctx, &kids, query)
@@ -82,8 +86,9 @@ func (r *KidRepository) GetAll(ctx context.Context) ([]*kid.Kid, error) {
 	return result, nil
 }
 
-// Update modifies an existing kid's information
-func (r *KidRepository) Update(ctx context.Context, k *kid.Kid) (*kid.Kid, error) {
+// Update modifies an existing kid's information.
+// The version check is part of the UPDATE's WHERE clause, so it is atomic.
+func (r *KidRepository) Update(ctx context.Context, k *kid.Kid, ifMatch ...time.Time) (*kid.Kid, error) {
 	// Validate the kid before saving
 	if err := k.Validate(); err != nil {
 		return nil, fmt.Errorf("kid validation failed: %w", err)
@@ -92,14 +97,26 @@ func (r *KidRepository) Update(ctx context.Context, k *kid.Kid) (*kid.Kid, error
 	query := `
 		UPDATE kids 
 		SET name = $2, birthdate = $3, updated_at = NOW()
-		WHERE id = $1
-		RETURNING id, name, birthdate, created_at, updated_at`
+		WHERE id = $1 AND deleted_at IS NULL`
+	args := []any{k.ID, k.Name, k.Birthdate}
+
+	if len(ifMatch) > 0 {
+		query += ` AND updated_at = ANY($4)`
+		args = append(args, ifMatch)
+	}
+	query += `
+		RETURNING id, public_id, name, birthdate, created_at, updated_at`
 
 	var updatedKid kid.Kid
-	err := r.db.QueryRowxContext(ctx, query, k.ID, k.Name, k.Birthdate).StructScan(&updatedKid)
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowxContext(ctx, query, args...).StructScan(&updatedKid); err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, event.KidUpdated, updatedKid.ID, updatedKid)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("kid with id %d not found", k.ID)
+			return nil, conditionalWriteError(ctx, r.db, "kids", k.ID, fmt.Errorf("kid with id %d %w", k.ID, interfaces.ErrNotFound))
 		}
 		return nil, fmt.Errorf("failed to update kid: %w", err)
 	}
@@ -107,44 +124,130 @@ func (r *KidRepository) Update(ctx context.Context, k *kid.Kid) (*kid.Kid, error
 	return &updatedKid, nil
 }
 
-// Delete removes a kid from the database
-func (r *KidRepository) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM kids WHERE id = $1`
+// Delete soft deletes a kid. The row and the kid's transactions are kept until
+// the kid is restored or purged, so a mistaken delete loses no history.
+func (r *KidRepository) Delete(ctx context.Context, id int, ifMatch ...time.Time) error {
+	query := `UPDATE kids SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	args := []any{id}
+
+	if len(ifMatch) > 0 {
+		query += ` AND updated_at = ANY($2)`
+		args = append(args, ifMatch)
+	}
+
+	query += `
+		RETURNING id, public_id, name, birthdate, created_at, updated_at, deleted_at`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	var deletedKid kid.Kid
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowxContext(ctx, query, args...).StructScan(&deletedKid); err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, event.KidDeleted, id, deletedKid)
+	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return conditionalWriteError(ctx, r.db, "kids", id, fmt.Errorf("kid with id %d %w", id, interfaces.ErrNotFound))
+		}
 		return fmt.Errorf("failed to delete kid: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return nil
+}
+
+// Restore brings back a soft-deleted kid
+func (r *KidRepository) Restore(ctx context.Context, id int) (*kid.Kid, error) {
+	query := `
+		UPDATE kids SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, public_id, name, birthdate, created_at, updated_at`
+
+	var restoredKid kid.Kid
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowxContext(ctx, query, id).StructScan(&restoredKid); err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, event.KidRestored, id, restoredKid)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, restoreError(ctx, r.db, "kids", id, fmt.Errorf("kid with id %d %w", id, interfaces.ErrNotFound))
+		}
+		return nil, fmt.Errorf("failed to restore kid: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("kid with id %d not found", id)
+	return &restoredKid, nil
+}
+
+// Purge permanently removes kids soft deleted before the given time, together with
+// their transactions. Transactions go first so their audit events can still name the kid
+func (r *KidRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged []purgedRow
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM transactions WHERE kid_id IN (SELECT id FROM kids WHERE deleted_at < $1)`, deletedBefore); err != nil {
+			return err
+		}
+		if err := tx.SelectContext(ctx, &purged, `DELETE FROM kids WHERE deleted_at < $1 RETURNING id, public_id`, deletedBefore); err != nil {
+			return err
+		}
+		for _, p := range purged {
+			if err := enqueue(ctx, tx, event.KidPurged, p.ID, event.Removed{ID: p.PublicID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge kids: %w", err)
 	}
 
-	return nil
+	return len(purged), nil
+}
+
+// kidSortColumns maps client sort keys to kid columns
+var kidSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"birthdate":  "birthdate",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
 }
 
 // GetByAgeRange retrieves kids within a specific age range based on birthdate calculations
 func (r *KidRepository) GetByAgeRange(ctx context.Context, minAge, maxAge int) ([]*kid.Kid, error) {
-	// Calculate birthdate ranges from age requirements
+	kids, err := r.Find(ctx, interfaces.KidFilter{MinAge: &minAge, MaxAge: &maxAge, Sort: "-birthdate,name"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kids by age range: %w", err)
+	}
+
+	return kids, nil
+}
+
+// Find retrieves kids matching the filter. Age bounds are translated into
+// birthdate ranges so the birthdate index can be used.
+func (r *KidRepository) Find(ctx context.Context, filter interfaces.KidFilter) ([]*kid.Kid, error) {
+	f := NewFilter().Deleted(filter.Deleted)
 	now := time.Now()
-	maxBirthdate := now.AddDate(-minAge, 0, 0)    // Youngest possible birthdate
-	minBirthdate := now.AddDate(-maxAge-1, 0, 0)  // Oldest possible birthdate (accounting for not having birthday yet)
 
-	query := `
-		SELECT id, name, birthdate, created_at, updated_at 
-		FROM kids 
-		WHERE birthdate <= $1 AND birthdate > $2
-		ORDER BY birthdate DESC, name ASC`
+	if filter.MinAge != nil {
+		// Youngest possible birthdate
+		f.Where("birthdate <= ?", now.AddDate(-*filter.MinAge, 0, 0))
+	}
+	if filter.MaxAge != nil {
+		// Oldest possible birthdate (accounting for not having birthday yet)
+		f.Where("birthdate > ?", now.AddDate(-*filter.MaxAge-1, 0, 0))
+	}
+
+	if err := f.Sort(filter.Sort, kidSortColumns, "created_at DESC"); err != nil {
+		return nil, err
+	}
+
+	query, args := f.Build(`SELECT id, public_id, name, birthdate, created_at, updated_at, deleted_at FROM kids`)
 
 	var kids []kid.Kid
-	err := r.db.SelectContext(ctx, &kids, query, maxBirthdate, minBirthdate)
+	err := r.db.SelectContext(ctx, &kids, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get kids by age range: %w", err)
+		return nil, fmt.Errorf("failed to find kids: %w", err)
 	}
 
 	// Convert to slice of pointers
@@ -154,4 +257,4 @@ func (r *KidRepository) GetByAgeRange(ctx context.Context, minAge, maxAge int) (
 	}
 
 	return result, nil
-}
\ No newline at end of file
+}
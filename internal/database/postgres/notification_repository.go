@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+	"github.com/lukasz/astras-mono-api/internal/models/notification"
+)
+
+// NotificationRepository implements the interfaces.NotificationRepository interface for PostgreSQL
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+// preferenceColumns are the columns of notification_preferences read into notification.Preferences
+const preferenceColumns = `caregiver_id, weekly_summary, pending_approvals, large_spends, large_spend_threshold, upcoming_birthdays, updated_at`
+
+// kindColumns maps each kind of notification to the preference column opting in to it
+var kindColumns = map[notification.Kind]string{
+	notification.KindWeeklySummary:    "weekly_summary",
+	notification.KindPendingApprovals: "pending_approvals",
+	notification.KindLargeSpend:       "large_spends",
+	notification.KindUpcomingBirthday: "upcoming_birthdays",
+}
+
+// GetPreferences retrieves the preferences of a caregiver, the defaults when none were saved
+func (r *NotificationRepository) GetPreferences(ctx context.Context, caregiverID int) (*notification.Preferences, error) {
+	var preferences notification.Preferences
+	err := r.db.GetContext(ctx, &preferences, `SELECT `+preferenceColumns+` FROM notification_preferences WHERE caregiver_id = $1`, caregiverID)
+	if err == nil {
+		return &preferences, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	// Only existing caregivers have defaults
+	var exists bool
+	err = r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM caregivers WHERE id = $1 AND deleted_at IS NULL)`, caregiverID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caregiver: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("caregiver with id %d %w", caregiverID, interfaces.ErrNotFound)
+	}
+
+	return notification.DefaultPreferences(caregiverID), nil
+}
+
+// SetPreferences saves the preferences of a caregiver and returns them
+func (r *NotificationRepository) SetPreferences(ctx context.Context, p *notification.Preferences) (*notification.Preferences, error) {
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("notification preferences validation failed: %w", err)
+	}
+
+	query := `
+		INSERT INTO notification_preferences (caregiver_id, weekly_summary, pending_approvals, large_spends, large_spend_threshold, upcoming_birthdays)
+		SELECT id, $2, $3, $4, $5, $6 FROM caregivers WHERE id = $1 AND deleted_at IS NULL
+		ON CONFLICT (caregiver_id) DO UPDATE SET
+			weekly_summary = EXCLUDED.weekly_summary,
+			pending_approvals = EXCLUDED.pending_approvals,
+			large_spends = EXCLUDED.large_spends,
+			large_spend_threshold = EXCLUDED.large_spend_threshold,
+			upcoming_birthdays = EXCLUDED.upcoming_birthdays
+		RETURNING ` + preferenceColumns
+
+	var saved notification.Preferences
+	err := r.db.GetContext(ctx, &saved, query, p.CaregiverID, p.WeeklySummary, p.PendingApprovals,
+		p.LargeSpends, p.LargeSpendThreshold, p.UpcomingBirthdays)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "23503") { // foreign_key_violation
+			return nil, fmt.Errorf("caregiver with id %d %w", p.CaregiverID, interfaces.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	return &saved, nil
+}
+
+// Recipients retrieves the active caregivers of a family, or of every family, who opted
+// in to the kind of notification, ordered by family. Only verified addresses are emailed.
+func (r *NotificationRepository) Recipients(ctx context.Context, kind notification.Kind, familyID ...int) ([]*notification.Recipient, error) {
+	column, ok := kindColumns[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown notification kind: %s", kind)
+	}
+
+	query := `
+		SELECT c.id AS caregiver_id, c.public_id AS caregiver_public_id, c.family_id, c.name, c.email, p.large_spend_threshold
+		FROM caregivers c
+		JOIN notification_preferences p ON p.caregiver_id = c.id
+		WHERE p.` + column + ` AND c.status = 'active' AND c.deleted_at IS NULL AND c.family_id IS NOT NULL`
+	args := []any{}
+	if len(familyID) > 0 {
+		query += ` AND c.family_id = $1`
+		args = append(args, familyID[0])
+	}
+	query += ` ORDER BY c.family_id, c.id`
+
+	var recipients []*notification.Recipient
+	if err := r.db.SelectContext(ctx, &recipients, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get notification recipients: %w", err)
+	}
+
+	return recipients, nil
+}
+
+// KidSummaries retrieves the stars of the kids in a family, or of the kids with the given
+// public IDs only, earned and spent in [from, to) with their balance at to, ordered by family and name
+func (r *NotificationRepository) KidSummaries(ctx context.Context, from, to time.Time, kidIDs ...string) ([]*notification.KidSummary, error) {
+	query := `
+		SELECT k.id AS kid_id, k.public_id AS kid_public_id, k.family_id, k.name, k.birthdate,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'earn' AND t.created_at >= $1), 0) AS earned,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'spend' AND t.created_at >= $1), 0) AS spent,
+			COALESCE(SUM(CASE WHEN t.type = 'earn' THEN t.amount ELSE -t.amount END), 0) AS balance
+		FROM kids k
+		LEFT JOIN transactions t ON t.kid_id = k.id AND t.created_at < $2
+		WHERE k.deleted_at IS NULL AND k.family_id IS NOT NULL`
+	args := []any{from, to}
+	if len(kidIDs) > 0 {
+		query += ` AND k.public_id = ANY($3::uuid[])`
+		args = append(args, kidIDs)
+	}
+	query += ` GROUP BY k.id ORDER BY k.family_id, k.name, k.id`
+
+	var summaries []*notification.KidSummary
+	if err := r.db.SelectContext(ctx, &summaries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get kid summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// PendingDeletions retrieves the family deletion requests waiting for confirmation
+// that have not expired
+func (r *NotificationRepository) PendingDeletions(ctx context.Context) ([]*family.DeletionRequest, error) {
+	query := `SELECT ` + deletionColumns + ` FROM data_deletions
+		WHERE status = 'pending' AND expires_at > NOW()
+		ORDER BY family_id, id`
+
+	var deletions []*family.DeletionRequest
+	if err := r.db.SelectContext(ctx, &deletions, query); err != nil {
+		return nil, fmt.Errorf("failed to get pending deletions: %w", err)
+	}
+
+	return deletions, nil
+}
+
+// MarkSent records that a caregiver is being notified about key; the unique
+// (caregiver_id, kind, key) constraint makes a second mark return false
+func (r *NotificationRepository) MarkSent(ctx context.Context, caregiverID int, kind notification.Kind, key string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO notifications_sent (caregiver_id, kind, key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (caregiver_id, kind, key) DO NOTHING`, caregiverID, string(kind), key)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// UnmarkSent forgets a notification that could not be sent
+func (r *NotificationRepository) UnmarkSent(ctx context.Context, caregiverID int, kind notification.Kind, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM notifications_sent WHERE caregiver_id = $1 AND kind = $2 AND key = $3`,
+		caregiverID, string(kind), key)
+	if err != nil {
+		return fmt.Errorf("failed to unmark notification: %w", err)
+	}
+	return nil
+}
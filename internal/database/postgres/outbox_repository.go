@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+)
+
+// OutboxRepository implements the interfaces.OutboxRepository interface for PostgreSQL
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+// Dispatch leases up to options.Limit pending events, publishes them in id order and
+// marks them one by one. The lease is committed before publishing, so no row locks are
+// held while the sink runs; the events not reached after a failure are released.
+func (r *OutboxRepository) Dispatch(ctx context.Context, options interfaces.DispatchOptions, publish func(ctx context.Context, e *event.Event) error) (int, error) {
+	var events []event.Event
+	err := r.db.SelectContext(ctx, &events, `
+		UPDATE outbox SET locked_until = NOW() + $2 * interval '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND parked_at IS NULL AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, event_type, entity, entity_id, payload, created_at`, options.Limit, options.Lease.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim pending events: %w", err)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	for i := range events {
+		if err := publish(ctx, &events[i]); err != nil {
+			return i, r.recordFailure(ctx, events[i:], options.MaxAttempts, err)
+		}
+
+		_, err := r.db.ExecContext(ctx, `UPDATE outbox SET published_at = NOW(), locked_until = NULL, last_error = NULL WHERE id = $1`, events[i].ID)
+		if err != nil {
+			// The event stays leased and is published again once the lease ends
+			return i, fmt.Errorf("failed to mark event published: %w", err)
+		}
+	}
+
+	return len(events), nil
+}
+
+// recordFailure records why the first of the remaining events failed, parking it once
+// it has used up maxAttempts, and releases all of them for the next Dispatch
+func (r *OutboxRepository) recordFailure(ctx context.Context, remaining []event.Event, maxAttempts int, publishErr error) error {
+	failed := remaining[0].ID
+	var parked bool
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2, locked_until = NULL,
+			parked_at = CASE WHEN $3 > 0 AND attempts + 1 >= $3 THEN NOW() END
+		WHERE id = $1
+		RETURNING parked_at IS NOT NULL`, failed, publishErr.Error(), maxAttempts).Scan(&parked)
+	if err != nil {
+		return fmt.Errorf("failed to record publish failure: %w", err)
+	}
+
+	ids := make([]int64, len(remaining)-1)
+	for i, e := range remaining[1:] {
+		ids[i] = e.ID
+	}
+	if _, err := r.db.ExecContext(ctx, `UPDATE outbox SET locked_until = NULL WHERE id = ANY($1::bigint[])`, ids); err != nil {
+		return fmt.Errorf("failed to release events: %w", err)
+	}
+
+	if parked {
+		return fmt.Errorf("failed to publish event %d, parked after %d attempts: %w", failed, maxAttempts, publishErr)
+	}
+	return fmt.Errorf("failed to publish event %d: %w", failed, publishErr)
+}
+
+// enqueue writes a domain event to the outbox in the database transaction of the change
+// it describes, so the event exists exactly when the change is committed
+func enqueue(ctx context.Context, tx sqlx.ExecerContext, t event.Type, entityID int, payload any) error {
+	e, err := event.New(t, entityID, payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO outbox (event_type, entity, entity_id, payload)
+		VALUES ($1, $2, $3, $4)`, string(e.Type), e.Entity, e.EntityID, string(e.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to write %s event: %w", t, err)
+	}
+
+	return nil
+}
@@ -3,18 +3,120 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 
 	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
 	"github.com/lukasz/astras-mono-api/internal/models/transaction"
 )
 
 // TransactionRepository implements the interfaces.TransactionRepository interface for PostgreSQL
 type TransactionRepository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	withTx func(ctx context.Context, fn func(*sqlx.Tx) error) error // Runs fn in a database transaction (see RepositoryManager.withTx)
+}
+
+// kidNotDeleted limits transactions to those of kids that are not soft deleted; the
+// history of a deleted kid is kept for a restore but hidden until then
+const kidNotDeleted = `EXISTS (SELECT 1 FROM kids WHERE kids.id = kid_id AND kids.deleted_at IS NULL)`
+
+// transactionColumns are the transaction columns read by scanTransaction; tags is a JSONB array
+// and kid_public_id the public ID of the transaction's kid
+const transactionColumns = `id, public_id, kid_id, (SELECT public_id FROM kids WHERE kids.id = kid_id) AS kid_public_id,
+	type, amount, description, category_id, tags::text, created_at, updated_at`
+
+// ledgerWithBalances returns the transactions table with the running balance of each
+// kid's ledger after every transaction, and the filter to compose the outer query with.
+// The kid and the end of the period are pushed into the window subquery, so only the
+// ledgers asked for are scanned; the start of the period and the other conditions
+// filter the rows after the balances are computed, since those depend on every
+// earlier transaction.
+func ledgerWithBalances(filter interfaces.TransactionFilter) (string, *Filter) {
+	ledger := NewFilter()
+	if filter.KidID > 0 {
+		ledger.Equal("kid_id", filter.KidID)
+	}
+	if filter.To != nil {
+		ledger.Where("created_at < ?", *filter.To)
+	}
+
+	query, _ := ledger.Build(`SELECT *, SUM(CASE WHEN type = 'earn' THEN amount ELSE -amount END) OVER (PARTITION BY kid_id ORDER BY created_at, id) AS balance FROM transactions`)
+	return "(" + query + ") transactions", ledger.Outer()
+}
+
+// scanTransaction reads the transactionColumns of a row, followed by the extra columns
+func scanTransaction(row interface{ Scan(dest ...any) error }, extra ...any) (*transaction.Transaction, error) {
+	var t transaction.Transaction
+	var typeStr, tags string
+
+	dest := []any{&t.ID, &t.PublicID, &t.KidID, &t.KidPublicID, &typeStr, &t.Amount, &t.Description, &t.CategoryID, &tags, &t.CreatedAt, &t.UpdatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	t.Type = transaction.TransactionType(typeStr)
+	if err := json.Unmarshal([]byte(tags), &t.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags of transaction %d: %w", t.ID, err)
+	}
+
+	return &t, nil
+}
+
+// queryTransactions runs a SELECT of transactionColumns and scans the rows
+func queryTransactions(ctx context.Context, q sqlx.QueryerContext, query string, args ...any) ([]*transaction.Transaction, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*transaction.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// tagsJSON encodes tags for the JSONB tags column
+func tagsJSON(tags []string) string {
+	encoded, _ := json.Marshal(transaction.NormalizeTags(tags))
+	return string(encoded)
+}
+
+// checkCategory makes sure a transaction's category belongs to the family of its kid
+func checkCategory(ctx context.Context, q sqlx.QueryerContext, kidID int, categoryID *int) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	var exists bool
+	err := q.QueryRowxContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM categories c JOIN kids k ON k.family_id = c.family_id
+			WHERE c.id = $1 AND k.id = $2
+		)`, *categoryID, kidID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check category: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("category with id %d %w in the family of the kid", *categoryID, interfaces.ErrNotFound)
+	}
+	return nil
 }
 
 // Create adds a new transaction to the database and returns the transaction with generated ID
@@ -24,86 +126,180 @@ func (r *TransactionRepository) Create(ctx context.Context, t *transaction.Trans
 		return nil, fmt.Errorf("transaction validation failed: %w", err)
 	}
 
+	var created *transaction.Transaction
+	err := r.withTx(ctx, func(tx *sqlx.Tx) (err error) {
+		if created, err = insertTransaction(ctx, tx, t); err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, createdEvent(created), created.ID, created)
+	})
+	return created, err
+}
+
+// CreateBatch adds transactions in a single database transaction.
+// Items are validated before any of them is written. In best-effort mode every
+// item runs under its own savepoint, so a failing insert is undone without
+// aborting the database transaction and the remaining items still commit.
+func (r *TransactionRepository) CreateBatch(ctx context.Context, transactions []*transaction.Transaction, atomic bool) ([]interfaces.BatchResult, error) {
+	results := make([]interfaces.BatchResult, len(transactions))
+
+	invalid := false
+	for i, t := range transactions {
+		if err := t.Validate(); err != nil {
+			results[i].Err = fmt.Errorf("transaction validation failed: %w", err)
+			invalid = true
+		}
+	}
+	if invalid && atomic {
+		return results, interfaces.ErrBatchRolledBack
+	}
+
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		for i, t := range transactions {
+			if results[i].Err != nil {
+				continue
+			}
+
+			if !atomic {
+				if _, err := tx.ExecContext(ctx, `SAVEPOINT batch_item`); err != nil {
+					return fmt.Errorf("failed to create savepoint: %w", err)
+				}
+			}
+
+			created, err := insertTransaction(ctx, tx, t)
+			if err != nil {
+				results[i].Err = batchItemError(t, err)
+				if atomic {
+					return interfaces.ErrBatchRolledBack
+				}
+				if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT batch_item`); err != nil {
+					return fmt.Errorf("failed to roll back to savepoint: %w", err)
+				}
+				continue
+			}
+
+			if err := enqueue(ctx, tx, createdEvent(created), created.ID, created); err != nil {
+				return err
+			}
+
+			if !atomic {
+				if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT batch_item`); err != nil {
+					return fmt.Errorf("failed to release savepoint: %w", err)
+				}
+			}
+			results[i].Transaction = created
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing was committed, so no item has been created
+		for i := range results {
+			results[i].Transaction = nil
+		}
+		return results, err
+	}
+
+	return results, nil
+}
+
+// insertTransaction adds a validated transaction using db or a database transaction.
+// Soft-deleted kids cannot get new transactions, so the kid must exist and not be deleted.
+func insertTransaction(ctx context.Context, q sqlx.QueryerContext, t *transaction.Transaction) (*transaction.Transaction, error) {
+	if err := checkCategory(ctx, q, t.KidID, t.CategoryID); err != nil {
+		return nil, err
+	}
+
 	query := `
-		INSERT INTO transactions (kid_id, type, amount, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, NOW(), NOW())
-		RETURNING id, created_at, updated_at`
+		INSERT INTO transactions (kid_id, type, amount, description, category_id, tags, created_at, updated_at)
+		SELECT id, $2::transaction_type, $3::integer, $4::varchar, $5::integer, $6::jsonb, NOW(), NOW() FROM kids WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + transactionColumns
 
-	var id int
-	var createdAt, updatedAt time.Time
-	err := r.db.QueryRowContext(ctx, query, t.KidID, string(t.Type), t.Amount, t.Description).Scan(&id, &createdAt, &updatedAt)
+	created, err := scanTransaction(q.QueryRowxContext(ctx, query, t.KidID, string(t.Type), t.Amount, t.Description, t.CategoryID, tagsJSON(t.Tags)))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("kid with id %s %w", t.KidPublicID, interfaces.ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	// Return the created transaction with all data
-	createdTransaction := &transaction.Transaction{
-		ID:          id,
-		KidID:       t.KidID,
-		Type:        t.Type,
-		Amount:      t.Amount,
-		Description: t.Description,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
+	return created, nil
+}
+
+// checkKid makes sure the kid a transaction is written for exists and is not soft deleted
+func checkKid(ctx context.Context, q sqlx.QueryerContext, t *transaction.Transaction) error {
+	var exists bool
+	err := q.QueryRowxContext(ctx, `SELECT EXISTS (SELECT 1 FROM kids WHERE id = $1 AND deleted_at IS NULL)`, t.KidID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check kid: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("kid with id %s %w", t.KidPublicID, interfaces.ErrNotFound)
 	}
+	return nil
+}
 
-	return createdTransaction, nil
+// createdEvent returns the event type of a new transaction: stars earned or spent
+func createdEvent(t *transaction.Transaction) event.Type {
+	if t.Type == transaction.TransactionTypeSpend {
+		return event.StarsSpent
+	}
+	return event.StarsEarned
+}
+
+// batchItemError explains why a batch item could not be inserted
+func batchItemError(t *transaction.Transaction, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+		return fmt.Errorf("kid with id %s %w", t.KidPublicID, interfaces.ErrNotFound)
+	}
+	return err
 }
 
 // GetByID retrieves a transaction by its unique identifier
 func (r *TransactionRepository) GetByID(ctx context.Context, id int) (*transaction.Transaction, error) {
-	query := `SELECT id, kid_id, type, amount, description, created_at, updated_at FROM transactions WHERE id = $1`
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 AND ` + kidNotDeleted
 
-	var t transaction.Transaction
-	var typeStr string
-	
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&t.ID, &t.KidID, &typeStr, &t.Amount, &t.Description, &t.CreatedAt, &t.UpdatedAt,
-	)
+	t, err := scanTransaction(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("transaction with id %d not found", id)
+			return nil, fmt.Errorf("transaction with id %d %w", id, interfaces.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 
-	t.Type = transaction.TransactionType(typeStr)
-	return &t, nil
+	return t, nil
+}
+
+// ResolveID returns the internal ID of the transaction with the given public ID
+func (r *TransactionRepository) ResolveID(ctx context.Context, publicID string) (int, error) {
+	return resolvePublicID(ctx, r.db, "transactions", publicID, fmt.Errorf("transaction with id %s %w", publicID, interfaces.ErrNotFound))
+}
+
+// ResolveKidID returns the internal ID of the kid with the given public ID, even if the kid is soft deleted
+func (r *TransactionRepository) ResolveKidID(ctx context.Context, publicID string) (int, error) {
+	return resolvePublicID(ctx, r.db, "kids", publicID, fmt.Errorf("kid with id %s %w", publicID, interfaces.ErrNotFound))
+}
+
+// ResolveFamilyID returns the internal ID of the family with the given public ID
+func (r *TransactionRepository) ResolveFamilyID(ctx context.Context, publicID string) (int, error) {
+	return resolvePublicID(ctx, r.db, "families", publicID, fmt.Errorf("family with id %s %w", publicID, interfaces.ErrNotFound))
 }
 
 // GetAll retrieves all transactions from the database
 func (r *TransactionRepository) GetAll(ctx context.Context) ([]*transaction.Transaction, error) {
-	query := `SELECT id, kid_id, type, amount, description, created_at, updated_at FROM transactions ORDER BY created_at DESC`
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE ` + kidNotDeleted + ` ORDER BY created_at DESC`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	transactions, err := queryTransactions(ctx, r.db, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all transactions: %w", err)
 	}
-	defer rows.Close()
-
-	var transactions []*transaction.Transaction
-	for rows.Next() {
-		var t transaction.Transaction
-		var typeStr string
-		
-		err := rows.Scan(&t.ID, &t.KidID, &typeStr, &t.Amount, &t.Description, &t.CreatedAt, &t.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
-		
-		t.Type = transaction.TransactionType(typeStr)
-		transactions = append(transactions, &t)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
-	}
 
 	return transactions, nil
 }
 
-// Update modifies an existing transaction's information
-func (r *TransactionRepository) Update(ctx context.Context, t *transaction.Transaction) (*transaction.Transaction, error) {
+// Update modifies an existing transaction's information.
+// The version check is part of the UPDATE's WHERE clause, so it is atomic.
+func (r *TransactionRepository) Update(ctx context.Context, t *transaction.Transaction, ifMatch ...time.Time) (*transaction.Transaction, error) {
 	// Validate the transaction before saving
 	if err := t.Validate(); err != nil {
 		return nil, fmt.Errorf("transaction validation failed: %w", err)
@@ -111,110 +307,141 @@ func (r *TransactionRepository) Update(ctx context.Context, t *transaction.Trans
 
 	query := `
 		UPDATE transactions 
-		SET kid_id = $2, type = $3, amount = $4, description = $5, updated_at = NOW()
-		WHERE id = $1
-		RETURNING id, kid_id, type, amount, description, created_at, updated_at`
-
-	var updatedTransaction transaction.Transaction
-	var typeStr string
-	
-	err := r.db.QueryRowContext(ctx, query, t.ID, t.KidID, string(t.Type), t.Amount, t.Description).Scan(
-		&updatedTransaction.ID, &updatedTransaction.KidID, &typeStr, &updatedTransaction.Amount, 
-		&updatedTransaction.Description, &updatedTransaction.CreatedAt, &updatedTransaction.UpdatedAt,
-	)
+		SET kid_id = $2, type = $3, amount = $4, description = $5, category_id = $6, tags = $7::jsonb, updated_at = NOW()
+		WHERE id = $1 AND ` + kidNotDeleted
+	args := []any{t.ID, t.KidID, string(t.Type), t.Amount, t.Description, t.CategoryID, tagsJSON(t.Tags)}
+
+	if len(ifMatch) > 0 {
+		query += ` AND updated_at = ANY($8)`
+		args = append(args, ifMatch)
+	}
+	query += `
+		RETURNING ` + transactionColumns
+
+	var updatedTransaction *transaction.Transaction
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkKid(ctx, tx, t); err != nil {
+			return err
+		}
+		if err := checkCategory(ctx, tx, t.KidID, t.CategoryID); err != nil {
+			return err
+		}
+		updated, err := scanTransaction(tx.QueryRowContext(ctx, query, args...))
+		if err != nil {
+			return err
+		}
+		updatedTransaction = updated
+		return enqueue(ctx, tx, event.TransactionUpdated, updated.ID, *updated)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("transaction with id %d not found", t.ID)
+			return nil, conditionalWriteError(ctx, r.db, "transactions", t.ID, fmt.Errorf("transaction with id %d %w", t.ID, interfaces.ErrNotFound))
+		}
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return nil, err
 		}
 		return nil, fmt.Errorf("failed to update transaction: %w", err)
 	}
 
-	updatedTransaction.Type = transaction.TransactionType(typeStr)
-	return &updatedTransaction, nil
+	return updatedTransaction, nil
 }
 
 // Delete removes a transaction from the database
-func (r *TransactionRepository) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM transactions WHERE id = $1`
+func (r *TransactionRepository) Delete(ctx context.Context, id int, ifMatch ...time.Time) error {
+	query := `DELETE FROM transactions WHERE id = $1 AND ` + kidNotDeleted
+	args := []any{id}
 
-	result, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete transaction: %w", err)
+	if len(ifMatch) > 0 {
+		query += ` AND updated_at = ANY($2)`
+		args = append(args, ifMatch)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+	query += `
+		RETURNING ` + transactionColumns
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("transaction with id %d not found", id)
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		deleted, err := scanTransaction(tx.QueryRowContext(ctx, query, args...))
+		if err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, event.TransactionDeleted, id, *deleted)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return conditionalWriteError(ctx, r.db, "transactions", id, fmt.Errorf("transaction with id %d %w", id, interfaces.ErrNotFound))
+		}
+		return fmt.Errorf("failed to delete transaction: %w", err)
 	}
 
 	return nil
 }
 
+// transactionSortColumns maps client sort keys to transaction columns
+var transactionSortColumns = map[string]string{
+	"id":         "id",
+	"kid_id":     "kid_id",
+	"type":       "type",
+	"amount":     "amount",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
 // GetByKidID retrieves all transactions for a specific kid
 func (r *TransactionRepository) GetByKidID(ctx context.Context, kidID int) ([]*transaction.Transaction, error) {
-	query := `
-		SELECT id, kid_id, type, amount, description, created_at, updated_at 
-		FROM transactions 
-		WHERE kid_id = $1 
-		ORDER BY created_at DESC`
-
-	rows, err := r.db.QueryContext(ctx, query, kidID)
+	transactions, err := r.Find(ctx, interfaces.TransactionFilter{KidID: kidID})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions by kid ID: %w", err)
 	}
-	defer rows.Close()
 
-	var transactions []*transaction.Transaction
-	for rows.Next() {
-		var t transaction.Transaction
-		var typeStr string
-		
-		err := rows.Scan(&t.ID, &t.KidID, &typeStr, &t.Amount, &t.Description, &t.CreatedAt, &t.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
-		
-		t.Type = transaction.TransactionType(typeStr)
-		transactions = append(transactions, &t)
+	return transactions, nil
+}
+
+// GetByType retrieves all transactions of a specific type (earn/spend)
+func (r *TransactionRepository) GetByType(ctx context.Context, transactionType transaction.TransactionType) ([]*transaction.Transaction, error) {
+	transactions, err := r.Find(ctx, interfaces.TransactionFilter{Type: transactionType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions by type: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
+	return transactions, nil
+}
+
+// GetByKidIDAndType retrieves transactions for a specific kid and type
+func (r *TransactionRepository) GetByKidIDAndType(ctx context.Context, kidID int, transactionType transaction.TransactionType) ([]*transaction.Transaction, error) {
+	transactions, err := r.Find(ctx, interfaces.TransactionFilter{KidID: kidID, Type: transactionType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions by kid ID and type: %w", err)
 	}
 
 	return transactions, nil
 }
 
-// GetByType retrieves all transactions of a specific type (earn/spend)
-func (r *TransactionRepository) GetByType(ctx context.Context, transactionType transaction.TransactionType) ([]*transaction.Transaction, error) {
-	query := `
-		SELECT id, kid_id, type, amount, description, created_at, updated_at 
-		FROM transactions 
-		WHERE type = $1 
-		ORDER BY created_at DESC`
+// Find retrieves transactions matching the filter with the running balance of their
+// kid's ledger, computed by a window function over the ledgers in the filter (ledgerWithBalances)
+func (r *TransactionRepository) Find(ctx context.Context, filter interfaces.TransactionFilter) ([]*transaction.Transaction, error) {
+	ledger, f := ledgerWithBalances(filter)
+	transactionConditions(f, filter)
+
+	if err := f.Sort(filter.Sort, transactionSortColumns, "created_at DESC"); err != nil {
+		return nil, err
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, string(transactionType))
+	query, args := f.Build(`SELECT ` + transactionColumns + `, balance FROM ` + ledger)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transactions by type: %w", err)
+		return nil, fmt.Errorf("failed to find transactions: %w", err)
 	}
 	defer rows.Close()
 
 	var transactions []*transaction.Transaction
 	for rows.Next() {
-		var t transaction.Transaction
-		var typeStr string
-		
-		err := rows.Scan(&t.ID, &t.KidID, &typeStr, &t.Amount, &t.Description, &t.CreatedAt, &t.UpdatedAt)
+		var balance int
+		t, err := scanTransaction(rows, &balance)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
-		
-		t.Type = transaction.TransactionType(typeStr)
-		transactions = append(transactions, &t)
+		t.Balance = &balance
+		transactions = append(transactions, t)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -224,73 +451,175 @@ func (r *TransactionRepository) GetByType(ctx context.Context, transactionType t
 	return transactions, nil
 }
 
-// GetByKidIDAndType retrieves transactions for a specific kid and type
-func (r *TransactionRepository) GetByKidIDAndType(ctx context.Context, kidID int, transactionType transaction.TransactionType) ([]*transaction.Transaction, error) {
-	query := `
-		SELECT id, kid_id, type, amount, description, created_at, updated_at 
-		FROM transactions 
-		WHERE kid_id = $1 AND type = $2 
-		ORDER BY created_at DESC`
+// FindAfter retrieves a page of transactions matching the filter in ledger order.
+// The row comparison on (created_at, id) is served by idx_transactions_kid_ledger,
+// so every page costs the same however deep into the history it is.
+func (r *TransactionRepository) FindAfter(ctx context.Context, filter interfaces.TransactionFilter, after interfaces.Cursor, limit int) ([]*transaction.Transaction, error) {
+	f := transactionConditions(NewFilter(), filter)
 
-	rows, err := r.db.QueryContext(ctx, query, kidID, string(transactionType))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get transactions by kid ID and type: %w", err)
+	if after.ID > 0 {
+		f.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
 	}
-	defer rows.Close()
+	if err := f.Sort("", nil, "created_at ASC, id ASC"); err != nil {
+		return nil, err
+	}
+	f.Limit(limit)
 
-	var transactions []*transaction.Transaction
-	for rows.Next() {
-		var t transaction.Transaction
-		var typeStr string
-		
-		err := rows.Scan(&t.ID, &t.KidID, &typeStr, &t.Amount, &t.Description, &t.CreatedAt, &t.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
-		
-		t.Type = transaction.TransactionType(typeStr)
-		transactions = append(transactions, &t)
+	return r.query(ctx, f)
+}
+
+// GetBalance calculates the star balance of the transactions matching the filter
+func (r *TransactionRepository) GetBalance(ctx context.Context, filter interfaces.TransactionFilter) (int, error) {
+	query, args := transactionConditions(NewFilter(), filter).Build(`
+		SELECT COALESCE(SUM(CASE WHEN type = 'earn' THEN amount ELSE -amount END), 0)
+		FROM transactions`)
+
+	var balance int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to get balance: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
+	return balance, nil
+}
+
+// transactionConditions adds the conditions of a transaction filter to f and returns it
+func transactionConditions(f *Filter, filter interfaces.TransactionFilter) *Filter {
+	f.Where(kidNotDeleted)
+
+	if filter.KidID > 0 {
+		f.Equal("kid_id", filter.KidID)
+	}
+	if filter.Type != "" {
+		f.Equal("type", string(filter.Type))
+	}
+	if filter.CategoryID != 0 {
+		f.Equal("category_id", filter.CategoryID)
+	}
+	if filter.Tag != "" {
+		f.Where("tags @> jsonb_build_array(?::text)", filter.Tag)
+	}
+	if filter.From != nil {
+		f.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		f.Where("created_at < ?", *filter.To)
+	}
+
+	return f
+}
+
+// query runs a transaction SELECT composed with f and scans the rows
+func (r *TransactionRepository) query(ctx context.Context, f *Filter) ([]*transaction.Transaction, error) {
+	query, args := f.Build(`SELECT ` + transactionColumns + ` FROM transactions`)
+
+	transactions, err := queryTransactions(ctx, r.db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transactions: %w", err)
 	}
 
 	return transactions, nil
 }
 
-// GetKidBalance calculates the current star balance for a kid
+// GetKidBalance returns the current star balance for a kid from kid_balances,
+// which a trigger keeps in step with the ledger
 func (r *TransactionRepository) GetKidBalance(ctx context.Context, kidID int) (int, error) {
-	query := `
-		SELECT 
-			COALESCE(SUM(CASE WHEN type = 'earn' THEN amount ELSE 0 END), 0) -
-			COALESCE(SUM(CASE WHEN type = 'spend' THEN amount ELSE 0 END), 0) as balance
-		FROM transactions 
-		WHERE kid_id = $1`
+	query := `SELECT balance FROM kid_balances WHERE kid_id = $1`
 
 	var balance int
 	err := r.db.QueryRowContext(ctx, query, kidID).Scan(&balance)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			// Kids without transactions have no totals
+			return 0, nil
+		}
 		return 0, fmt.Errorf("failed to get kid balance: %w", err)
 	}
 
 	return balance, nil
 }
 
-// GetKidTransactionStats returns transaction statistics for a kid
+// GetKidBalanceAt returns the star balance of a kid at a point in time: the latest
+// snapshot taken at or before it plus the transactions created between the two, found
+// with idx_transactions_kid_ledger
+func (r *TransactionRepository) GetKidBalanceAt(ctx context.Context, kidID int, at time.Time) (int, error) {
+	query := `
+		WITH snapshot AS (
+			SELECT taken_at, balance
+			FROM kid_balance_snapshots
+			WHERE kid_id = $1 AND taken_at <= $2
+			ORDER BY taken_at DESC
+			LIMIT 1
+		)
+		SELECT
+			COALESCE((SELECT balance FROM snapshot), 0) +
+			COALESCE(SUM(CASE WHEN type = 'earn' THEN amount ELSE -amount END), 0)
+		FROM transactions
+		WHERE kid_id = $1 AND created_at < $2
+			AND created_at >= COALESCE((SELECT taken_at FROM snapshot), '-infinity')`
+
+	var balance int
+	if err := r.db.QueryRowContext(ctx, query, kidID, at).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to get kid balance at %s: %w", at.Format(time.RFC3339), err)
+	}
+
+	return balance, nil
+}
+
+// TakeBalanceSnapshots adds the transactions created since each kid's previous snapshot
+// to it, as a new snapshot at the given time. The ledger is locked against writes (SHARE
+// mode) meanwhile, so a write backdated before at cannot slip past the invalidation trigger.
+func (r *TransactionRepository) TakeBalanceSnapshots(ctx context.Context, at time.Time) (int, error) {
+	if at.After(time.Now()) {
+		return 0, fmt.Errorf("cannot snapshot balances in the future: %s", at.Format(time.RFC3339))
+	}
+
+	query := `
+		WITH previous AS (
+			SELECT DISTINCT ON (kid_id) kid_id, taken_at, total_earned, total_spent, earn_count, spend_count
+			FROM kid_balance_snapshots
+			WHERE taken_at <= $1
+			ORDER BY kid_id, taken_at DESC
+		)
+		INSERT INTO kid_balance_snapshots (kid_id, taken_at, total_earned, total_spent, earn_count, spend_count)
+		SELECT
+			t.kid_id,
+			$1,
+			COALESCE(MAX(p.total_earned), 0) + COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'earn'), 0),
+			COALESCE(MAX(p.total_spent), 0) + COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'spend'), 0),
+			COALESCE(MAX(p.earn_count), 0) + COUNT(*) FILTER (WHERE t.type = 'earn'),
+			COALESCE(MAX(p.spend_count), 0) + COUNT(*) FILTER (WHERE t.type = 'spend')
+		FROM transactions t
+		LEFT JOIN previous p ON p.kid_id = t.kid_id
+		WHERE t.created_at < $1 AND t.created_at >= COALESCE(p.taken_at, '-infinity')
+		GROUP BY t.kid_id
+		ON CONFLICT (kid_id, taken_at) DO NOTHING`
+
+	var taken int64
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE transactions IN SHARE MODE`); err != nil {
+			return fmt.Errorf("failed to lock transactions: %w", err)
+		}
+		result, err := tx.ExecContext(ctx, query, at)
+		if err != nil {
+			return fmt.Errorf("failed to take balance snapshots: %w", err)
+		}
+		taken, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(taken), nil
+}
+
+// GetKidTransactionStats returns transaction statistics for a kid. The totals are read
+// from kid_balances; the breakdown by category is computed from the ledger.
 func (r *TransactionRepository) GetKidTransactionStats(ctx context.Context, kidID int) (*interfaces.TransactionStats, error) {
 	query := `
-		SELECT 
-			kid_id,
-			COALESCE(SUM(CASE WHEN type = 'earn' THEN amount ELSE 0 END), 0) as total_earned,
-			COALESCE(SUM(CASE WHEN type = 'spend' THEN amount ELSE 0 END), 0) as total_spent,
-			COALESCE(SUM(CASE WHEN type = 'earn' THEN amount ELSE 0 END), 0) -
-			COALESCE(SUM(CASE WHEN type = 'spend' THEN amount ELSE 0 END), 0) as balance,
-			COUNT(CASE WHEN type = 'earn' THEN 1 END) as earn_count,
-			COUNT(CASE WHEN type = 'spend' THEN 1 END) as spend_count
-		FROM transactions 
-		WHERE kid_id = $1
-		GROUP BY kid_id`
+		SELECT kid_id, total_earned, total_spent, balance, earn_count, spend_count
+		FROM kid_balances
+		WHERE kid_id = $1`
 
 	var stats interfaces.TransactionStats
 	err := r.db.QueryRowContext(ctx, query, kidID).Scan(
@@ -307,10 +636,178 @@ func (r *TransactionRepository) GetKidTransactionStats(ctx context.Context, kidI
 				Balance:     0,
 				EarnCount:   0,
 				SpendCount:  0,
+				Categories:  []interfaces.CategoryStats{},
 			}, nil
 		}
 		return nil, fmt.Errorf("failed to get kid transaction stats: %w", err)
 	}
 
+	// Break the totals down by category, e.g. how many stars came from homework
+	stats.Categories = []interfaces.CategoryStats{}
+	err = r.db.SelectContext(ctx, &stats.Categories, `
+		SELECT
+			t.category_id,
+			COALESCE(c.name, '') AS name,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'earn'), 0) AS total_earned,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'spend'), 0) AS total_spent,
+			COUNT(*) FILTER (WHERE t.type = 'earn') AS earn_count,
+			COUNT(*) FILTER (WHERE t.type = 'spend') AS spend_count
+		FROM transactions t
+		LEFT JOIN categories c ON c.id = t.category_id
+		WHERE t.kid_id = $1
+		GROUP BY t.category_id, c.name
+		ORDER BY t.category_id IS NULL, c.name, t.category_id`, kidID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kid transaction stats by category: %w", err)
+	}
+
 	return &stats, nil
-}
\ No newline at end of file
+}
+
+// balanceDriftQuery compares kid_balances with totals recomputed from the ledger, in
+// one snapshot, and returns the kids whose totals differ
+const balanceDriftQuery = `
+	WITH ledger AS (
+		SELECT
+			kid_id,
+			COALESCE(SUM(amount) FILTER (WHERE type = 'earn'), 0) AS total_earned,
+			COALESCE(SUM(amount) FILTER (WHERE type = 'spend'), 0) AS total_spent,
+			COUNT(*) FILTER (WHERE type = 'earn') AS earn_count,
+			COUNT(*) FILTER (WHERE type = 'spend') AS spend_count
+		FROM transactions
+		GROUP BY kid_id
+	)
+	SELECT
+		COALESCE(l.kid_id, b.kid_id),
+		COALESCE(b.total_earned, 0), COALESCE(b.total_spent, 0), COALESCE(b.earn_count, 0), COALESCE(b.spend_count, 0),
+		COALESCE(l.total_earned, 0), COALESCE(l.total_spent, 0), COALESCE(l.earn_count, 0), COALESCE(l.spend_count, 0)
+	FROM ledger l
+	FULL JOIN kid_balances b ON b.kid_id = l.kid_id
+	WHERE (COALESCE(b.total_earned, 0), COALESCE(b.total_spent, 0), COALESCE(b.earn_count, 0), COALESCE(b.spend_count, 0))
+		IS DISTINCT FROM (COALESCE(l.total_earned, 0), COALESCE(l.total_spent, 0), COALESCE(l.earn_count, 0), COALESCE(l.spend_count, 0))
+	ORDER BY 1`
+
+// ReconcileBalances recomputes every kid's totals from the ledger and returns the kids
+// whose kid_balances row disagrees. With fix, the ledger is locked against writes
+// (SHARE mode) while the drifted rows are overwritten, so no write lands in between.
+func (r *TransactionRepository) ReconcileBalances(ctx context.Context, fix bool) ([]*interfaces.BalanceDrift, error) {
+	var drifts []*interfaces.BalanceDrift
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if fix {
+			if _, err := tx.ExecContext(ctx, `LOCK TABLE transactions IN SHARE MODE`); err != nil {
+				return fmt.Errorf("failed to lock transactions: %w", err)
+			}
+		}
+
+		rows, err := tx.QueryContext(ctx, balanceDriftQuery)
+		if err != nil {
+			return fmt.Errorf("failed to compare kid balances: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			d := &interfaces.BalanceDrift{}
+			if err := rows.Scan(&d.KidID,
+				&d.Stored.TotalEarned, &d.Stored.TotalSpent, &d.Stored.EarnCount, &d.Stored.SpendCount,
+				&d.Ledger.TotalEarned, &d.Ledger.TotalSpent, &d.Ledger.EarnCount, &d.Ledger.SpendCount,
+			); err != nil {
+				return fmt.Errorf("failed to scan kid balance: %w", err)
+			}
+			d.Stored.Balance = d.Stored.TotalEarned - d.Stored.TotalSpent
+			d.Ledger.Balance = d.Ledger.TotalEarned - d.Ledger.TotalSpent
+			drifts = append(drifts, d)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to compare kid balances: %w", err)
+		}
+
+		if !fix {
+			return nil
+		}
+		for _, d := range drifts {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO kid_balances (kid_id, total_earned, total_spent, earn_count, spend_count, updated_at)
+				VALUES ($1, $2, $3, $4, $5, NOW())
+				ON CONFLICT (kid_id) DO UPDATE SET
+					total_earned = EXCLUDED.total_earned,
+					total_spent = EXCLUDED.total_spent,
+					earn_count = EXCLUDED.earn_count,
+					spend_count = EXCLUDED.spend_count,
+					updated_at = NOW()`,
+				d.KidID, d.Ledger.TotalEarned, d.Ledger.TotalSpent, d.Ledger.EarnCount, d.Ledger.SpendCount)
+			if err != nil {
+				return fmt.Errorf("failed to fix balance of kid %d: %w", d.KidID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return drifts, nil
+}
+
+// GetSeries returns the stars earned and spent per bucket of the filter's range. Buckets
+// are truncated with date_trunc in the filter's location and generate_series fills the
+// ones without transactions; the balance adds everything before the range.
+func (r *TransactionRepository) GetSeries(ctx context.Context, filter interfaces.SeriesFilter) ([]*interfaces.SeriesPoint, error) {
+	switch filter.Interval {
+	case interfaces.SeriesDay, interfaces.SeriesWeek, interfaces.SeriesMonth:
+	default:
+		return nil, fmt.Errorf("invalid series interval: %s", filter.Interval)
+	}
+	location := "UTC"
+	if filter.Location != nil {
+		location = filter.Location.String()
+	}
+
+	scope, id := `t.kid_id = $5`, filter.KidID
+	if filter.FamilyID != 0 {
+		if _, err := getFamily(ctx, r.db, filter.FamilyID); err != nil {
+			return nil, err
+		}
+		scope, id = `t.kid_id IN (SELECT id FROM kids WHERE family_id = $5 AND deleted_at IS NULL)`, filter.FamilyID
+	}
+
+	query := `
+		WITH scoped AS (
+			SELECT t.type, t.amount, t.created_at FROM transactions t WHERE ` + scope + `
+		),
+		buckets AS (
+			SELECT generate_series(
+				date_trunc($1, $2::timestamptz AT TIME ZONE $4),
+				date_trunc($1, ($3::timestamptz - interval '1 microsecond') AT TIME ZONE $4),
+				('1 ' || $1)::interval
+			) AS start
+		),
+		totals AS (
+			SELECT date_trunc($1, created_at AT TIME ZONE $4) AS start,
+				SUM(amount) FILTER (WHERE type = 'earn') AS earned,
+				SUM(amount) FILTER (WHERE type = 'spend') AS spent
+			FROM scoped
+			WHERE created_at >= $2 AND created_at < $3
+			GROUP BY 1
+		),
+		opening AS (
+			SELECT COALESCE(SUM(CASE WHEN type = 'earn' THEN amount ELSE -amount END), 0) AS balance
+			FROM scoped
+			WHERE created_at < $2
+		)
+		SELECT b.start AT TIME ZONE $4 AS start,
+			COALESCE(t.earned, 0) AS earned,
+			COALESCE(t.spent, 0) AS spent,
+			COALESCE(t.earned, 0) - COALESCE(t.spent, 0) AS net,
+			(o.balance + SUM(COALESCE(t.earned, 0) - COALESCE(t.spent, 0)) OVER (ORDER BY b.start))::bigint AS balance
+		FROM buckets b
+		CROSS JOIN opening o
+		LEFT JOIN totals t ON t.start = b.start
+		ORDER BY b.start`
+
+	points := []*interfaces.SeriesPoint{}
+	if err := r.db.SelectContext(ctx, &points, query, string(filter.Interval), filter.From, filter.To, location, id); err != nil {
+		return nil, fmt.Errorf("failed to get transaction series: %w", err)
+	}
+
+	return points, nil
+}
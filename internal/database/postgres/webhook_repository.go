@@ -0,0 +1,326 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+	"github.com/lukasz/astras-mono-api/internal/models/webhook"
+)
+
+// WebhookRepository implements the interfaces.WebhookRepository interface for PostgreSQL
+type WebhookRepository struct {
+	db     *sqlx.DB
+	withTx func(ctx context.Context, fn func(*sqlx.Tx) error) error // Runs fn in a database transaction (see RepositoryManager.withTx)
+}
+
+// webhookColumns are the webhook columns read by scanWebhook; event_types is a JSONB array
+const webhookColumns = `id, family_id, url, event_types::text, secret, active, consecutive_failures, disabled_at, created_at, updated_at`
+
+// scanWebhook reads the webhookColumns of a row
+func scanWebhook(row interface{ Scan(dest ...any) error }) (*webhook.Webhook, error) {
+	var w webhook.Webhook
+	var eventTypes string
+
+	err := row.Scan(&w.ID, &w.FamilyID, &w.URL, &eventTypes, &w.Secret, &w.Active,
+		&w.ConsecutiveFailures, &w.DisabledAt, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(eventTypes), &w.EventTypes); err != nil {
+		return nil, fmt.Errorf("failed to decode event types of webhook %d: %w", w.ID, err)
+	}
+	return &w, nil
+}
+
+// Create adds a webhook to its family and returns it with generated ID
+func (r *WebhookRepository) Create(ctx context.Context, w *webhook.Webhook) (*webhook.Webhook, error) {
+	if err := w.Validate(); err != nil {
+		return nil, fmt.Errorf("webhook validation failed: %w", err)
+	}
+
+	eventTypes, err := json.Marshal(w.EventTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event types: %w", err)
+	}
+
+	query := `
+		INSERT INTO webhooks (family_id, url, event_types, secret, created_at, updated_at)
+		VALUES ($1, $2, $3::jsonb, $4, NOW(), NOW())
+		RETURNING ` + webhookColumns
+
+	created, err := scanWebhook(r.db.QueryRowContext(ctx, query, w.FamilyID, w.URL, string(eventTypes), w.Secret))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			return nil, fmt.Errorf("family with id %d %w", w.FamilyID, interfaces.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a webhook of a family
+func (r *WebhookRepository) GetByID(ctx context.Context, familyID, id int) (*webhook.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND family_id = $2`
+
+	w, err := scanWebhook(r.db.QueryRowContext(ctx, query, id, familyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook with id %d %w", id, interfaces.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// GetByFamily retrieves the webhooks of a family, oldest first
+func (r *WebhookRepository) GetByFamily(ctx context.Context, familyID int) ([]*webhook.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE family_id = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*webhook.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// Update changes the URL, event types and active flag of a webhook
+func (r *WebhookRepository) Update(ctx context.Context, w *webhook.Webhook) (*webhook.Webhook, error) {
+	if err := w.Validate(); err != nil {
+		return nil, fmt.Errorf("webhook validation failed: %w", err)
+	}
+
+	eventTypes, err := json.Marshal(w.EventTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event types: %w", err)
+	}
+
+	// In SET, active still refers to the stored value
+	query := `
+		UPDATE webhooks
+		SET url = $3, event_types = $4::jsonb, active = $5,
+			consecutive_failures = CASE WHEN $5 AND NOT active THEN 0 ELSE consecutive_failures END,
+			disabled_at = CASE WHEN $5 THEN NULL ELSE disabled_at END,
+			updated_at = NOW()
+		WHERE id = $1 AND family_id = $2
+		RETURNING ` + webhookColumns
+
+	updated, err := scanWebhook(r.db.QueryRowContext(ctx, query, w.ID, w.FamilyID, w.URL, string(eventTypes), w.Active))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook with id %d %w", w.ID, interfaces.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return updated, nil
+}
+
+// Delete removes a webhook of a family; its deliveries are removed by ON DELETE CASCADE
+func (r *WebhookRepository) Delete(ctx context.Context, familyID, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND family_id = $2`, id, familyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook with id %d %w", id, interfaces.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Enqueue creates the deliveries of an event. The unique (webhook_id, event_id) key
+// turns a repeated publish of the same event into a no-op.
+func (r *WebhookRepository) Enqueue(ctx context.Context, e *event.Event, kidID string) (int, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, body, next_attempt_at)
+		SELECT w.id, $1, $2, $3, NOW()
+		FROM webhooks w
+		JOIN kids k ON k.family_id = w.family_id
+		WHERE k.public_id = $4 AND w.active AND w.event_types @> jsonb_build_array($2::text)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`, e.ID, string(e.Type), string(body), kidID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ClaimDue moves the next attempt of the due deliveries to the end of the lease and
+// returns them with their webhooks, oldest first
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*interfaces.DueDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $2 * interval '1 millisecond'
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT due.id FROM webhook_deliveries due
+			JOIN webhooks active ON active.id = due.webhook_id
+			WHERE due.status = 'pending' AND due.next_attempt_at <= NOW() AND active.active
+			ORDER BY due.next_attempt_at, due.id
+			LIMIT $1
+			FOR UPDATE OF due SKIP LOCKED)
+		RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.body, d.status, d.attempts,
+			d.next_attempt_at, d.created_at, w.url, w.secret, w.family_id`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var due []*interfaces.DueDelivery
+	for rows.Next() {
+		var d webhook.Delivery
+		var w webhook.Webhook
+		var status string
+
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Body, &status, &d.Attempts,
+			&d.NextAttemptAt, &d.CreatedAt, &w.URL, &w.Secret, &w.FamilyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+
+		d.Status = webhook.DeliveryStatus(status)
+		w.ID, w.Active = d.WebhookID, true
+		due = append(due, &interfaces.DueDelivery{Delivery: &d, Webhook: &w})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhook deliveries: %w", err)
+	}
+
+	// UPDATE ... RETURNING does not keep the order of the subquery
+	sort.Slice(due, func(i, j int) bool { return due[i].Delivery.ID < due[j].Delivery.ID })
+	return due, nil
+}
+
+// RecordAttempt updates the delivery and the failure count of its webhook in one
+// database transaction
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, due *interfaces.DueDelivery, attempt webhook.Attempt, disableAfter int) (bool, error) {
+	status := webhook.DeliveryFailed
+	switch {
+	case attempt.Succeeded():
+		status = webhook.DeliverySucceeded
+	case attempt.NextAttemptAt != nil:
+		status = webhook.DeliveryPending
+	}
+
+	var statusCode *int
+	if attempt.StatusCode != 0 {
+		statusCode = &attempt.StatusCode
+	}
+	var lastError *string
+	if attempt.Error != "" {
+		lastError = &attempt.Error
+	}
+
+	disabled := false
+	err := r.withTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET attempts = attempts + 1, status = $2::webhook_delivery_status, next_attempt_at = $3,
+				last_status_code = $4, last_error = $5,
+				delivered_at = CASE WHEN $2::webhook_delivery_status = 'succeeded' THEN NOW() ELSE delivered_at END
+			WHERE id = $1`, due.Delivery.ID, string(status), attempt.NextAttemptAt, statusCode, lastError)
+		if err != nil {
+			return fmt.Errorf("failed to record delivery attempt: %w", err)
+		}
+
+		if attempt.Succeeded() {
+			_, err := tx.ExecContext(ctx, `UPDATE webhooks SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures <> 0`, due.Webhook.ID)
+			if err != nil {
+				return fmt.Errorf("failed to reset webhook failures: %w", err)
+			}
+			return nil
+		}
+
+		var failures int
+		var active bool
+		err = tx.QueryRowContext(ctx, `
+			UPDATE webhooks SET consecutive_failures = consecutive_failures + 1
+			WHERE id = $1
+			RETURNING consecutive_failures, active`, due.Webhook.ID).Scan(&failures, &active)
+		if err != nil {
+			return fmt.Errorf("failed to count webhook failure: %w", err)
+		}
+
+		if active && disableAfter > 0 && failures >= disableAfter {
+			_, err := tx.ExecContext(ctx, `UPDATE webhooks SET active = FALSE, disabled_at = NOW() WHERE id = $1`, due.Webhook.ID)
+			if err != nil {
+				return fmt.Errorf("failed to disable webhook: %w", err)
+			}
+			disabled = true
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return disabled, nil
+}
+
+// Deliveries retrieves the delivery log of a webhook, newest first
+func (r *WebhookRepository) Deliveries(ctx context.Context, webhookID int, limit int) ([]*webhook.Delivery, error) {
+	query := `
+		SELECT id, webhook_id, event_id, event_type, status, attempts, next_attempt_at,
+			last_status_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	var deliveries []webhook.Delivery
+	if err := r.db.SelectContext(ctx, &deliveries, query, webhookID, limit); err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	result := make([]*webhook.Delivery, len(deliveries))
+	for i := range deliveries {
+		result[i] = &deliveries[i]
+	}
+
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+// MaxBodySize is the largest request body accepted, in bytes
+const MaxBodySize = 64 << 10
+
+// ValidationError reports the field-level violations of a request body schema
+type ValidationError struct {
+	Fields []schema.FieldError
+}
+
+// Error lists the violations in a single message
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+	return "invalid request body: " + strings.Join(messages, "; ")
+}
+
+// DecodeJSON strictly decodes a JSON request body into dst. Unknown members,
+// trailing data and bodies larger than MaxBodySize are rejected.
+func DecodeJSON(body string, dst any) error {
+	if len(body) > MaxBodySize {
+		return bodyTooLarge()
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return fmt.Errorf("invalid JSON format: %v", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("invalid JSON format: unexpected data after the JSON value")
+	}
+
+	return nil
+}
+
+// ValidateRequest checks the body of a request against the schema of the route
+// serving it, so malformed payloads are rejected with field-level errors before
+// any handler logic runs. Routes without a body schema accept any body.
+func ValidateRequest(request HTTPRequest, routes []Route) error {
+	if len(request.Body) > MaxBodySize {
+		return bodyTooLarge()
+	}
+
+	route, ok := findRoute(request, routes)
+	if !ok || route.Body == nil {
+		return nil
+	}
+
+	body := bytes.TrimSpace([]byte(request.Body))
+	if len(body) == 0 {
+		return &ValidationError{Fields: []schema.FieldError{{Message: "request body is required"}}}
+	}
+
+	if errs := route.Body.Validate(body); len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+
+	return nil
+}
+
+// ErrorResponse builds the JSON error response for an error returned by a handler.
+// Errors carry their status code as an *Error, and validation errors list the
+// invalid fields; anything else is reported as a bad request.
+func ErrorResponse(err error) HTTPResponse {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		body, _ := json.Marshal(struct {
+			Error  string              `json:"error"`
+			Fields []schema.FieldError `json:"fields"`
+		}{"invalid request body", validationErr.Fields})
+
+		return HTTPResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       string(body),
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+		}
+	}
+
+	statusCode := http.StatusBadRequest
+	var httpErr *Error
+	if errors.As(err, &httpErr) {
+		statusCode = httpErr.StatusCode
+	}
+	return errorResponse(statusCode, err.Error())
+}
+
+// findRoute returns the route serving a request, preferring the route template
+// resolved by the trigger over matching the path
+func findRoute(request HTTPRequest, routes []Route) (Route, bool) {
+	if request.Resource != "" {
+		for _, route := range routes {
+			if strings.EqualFold(route.Method, request.HTTPMethod) && route.Path == request.Resource {
+				return route, true
+			}
+		}
+	}
+
+	route, _, ok := MatchRoute(routes, request.HTTPMethod, request.Path)
+	return route, ok
+}
+
+// bodyTooLarge is the error for request bodies over MaxBodySize
+func bodyTooLarge() error {
+	return NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body cannot exceed %d bytes", MaxBodySize))
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+type testBody struct {
+	Name   string `json:"name" validate:"required"`
+	Amount int    `json:"amount"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectError    bool
+	}{
+		{"valid", `{"name":"Alice","amount":5}`, 0, false},
+		{"unknown field", `{"name":"Alice","amout":5}`, 0, true},
+		{"wrong type", `{"name":"Alice","amount":"5"}`, 0, true},
+		{"trailing data", `{"name":"Alice"} {"name":"Bob"}`, 0, true},
+		{"malformed", `{"name":`, 0, true},
+		{"too large", `{"name":"` + strings.Repeat("a", MaxBodySize) + `"}`, http.StatusRequestEntityTooLarge, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst testBody
+			err := DecodeJSON(tt.body, &dst)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if tt.expectedStatus != 0 {
+				var httpErr *Error
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.expectedStatus {
+					t.Errorf("expected status %d, got %v", tt.expectedStatus, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	routes := []Route{
+		{Method: "POST", Path: "/items", Body: schema.Generate(testBody{})},
+		{Method: "GET", Path: "/items/{id}"},
+	}
+
+	tests := []struct {
+		name           string
+		request        HTTPRequest
+		expectedStatus int
+		expectedFields []string
+	}{
+		{"valid body", HTTPRequest{HTTPMethod: "POST", Path: "/items", Body: `{"name":"Alice"}`}, 0, nil},
+		{"route without body", HTTPRequest{HTTPMethod: "GET", Path: "/items/1"}, 0, nil},
+		{"unknown route", HTTPRequest{HTTPMethod: "PUT", Path: "/other", Body: `nonsense`}, 0, nil},
+		{"resolved by resource", HTTPRequest{HTTPMethod: "POST", Resource: "/items", Path: "/stage/items", Body: `{}`}, http.StatusBadRequest, []string{"name"}},
+		{"missing body", HTTPRequest{HTTPMethod: "POST", Path: "/items"}, http.StatusBadRequest, []string{""}},
+		{"field errors", HTTPRequest{HTTPMethod: "POST", Path: "/items", Body: `{"amount":"5","amout":1}`}, http.StatusBadRequest, []string{"name", "amount", "amout"}},
+		{"too large", HTTPRequest{HTTPMethod: "GET", Path: "/items/1", Body: strings.Repeat(" ", MaxBodySize+1)}, http.StatusRequestEntityTooLarge, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequest(tt.request, routes)
+			if tt.expectedStatus == 0 {
+				if err != nil {
+					t.Fatalf("expected no error but got: %v", err)
+				}
+				return
+			}
+
+			response := ErrorResponse(err)
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, response.StatusCode)
+			}
+			if tt.expectedFields == nil {
+				return
+			}
+
+			var body struct {
+				Fields []schema.FieldError `json:"fields"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("expected JSON body, got %q", response.Body)
+			}
+			if len(body.Fields) != len(tt.expectedFields) {
+				t.Fatalf("expected fields %v, got %v", tt.expectedFields, body.Fields)
+			}
+			for i, field := range tt.expectedFields {
+				if body.Fields[i].Field != field {
+					t.Errorf("expected field %q at %d, got %q", field, i, body.Fields[i].Field)
+				}
+			}
+		})
+	}
+}
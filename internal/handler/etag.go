@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ETag formats a strong entity tag for a resource from its last modification time.
+// PostgreSQL stores timestamps with microsecond precision, so the tag uses microseconds.
+func ETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// Header returns the value of a request header, matching the name case-insensitively
+// since REST APIs pass headers through with the client's casing while HTTP APIs
+// and ALBs lowercase them.
+func Header(request HTTPRequest, name string) string {
+	if value, ok := request.Headers[name]; ok {
+		return value
+	}
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// IfMatch reads the If-Match precondition of a write request and returns the
+// resource versions the client is willing to overwrite. It returns nil when the
+// client sent "*" (any current version). A missing header is rejected with
+// 428 Precondition Required, and a header naming no valid version with 412.
+func IfMatch(request HTTPRequest) ([]time.Time, error) {
+	value := strings.TrimSpace(Header(request, "If-Match"))
+	if value == "" {
+		return nil, NewError(http.StatusPreconditionRequired, "If-Match header is required")
+	}
+	if value == "*" {
+		return nil, nil
+	}
+
+	var versions []time.Time
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		// If-Match uses strong comparison, so weak tags never match
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if version, ok := parseETag(tag); ok {
+			versions = append(versions, version)
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, NewError(http.StatusPreconditionFailed, "resource has been modified")
+	}
+
+	return versions, nil
+}
+
+// NotModified reports whether the If-None-Match header of a read request matches
+// the current entity tag, in which case the client's cached copy is still fresh.
+func NotModified(request HTTPRequest, etag string) bool {
+	value := strings.TrimSpace(Header(request, "If-None-Match"))
+	if value == "" {
+		return false
+	}
+	if value == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(value, ",") {
+		// If-None-Match uses weak comparison
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseETag converts an entity tag produced by ETag back into a modification time
+func parseETag(tag string) (time.Time, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return time.Time{}, false
+	}
+
+	micros, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMicro(micros), true
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIfMatch(t *testing.T) {
+	version := time.Date(2025, 3, 15, 10, 30, 0, 123456000, time.UTC)
+	etag := ETag(version)
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"missing header", map[string]string{}, http.StatusPreconditionRequired, 0},
+		{"wildcard", map[string]string{"If-Match": "*"}, 0, 0},
+		{"single tag", map[string]string{"If-Match": etag}, 0, 1},
+		{"lowercase header name", map[string]string{"if-match": etag}, 0, 1},
+		{"tag list", map[string]string{"If-Match": etag + `, "42"`}, 0, 2},
+		{"weak tag only", map[string]string{"If-Match": "W/" + etag}, http.StatusPreconditionFailed, 0},
+		{"malformed tag", map[string]string{"If-Match": "abc"}, http.StatusPreconditionFailed, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions, err := IfMatch(HTTPRequest{Headers: tt.headers})
+			if tt.expectedStatus != 0 {
+				var httpErr *Error
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %v", tt.expectedStatus, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if len(versions) != tt.expectedCount {
+				t.Fatalf("expected %d versions, got %d", tt.expectedCount, len(versions))
+			}
+			if len(versions) > 0 && !versions[0].Equal(version) {
+				t.Errorf("expected version %v, got %v", version, versions[0])
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := ETag(time.Date(2025, 3, 15, 10, 30, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		header   string
+		expected bool
+	}{
+		{"no header", "", false},
+		{"matching tag", etag, true},
+		{"weak matching tag", "W/" + etag, true},
+		{"tag in list", `"1", ` + etag, true},
+		{"different tag", `"1"`, false},
+		{"wildcard", "*", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := HTTPRequest{Headers: map[string]string{"If-None-Match": tt.header}}
+			if got := NotModified(request, etag); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-
-	"github.com/aws/aws-lambda-go/events"
 )
 
 // Response represents the standardized JSON response structure for all API endpoints.
@@ -17,6 +15,26 @@ type Response struct {
 	Message string `json:"message"`          // Human-readable message describing the operation result
 	Service string `json:"service"`          // Name of the service that handled the request
 	Data    any    `json:"data,omitempty"`   // Optional data payload (omitted if nil/empty)
+
+	StatusCode int               `json:"-"` // Overrides the default status code for the method when non-zero
+	Headers    map[string]string `json:"-"` // Additional response headers (e.g. ETag)
+}
+
+// Error is an error carrying the HTTP status code it should be reported with.
+// Handlers return it for failures that are not plain bad requests.
+type Error struct {
+	StatusCode int    // HTTP status code of the error response
+	Message    string // Human-readable error message
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError creates an Error with the given HTTP status code
+func NewError(statusCode int, message string) *Error {
+	return &Error{StatusCode: statusCode, Message: message}
 }
 
 // Handler defines the contract that all service handlers must implement.
@@ -24,26 +42,29 @@ type Response struct {
 // This interface enables polymorphic handling of different resource types.
 type Handler interface {
 	// GetAll retrieves and returns a list of all resources of this type
-	GetAll(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error)
+	GetAll(ctx context.Context, request HTTPRequest) (Response, error)
 	
 	// GetByID retrieves a specific resource by its unique identifier
-	GetByID(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error)
+	GetByID(ctx context.Context, request HTTPRequest) (Response, error)
 	
 	// Create processes a request to create a new resource instance
-	Create(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error)
+	Create(ctx context.Context, request HTTPRequest) (Response, error)
 	
 	// Update modifies an existing resource identified by ID with new data
-	Update(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error)
+	Update(ctx context.Context, request HTTPRequest) (Response, error)
+	
+	// Patch partially modifies an existing resource using a JSON Merge Patch (RFC 7386) body
+	Patch(ctx context.Context, request HTTPRequest) (Response, error)
 	
 	// Delete removes a resource identified by ID from the system
-	Delete(ctx context.Context, request events.APIGatewayProxyRequest) (Response, error)
+	Delete(ctx context.Context, request HTTPRequest) (Response, error)
 }
 
 // HandleRequest is the central HTTP request router and processor for AWS Lambda functions.
 // It examines the HTTP method and path parameters to determine which handler method to call,
 // then formats the response consistently with proper HTTP status codes and CORS headers.
 // This function eliminates the need for each service to implement its own routing logic.
-func HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest, h Handler) (events.APIGatewayProxyResponse, error) {
+func HandleRequest(ctx context.Context, request HTTPRequest, h Handler) (HTTPResponse, error) {
 	var response Response
 	var err error
 	var statusCode int
@@ -66,13 +87,17 @@ func HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest, h
 		// Handle resource update requests
 		response, err = h.Update(ctx, request)
 		statusCode = http.StatusOK
+	case http.MethodPatch:
+		// Handle partial resource update requests
+		response, err = h.Patch(ctx, request)
+		statusCode = http.StatusOK
 	case http.MethodDelete:
 		// Handle resource deletion requests
 		response, err = h.Delete(ctx, request)
 		statusCode = http.StatusOK
 	default:
 		// Return 405 Method Not Allowed for unsupported HTTP methods
-		return events.APIGatewayProxyResponse{
+		return HTTPResponse{
 			StatusCode: http.StatusMethodNotAllowed,
 			Body:       `{"error": "Method not allowed"}`,
 			Headers: map[string]string{
@@ -83,35 +108,60 @@ func HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest, h
 
 	// Handle any errors returned by the handler methods
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       `{"error": "` + err.Error() + `"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}, nil
+		return ErrorResponse(err), nil
+	}
+
+	return Respond(statusCode, response), nil
+}
+
+// Respond formats a handler response as JSON with CORS headers. The status code
+// applies unless the response overrides it.
+func Respond(statusCode int, response Response) HTTPResponse {
+	if response.StatusCode != 0 {
+		statusCode = response.StatusCode
+	}
+
+	headers := map[string]string{
+		"Content-Type":                 "application/json",
+		"Access-Control-Allow-Origin":  "*",  // Enable CORS for all origins
+		"Access-Control-Expose-Headers": "ETag", // Let browser clients read versions for If-Match
+	}
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+
+	// 304 responses carry validators only, never a body
+	if statusCode == http.StatusNotModified {
+		delete(headers, "Content-Type")
+		return HTTPResponse{
+			StatusCode: statusCode,
+			Headers:    headers,
+		}
 	}
 
 	// Marshal the response to JSON format
 	body, err := json.Marshal(response)
 	if err != nil {
 		// Return 500 Internal Server Error if JSON marshaling fails
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusInternalServerError,
-			Body:       `{"error": "Internal server error"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}, nil
+		return errorResponse(http.StatusInternalServerError, "Internal server error")
 	}
 
 	// Return successful response with appropriate status code and CORS headers
-	return events.APIGatewayProxyResponse{
+	return HTTPResponse{
+		StatusCode: statusCode,
+		Body:       string(body),
+		Headers:    headers,
+	}
+}
+
+// errorResponse builds a JSON error response with the given status code
+func errorResponse(statusCode int, message string) HTTPResponse {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	return HTTPResponse{
 		StatusCode: statusCode,
 		Body:       string(body),
 		Headers: map[string]string{
-			"Content-Type":                 "application/json",
-			"Access-Control-Allow-Origin":  "*",  // Enable CORS for all origins
+			"Content-Type": "application/json",
 		},
-	}, nil
-}
\ No newline at end of file
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatch applies a JSON Merge Patch (RFC 7386) document to target and returns the result.
+// Object members in the patch replace or add members in the target, members set to null
+// are removed, and any non-object patch replaces the target entirely.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %v", err)
+	}
+
+	var targetValue any
+	if len(target) > 0 {
+		if err := json.Unmarshal(target, &targetValue); err != nil {
+			return nil, fmt.Errorf("invalid merge patch target: %v", err)
+		}
+	}
+
+	return json.Marshal(mergeValue(targetValue, patchValue))
+}
+
+// mergeValue implements the MergePatch algorithm from RFC 7386 section 2
+func mergeValue(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergeValue(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+// ApplyMergePatch serializes base to JSON, applies the merge patch and decodes
+// the patched document into dst. The patch must be a JSON object, since every
+// resource in the API is represented as one.
+func ApplyMergePatch(base any, patch string, dst any) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(patch), &members); err != nil {
+		return fmt.Errorf("merge patch must be a JSON object")
+	}
+
+	target, err := json.Marshal(base)
+	if err != nil {
+		return fmt.Errorf("failed to encode resource: %v", err)
+	}
+
+	patched, err := MergePatch(target, []byte(patch))
+	if err != nil {
+		return err
+	}
+
+	return DecodeJSON(string(patched), dst)
+}
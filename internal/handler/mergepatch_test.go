@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestMergePatch covers the example test cases from RFC 7386 appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		patch    string
+		expected string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"remove one of many", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"array replaced", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"value replaced by array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"arrays are not merged", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"non-object target", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"object replaced by array", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"object replaced by null", `{"a":"foo"}`, `null`, `null`},
+		{"object replaced by string", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null member kept", `{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{"array target", `[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{"nested null creates no member", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MergePatch([]byte(tt.target), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			var got, want any
+			if err := json.Unmarshal(result, &got); err != nil {
+				t.Fatalf("result is not valid JSON: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &want); err != nil {
+				t.Fatalf("expected value is not valid JSON: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	type resource struct {
+		Name   string `json:"name,omitempty"`
+		Amount int    `json:"amount,omitempty"`
+	}
+
+	var patched resource
+	if err := ApplyMergePatch(resource{Name: "Alice", Amount: 5}, `{"amount":7}`, &patched); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if patched.Name != "Alice" || patched.Amount != 7 {
+		t.Errorf("expected {Alice 7}, got %+v", patched)
+	}
+
+	if err := ApplyMergePatch(resource{}, `["not","an","object"]`, &patched); err == nil {
+		t.Error("expected error for non-object patch but got none")
+	}
+}
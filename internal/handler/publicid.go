@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+// IsUUID reports whether s is a UUID in its canonical 8-4-4-4-12 hex form, the form
+// of the public IDs families, kids, caregivers and transactions are addressed by
+func IsUUID(s string) bool {
+	return schema.IsUUID(s)
+}
+
+// QueryUUID parses a public ID query-string parameter such as ?kid_id=.
+// Returns "" when the parameter is absent and an error when it is not a UUID.
+func QueryUUID(request HTTPRequest, name string) (string, error) {
+	value := QueryString(request, name)
+	if value == "" {
+		return "", nil
+	}
+
+	if !IsUUID(value) {
+		return "", fmt.Errorf("invalid %s: %s", name, value)
+	}
+
+	return strings.ToLower(value), nil
+}
+
+// UUIDPathParam describes a path parameter holding a public ID
+func UUIDPathParam(name, description string) Param {
+	return Param{Name: name, In: "path", Description: description, Required: true, Schema: &schema.Schema{Type: "string", Format: "uuid"}}
+}
+
+// UUIDQueryParam describes an optional query-string parameter holding a public ID
+func UUIDQueryParam(name, description string) Param {
+	return Param{Name: name, In: "query", Description: description, Schema: &schema.Schema{Type: "string", Format: "uuid"}}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import "testing"
+
+func TestQueryUUID(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expected      string
+		expectedError bool
+	}{
+		{"absent", "", "", false},
+		{"lowercase", "0b7c6f0e-2d1a-4c3b-9f8e-7a6b5c4d3e2f", "0b7c6f0e-2d1a-4c3b-9f8e-7a6b5c4d3e2f", false},
+		{"uppercase is normalized", "0B7C6F0E-2D1A-4C3B-9F8E-7A6B5C4D3E2F", "0b7c6f0e-2d1a-4c3b-9f8e-7a6b5c4d3e2f", false},
+		{"sequential ID", "1", "", true},
+		{"missing dashes", "0b7c6f0e2d1a4c3b9f8e7a6b5c4d3e2f", "", true},
+		{"not hex", "0b7c6f0e-2d1a-4c3b-9f8e-7a6b5c4d3e2g", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := HTTPRequest{QueryStringParameters: map[string]string{"kid_id": tt.value}}
+
+			value, err := QueryUUID(request, "kid_id")
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("expected error but got %q", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if value != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, value)
+			}
+		})
+	}
+}
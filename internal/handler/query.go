@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryString returns the trimmed value of a query-string parameter, or "" when absent.
+func QueryString(request HTTPRequest, name string) string {
+	return strings.TrimSpace(request.QueryStringParameters[name])
+}
+
+// QueryInt parses an integer query-string parameter.
+// Returns nil when the parameter is absent and an error when it is not a valid integer.
+func QueryInt(request HTTPRequest, name string) (*int, error) {
+	value := QueryString(request, name)
+	if value == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+
+	return &n, nil
+}
+
+// QueryBool parses a boolean query-string parameter such as ?deleted=true.
+// Returns false when the parameter is absent and an error when it is not a valid boolean.
+func QueryBool(request HTTPRequest, name string) (bool, error) {
+	value := QueryString(request, name)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %s", name, value)
+	}
+
+	return b, nil
+}
+
+// QueryDateRange parses the "from" and "to" query-string parameters.
+// Both accept RFC 3339 timestamps or YYYY-MM-DD dates. The returned range is
+// half-open [from, to): a date-only "to" is moved to the start of the next day
+// so that the whole day is included. Dates start at midnight in the optional
+// location, UTC by default.
+func QueryDateRange(request HTTPRequest, location ...*time.Location) (from, to *time.Time, err error) {
+	loc := time.UTC
+	if len(location) > 0 && location[0] != nil {
+		loc = location[0]
+	}
+
+	if value := QueryString(request, "from"); value != "" {
+		t, _, err := parseQueryTime(value, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid from: %s", value)
+		}
+		from = &t
+	}
+
+	if value := QueryString(request, "to"); value != "" {
+		t, dateOnly, err := parseQueryTime(value, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid to: %s", value)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = &t
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, fmt.Errorf("from must be before to")
+	}
+
+	return from, to, nil
+}
+
+// QueryTime parses a query-string parameter holding an RFC 3339 timestamp or a
+// YYYY-MM-DD date, which starts at midnight in the optional location (UTC by default).
+// Returns nil when the parameter is absent, and whether a plain date was given.
+func QueryTime(request HTTPRequest, name string, location ...*time.Location) (t *time.Time, dateOnly bool, err error) {
+	loc := time.UTC
+	if len(location) > 0 && location[0] != nil {
+		loc = location[0]
+	}
+
+	value := QueryString(request, name)
+	if value == "" {
+		return nil, false, nil
+	}
+
+	parsed, dateOnly, err := parseQueryTime(value, loc)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s: %s", name, value)
+	}
+
+	return &parsed, dateOnly, nil
+}
+
+// parseQueryTime accepts RFC 3339 timestamps and plain dates in loc, reporting which one it got
+func parseQueryTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+)
+
+// HTTPRequest is the trigger-independent HTTP request handled by the services.
+// Adapters build it from API Gateway REST (v1) and HTTP API (v2) events, ALB
+// target group events and plain net/http requests, so handlers never depend on
+// the payload format of the trigger. Field names follow events.APIGatewayProxyRequest.
+type HTTPRequest struct {
+	HTTPMethod            string            // HTTP method, e.g. GET
+	Path                  string            // Request path, e.g. /kids/42
+	Resource              string            // Matched route path template, e.g. /kids/{id}
+	PathParameters        map[string]string // Values of the {name} parameters in Resource
+	QueryStringParameters map[string]string // Query parameters, last value wins for repeated names
+	Headers               map[string]string // Request headers, names in the casing sent by the trigger
+	Body                  string            // Request body, already base64-decoded
+	RequestID             string            // Request ID assigned by the trigger
+	SourceIP              string            // IP address of the client
+	Principal             string            // Caller authenticated by the trigger's authorizer, "" when unauthenticated
+	Version               string            // API version serving the request, e.g. v1 (see ResolveVersion)
+}
+
+// HTTPResponse is the trigger-independent HTTP response returned by the services.
+// Adapters convert it into the response payload expected by the trigger.
+type HTTPResponse struct {
+	StatusCode int                     // HTTP status code
+	Headers    map[string]string       // Response headers
+	Body       string                  // Response body
+	Stream     func(w io.Writer) error // Writes the body incrementally instead of Body when set (see Buffered)
+}
+
+// Buffered returns the response with its Stream written into Body, for triggers
+// such as Lambda that need the whole body at once
+func (r HTTPResponse) Buffered() (HTTPResponse, error) {
+	if r.Stream == nil {
+		return r, nil
+	}
+
+	var body strings.Builder
+	if err := r.Stream(&body); err != nil {
+		return HTTPResponse{}, fmt.Errorf("failed to write response body: %w", err)
+	}
+	r.Body = body.String()
+	r.Stream = nil
+	return r, nil
+}
+
+// maxAuditSourceLength is the longest actor or request ID the audit log stores
+const maxAuditSourceLength = 255
+
+// WithAuditSource returns a copy of ctx attributing the changes made while serving the
+// request to its authenticated principal and request ID (X-Request-ID, or the ID
+// assigned by the trigger). The actor named in audit.ActorHeader is kept apart as the
+// claimed actor, since any client can send it. Adapters call it for every request.
+func WithAuditSource(ctx context.Context, request HTTPRequest) context.Context {
+	actor := request.Principal
+	if actor == "" {
+		actor = audit.AnonymousActor
+	}
+
+	requestID := strings.TrimSpace(Header(request, "X-Request-ID"))
+	if requestID == "" {
+		requestID = request.RequestID
+	}
+
+	return audit.NewContext(ctx, audit.Source{
+		Actor:        truncate(actor, maxAuditSourceLength),
+		ClaimedActor: truncate(strings.TrimSpace(Header(request, audit.ActorHeader)), maxAuditSourceLength),
+		RequestID:    truncate(requestID, maxAuditSourceLength),
+	})
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+)
+
+func TestWithAuditSource(t *testing.T) {
+	tests := []struct {
+		name                 string
+		request              HTTPRequest
+		expectedActor        string
+		expectedClaimedActor string
+		expectedRequestID    string
+	}{
+		{"anonymous", HTTPRequest{RequestID: "req-1"}, audit.AnonymousActor, "", "req-1"},
+		{"authenticated principal", HTTPRequest{RequestID: "req-1", Principal: "user-7"}, "user-7", "", "req-1"},
+		{"actor header is only claimed", HTTPRequest{RequestID: "req-1", Headers: map[string]string{"x-actor": " user-42 "}}, audit.AnonymousActor, "user-42", "req-1"},
+		{"principal wins over actor header", HTTPRequest{Principal: "user-7", Headers: map[string]string{"X-Actor": "user-42"}}, "user-7", "user-42", ""},
+		{"client request ID wins", HTTPRequest{RequestID: "req-1", Headers: map[string]string{"X-Request-ID": "abc"}}, audit.AnonymousActor, "", "abc"},
+		{"long claimed actor is truncated", HTTPRequest{Headers: map[string]string{"X-Actor": strings.Repeat("a", 300)}}, audit.AnonymousActor, strings.Repeat("a", 255), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, ok := audit.FromContext(WithAuditSource(context.Background(), tt.request))
+			if !ok {
+				t.Fatalf("expected an audit source in the context")
+			}
+			if source.Actor != tt.expectedActor {
+				t.Errorf("expected actor %q, got %q", tt.expectedActor, source.Actor)
+			}
+			if source.ClaimedActor != tt.expectedClaimedActor {
+				t.Errorf("expected claimed actor %q, got %q", tt.expectedClaimedActor, source.ClaimedActor)
+			}
+			if source.RequestID != tt.expectedRequestID {
+				t.Errorf("expected request ID %q, got %q", tt.expectedRequestID, source.RequestID)
+			}
+		})
+	}
+}
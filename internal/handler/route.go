@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+// Route describes one API Gateway route served by a service: an HTTP method
+// and a resource path template such as /kids/{id}. The remaining fields
+// document the operation in the generated OpenAPI document.
+type Route struct {
+	Method   string         // HTTP method, e.g. GET
+	Path     string         // Resource path template with {name} path parameters
+	Summary  string         // Short description of the operation
+	Params   []Param        // Path, query and header parameters
+	Body     *schema.Schema // Schema of the JSON request body, nil when the route takes none
+	Status   int            // Status code of successful responses, 200 when zero
+	Response *schema.Schema // Schema of the JSON body of successful responses
+
+	// ContentTypes lists the media types of successful responses, application/json when empty.
+	// Response describes one record of JSON types such as application/x-ndjson.
+	ContentTypes []string
+
+	// ResponseByVersion overrides Response for API versions with a different response shape
+	ResponseByVersion map[string]*schema.Schema
+}
+
+// ResponseFor returns the schema of successful responses in the given API version
+func (r Route) ResponseFor(version string) *schema.Schema {
+	if response, ok := r.ResponseByVersion[version]; ok {
+		return response
+	}
+	return r.Response
+}
+
+// Param describes a path, query or header parameter of a route
+type Param struct {
+	Name        string         // Parameter name
+	In          string         // Parameter location: path, query or header
+	Description string         // Human-readable description
+	Required    bool           // Whether the parameter must be present
+	Schema      *schema.Schema // Schema of the parameter value
+}
+
+// PathParam describes a path parameter of the given JSON Schema type
+func PathParam(name, typ, description string) Param {
+	return Param{Name: name, In: "path", Description: description, Required: true, Schema: &schema.Schema{Type: typ}}
+}
+
+// QueryParam describes an optional query-string parameter of the given JSON Schema type
+func QueryParam(name, typ, description string) Param {
+	return Param{Name: name, In: "query", Description: description, Schema: &schema.Schema{Type: typ}}
+}
+
+var (
+	// IfMatchParam is the If-Match precondition required by conditional writes (see IfMatch)
+	IfMatchParam = Param{
+		Name:        "If-Match",
+		In:          "header",
+		Description: "ETag of the version being modified, or * for any version",
+		Required:    true,
+		Schema:      &schema.Schema{Type: "string"},
+	}
+
+	// IfNoneMatchParam is the If-None-Match validator accepted by conditional reads (see NotModified)
+	IfNoneMatchParam = Param{
+		Name:        "If-None-Match",
+		In:          "header",
+		Description: "ETag of the cached version, answered with 304 Not Modified when still current",
+		Schema:      &schema.Schema{Type: "string"},
+	}
+)
+
+// ResponseSchema returns the schema of a Response envelope carrying data of the
+// given type in its data member, or no data member when data is nil
+func ResponseSchema(data any, models ...any) *schema.Schema {
+	s := schema.Generate(Response{})
+	if data == nil {
+		delete(s.Properties, "data")
+	} else {
+		s.Properties["data"] = schema.Generate(data, models...)
+	}
+	return s
+}
+
+// ErrorSchema is the schema of the JSON body of error responses
+var ErrorSchema = &schema.Schema{
+	Type:       "object",
+	Properties: map[string]*schema.Schema{"error": {Type: "string"}},
+	Required:   []string{"error"},
+}
+
+// Match reports whether the route serves the given method and request path,
+// and returns the values of its path parameters when it does
+func (r Route) Match(method, path string) (map[string]string, bool) {
+	if !strings.EqualFold(r.Method, method) {
+		return nil, false
+	}
+
+	templateSegments := strings.Split(strings.Trim(r.Path, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(templateSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[segment[1:len(segment)-1]] = pathSegments[i]
+			continue
+		}
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// MatchRoute finds the route serving the given method and request path
+// and returns it together with its path parameter values
+func MatchRoute(routes []Route, method, path string) (Route, map[string]string, bool) {
+	for _, route := range routes {
+		if params, ok := route.Match(method, path); ok {
+			return route, params, true
+		}
+	}
+	return Route{}, nil, false
+}
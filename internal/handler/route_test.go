@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRouteMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		route    Route
+		method   string
+		path     string
+		matched  bool
+		expected map[string]string
+	}{
+		{"static path", Route{Method: "GET", Path: "/kids"}, "GET", "/kids", true, nil},
+		{"trailing slash", Route{Method: "GET", Path: "/kids"}, "GET", "/kids/", true, nil},
+		{"path parameter", Route{Method: "PUT", Path: "/kids/{id}"}, "PUT", "/kids/42", true, map[string]string{"id": "42"}},
+		{"method case", Route{Method: "GET", Path: "/kids/{id}"}, "get", "/kids/42", true, map[string]string{"id": "42"}},
+		{"other method", Route{Method: "GET", Path: "/kids/{id}"}, "DELETE", "/kids/42", false, nil},
+		{"other path", Route{Method: "GET", Path: "/kids"}, "GET", "/caregivers", false, nil},
+		{"extra segment", Route{Method: "GET", Path: "/kids/{id}"}, "GET", "/kids/42/transactions", false, nil},
+		{"missing parameter", Route{Method: "GET", Path: "/kids/{id}"}, "GET", "/kids", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, ok := tt.route.Match(tt.method, tt.path)
+			if ok != tt.matched {
+				t.Fatalf("expected match %v, got %v", tt.matched, ok)
+			}
+			if !reflect.DeepEqual(params, tt.expected) {
+				t.Errorf("expected params %v, got %v", tt.expected, params)
+			}
+		})
+	}
+}
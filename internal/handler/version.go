@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is a major version of the API, served under the /{Name} path prefix.
+// Old versions keep being served with Deprecation and Sunset headers until they
+// are removed, so clients can migrate before breaking changes reach them.
+type Version struct {
+	Name        string    // Path prefix segment, e.g. v1
+	Deprecation time.Time // When the version was (or will be) deprecated, zero while current
+	Sunset      time.Time // When the version stops being served, zero when not scheduled
+}
+
+// Versions lists the supported API versions from oldest to newest.
+// Requests without a version prefix are served by the oldest version,
+// which is how clients called the API before it was versioned. Deprecation
+// and sunset dates are set per deployment, see ScheduleFromEnv.
+var Versions = []Version{
+	{Name: "v1"},
+	{Name: "v2"},
+}
+
+// Schedule is the supported API versions, from oldest to newest, and the clock
+// deciding whether a version is past its sunset
+type Schedule struct {
+	Versions []Version
+	Now      func() time.Time
+}
+
+// DefaultSchedule serves Versions against the wall clock. Services replace it
+// at startup with the dates configured in the environment, see LoadSchedule.
+var DefaultSchedule = Schedule{Versions: Versions, Now: time.Now}
+
+// ScheduleFromEnv returns the schedule of Versions with the deprecation and sunset
+// dates read from API_<VERSION>_DEPRECATION and API_<VERSION>_SUNSET, e.g.
+// API_V1_SUNSET=2027-05-01. Dates are YYYY-MM-DD in UTC; unset ones keep the dates of Versions.
+func ScheduleFromEnv(getenv func(string) string) (Schedule, error) {
+	schedule := Schedule{Versions: make([]Version, len(Versions)), Now: time.Now}
+	for i, version := range Versions {
+		prefix := "API_" + strings.ToUpper(version.Name) + "_"
+
+		deprecation, err := envDate(getenv, prefix+"DEPRECATION")
+		if err != nil {
+			return Schedule{}, err
+		}
+		sunset, err := envDate(getenv, prefix+"SUNSET")
+		if err != nil {
+			return Schedule{}, err
+		}
+		if !deprecation.IsZero() {
+			version.Deprecation = deprecation
+		}
+		if !sunset.IsZero() {
+			version.Sunset = sunset
+		}
+		if !version.Sunset.IsZero() && (version.Deprecation.IsZero() || !version.Deprecation.Before(version.Sunset)) {
+			return Schedule{}, fmt.Errorf("%sSUNSET requires an earlier %sDEPRECATION", prefix, prefix)
+		}
+
+		schedule.Versions[i] = version
+	}
+	return schedule, nil
+}
+
+// envDate parses a YYYY-MM-DD date from the environment, zero when unset
+func envDate(getenv func(string) string, key string) (time.Time, error) {
+	value := getenv(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %s (expected YYYY-MM-DD)", key, value)
+	}
+	return date, nil
+}
+
+// LoadSchedule sets DefaultSchedule from the environment. Services call it before
+// serving requests and refuse to start on an error, since a misconfigured schedule
+// would otherwise retire or keep serving a version by accident.
+func LoadSchedule() error {
+	schedule, err := ScheduleFromEnv(os.Getenv)
+	if err != nil {
+		return fmt.Errorf("invalid API version schedule: %w", err)
+	}
+	DefaultSchedule = schedule
+	return nil
+}
+
+// Lookup returns the supported version with the given name
+func (s Schedule) Lookup(name string) (Version, bool) {
+	for _, version := range s.Versions {
+		if version.Name == name {
+			return version, true
+		}
+	}
+	return Version{}, false
+}
+
+// versionSegment matches the first path segment of versioned requests
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// Deprecated reports whether the version has a deprecation date
+func (v Version) Deprecated() bool {
+	return !v.Deprecation.IsZero()
+}
+
+// Headers returns the Deprecation (RFC 9745) and Sunset (RFC 8594) headers
+// announcing the retirement of the version, or nil for current versions
+func (v Version) Headers() map[string]string {
+	if !v.Deprecated() {
+		return nil
+	}
+
+	headers := map[string]string{
+		"Deprecation": "@" + strconv.FormatInt(v.Deprecation.Unix(), 10),
+	}
+	if !v.Sunset.IsZero() {
+		headers["Sunset"] = v.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return headers
+}
+
+// LookupVersion returns the supported version with the given name in DefaultSchedule
+func LookupVersion(name string) (Version, bool) {
+	return DefaultSchedule.Lookup(name)
+}
+
+// ResolveVersion strips the version prefix from the request path and returns
+// the request as served by that version, with its route resolved against the
+// unversioned routes. Unprefixed requests are served by the oldest version.
+// Unknown versions are reported as 404 Not Found and retired ones as 410 Gone.
+func ResolveVersion(request HTTPRequest, routes []Route, schedule Schedule) (HTTPRequest, Version, error) {
+	version := schedule.Versions[0]
+
+	segment, rest, _ := strings.Cut(strings.TrimPrefix(request.Path, "/"), "/")
+	if versionSegment.MatchString(segment) {
+		var ok bool
+		if version, ok = schedule.Lookup(segment); !ok {
+			return request, Version{}, NewError(http.StatusNotFound, fmt.Sprintf("API version %s is not supported", segment))
+		}
+
+		// The trigger routed the prefixed path (e.g. /v1/{proxy+}), so the
+		// route and its path parameters are resolved again without the prefix
+		request.Path = "/" + rest
+		route, params, ok := MatchRoute(routes, request.HTTPMethod, request.Path)
+		if !ok {
+			return request, version, NewError(http.StatusNotFound, "Not found")
+		}
+		request.Resource = route.Path
+		request.PathParameters = params
+	}
+
+	if !version.Sunset.IsZero() && !schedule.Now().Before(version.Sunset) {
+		return request, version, NewError(http.StatusGone, fmt.Sprintf("API version %s was retired on %s", version.Name, version.Sunset.Format(time.DateOnly)))
+	}
+
+	request.Version = version.Name
+	return request, version, nil
+}
+
+// ServeVersion resolves the API version of a request in the schedule, serves it with
+// fn and adds the deprecation headers of the version to the response, including errors
+func ServeVersion(ctx context.Context, request HTTPRequest, routes []Route, schedule Schedule, fn func(context.Context, HTTPRequest) (HTTPResponse, error)) (HTTPResponse, error) {
+	request, version, err := ResolveVersion(request, routes, schedule)
+
+	var response HTTPResponse
+	if err != nil {
+		response = ErrorResponse(err)
+	} else if response, err = fn(ctx, request); err != nil {
+		return response, err
+	}
+
+	headers := version.Headers()
+	if len(headers) == 0 {
+		return response, nil
+	}
+	if response.Headers == nil {
+		response.Headers = make(map[string]string)
+	}
+	for name, value := range headers {
+		response.Headers[name] = value
+	}
+	// Let browser clients see that they are calling a deprecated version
+	if exposed := response.Headers["Access-Control-Expose-Headers"]; exposed != "" {
+		response.Headers["Access-Control-Expose-Headers"] = exposed + ", Deprecation, Sunset"
+	} else {
+		response.Headers["Access-Control-Expose-Headers"] = "Deprecation, Sunset"
+	}
+	return response, nil
+}
+
+// VersionedRoutes returns the routes followed by their copies under the prefix
+// of every supported version, for adapters that route requests themselves
+func VersionedRoutes(routes []Route) []Route {
+	versioned := append([]Route(nil), routes...)
+	for _, version := range Versions {
+		for _, route := range routes {
+			route.Path = "/" + version.Name + route.Path
+			versioned = append(versioned, route)
+		}
+	}
+	return versioned
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testSchedule deprecates v1 with a sunset, on a clock between the two dates
+var testSchedule = Schedule{
+	Versions: []Version{
+		{
+			Name:        "v1",
+			Deprecation: time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+			Sunset:      time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{Name: "v2"},
+	},
+	Now: func() time.Time { return time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC) },
+}
+
+func TestResolveVersion(t *testing.T) {
+	routes := []Route{
+		{Method: "GET", Path: "/kids"},
+		{Method: "GET", Path: "/kids/{id}"},
+	}
+
+	tests := []struct {
+		name             string
+		request          HTTPRequest
+		expectedStatus   int
+		expectedVersion  string
+		expectedPath     string
+		expectedResource string
+		expectedParams   map[string]string
+	}{
+		{
+			name:             "unprefixed path served by oldest version",
+			request:          HTTPRequest{HTTPMethod: "GET", Path: "/kids/7", Resource: "/kids/{id}", PathParameters: map[string]string{"id": "7"}},
+			expectedVersion:  "v1",
+			expectedPath:     "/kids/7",
+			expectedResource: "/kids/{id}",
+			expectedParams:   map[string]string{"id": "7"},
+		},
+		{
+			name:             "proxy event",
+			request:          HTTPRequest{HTTPMethod: "GET", Path: "/v2/kids/7", Resource: "/v2/{proxy+}", PathParameters: map[string]string{"proxy": "kids/7"}},
+			expectedVersion:  "v2",
+			expectedPath:     "/kids/7",
+			expectedResource: "/kids/{id}",
+			expectedParams:   map[string]string{"id": "7"},
+		},
+		{
+			name:             "versioned route",
+			request:          HTTPRequest{HTTPMethod: "GET", Path: "/v1/kids", Resource: "/v1/kids"},
+			expectedVersion:  "v1",
+			expectedPath:     "/kids",
+			expectedResource: "/kids",
+		},
+		{
+			name:           "unknown version",
+			request:        HTTPRequest{HTTPMethod: "GET", Path: "/v9/kids"},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "unknown route in version",
+			request:        HTTPRequest{HTTPMethod: "GET", Path: "/v2/unknown"},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, version, err := ResolveVersion(tt.request, routes, testSchedule)
+			if tt.expectedStatus != 0 {
+				var httpErr *Error
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %v", tt.expectedStatus, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if version.Name != tt.expectedVersion || got.Version != tt.expectedVersion {
+				t.Errorf("expected version %s, got %s (request %s)", tt.expectedVersion, version.Name, got.Version)
+			}
+			if got.Path != tt.expectedPath || got.Resource != tt.expectedResource {
+				t.Errorf("expected %s on %s, got %s on %s", tt.expectedPath, tt.expectedResource, got.Path, got.Resource)
+			}
+			if !reflect.DeepEqual(got.PathParameters, tt.expectedParams) {
+				t.Errorf("expected path parameters %v, got %v", tt.expectedParams, got.PathParameters)
+			}
+		})
+	}
+}
+
+func TestResolveVersionSunset(t *testing.T) {
+	schedule := testSchedule
+	schedule.Now = func() time.Time { return time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC) }
+
+	_, _, err := ResolveVersion(HTTPRequest{HTTPMethod: "GET", Path: "/kids"}, []Route{{Method: "GET", Path: "/kids"}}, schedule)
+	var httpErr *Error
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusGone {
+		t.Errorf("expected status %d, got %v", http.StatusGone, err)
+	}
+}
+
+func TestScheduleFromEnv(t *testing.T) {
+	tests := []struct {
+		name                string
+		env                 map[string]string
+		expectedError       bool
+		expectedDeprecation string
+		expectedSunset      string
+	}{
+		{"unscheduled", map[string]string{}, false, "", ""},
+		{"deprecated", map[string]string{"API_V1_DEPRECATION": "2026-11-01"}, false, "2026-11-01", ""},
+		{"deprecated with sunset", map[string]string{"API_V1_DEPRECATION": "2026-11-01", "API_V1_SUNSET": "2027-05-01"}, false, "2026-11-01", "2027-05-01"},
+		{"sunset without deprecation", map[string]string{"API_V1_SUNSET": "2027-05-01"}, true, "", ""},
+		{"sunset before deprecation", map[string]string{"API_V1_DEPRECATION": "2027-05-01", "API_V1_SUNSET": "2026-11-01"}, true, "", ""},
+		{"invalid date", map[string]string{"API_V1_DEPRECATION": "next year"}, true, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ScheduleFromEnv(func(key string) string { return tt.env[key] })
+			if tt.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", schedule.Versions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			v1, _ := schedule.Lookup("v1")
+			if got := dateOrEmpty(v1.Deprecation); got != tt.expectedDeprecation {
+				t.Errorf("expected deprecation %q, got %q", tt.expectedDeprecation, got)
+			}
+			if got := dateOrEmpty(v1.Sunset); got != tt.expectedSunset {
+				t.Errorf("expected sunset %q, got %q", tt.expectedSunset, got)
+			}
+			if v2, _ := schedule.Lookup("v2"); v2.Deprecated() {
+				t.Errorf("expected v2 to stay current, got %+v", v2)
+			}
+		})
+	}
+}
+
+// dateOrEmpty formats a date as YYYY-MM-DD, "" when zero
+func dateOrEmpty(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+func TestServeVersion(t *testing.T) {
+	routes := []Route{{Method: "GET", Path: "/kids"}}
+	echo := func(ctx context.Context, request HTTPRequest) (HTTPResponse, error) {
+		return HTTPResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Access-Control-Expose-Headers": "ETag"},
+			Body:       request.Version,
+		}, nil
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		deprecated     bool
+	}{
+		{"deprecated version", "/v1/kids", http.StatusOK, "v1", true},
+		{"unprefixed path", "/kids", http.StatusOK, "v1", true},
+		{"current version", "/v2/kids", http.StatusOK, "v2", false},
+		{"unknown version", "/v3/kids", http.StatusNotFound, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := ServeVersion(context.Background(), HTTPRequest{HTTPMethod: "GET", Path: tt.path}, routes, testSchedule, echo)
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, response.StatusCode)
+			}
+			if tt.expectedBody != "" && response.Body != tt.expectedBody {
+				t.Errorf("expected version %s, got %s", tt.expectedBody, response.Body)
+			}
+
+			_, deprecation := response.Headers["Deprecation"]
+			_, sunset := response.Headers["Sunset"]
+			if deprecation != tt.deprecated || sunset != tt.deprecated {
+				t.Errorf("expected deprecation headers %v, got %v", tt.deprecated, response.Headers)
+			}
+			if tt.deprecated && response.Headers["Access-Control-Expose-Headers"] != "ETag, Deprecation, Sunset" {
+				t.Errorf("expected deprecation headers to be exposed, got %q", response.Headers["Access-Control-Expose-Headers"])
+			}
+		})
+	}
+}
+
+func TestVersionHeaders(t *testing.T) {
+	version := Version{
+		Name:        "v1",
+		Deprecation: time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:      time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	expected := map[string]string{
+		"Deprecation": "@1793491200",
+		"Sunset":      "Sat, 01 May 2027 00:00:00 GMT",
+	}
+	if got := version.Headers(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got := (Version{Name: "v2"}).Headers(); got != nil {
+		t.Errorf("expected no headers for current version, got %v", got)
+	}
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	var got []string
+	for _, route := range VersionedRoutes([]Route{{Method: "GET", Path: "/kids/{id}"}}) {
+		got = append(got, route.Method+" "+route.Path)
+	}
+
+	expected := []string{"GET /kids/{id}", "GET /v1/kids/{id}", "GET /v2/kids/{id}"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,161 @@
+// Package httpadapter runs the service handlers behind a plain net/http server.
+// It converts http.Request values into service requests and writes the service
+// responses back, so services can be developed without Lambda tooling.
+package httpadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/middleware"
+)
+
+// NewRequest converts an HTTP request matched against the resource path template
+// (e.g. /kids/{id}) into a service request. Path parameters are read from the
+// ServeMux pattern match, so the request must have been routed by a pattern
+// using the same parameter names. Bodies over handler.MaxBodySize are rejected
+// with an *http.MaxBytesError.
+func NewRequest(w http.ResponseWriter, r *http.Request, resource string) (handler.HTTPRequest, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, handler.MaxBodySize))
+	if err != nil {
+		return handler.HTTPRequest{}, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	request := handler.HTTPRequest{
+		HTTPMethod: r.Method,
+		Path:       r.URL.Path,
+		Resource:   resource,
+		Body:       string(body),
+		RequestID:  fmt.Sprintf("local-%d", time.Now().UnixNano()),
+		SourceIP:   sourceIP(r),
+	}
+
+	if names := pathParameterNames(resource); len(names) > 0 {
+		request.PathParameters = make(map[string]string, len(names))
+		for _, name := range names {
+			request.PathParameters[name] = r.PathValue(name)
+		}
+	}
+
+	if len(r.Header) > 0 {
+		request.Headers = make(map[string]string, len(r.Header))
+		for name, values := range r.Header {
+			request.Headers[name] = strings.Join(values, ",")
+		}
+	}
+
+	if query := r.URL.Query(); len(query) > 0 {
+		// API Gateway keeps the last value of repeated parameters
+		request.QueryStringParameters = make(map[string]string, len(query))
+		for name, values := range query {
+			request.QueryStringParameters[name] = values[len(values)-1]
+		}
+	}
+
+	return request, nil
+}
+
+// WriteResponse writes a service response to the HTTP response writer,
+// streaming the body as it is produced when the response has a Stream
+func WriteResponse(w http.ResponseWriter, response handler.HTTPResponse) error {
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
+
+	if response.Stream != nil {
+		return response.Stream(w)
+	}
+
+	_, err := w.Write([]byte(response.Body))
+	return err
+}
+
+// Handler adapts a service handler function serving one resource into an http.Handler.
+// Handler errors are reported as 502 Bad Gateway, like API Gateway does for a
+// failed Lambda invocation.
+func Handler(resource string, fn middleware.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, err := NewRequest(w, r, resource)
+		if err != nil {
+			statusCode := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				statusCode = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), statusCode)
+			return
+		}
+
+		response, err := fn(handler.WithAuditSource(r.Context(), request), request)
+		if err != nil {
+			http.Error(w, `{"message": "Internal server error"}`, http.StatusBadGateway)
+			return
+		}
+
+		if err := WriteResponse(w, response); err != nil && response.Stream != nil {
+			// The status line is already sent, so abort the connection rather
+			// than let the client take a truncated body for a complete one
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
+
+// Mount registers the routes of a service on the mux, serving each with fn.
+// Every resource also answers CORS preflight requests like the API Gateway
+// configuration in template.yaml.
+func Mount(mux *http.ServeMux, routes []handler.Route, fn middleware.HandlerFunc) {
+	resources := make(map[string]bool)
+	for _, route := range routes {
+		mux.Handle(route.Method+" "+route.Path, Handler(route.Path, fn))
+
+		if !resources[route.Path] {
+			resources[route.Path] = true
+			mux.Handle(http.MethodOptions+" "+route.Path, Handler(route.Path, preflight))
+		}
+	}
+}
+
+// preflight answers CORS preflight requests
+func preflight(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	return handler.HTTPResponse{
+		StatusCode: http.StatusNoContent,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token,If-Match,If-None-Match,X-Actor,X-Request-ID",
+		},
+	}, nil
+}
+
+// pathParameterNames returns the {name} parameters of a resource path template
+func pathParameterNames(resource string) []string {
+	var names []string
+	for _, segment := range strings.Split(resource, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}"))
+		}
+	}
+	return names
+}
+
+// sourceIP returns the client IP address without the port
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,131 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+)
+
+// echo returns the request it received as the response body
+func echo(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return handler.HTTPResponse{}, err
+	}
+	return handler.HTTPResponse{
+		StatusCode: http.StatusCreated,
+		Headers:    map[string]string{"ETag": `"1"`},
+		Body:       string(body),
+	}, nil
+}
+
+func TestMount(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, []handler.Route{
+		{Method: http.MethodGet, Path: "/kids"},
+		{Method: http.MethodPut, Path: "/kids/{id}"},
+	}, echo)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/kids/42?sort=-age&sort=name", strings.NewReader(`{"name":"Alice"}`))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("If-Match", `"1"`)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, resp.StatusCode)
+	}
+	if etag := resp.Header.Get("ETag"); etag != `"1"` {
+		t.Errorf("expected ETag header %q, got %q", `"1"`, etag)
+	}
+
+	var got handler.HTTPRequest
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode echoed request: %v", err)
+	}
+
+	if got.HTTPMethod != http.MethodPut || got.Path != "/kids/42" || got.Resource != "/kids/{id}" {
+		t.Errorf("expected PUT /kids/42 on /kids/{id}, got %s %s on %s", got.HTTPMethod, got.Path, got.Resource)
+	}
+	if got.PathParameters["id"] != "42" {
+		t.Errorf("expected id path parameter 42, got %q", got.PathParameters["id"])
+	}
+	if got.QueryStringParameters["sort"] != "name" {
+		t.Errorf("expected last sort value, got %v", got.QueryStringParameters)
+	}
+	if handler.Header(got, "if-match") != `"1"` {
+		t.Errorf("expected If-Match header to be passed through, got %v", got.Headers)
+	}
+	if got.Body != `{"name":"Alice"}` {
+		t.Errorf("expected body to be passed through, got %q", got.Body)
+	}
+}
+
+func TestMountRoutingAndPreflight(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, []handler.Route{{Method: http.MethodGet, Path: "/kids"}}, echo)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"mounted route", http.MethodGet, "/kids", "", http.StatusCreated},
+		{"body at the limit", http.MethodGet, "/kids", strings.Repeat(" ", handler.MaxBodySize), http.StatusCreated},
+		{"body over the limit", http.MethodGet, "/kids", strings.Repeat(" ", handler.MaxBodySize+1), http.StatusRequestEntityTooLarge},
+		{"cors preflight", http.MethodOptions, "/kids", "", http.StatusNoContent},
+		{"method not mounted", http.MethodDelete, "/kids", "", http.StatusMethodNotAllowed},
+		{"path not mounted", http.MethodGet, "/caregivers", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if recorder.Code != tt.expected {
+				body, _ := io.ReadAll(recorder.Body)
+				t.Errorf("expected status %d, got %d: %s", tt.expected, recorder.Code, body)
+			}
+		})
+	}
+}
+
+func TestWriteResponseStream(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := WriteResponse(recorder, handler.HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "text/csv"},
+		Body:       "ignored",
+		Stream: func(w io.Writer) error {
+			_, err := io.WriteString(w, "id\n1\n")
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("expected 200 text/csv, got %d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if got := recorder.Body.String(); got != "id\n1\n" {
+		t.Errorf("expected streamed body, got %q", got)
+	}
+}
@@ -0,0 +1,381 @@
+// Package importer loads existing family data (kids, caregivers and their star
+// history) from CSV or JSON, e.g. when a family moves over from paper charts or
+// another app. Every row is validated with the model Validate methods before
+// anything is written, and the rows are inserted in a single database transaction.
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/kid"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// Entities that can be imported, also the keys of the JSON document
+const (
+	Kids         = "kids"
+	Caregivers   = "caregivers"
+	Transactions = "transactions"
+)
+
+// KidRow is a kid to import
+type KidRow struct {
+	ExternalID string `json:"external_id"` // ID of the kid in the source system
+	Name       string `json:"name"`
+	Birthdate  string `json:"birthdate"` // YYYY-MM-DD
+}
+
+// CaregiverRow is a caregiver to import
+type CaregiverRow struct {
+	ExternalID   string `json:"external_id"` // ID of the caregiver in the source system
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Relationship string `json:"relationship"`
+}
+
+// TransactionRow is a historical star transaction to import
+type TransactionRow struct {
+	ExternalID  string `json:"external_id"` // ID of the transaction in the source system
+	KidID       string `json:"kid_id"`      // External ID of a kid in the same import
+	Type        string `json:"type"`
+	Amount      int    `json:"amount"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at,omitempty"` // RFC 3339 timestamp or YYYY-MM-DD date, the import time when empty
+}
+
+// Data holds the rows of an import
+type Data struct {
+	Kids         []KidRow         `json:"kids"`
+	Caregivers   []CaregiverRow   `json:"caregivers"`
+	Transactions []TransactionRow `json:"transactions"`
+
+	errors []RowError // Values that could not be read, reported with the validation errors
+}
+
+// RowError reports why a row cannot be imported
+type RowError struct {
+	Entity     string `json:"entity"`                // kids, caregivers or transactions
+	Row        int    `json:"row"`                   // 1-based position of the row; for CSV, the line after the header
+	ExternalID string `json:"external_id,omitempty"` // External ID of the row, when it has one
+	Error      string `json:"error"`
+}
+
+// Report is the outcome of an import
+type Report struct {
+	DryRun       bool                      `json:"dry_run"`
+	Kids         int                       `json:"kids"`         // Kids imported, or that would be imported in a dry run
+	Caregivers   int                       `json:"caregivers"`   // Caregivers imported, or that would be imported
+	Transactions int                       `json:"transactions"` // Transactions imported, or that would be imported
+	Errors       []RowError                `json:"errors,omitempty"`
+	IDs          map[string]map[string]string `json:"ids,omitempty"` // Generated public ID of every external ID, per entity
+}
+
+// ReadJSON reads an import document with kids, caregivers and transactions arrays
+func ReadJSON(r io.Reader) (*Data, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var data Data
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+	return &data, nil
+}
+
+// ReadCSV appends the rows of a CSV file of one entity. The first line names the
+// columns, using the JSON member names (e.g. external_id,name,birthdate); columns
+// may come in any order. Values that cannot be read are reported per row.
+func (d *Data) ReadCSV(entity string, r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read %s header: %w", entity, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	var required []string
+	switch entity {
+	case Kids:
+		required = []string{"external_id", "name", "birthdate"}
+	case Caregivers:
+		required = []string{"external_id", "name", "email", "relationship"}
+	case Transactions:
+		required = []string{"external_id", "kid_id", "type", "amount", "description"}
+	default:
+		return fmt.Errorf("unknown entity: %s", entity)
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("%s CSV is missing the %s column", entity, name)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", entity, err)
+		}
+
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		switch entity {
+		case Kids:
+			d.Kids = append(d.Kids, KidRow{
+				ExternalID: value("external_id"),
+				Name:       value("name"),
+				Birthdate:  value("birthdate"),
+			})
+		case Caregivers:
+			d.Caregivers = append(d.Caregivers, CaregiverRow{
+				ExternalID:   value("external_id"),
+				Name:         value("name"),
+				Email:        value("email"),
+				Relationship: value("relationship"),
+			})
+		case Transactions:
+			row := TransactionRow{
+				ExternalID:  value("external_id"),
+				KidID:       value("kid_id"),
+				Type:        value("type"),
+				Description: value("description"),
+				CreatedAt:   value("created_at"),
+			}
+			amount, err := strconv.Atoi(value("amount"))
+			if err != nil {
+				d.errors = append(d.errors, RowError{
+					Entity:     Transactions,
+					Row:        len(d.Transactions) + 1,
+					ExternalID: row.ExternalID,
+					Error:      fmt.Sprintf("amount must be a whole number, got %q", value("amount")),
+				})
+			}
+			row.Amount = amount
+			d.Transactions = append(d.Transactions, row)
+		}
+	}
+}
+
+// Plan validates every row and converts the data into a batch for the repository.
+// All problems are reported, not just the first one; the batch is only usable
+// when no errors are returned.
+func Plan(data *Data) (*interfaces.ImportBatch, []RowError) {
+	batch := &interfaces.ImportBatch{}
+	rowErrors := append([]RowError(nil), data.errors...)
+	unreadable := make(map[int]bool) // Transaction rows with values that could not be read
+	for _, rowErr := range data.errors {
+		unreadable[rowErr.Row-1] = true
+	}
+
+	fail := func(entity string, i int, externalID string, err error) {
+		rowErrors = append(rowErrors, RowError{Entity: entity, Row: i + 1, ExternalID: externalID, Error: err.Error()})
+	}
+	// external checks that every row of an entity has its own external ID
+	external := func(entity string) func(i int, externalID string) bool {
+		seen := make(map[string]int)
+		return func(i int, externalID string) bool {
+			if externalID == "" {
+				fail(entity, i, externalID, errors.New("external_id is required"))
+				return false
+			}
+			if first, ok := seen[externalID]; ok {
+				fail(entity, i, externalID, fmt.Errorf("external_id is also used by row %d", first+1))
+				return false
+			}
+			seen[externalID] = i
+			return true
+		}
+	}
+
+	kidIndexes := make(map[string]int)
+	checkKid := external(Kids)
+	for i, row := range data.Kids {
+		if !checkKid(i, row.ExternalID) {
+			continue
+		}
+
+		k := &kid.Kid{Name: strings.TrimSpace(row.Name)}
+		birthdate, err := time.Parse(kid.BirthdateFormat, row.Birthdate)
+		if err == nil {
+			k.Birthdate = birthdate
+			err = k.Validate()
+		} else if row.Birthdate == "" {
+			err = errors.New("birthdate is required")
+		} else {
+			err = fmt.Errorf("birthdate must be a YYYY-MM-DD date, got %q", row.Birthdate)
+		}
+		if err != nil {
+			fail(Kids, i, row.ExternalID, err)
+			continue
+		}
+
+		kidIndexes[row.ExternalID] = len(batch.Kids)
+		batch.Kids = append(batch.Kids, k)
+	}
+
+	emails := make(map[string]int)
+	checkCaregiver := external(Caregivers)
+	for i, row := range data.Caregivers {
+		if !checkCaregiver(i, row.ExternalID) {
+			continue
+		}
+
+		c := &caregiver.Caregiver{
+			Name:         strings.TrimSpace(row.Name),
+			Email:        strings.TrimSpace(row.Email),
+			Relationship: caregiver.RelationshipType(strings.TrimSpace(row.Relationship)),
+		}
+		if err := c.Validate(); err != nil {
+			fail(Caregivers, i, row.ExternalID, err)
+			continue
+		}
+
+		email := strings.ToLower(c.Email)
+		if first, ok := emails[email]; ok {
+			fail(Caregivers, i, row.ExternalID, fmt.Errorf("email is also used by row %d", first+1))
+			continue
+		}
+		emails[email] = i
+
+		batch.Caregivers = append(batch.Caregivers, c)
+	}
+
+	now := time.Now()
+	checkTransaction := external(Transactions)
+	for i, row := range data.Transactions {
+		if !checkTransaction(i, row.ExternalID) || unreadable[i] {
+			continue
+		}
+
+		kidIndex, ok := kidIndexes[row.KidID]
+		if !ok {
+			fail(Transactions, i, row.ExternalID, fmt.Errorf("kid_id %q is not a valid kid of this import", row.KidID))
+			continue
+		}
+
+		t := &transaction.Transaction{
+			// The kid is created by the import, so its ID is not known yet. The
+			// placeholder lets Validate check the other fields.
+			KidID:       1,
+			Type:        transaction.TransactionType(strings.TrimSpace(strings.ToLower(row.Type))),
+			Amount:      row.Amount,
+			Description: strings.TrimSpace(row.Description),
+		}
+		err := t.Validate()
+		if err == nil && row.CreatedAt != "" {
+			t.CreatedAt, err = parseTime(row.CreatedAt)
+			if err == nil && t.CreatedAt.After(now) {
+				err = errors.New("created_at cannot be in the future")
+			}
+		}
+		if err != nil {
+			fail(Transactions, i, row.ExternalID, err)
+			continue
+		}
+		t.KidID = 0
+
+		batch.Transactions = append(batch.Transactions, interfaces.ImportedTransaction{Transaction: t, Kid: kidIndex})
+	}
+
+	return batch, rowErrors
+}
+
+// Import validates the data and inserts it with the repository. Invalid rows are
+// reported in the returned Report and nothing is written. In a dry run the rows are
+// also checked against the database (e.g. for emails already in use) and then
+// rolled back, so the report lists no generated IDs.
+func Import(ctx context.Context, repo interfaces.ImportRepository, data *Data, dryRun bool) (*Report, error) {
+	report := &Report{DryRun: dryRun}
+
+	batch, rowErrors := Plan(data)
+	if len(rowErrors) > 0 {
+		report.Errors = rowErrors
+		return report, nil
+	}
+
+	result, err := repo.Import(ctx, batch, dryRun)
+	if err != nil {
+		var rowErr *interfaces.ImportRowError
+		if !errors.As(err, &rowErr) {
+			return nil, fmt.Errorf("failed to import: %w", err)
+		}
+
+		// Batch rows are the valid rows in input order, and every row is valid here
+		report.Errors = []RowError{{
+			Entity:     rowErr.Entity,
+			Row:        rowErr.Index + 1,
+			ExternalID: externalID(data, rowErr.Entity, rowErr.Index),
+			Error:      rowErr.Err.Error(),
+		}}
+		return report, nil
+	}
+
+	report.Kids, report.Caregivers, report.Transactions = len(batch.Kids), len(batch.Caregivers), len(batch.Transactions)
+	if dryRun {
+		return report, nil
+	}
+
+	report.IDs = map[string]map[string]string{
+		Kids:         make(map[string]string, len(result.KidIDs)),
+		Caregivers:   make(map[string]string, len(result.CaregiverIDs)),
+		Transactions: make(map[string]string, len(result.TransactionIDs)),
+	}
+	for i, id := range result.KidIDs {
+		report.IDs[Kids][data.Kids[i].ExternalID] = id
+	}
+	for i, id := range result.CaregiverIDs {
+		report.IDs[Caregivers][data.Caregivers[i].ExternalID] = id
+	}
+	for i, id := range result.TransactionIDs {
+		report.IDs[Transactions][data.Transactions[i].ExternalID] = id
+	}
+
+	return report, nil
+}
+
+// externalID returns the external ID of a row
+func externalID(data *Data, entity string, index int) string {
+	switch {
+	case entity == Kids && index < len(data.Kids):
+		return data.Kids[index].ExternalID
+	case entity == Caregivers && index < len(data.Caregivers):
+		return data.Caregivers[index].ExternalID
+	case entity == Transactions && index < len(data.Transactions):
+		return data.Transactions[index].ExternalID
+	}
+	return ""
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates (midnight UTC)
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("created_at must be an RFC 3339 timestamp or YYYY-MM-DD date, got %q", value)
+	}
+	return t, nil
+}
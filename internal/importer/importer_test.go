@@ -0,0 +1,241 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+)
+
+// importRepository assigns public IDs ending in 100, 101... and can reject one row like a database constraint would
+type importRepository struct {
+	batch  *interfaces.ImportBatch
+	dryRun bool
+	reject *interfaces.ImportRowError
+}
+
+func (r *importRepository) Import(ctx context.Context, batch *interfaces.ImportBatch, dryRun bool) (*interfaces.ImportResult, error) {
+	r.batch, r.dryRun = batch, dryRun
+	if r.reject != nil {
+		return nil, r.reject
+	}
+
+	result := &interfaces.ImportResult{}
+	id := 100
+	next := func() string {
+		id++
+		return fmt.Sprintf("00000000-0000-4000-8000-%012d", id-1)
+	}
+	for range batch.Kids {
+		result.KidIDs = append(result.KidIDs, next())
+	}
+	for range batch.Caregivers {
+		result.CaregiverIDs = append(result.CaregiverIDs, next())
+	}
+	for range batch.Transactions {
+		result.TransactionIDs = append(result.TransactionIDs, next())
+	}
+	if dryRun {
+		return &interfaces.ImportResult{}, nil
+	}
+	return result, nil
+}
+
+func birthdate(yearsAgo int) string {
+	return time.Now().AddDate(-yearsAgo, 0, 0).Format(time.DateOnly)
+}
+
+func TestReadCSV(t *testing.T) {
+	data := &Data{}
+	kids := "external_id,birthdate,name\nk1," + birthdate(8) + ", Alice \n"
+	if err := data.ReadCSV(Kids, strings.NewReader(kids)); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	transactions := "external_id,kid_id,type,amount,description,created_at\n" +
+		"t1,k1,earn,5,Homework,2025-01-01\n" +
+		"t2,k1,earn,five,Homework,\n"
+	if err := data.ReadCSV(Transactions, strings.NewReader(transactions)); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	expectedKids := []KidRow{{ExternalID: "k1", Name: "Alice", Birthdate: birthdate(8)}}
+	if !reflect.DeepEqual(data.Kids, expectedKids) {
+		t.Errorf("expected kids %+v, got %+v", expectedKids, data.Kids)
+	}
+	if len(data.Transactions) != 2 || data.Transactions[0].Amount != 5 || data.Transactions[0].CreatedAt != "2025-01-01" {
+		t.Errorf("expected 2 transactions, got %+v", data.Transactions)
+	}
+	if len(data.errors) != 1 || data.errors[0].Row != 2 || data.errors[0].ExternalID != "t2" {
+		t.Errorf("expected unreadable amount on row 2, got %+v", data.errors)
+	}
+
+	err := (&Data{}).ReadCSV(Caregivers, strings.NewReader("external_id,name,email\n"))
+	if err == nil || !strings.Contains(err.Error(), "relationship") {
+		t.Errorf("expected missing column error, got %v", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	validKid := KidRow{ExternalID: "k1", Name: "Alice", Birthdate: birthdate(8)}
+	validCaregiver := CaregiverRow{ExternalID: "c1", Name: "Sarah", Email: "sarah@example.com", Relationship: "parent"}
+	validTransaction := TransactionRow{ExternalID: "t1", KidID: "k1", Type: "earn", Amount: 5, Description: "Homework", CreatedAt: "2025-01-01"}
+
+	tests := []struct {
+		name           string
+		data           Data
+		expectedErrors []string
+	}{
+		{
+			name: "valid",
+			data: Data{Kids: []KidRow{validKid}, Caregivers: []CaregiverRow{validCaregiver}, Transactions: []TransactionRow{validTransaction}},
+		},
+		{
+			name: "invalid kids",
+			data: Data{Kids: []KidRow{
+				validKid,
+				{ExternalID: "k1", Name: "Bob", Birthdate: birthdate(5)},
+				{ExternalID: "k2", Name: "Carl", Birthdate: "15/03/2015"},
+				{ExternalID: "k3", Name: "Dora", Birthdate: birthdate(30)},
+				{Name: "Eve", Birthdate: birthdate(5)},
+			}},
+			expectedErrors: []string{
+				"kids row 2: external_id is also used by row 1",
+				`kids row 3: birthdate must be a YYYY-MM-DD date, got "15/03/2015"`,
+				"kids row 4: age cannot exceed 18 for kids",
+				"kids row 5: external_id is required",
+			},
+		},
+		{
+			name: "invalid caregivers",
+			data: Data{Caregivers: []CaregiverRow{
+				validCaregiver,
+				{ExternalID: "c2", Name: "Mike", Email: "SARAH@example.com", Relationship: "guardian"},
+				{ExternalID: "c3", Name: "Grace", Email: "grace@example.com", Relationship: "neighbour"},
+				{ExternalID: "c4", Name: "Sam", Email: " sarah@example.com ", Relationship: "parent"},
+				{ExternalID: "c5", Name: "   ", Email: "kim@example.com", Relationship: "parent"},
+			}},
+			expectedErrors: []string{
+				"caregivers row 2: email is also used by row 1",
+				"caregivers row 3: relationship must be one of: parent, guardian, grandparent, relative, caregiver",
+				"caregivers row 4: email is also used by row 1",
+				"caregivers row 5: name is required and cannot be empty",
+			},
+		},
+		{
+			name: "invalid transactions",
+			data: Data{Kids: []KidRow{validKid}, Transactions: []TransactionRow{
+				validTransaction,
+				{ExternalID: "t2", KidID: "k9", Type: "earn", Amount: 5, Description: "Homework"},
+				{ExternalID: "t3", KidID: "k1", Type: "earn", Amount: 500, Description: "Homework"},
+				{ExternalID: "t4", KidID: "k1", Type: "earn", Amount: 5, Description: "Homework", CreatedAt: "2999-01-01"},
+			}},
+			expectedErrors: []string{
+				`transactions row 2: kid_id "k9" is not a valid kid of this import`,
+				"transactions row 3: amount cannot exceed 100 stars",
+				"transactions row 4: created_at cannot be in the future",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch, rowErrors := Plan(&tt.data)
+
+			var got []string
+			for _, rowErr := range rowErrors {
+				got = append(got, fmt.Sprintf("%s row %d: %s", rowErr.Entity, rowErr.Row, rowErr.Error))
+			}
+			if !reflect.DeepEqual(got, tt.expectedErrors) {
+				t.Errorf("expected errors %q, got %q", tt.expectedErrors, got)
+			}
+			if len(rowErrors) > 0 {
+				return
+			}
+
+			if len(batch.Kids) != len(tt.data.Kids) || len(batch.Caregivers) != len(tt.data.Caregivers) || len(batch.Transactions) != len(tt.data.Transactions) {
+				t.Fatalf("expected every row in the batch, got %+v", batch)
+			}
+			imported := batch.Transactions[0]
+			if imported.Kid != 0 || imported.Transaction.KidID != 0 {
+				t.Errorf("expected transaction of kid 0 without kid_id, got kid %d with kid_id %d", imported.Kid, imported.Transaction.KidID)
+			}
+			if !imported.Transaction.CreatedAt.Equal(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("expected historical created_at, got %v", imported.Transaction.CreatedAt)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	data := &Data{
+		Kids:         []KidRow{{ExternalID: "k1", Name: "Alice", Birthdate: birthdate(8)}, {ExternalID: "k2", Name: "Bob", Birthdate: birthdate(6)}},
+		Caregivers:   []CaregiverRow{{ExternalID: "c1", Name: "Sarah", Email: "sarah@example.com", Relationship: "parent"}},
+		Transactions: []TransactionRow{{ExternalID: "t1", KidID: "k2", Type: "spend", Amount: 3, Description: "Sticker"}},
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		repo := &importRepository{}
+		report, err := Import(context.Background(), repo, data, false)
+		if err != nil {
+			t.Fatalf("expected no error but got: %v", err)
+		}
+
+		expected := map[string]map[string]string{
+			Kids:         {"k1": "00000000-0000-4000-8000-000000000100", "k2": "00000000-0000-4000-8000-000000000101"},
+			Caregivers:   {"c1": "00000000-0000-4000-8000-000000000102"},
+			Transactions: {"t1": "00000000-0000-4000-8000-000000000103"},
+		}
+		if !reflect.DeepEqual(report.IDs, expected) {
+			t.Errorf("expected IDs %v, got %v", expected, report.IDs)
+		}
+		if report.Kids != 2 || report.Caregivers != 1 || report.Transactions != 1 || len(report.Errors) != 0 {
+			t.Errorf("expected 2 kids, 1 caregiver and 1 transaction, got %+v", report)
+		}
+		if repo.batch.Transactions[0].Kid != 1 {
+			t.Errorf("expected transaction of the second kid, got kid %d", repo.batch.Transactions[0].Kid)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		repo := &importRepository{}
+		report, err := Import(context.Background(), repo, data, true)
+		if err != nil {
+			t.Fatalf("expected no error but got: %v", err)
+		}
+		if !repo.dryRun || !report.DryRun || report.IDs != nil || report.Kids != 2 {
+			t.Errorf("expected dry run with counts and no IDs, got %+v", report)
+		}
+	})
+
+	t.Run("invalid rows are not imported", func(t *testing.T) {
+		repo := &importRepository{}
+		invalid := &Data{Kids: []KidRow{{ExternalID: "k1", Name: "A", Birthdate: birthdate(8)}}}
+		report, err := Import(context.Background(), repo, invalid, false)
+		if err != nil {
+			t.Fatalf("expected no error but got: %v", err)
+		}
+		if repo.batch != nil {
+			t.Errorf("expected no repository call, got %+v", repo.batch)
+		}
+		if len(report.Errors) != 1 || report.Errors[0].ExternalID != "k1" {
+			t.Errorf("expected one error for k1, got %+v", report.Errors)
+		}
+	})
+
+	t.Run("row rejected by the database", func(t *testing.T) {
+		repo := &importRepository{reject: &interfaces.ImportRowError{Entity: Caregivers, Index: 0, Err: errors.New("already exists (caregivers_email_key)")}}
+		report, err := Import(context.Background(), repo, data, false)
+		if err != nil {
+			t.Fatalf("expected no error but got: %v", err)
+		}
+		expected := []RowError{{Entity: Caregivers, Row: 1, ExternalID: "c1", Error: "already exists (caregivers_email_key)"}}
+		if !reflect.DeepEqual(report.Errors, expected) || report.IDs != nil {
+			t.Errorf("expected errors %+v without IDs, got %+v", expected, report)
+		}
+	})
+}
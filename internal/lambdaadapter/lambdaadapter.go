@@ -0,0 +1,345 @@
+// Package lambdaadapter runs the service handlers as AWS Lambda functions.
+// It converts API Gateway REST API (v1), HTTP API (v2) and ALB target group
+// events into service requests and the service responses back, so each service
+// works unchanged behind any of the three triggers.
+package lambdaadapter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/middleware"
+)
+
+// Start starts the Lambda runtime serving the routes of a service with fn
+func Start(routes []handler.Route, fn middleware.HandlerFunc) {
+	lambda.Start(Handler(routes, fn))
+}
+
+// eventProbe holds the fields that tell the supported event payloads apart
+type eventProbe struct {
+	Version        string `json:"version"`
+	RequestContext struct {
+		ELB json.RawMessage `json:"elb"`
+	} `json:"requestContext"`
+}
+
+// Handler returns a Lambda handler that detects the trigger from the event
+// payload, converts it for fn and converts the result back for the trigger.
+// Routes are used to resolve path parameters when the trigger does not.
+func Handler(routes []handler.Route, fn middleware.HandlerFunc) func(context.Context, json.RawMessage) (any, error) {
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		var probe eventProbe
+		if err := json.Unmarshal(payload, &probe); err != nil {
+			return nil, fmt.Errorf("failed to decode event: %w", err)
+		}
+
+		switch {
+		case probe.RequestContext.ELB != nil:
+			var event events.ALBTargetGroupRequest
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return nil, fmt.Errorf("failed to decode ALB event: %w", err)
+			}
+			multiValue := event.MultiValueHeaders != nil
+			request, err := FromALBTargetGroup(event)
+			if err != nil {
+				return ToALBTargetGroup(badRequest(err), multiValue), nil
+			}
+			response, err := serve(ctx, routes, request, fn)
+			if err != nil {
+				return nil, err
+			}
+			return ToALBTargetGroup(response, multiValue), nil
+
+		case probe.Version == "2.0":
+			var event events.APIGatewayV2HTTPRequest
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return nil, fmt.Errorf("failed to decode HTTP API event: %w", err)
+			}
+			request, err := FromAPIGatewayV2HTTP(event)
+			if err != nil {
+				return ToAPIGatewayV2HTTP(badRequest(err)), nil
+			}
+			response, err := serve(ctx, routes, request, fn)
+			if err != nil {
+				return nil, err
+			}
+			return ToAPIGatewayV2HTTP(response), nil
+
+		default:
+			// REST APIs and HTTP APIs with payload format 1.0 send proxy events
+			var event events.APIGatewayProxyRequest
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return nil, fmt.Errorf("failed to decode API Gateway event: %w", err)
+			}
+			request, err := FromAPIGatewayProxy(event)
+			if err != nil {
+				return ToAPIGatewayProxy(badRequest(err)), nil
+			}
+			response, err := serve(ctx, routes, request, fn)
+			if err != nil {
+				return nil, err
+			}
+			return ToAPIGatewayProxy(response), nil
+		}
+	}
+}
+
+// serve resolves the route of requests the trigger did not route itself
+// (ALB, HTTP API $default), calls fn and buffers streamed response bodies
+func serve(ctx context.Context, routes []handler.Route, request handler.HTTPRequest, fn middleware.HandlerFunc) (handler.HTTPResponse, error) {
+	if request.Resource == "" {
+		route, params, ok := handler.MatchRoute(routes, request.HTTPMethod, request.Path)
+		if !ok {
+			return handler.HTTPResponse{
+				StatusCode: http.StatusNotFound,
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       `{"error": "Not found"}`,
+			}, nil
+		}
+		request.Resource = route.Path
+		request.PathParameters = params
+	}
+
+	response, err := fn(handler.WithAuditSource(ctx, request), request)
+	if err != nil {
+		return response, err
+	}
+	// Lambda returns the whole response at once, so streamed bodies are buffered
+	return response.Buffered()
+}
+
+// badRequest builds the response for events that cannot be converted
+func badRequest(err error) handler.HTTPResponse {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return handler.HTTPResponse{
+		StatusCode: http.StatusBadRequest,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
+
+// FromAPIGatewayProxy converts an API Gateway REST API (v1) proxy event
+func FromAPIGatewayProxy(event events.APIGatewayProxyRequest) (handler.HTTPRequest, error) {
+	body, err := decodeBody(event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return handler.HTTPRequest{}, err
+	}
+
+	return handler.HTTPRequest{
+		HTTPMethod:            event.HTTPMethod,
+		Path:                  event.Path,
+		Resource:              event.Resource,
+		PathParameters:        event.PathParameters,
+		QueryStringParameters: event.QueryStringParameters,
+		Headers:               event.Headers,
+		Body:                  body,
+		RequestID:             event.RequestContext.RequestID,
+		SourceIP:              event.RequestContext.Identity.SourceIP,
+		Principal:             proxyPrincipal(event.RequestContext),
+	}, nil
+}
+
+// proxyPrincipal returns the caller authenticated by a REST API authorizer: the principal
+// ID of a Lambda authorizer, the subject of Cognito user pool claims or the IAM user
+func proxyPrincipal(requestContext events.APIGatewayProxyRequestContext) string {
+	if principalID, ok := requestContext.Authorizer["principalId"].(string); ok && principalID != "" {
+		return principalID
+	}
+	if claims, ok := requestContext.Authorizer["claims"].(map[string]any); ok {
+		if subject, ok := claims["sub"].(string); ok && subject != "" {
+			return subject
+		}
+	}
+	return requestContext.Identity.UserArn
+}
+
+// ToAPIGatewayProxy converts a response for an API Gateway REST API (v1) proxy integration
+func ToAPIGatewayProxy(response handler.HTTPResponse) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		StatusCode: response.StatusCode,
+		Headers:    response.Headers,
+		Body:       response.Body,
+	}
+}
+
+// FromAPIGatewayV2HTTP converts an API Gateway HTTP API (payload format 2.0) event
+func FromAPIGatewayV2HTTP(event events.APIGatewayV2HTTPRequest) (handler.HTTPRequest, error) {
+	body, err := decodeBody(event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return handler.HTTPRequest{}, err
+	}
+
+	request := handler.HTTPRequest{
+		HTTPMethod:     event.RequestContext.HTTP.Method,
+		Path:           event.RawPath,
+		PathParameters: event.PathParameters,
+		Headers:        event.Headers,
+		Body:           body,
+		RequestID:      event.RequestContext.RequestID,
+		SourceIP:       event.RequestContext.HTTP.SourceIP,
+		Principal:      httpPrincipal(event.RequestContext.Authorizer),
+	}
+
+	// Route keys look like "GET /kids/{id}"; the $default route matches anything
+	if _, resource, ok := strings.Cut(event.RouteKey, " "); ok {
+		request.Resource = resource
+	}
+
+	// Payload format 2.0 joins repeated query parameters with commas, so the
+	// raw query string is parsed instead to keep the last value like REST APIs
+	if event.RawQueryString != "" {
+		query, err := url.ParseQuery(event.RawQueryString)
+		if err != nil {
+			return handler.HTTPRequest{}, fmt.Errorf("invalid query string: %w", err)
+		}
+		request.QueryStringParameters = lastValues(query)
+	}
+
+	// Payload format 2.0 moves cookies out of the headers
+	if len(event.Cookies) > 0 {
+		request.Headers = copyHeaders(request.Headers)
+		request.Headers["cookie"] = strings.Join(event.Cookies, "; ")
+	}
+
+	return request, nil
+}
+
+// httpPrincipal returns the caller authenticated by an HTTP API authorizer: the subject
+// of a JWT, the principal ID in a Lambda authorizer's context or the IAM user
+func httpPrincipal(authorizer *events.APIGatewayV2HTTPRequestContextAuthorizerDescription) string {
+	switch {
+	case authorizer == nil:
+		return ""
+	case authorizer.JWT != nil:
+		return authorizer.JWT.Claims["sub"]
+	case authorizer.IAM != nil:
+		return authorizer.IAM.UserARN
+	}
+	principalID, _ := authorizer.Lambda["principalId"].(string)
+	return principalID
+}
+
+// ToAPIGatewayV2HTTP converts a response for an API Gateway HTTP API (payload format 2.0)
+func ToAPIGatewayV2HTTP(response handler.HTTPResponse) events.APIGatewayV2HTTPResponse {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: response.StatusCode,
+		Headers:    response.Headers,
+		Body:       response.Body,
+	}
+}
+
+// FromALBTargetGroup converts an Application Load Balancer target group event.
+// ALBs do not resolve path parameters, so the returned request has no Resource.
+func FromALBTargetGroup(event events.ALBTargetGroupRequest) (handler.HTTPRequest, error) {
+	body, err := decodeBody(event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return handler.HTTPRequest{}, err
+	}
+
+	request := handler.HTTPRequest{
+		HTTPMethod: event.HTTPMethod,
+		Path:       event.Path,
+		Headers:    event.Headers,
+		Body:       body,
+	}
+
+	// With multi-value headers enabled the target group sends only the multi-value maps
+	if event.MultiValueHeaders != nil {
+		request.Headers = make(map[string]string, len(event.MultiValueHeaders))
+		for name, values := range event.MultiValueHeaders {
+			request.Headers[name] = strings.Join(values, ",")
+		}
+	}
+
+	// ALBs pass query parameters through still URL-encoded
+	query := url.Values{}
+	for name, value := range event.QueryStringParameters {
+		query[name] = []string{value}
+	}
+	for name, values := range event.MultiValueQueryStringParameters {
+		query[name] = values
+	}
+	if len(query) > 0 {
+		request.QueryStringParameters = make(map[string]string, len(query))
+		for name, values := range query {
+			key, err := url.QueryUnescape(name)
+			if err != nil {
+				return handler.HTTPRequest{}, fmt.Errorf("invalid query parameter %q: %w", name, err)
+			}
+			value, err := url.QueryUnescape(values[len(values)-1])
+			if err != nil {
+				return handler.HTTPRequest{}, fmt.Errorf("invalid value for query parameter %q: %w", key, err)
+			}
+			request.QueryStringParameters[key] = value
+		}
+	}
+
+	request.RequestID = handler.Header(request, "X-Amzn-Trace-Id")
+	if forwarded := handler.Header(request, "X-Forwarded-For"); forwarded != "" {
+		request.SourceIP = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+
+	return request, nil
+}
+
+// ToALBTargetGroup converts a response for an Application Load Balancer target group.
+// multiValue must match whether the target group has multi-value headers enabled.
+func ToALBTargetGroup(response handler.HTTPResponse, multiValue bool) events.ALBTargetGroupResponse {
+	result := events.ALBTargetGroupResponse{
+		StatusCode:        response.StatusCode,
+		StatusDescription: fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		Body:              response.Body,
+	}
+
+	if multiValue {
+		result.MultiValueHeaders = make(map[string][]string, len(response.Headers))
+		for name, value := range response.Headers {
+			result.MultiValueHeaders[name] = []string{value}
+		}
+	} else {
+		result.Headers = response.Headers
+	}
+
+	return result
+}
+
+// decodeBody returns the request body, decoding it when the trigger base64-encoded it
+func decodeBody(body string, isBase64Encoded bool) (string, error) {
+	if !isBase64Encoded {
+		return body, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 request body: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// lastValues flattens query values keeping the last value of each parameter
+func lastValues(query url.Values) map[string]string {
+	values := make(map[string]string, len(query))
+	for name, v := range query {
+		values[name] = v[len(v)-1]
+	}
+	return values
+}
+
+// copyHeaders returns a copy of the headers that can be modified safely
+func copyHeaders(headers map[string]string) map[string]string {
+	copied := make(map[string]string, len(headers)+1)
+	for name, value := range headers {
+		copied[name] = value
+	}
+	return copied
+}
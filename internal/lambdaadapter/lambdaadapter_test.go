@@ -0,0 +1,230 @@
+package lambdaadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+)
+
+var routes = []handler.Route{
+	{Method: http.MethodGet, Path: "/kids"},
+	{Method: http.MethodPut, Path: "/kids/{id}"},
+}
+
+// TestHandler sends the same request through each trigger and expects the
+// service to see an identical request and the trigger to get its own response type
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		response any
+	}{
+		{
+			name: "rest api",
+			payload: `{
+				"resource": "/kids/{id}", "path": "/kids/42", "httpMethod": "PUT",
+				"headers": {"If-Match": "\"1\""},
+				"queryStringParameters": {"dry_run": "true"},
+				"pathParameters": {"id": "42"},
+				"requestContext": {"requestId": "req-1", "identity": {"sourceIp": "10.0.0.1"}},
+				"body": "{\"name\":\"Alice\"}", "isBase64Encoded": false
+			}`,
+			response: events.APIGatewayProxyResponse{},
+		},
+		{
+			name: "http api",
+			payload: `{
+				"version": "2.0", "routeKey": "PUT /kids/{id}", "rawPath": "/kids/42",
+				"rawQueryString": "dry_run=false&dry_run=true",
+				"headers": {"if-match": "\"1\""},
+				"queryStringParameters": {"dry_run": "false,true"},
+				"pathParameters": {"id": "42"},
+				"requestContext": {"requestId": "req-1", "http": {"method": "PUT", "path": "/kids/42", "sourceIp": "10.0.0.1"}},
+				"body": "eyJuYW1lIjoiQWxpY2UifQ==", "isBase64Encoded": true
+			}`,
+			response: events.APIGatewayV2HTTPResponse{},
+		},
+		{
+			name: "http api default route",
+			payload: `{
+				"version": "2.0", "routeKey": "$default", "rawPath": "/kids/42",
+				"rawQueryString": "dry_run=true",
+				"headers": {"if-match": "\"1\""},
+				"requestContext": {"requestId": "req-1", "http": {"method": "PUT", "path": "/kids/42", "sourceIp": "10.0.0.1"}},
+				"body": "{\"name\":\"Alice\"}"
+			}`,
+			response: events.APIGatewayV2HTTPResponse{},
+		},
+		{
+			name: "alb",
+			payload: `{
+				"httpMethod": "PUT", "path": "/kids/42",
+				"queryStringParameters": {"dry%5Frun": "tru%65"},
+				"headers": {"if-match": "\"1\"", "x-amzn-trace-id": "req-1", "x-forwarded-for": "10.0.0.1, 10.0.0.2"},
+				"requestContext": {"elb": {"targetGroupArn": "arn:aws:elasticloadbalancing:eu-central-1:123456789012:targetgroup/kids/1"}},
+				"body": "{\"name\":\"Alice\"}"
+			}`,
+			response: events.ALBTargetGroupResponse{},
+		},
+		{
+			name: "alb multi-value headers",
+			payload: `{
+				"httpMethod": "PUT", "path": "/kids/42",
+				"multiValueQueryStringParameters": {"dry_run": ["false", "true"]},
+				"multiValueHeaders": {"if-match": ["\"1\""], "x-amzn-trace-id": ["req-1"], "x-forwarded-for": ["10.0.0.1"]},
+				"requestContext": {"elb": {"targetGroupArn": "arn:aws:elasticloadbalancing:eu-central-1:123456789012:targetgroup/kids/1"}},
+				"body": "{\"name\":\"Alice\"}"
+			}`,
+			response: events.ALBTargetGroupResponse{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got handler.HTTPRequest
+			fn := Handler(routes, func(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+				got = request
+				return handler.HTTPResponse{
+					StatusCode: http.StatusOK,
+					Headers:    map[string]string{"ETag": `"2"`},
+					Body:       `{"message":"ok"}`,
+				}, nil
+			})
+
+			result, err := fn(context.Background(), json.RawMessage(tt.payload))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			if reflect.TypeOf(result) != reflect.TypeOf(tt.response) {
+				t.Fatalf("expected response of type %T, got %T", tt.response, result)
+			}
+
+			if got.HTTPMethod != http.MethodPut || got.Path != "/kids/42" || got.Resource != "/kids/{id}" {
+				t.Errorf("expected PUT /kids/42 on /kids/{id}, got %s %s on %s", got.HTTPMethod, got.Path, got.Resource)
+			}
+			if got.PathParameters["id"] != "42" {
+				t.Errorf("expected id path parameter 42, got %v", got.PathParameters)
+			}
+			if got.QueryStringParameters["dry_run"] != "true" {
+				t.Errorf("expected dry_run query parameter true, got %v", got.QueryStringParameters)
+			}
+			if handler.Header(got, "If-Match") != `"1"` {
+				t.Errorf("expected If-Match header, got %v", got.Headers)
+			}
+			if got.Body != `{"name":"Alice"}` {
+				t.Errorf("expected decoded body, got %q", got.Body)
+			}
+			if got.RequestID != "req-1" || got.SourceIP != "10.0.0.1" {
+				t.Errorf("expected request req-1 from 10.0.0.1, got %q from %q", got.RequestID, got.SourceIP)
+			}
+		})
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	tests := []struct {
+		name              string
+		payload           string
+		expectedPrincipal string
+	}{
+		{
+			name:              "rest api lambda authorizer",
+			payload:           `{"path": "/kids", "httpMethod": "GET", "requestContext": {"authorizer": {"principalId": "user-7"}}}`,
+			expectedPrincipal: "user-7",
+		},
+		{
+			name:              "rest api cognito authorizer",
+			payload:           `{"path": "/kids", "httpMethod": "GET", "requestContext": {"authorizer": {"claims": {"sub": "user-7"}}}}`,
+			expectedPrincipal: "user-7",
+		},
+		{
+			name:              "rest api iam",
+			payload:           `{"path": "/kids", "httpMethod": "GET", "requestContext": {"identity": {"userArn": "arn:aws:iam::123456789012:user/ops"}}}`,
+			expectedPrincipal: "arn:aws:iam::123456789012:user/ops",
+		},
+		{
+			name:    "rest api without authorizer",
+			payload: `{"path": "/kids", "httpMethod": "GET", "headers": {"X-Actor": "user-42"}, "requestContext": {}}`,
+		},
+		{
+			name:              "http api jwt authorizer",
+			payload:           `{"version": "2.0", "rawPath": "/kids", "requestContext": {"http": {"method": "GET"}, "authorizer": {"jwt": {"claims": {"sub": "user-7"}}}}}`,
+			expectedPrincipal: "user-7",
+		},
+		{
+			name:              "http api lambda authorizer",
+			payload:           `{"version": "2.0", "rawPath": "/kids", "requestContext": {"http": {"method": "GET"}, "authorizer": {"lambda": {"principalId": "user-7"}}}}`,
+			expectedPrincipal: "user-7",
+		},
+		{
+			name:    "http api without authorizer",
+			payload: `{"version": "2.0", "rawPath": "/kids", "requestContext": {"http": {"method": "GET"}}}`,
+		},
+		{
+			name:    "alb",
+			payload: `{"httpMethod": "GET", "path": "/kids", "headers": {"x-actor": "user-42"}, "requestContext": {"elb": {"targetGroupArn": "arn"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got handler.HTTPRequest
+			fn := Handler(routes, func(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+				got = request
+				return handler.HTTPResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			if _, err := fn(context.Background(), json.RawMessage(tt.payload)); err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if got.Principal != tt.expectedPrincipal {
+				t.Errorf("expected principal %q, got %q", tt.expectedPrincipal, got.Principal)
+			}
+		})
+	}
+}
+
+func TestHandlerUnknownRoute(t *testing.T) {
+	fn := Handler(routes, func(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+		t.Fatal("expected handler not to be called for an unknown route")
+		return handler.HTTPResponse{}, nil
+	})
+
+	payload := `{"httpMethod": "GET", "path": "/kids/42/stars", "requestContext": {"elb": {}}}`
+	result, err := fn(context.Background(), json.RawMessage(payload))
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	response, ok := result.(events.ALBTargetGroupResponse)
+	if !ok {
+		t.Fatalf("expected ALB response, got %T", result)
+	}
+	if response.StatusCode != http.StatusNotFound || response.StatusDescription != "404 Not Found" {
+		t.Errorf("expected 404 Not Found, got %d %q", response.StatusCode, response.StatusDescription)
+	}
+}
+
+func TestToALBTargetGroupMultiValue(t *testing.T) {
+	response := ToALBTargetGroup(handler.HTTPResponse{
+		StatusCode: http.StatusCreated,
+		Headers:    map[string]string{"ETag": `"2"`},
+	}, true)
+
+	if response.Headers != nil {
+		t.Errorf("expected no single-value headers, got %v", response.Headers)
+	}
+	if !reflect.DeepEqual(response.MultiValueHeaders, map[string][]string{"ETag": {`"2"`}}) {
+		t.Errorf("expected multi-value ETag header, got %v", response.MultiValueHeaders)
+	}
+	if response.StatusDescription != "201 Created" {
+		t.Errorf("expected status description 201 Created, got %q", response.StatusDescription)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"os"
 	"strings"
 
-	"github.com/aws/aws-lambda-go/events"
+	"github.com/lukasz/astras-mono-api/internal/handler"
 	"github.com/lukasz/astras-mono-api/internal/logger"
 )
 
@@ -50,10 +50,10 @@ func NewLocalLoggingMiddleware(serviceName string) (*LocalLoggingMiddleware, err
 }
 
 // WrapHandler wraps a handler with appropriate logging for the environment
-func (lm *LocalLoggingMiddleware) WrapHandler(handler HandlerFunc) HandlerFunc {
-	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+func (lm *LocalLoggingMiddleware) WrapHandler(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
 		if lm.isLocal {
-			return lm.wrapLocalHandler(handler)(ctx, request)
+			return lm.wrapLocalHandler(next)(ctx, request)
 		}
 
 		// Use regular middleware for Lambda
@@ -61,13 +61,13 @@ func (lm *LocalLoggingMiddleware) WrapHandler(handler HandlerFunc) HandlerFunc {
 			logger:      lm.logger,
 			serviceName: lm.serviceName,
 		}
-		return middleware.WrapHandler(handler)(ctx, request)
+		return middleware.WrapHandler(next)(ctx, request)
 	}
 }
 
 // wrapLocalHandler provides enhanced logging for local development
-func (lm *LocalLoggingMiddleware) wrapLocalHandler(handler HandlerFunc) HandlerFunc {
-	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+func (lm *LocalLoggingMiddleware) wrapLocalHandler(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
 		// Enhanced local logging with more details
 		lm.logger.Debug(ctx, "=== LOCAL DEVELOPMENT REQUEST ===")
 		lm.logger.Info(ctx, "Incoming request",
@@ -83,7 +83,7 @@ func (lm *LocalLoggingMiddleware) wrapLocalHandler(handler HandlerFunc) HandlerF
 		}
 
 		// Execute handler
-		response, err := handler(ctx, request)
+		response, err := next(ctx, request)
 
 		// Log response
 		if err != nil {
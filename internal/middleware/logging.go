@@ -6,7 +6,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-lambda-go/events"
+	"github.com/lukasz/astras-mono-api/internal/handler"
 	"github.com/lukasz/astras-mono-api/internal/logger"
 )
 
@@ -27,12 +27,12 @@ func NewLoggingMiddleware(serviceName string) *LoggingMiddleware {
 	}
 }
 
-// HandlerFunc represents a Lambda handler function
-type HandlerFunc func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+// HandlerFunc represents a service handler function, independent of the Lambda trigger
+type HandlerFunc func(context.Context, handler.HTTPRequest) (handler.HTTPResponse, error)
 
 // WrapHandler wraps a Lambda handler with logging middleware
-func (lm *LoggingMiddleware) WrapHandler(handler HandlerFunc) HandlerFunc {
-	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+func (lm *LoggingMiddleware) WrapHandler(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
 		startTime := time.Now()
 		requestID := getRequestID(request)
 
@@ -40,7 +40,7 @@ func (lm *LoggingMiddleware) WrapHandler(handler HandlerFunc) HandlerFunc {
 		lm.logIncomingRequest(ctx, request, requestID)
 
 		// Execute handler
-		response, err := handler(ctx, request)
+		response, err := next(ctx, request)
 
 		duration := time.Since(startTime)
 		statusCode := response.StatusCode
@@ -56,7 +56,7 @@ func (lm *LoggingMiddleware) WrapHandler(handler HandlerFunc) HandlerFunc {
 }
 
 // logIncomingRequest logs details about the incoming HTTP request
-func (lm *LoggingMiddleware) logIncomingRequest(ctx context.Context, request events.APIGatewayProxyRequest, requestID string) {
+func (lm *LoggingMiddleware) logIncomingRequest(ctx context.Context, request handler.HTTPRequest, requestID string) {
 	// Don't log sensitive headers
 	safeHeaders := filterSensitiveHeaders(request.Headers)
 
@@ -68,7 +68,7 @@ func (lm *LoggingMiddleware) logIncomingRequest(ctx context.Context, request eve
 		logger.Any("path_params", request.PathParameters),
 		logger.Any("headers", safeHeaders),
 		logger.String("source_ip", getSourceIP(request)),
-		logger.String("user_agent", request.Headers["User-Agent"]),
+		logger.String("user_agent", handler.Header(request, "User-Agent")),
 	}
 
 	// Log request body for POST/PUT requests (but limit size and filter sensitive data)
@@ -82,7 +82,7 @@ func (lm *LoggingMiddleware) logIncomingRequest(ctx context.Context, request eve
 }
 
 // logResponse logs details about the HTTP response
-func (lm *LoggingMiddleware) logResponse(ctx context.Context, request events.APIGatewayProxyRequest, response events.APIGatewayProxyResponse, err error, duration time.Duration, requestID string, statusCode int) {
+func (lm *LoggingMiddleware) logResponse(ctx context.Context, request handler.HTTPRequest, response handler.HTTPResponse, err error, duration time.Duration, requestID string, statusCode int) {
 	level := logger.INFO
 	message := "HTTP request completed"
 
@@ -139,23 +139,23 @@ func (lm *LoggingMiddleware) logResponse(ctx context.Context, request events.API
 
 // Helper functions
 
-func getRequestID(request events.APIGatewayProxyRequest) string {
+func getRequestID(request handler.HTTPRequest) string {
 	// Try to get request ID from various headers
-	if id := request.Headers["X-Request-ID"]; id != "" {
+	if id := handler.Header(request, "X-Request-ID"); id != "" {
 		return id
 	}
-	if id := request.Headers["X-Amzn-Trace-Id"]; id != "" {
+	if id := handler.Header(request, "X-Amzn-Trace-Id"); id != "" {
 		return id
 	}
-	if id := request.RequestContext.RequestID; id != "" {
+	if id := request.RequestID; id != "" {
 		return id
 	}
 	return "unknown"
 }
 
-func getSourceIP(request events.APIGatewayProxyRequest) string {
+func getSourceIP(request handler.HTTPRequest) string {
 	// Try X-Forwarded-For first (for requests through ALB/CloudFront)
-	if xff := request.Headers["X-Forwarded-For"]; xff != "" {
+	if xff := handler.Header(request, "X-Forwarded-For"); xff != "" {
 		// X-Forwarded-For can contain multiple IPs, take the first one
 		if ips := strings.Split(xff, ","); len(ips) > 0 {
 			return strings.TrimSpace(ips[0])
@@ -163,19 +163,19 @@ func getSourceIP(request events.APIGatewayProxyRequest) string {
 	}
 
 	// Fall back to X-Real-IP
-	if realIP := request.Headers["X-Real-IP"]; realIP != "" {
+	if realIP := handler.Header(request, "X-Real-IP"); realIP != "" {
 		return realIP
 	}
 
 	// Finally, use the source IP from request context
-	if request.RequestContext.Identity.SourceIP != "" {
-		return request.RequestContext.Identity.SourceIP
+	if request.SourceIP != "" {
+		return request.SourceIP
 	}
 
 	return "unknown"
 }
 
-func shouldLogRequestBody(request events.APIGatewayProxyRequest) bool {
+func shouldLogRequestBody(request handler.HTTPRequest) bool {
 	// Only log body for POST, PUT, PATCH requests
 	method := strings.ToUpper(request.HTTPMethod)
 	return method == "POST" || method == "PUT" || method == "PATCH"
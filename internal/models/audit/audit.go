@@ -0,0 +1,91 @@
+// Package audit provides the audit Event model, a record of one change to a kid,
+// caregiver or transaction, and carries who made a change through the request context.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Action says what kind of change an audit event records
+type Action string
+
+const (
+	// ActionCreate records a new row
+	ActionCreate Action = "create"
+
+	// ActionUpdate records a modified row
+	ActionUpdate Action = "update"
+
+	// ActionDelete records a soft delete, or a hard delete of a row that was not soft deleted
+	ActionDelete Action = "delete"
+
+	// ActionRestore records a soft-deleted row being brought back
+	ActionRestore Action = "restore"
+
+	// ActionPurge records a soft-deleted row being removed permanently
+	ActionPurge Action = "purge"
+)
+
+// Audited entities, as stored in Event.Entity
+const (
+	EntityKid         = "kid"
+	EntityCaregiver   = "caregiver"
+	EntityTransaction = "transaction"
+)
+
+// ActorHeader is the request header in which a client names who makes the request, e.g.
+// the user signed in to the frontend. It is not verified, so it is only recorded as
+// Event.ClaimedActor; Event.Actor comes from the authorizer of the API.
+const ActorHeader = "X-Actor"
+
+// AnonymousActor is recorded for API requests that no authorizer authenticated
+const AnonymousActor = "anonymous"
+
+// Event is one change to an audited entity
+type Event struct {
+	ID           int64           `json:"id" db:"id"`
+	Actor        string          `json:"actor" db:"actor"`                           // Who made the change, see Source
+	ClaimedActor string          `json:"claimed_actor,omitempty" db:"claimed_actor"` // Who the client said made the change, untrusted
+	Action       Action          `json:"action" db:"action"`
+	Entity       string          `json:"entity" db:"entity"`              // kid, caregiver or transaction
+	EntityID     int             `json:"-" db:"entity_id"`                // Internal ID of the changed record
+	PublicID     *string         `json:"entity_id" db:"entity_public_id"` // Public ID of the changed record, null for records purged before public IDs existed
+	Before       json.RawMessage `json:"before,omitempty" db:"before"`    // Row before the change with public IDs, absent for create
+	After        json.RawMessage `json:"after,omitempty" db:"after"`      // Row after the change with public IDs, absent for hard deletes
+	RequestID    string          `json:"request_id,omitempty" db:"request_id"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ValidateEntity validates if the entity is audited
+func ValidateEntity(entity string) error {
+	switch entity {
+	case EntityKid, EntityCaregiver, EntityTransaction:
+		return nil
+	default:
+		return fmt.Errorf("entity must be one of: %s, %s, %s", EntityKid, EntityCaregiver, EntityTransaction)
+	}
+}
+
+// Source identifies who makes the changes of a request
+type Source struct {
+	Actor        string // Who makes the request, as authenticated by the API or a CLI name
+	ClaimedActor string // Who the client says makes the request (ActorHeader), untrusted
+	RequestID    string // Request the changes belong to
+}
+
+// sourceKey is the context key of the Source
+type sourceKey struct{}
+
+// NewContext returns a copy of ctx carrying the source of the changes made with it
+func NewContext(ctx context.Context, source Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// FromContext returns the source carried by ctx, if any
+func FromContext(ctx context.Context) (Source, bool) {
+	source, ok := ctx.Value(sourceKey{}).(Source)
+	return source, ok
+}
@@ -29,15 +29,32 @@ const (
 	RelationshipCaregiver RelationshipType = "caregiver"
 )
 
+// Status is where a caregiver is in the invitation flow
+type Status string
+
+const (
+	// StatusInvited caregivers have not proven they own their email address yet
+	StatusInvited Status = "invited"
+
+	// StatusActive caregivers accepted an invitation to their family
+	StatusActive Status = "active"
+
+	// StatusRevoked caregivers lost access to their family
+	StatusRevoked Status = "revoked"
+)
+
 // Caregiver represents a parent or guardian in the Astras system
 // with contact information and relationship details.
 type Caregiver struct {
-	ID           int              `json:"id" db:"id"`                                       // Unique identifier
+	ID           int              `json:"-" db:"id"`                                     // Internal identifier, never exposed
+	PublicID     string           `json:"id" db:"public_id" validate:"omitempty,uuid"` // Identifier used by the API
 	Name         string           `json:"name" db:"name" validate:"required,min=2,max=100"`  // Full name
 	Email        string           `json:"email" db:"email" validate:"required,email"`         // Contact email address
 	Relationship RelationshipType `json:"relationship" db:"relationship" validate:"required,oneof=parent guardian grandparent relative caregiver"` // Relationship to child
+	Status       Status           `json:"status,omitempty" db:"status"`                           // Set by the invitation flow, not by clients
 	CreatedAt    time.Time        `json:"created_at" db:"created_at"`                               // Record creation timestamp
 	UpdatedAt    time.Time        `json:"updated_at,omitempty" db:"updated_at"`                   // Last update timestamp
+	DeletedAt    *time.Time       `json:"deleted_at,omitempty" db:"deleted_at"`                   // Set while the caregiver is soft deleted
 }
 
 var validate *validator.Validate
@@ -0,0 +1,40 @@
+// Package category provides the Category model: a family-defined kind of transaction,
+// e.g. homework or chores, so stars can be counted by what they were for.
+package category
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the longest accepted category name
+const MaxNameLength = 50
+
+// Category is a kind of transaction defined by a family
+type Category struct {
+	ID        int       `json:"id" db:"id"`
+	FamilyID  int       `json:"-" db:"family_id"` // Internal ID of the family
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// Validate checks if the Category data meets business requirements
+func (c *Category) Validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+
+	if c.FamilyID <= 0 {
+		return errors.New("family_id must be greater than 0")
+	}
+	if c.Name == "" {
+		return errors.New("name is required and cannot be empty")
+	}
+	if utf8.RuneCountInString(c.Name) > MaxNameLength {
+		return fmt.Errorf("name cannot exceed %d characters", MaxNameLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package category
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCategoryValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		category     Category
+		expectError  bool
+		expectedName string
+	}{
+		{"valid", Category{FamilyID: 1, Name: "Homework"}, false, "Homework"},
+		{"trimmed", Category{FamilyID: 1, Name: "  Chores "}, false, "Chores"},
+		{"longest name", Category{FamilyID: 1, Name: strings.Repeat("ż", MaxNameLength)}, false, strings.Repeat("ż", MaxNameLength)},
+		{"empty name", Category{FamilyID: 1, Name: "   "}, true, ""},
+		{"name too long", Category{FamilyID: 1, Name: strings.Repeat("a", MaxNameLength+1)}, true, ""},
+		{"no family", Category{Name: "Homework"}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.category.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+			if !tt.expectError && tt.category.Name != tt.expectedName {
+				t.Errorf("expected name %q, got %q", tt.expectedName, tt.category.Name)
+			}
+		})
+	}
+}
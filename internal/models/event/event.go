@@ -0,0 +1,74 @@
+// Package event provides the domain Event model: a fact about a kid, caregiver or
+// star transaction that other features react to, e.g. notifications or analytics.
+// Events are written to the outbox in the database transaction of the change they
+// describe and published afterwards, so they are never lost or sent for a change
+// that was rolled back.
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Type names what happened, as "<entity>.<what>"
+type Type string
+
+const (
+	KidCreated  Type = "kid.created"
+	KidUpdated  Type = "kid.updated"
+	KidDeleted  Type = "kid.deleted" // Soft deleted, can still be restored
+	KidRestored Type = "kid.restored"
+	KidPurged   Type = "kid.purged" // Removed permanently, together with the kid's transactions
+
+	CaregiverCreated  Type = "caregiver.created"
+	CaregiverUpdated  Type = "caregiver.updated"
+	CaregiverDeleted  Type = "caregiver.deleted"
+	CaregiverRestored Type = "caregiver.restored"
+	CaregiverPurged   Type = "caregiver.purged"
+
+	StarsEarned        Type = "stars.earned" // An earn transaction was created
+	StarsSpent         Type = "stars.spent"  // A spend transaction was created
+	TransactionUpdated Type = "transaction.updated"
+	TransactionDeleted Type = "transaction.deleted"
+)
+
+// Entity returns the kind of record the event is about: kid, caregiver or transaction
+func (t Type) Entity() string {
+	entity, _, _ := strings.Cut(string(t), ".")
+	if entity == "stars" {
+		return "transaction"
+	}
+	return entity
+}
+
+// Event is one domain event
+type Event struct {
+	ID        int64           `json:"id" db:"id"` // Position in the outbox, increases with every event
+	Type      Type            `json:"type" db:"event_type"`
+	Entity    string          `json:"entity" db:"entity"`
+	EntityID  int             `json:"-" db:"entity_id"`     // Internal ID, the payload carries the public one
+	Payload   json.RawMessage `json:"payload" db:"payload"` // The record after the change, or its ID when it is gone
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// New creates an event about the record with the given ID; payload is encoded as JSON
+func New(t Type, entityID int, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", t, err)
+	}
+
+	return &Event{
+		Type:     t,
+		Entity:   t.Entity(),
+		EntityID: entityID,
+		Payload:  data,
+	}, nil
+}
+
+// Removed is the payload of events about records that no longer exist
+type Removed struct {
+	ID string `json:"id"` // Public ID of the record
+}
@@ -0,0 +1,31 @@
+package event
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		eventType       Type
+		expectedEntity  string
+		expectedPayload string
+	}{
+		{KidCreated, "kid", `{"id":"6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"}`},
+		{CaregiverPurged, "caregiver", `{"id":"6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"}`},
+		{StarsEarned, "transaction", `{"id":"6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"}`},
+		{TransactionDeleted, "transaction", `{"id":"6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.eventType), func(t *testing.T) {
+			e, err := New(tt.eventType, 7, Removed{ID: "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if e.Entity != tt.expectedEntity || e.EntityID != 7 {
+				t.Errorf("expected %s 7, got %s %d", tt.expectedEntity, e.Entity, e.EntityID)
+			}
+			if string(e.Payload) != tt.expectedPayload {
+				t.Errorf("expected payload %s, got %s", tt.expectedPayload, e.Payload)
+			}
+		})
+	}
+}
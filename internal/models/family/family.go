@@ -0,0 +1,105 @@
+// Package family provides the Family model, which groups the kids and caregivers of
+// one household, and the data deletion requests that erase a family's personal data.
+package family
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MinNameLength defines the minimum required length for family names
+	MinNameLength = 2
+	// MaxNameLength defines the maximum allowed length for family names
+	MaxNameLength = 100
+)
+
+// Family represents a household whose kids and caregivers are managed together
+type Family struct {
+	ID        int       `json:"-" db:"id"`                                        // Internal identifier, never exposed
+	PublicID  string    `json:"id" db:"public_id"`                                // Identifier used by the API
+	Name      string    `json:"name" db:"name" validate:"required,min=2,max=100"` // Display name, e.g. "The Johnsons"
+	CreatedAt time.Time `json:"created_at" db:"created_at"`                       // Record creation timestamp
+	UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at"`             // Last update timestamp
+}
+
+// Validate checks if the Family data meets business requirements
+func (f *Family) Validate() error {
+	f.Name = strings.TrimSpace(f.Name)
+
+	if f.Name == "" {
+		return errors.New("name is required and cannot be empty")
+	}
+	if len(f.Name) < MinNameLength {
+		return fmt.Errorf("name must be at least %d characters long", MinNameLength)
+	}
+	if len(f.Name) > MaxNameLength {
+		return fmt.Errorf("name cannot exceed %d characters", MaxNameLength)
+	}
+
+	return nil
+}
+
+// DeletionMode says how a family's personal data is erased
+type DeletionMode string
+
+const (
+	// DeletionModeDelete removes the family, its kids, caregivers and transactions
+	DeletionModeDelete DeletionMode = "delete"
+
+	// DeletionModeAnonymize replaces names, emails, birthdays and transaction descriptions
+	// with placeholders and keeps the records, so ledger totals stay intact
+	DeletionModeAnonymize DeletionMode = "anonymize"
+)
+
+// DeletionStatus is the state of a data deletion request
+type DeletionStatus string
+
+const (
+	// DeletionPending requests wait for confirmation
+	DeletionPending DeletionStatus = "pending"
+
+	// DeletionCompleted requests have been confirmed and carried out
+	DeletionCompleted DeletionStatus = "completed"
+)
+
+// DeletionTTL is how long a deletion request can be confirmed
+const DeletionTTL = 15 * time.Minute
+
+// DeletionRequest is the audit record of a request to erase a family's personal data.
+// It holds no personal data itself, so it is kept after the family is gone.
+type DeletionRequest struct {
+	ID           int            `json:"id" db:"id"`
+	FamilyID     int            `json:"-" db:"family_id"` // Internal ID of the family
+	Mode         DeletionMode   `json:"mode" db:"mode" validate:"required,oneof=delete anonymize"`
+	Status       DeletionStatus `json:"status" db:"status"`
+	TokenHash    string         `json:"-" db:"token_hash"`              // SHA-256 of the confirmation token
+	Kids         int            `json:"kids" db:"kids"`                 // Kids erased, or to be erased while pending
+	Caregivers   int            `json:"caregivers" db:"caregivers"`     // Caregivers erased, or to be erased while pending
+	Transactions int            `json:"transactions" db:"transactions"` // Transactions erased, or to be erased while pending
+	RequestedAt  time.Time      `json:"requested_at" db:"requested_at"`
+	ExpiresAt    time.Time      `json:"expires_at" db:"expires_at"` // Confirmation deadline
+	CompletedAt  *time.Time     `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// ValidateDeletionMode validates if the deletion mode is valid
+func ValidateDeletionMode(mode string) error {
+	switch DeletionMode(mode) {
+	case DeletionModeDelete, DeletionModeAnonymize:
+		return nil
+	default:
+		return fmt.Errorf("mode must be either '%s' or '%s'", DeletionModeDelete, DeletionModeAnonymize)
+	}
+}
+
+// Expired reports whether the confirmation deadline of a pending request has passed.
+// If no time is provided, uses current time (time.Now()).
+func (d *DeletionRequest) Expired(at ...time.Time) bool {
+	now := time.Now()
+	if len(at) > 0 {
+		now = at[0]
+	}
+	return d.Status == DeletionPending && !now.Before(d.ExpiresAt)
+}
@@ -0,0 +1,62 @@
+package family
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFamilyValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		familyName   string
+		errorMessage string
+	}{
+		{"valid", "The Johnsons", ""},
+		{"trimmed", "  Smiths  ", ""},
+		{"empty", "   ", "name is required and cannot be empty"},
+		{"too short", "J", "name must be at least 2 characters long"},
+		{"too long", strings.Repeat("a", 101), "name cannot exceed 100 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Family{Name: tt.familyName}
+			err := f.Validate()
+			if tt.errorMessage == "" {
+				if err != nil {
+					t.Errorf("expected no error but got: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.errorMessage {
+				t.Errorf("expected error message %q, got %v", tt.errorMessage, err)
+			}
+		})
+	}
+}
+
+func TestDeletionRequestExpired(t *testing.T) {
+	requestedAt := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	request := DeletionRequest{Status: DeletionPending, RequestedAt: requestedAt, ExpiresAt: requestedAt.Add(DeletionTTL)}
+
+	tests := []struct {
+		name     string
+		status   DeletionStatus
+		at       time.Time
+		expected bool
+	}{
+		{"pending within deadline", DeletionPending, requestedAt.Add(time.Minute), false},
+		{"pending at deadline", DeletionPending, requestedAt.Add(DeletionTTL), true},
+		{"completed after deadline", DeletionCompleted, requestedAt.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request.Status = tt.status
+			if got := request.Expired(tt.at); got != tt.expected {
+				t.Errorf("expected expired %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Package invitation provides the Invitation model: an email address invited to a
+// family by one of its caregivers. Accepting the invitation with the single-use token
+// emailed to the address proves the invitee owns it and makes them an active caregiver.
+package invitation
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+)
+
+// TTL is how long an invitation can be accepted
+const TTL = 7 * 24 * time.Hour
+
+// Status is the state of an invitation
+type Status string
+
+const (
+	// StatusPending invitations wait for the invitee to accept them
+	StatusPending Status = "pending"
+
+	// StatusAccepted invitations have created or linked a caregiver
+	StatusAccepted Status = "accepted"
+
+	// StatusRevoked invitations were withdrawn before they were accepted
+	StatusRevoked Status = "revoked"
+)
+
+// Invitation is an email address invited to join a family as a caregiver
+type Invitation struct {
+	ID                int                        `json:"id" db:"id"`
+	FamilyID          int                        `json:"-" db:"family_id"`                                // Internal ID of the family
+	Email             string                     `json:"email" db:"email"`                                // Address the token is sent to
+	Name              string                     `json:"name" db:"name"`                                  // Name of the caregiver created on acceptance
+	Relationship      caregiver.RelationshipType `json:"relationship" db:"relationship"`                  // Relationship of the caregiver created on acceptance
+	InvitedBy         *int                       `json:"-" db:"invited_by"`                               // Internal ID of the caregiver who sent the invitation
+	InvitedByPublicID *string                    `json:"invited_by,omitempty" db:"invited_by_public_id"`  // Public ID of the caregiver who sent the invitation
+	CaregiverID       *int                       `json:"-" db:"caregiver_id"`                             // Internal ID of the caregiver created or linked on acceptance
+	CaregiverPublicID *string                    `json:"caregiver_id,omitempty" db:"caregiver_public_id"` // Public ID of that caregiver
+	Status            Status                     `json:"status" db:"status"`
+	TokenHash         string                     `json:"-" db:"token_hash"` // SHA-256 of the token
+	ExpiresAt         time.Time                  `json:"expires_at" db:"expires_at"`
+	CreatedAt         time.Time                  `json:"created_at" db:"created_at"`
+	AcceptedAt        *time.Time                 `json:"accepted_at,omitempty" db:"accepted_at"`
+	RevokedAt         *time.Time                 `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// Validate checks the invitee's details with the rules of the caregiver they become
+func (i *Invitation) Validate() error {
+	if i.FamilyID <= 0 {
+		return errors.New("family_id is required")
+	}
+
+	c := caregiver.Caregiver{Name: i.Name, Email: i.Email, Relationship: i.Relationship}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	i.Name, i.Email, i.Relationship = c.Name, strings.ToLower(c.Email), c.Relationship
+
+	return nil
+}
+
+// Expired reports whether the deadline of a pending invitation has passed.
+// If no time is provided, uses current time (time.Now()).
+func (i *Invitation) Expired(at ...time.Time) bool {
+	now := time.Now()
+	if len(at) > 0 {
+		now = at[0]
+	}
+	return i.Status == StatusPending && !now.Before(i.ExpiresAt)
+}
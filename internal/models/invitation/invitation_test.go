@@ -0,0 +1,63 @@
+package invitation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInvitationValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		invitation    Invitation
+		expectedEmail string
+		errorMessage  string
+	}{
+		{"valid", Invitation{FamilyID: 1, Name: "Grace Wilson", Email: "grace@example.com", Relationship: "grandparent"}, "grace@example.com", ""},
+		{"email is normalized", Invitation{FamilyID: 1, Name: "Grace Wilson", Email: " Grace@Example.com ", Relationship: "Grandparent"}, "grace@example.com", ""},
+		{"missing family", Invitation{Name: "Grace Wilson", Email: "grace@example.com", Relationship: "grandparent"}, "", "family_id is required"},
+		{"invalid email", Invitation{FamilyID: 1, Name: "Grace Wilson", Email: "grace", Relationship: "grandparent"}, "", "email format is invalid"},
+		{"invalid relationship", Invitation{FamilyID: 1, Name: "Grace Wilson", Email: "grace@example.com", Relationship: "friend"}, "", "relationship must be one of: parent, guardian, grandparent, relative, caregiver"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.invitation.Validate()
+			if tt.errorMessage != "" {
+				if err == nil || err.Error() != tt.errorMessage {
+					t.Errorf("expected error message %q, got %v", tt.errorMessage, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if tt.invitation.Email != tt.expectedEmail {
+				t.Errorf("expected email %q, got %q", tt.expectedEmail, tt.invitation.Email)
+			}
+		})
+	}
+}
+
+func TestInvitationExpired(t *testing.T) {
+	createdAt := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		status   Status
+		at       time.Time
+		expected bool
+	}{
+		{"pending within the TTL", StatusPending, createdAt.Add(TTL - time.Second), false},
+		{"pending at the deadline", StatusPending, createdAt.Add(TTL), true},
+		{"accepted invitations do not expire", StatusAccepted, createdAt.Add(2 * TTL), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := Invitation{Status: tt.status, CreatedAt: createdAt, ExpiresAt: createdAt.Add(TTL)}
+			if i.Expired(tt.at) != tt.expected {
+				t.Errorf("expected expired %v, got %v", tt.expected, i.Expired(tt.at))
+			}
+		})
+	}
+}
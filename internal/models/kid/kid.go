@@ -6,6 +6,8 @@ import (
 	"errors"
 	"strings"
 	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/schema"
 )
 
 const (
@@ -26,11 +28,13 @@ const (
 // Kid represents a child in the Astras system with personal information
 // and validation rules for data integrity.
 type Kid struct {
-	ID        int       `json:"id" db:"id"`                           // Unique identifier
-	Name      string    `json:"name" db:"name"`                       // Full name of the child
-	Birthdate time.Time `json:"birthdate" db:"birthdate"`             // Date of birth
+	ID        int       `json:"-" db:"id"`                                     // Internal identifier, never exposed
+	PublicID  string    `json:"id" db:"public_id" validate:"omitempty,uuid"` // Identifier used by the API
+	Name      string    `json:"name" db:"name" validate:"required,min=2,max=255"` // Full name of the child
+	Birthdate time.Time `json:"birthdate" db:"birthdate" validate:"required"`     // Date of birth
 	CreatedAt time.Time `json:"created_at" db:"created_at"`           // Record creation timestamp
 	UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at"` // Last update timestamp
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Set while the kid is soft deleted
 }
 
 // Age calculates and returns the current age of the kid based on their birthdate.
@@ -68,6 +72,12 @@ func (k *Kid) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// ExtendJSONSchema documents the computed age member added by MarshalJSON
+func (k *Kid) ExtendJSONSchema(s *schema.Schema) {
+	minAge, maxAge := MinKidAge, MaxKidAge
+	s.Properties["age"] = &schema.Schema{Type: "integer", Minimum: &minAge, Maximum: &maxAge}
+}
+
 // Validate checks if the Kid data meets business requirements.
 // Returns an error if any validation rules are violated.
 func (k *Kid) Validate() error {
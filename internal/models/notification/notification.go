@@ -0,0 +1,101 @@
+// Package notification provides the email notification preferences of caregivers and
+// the data the notification emails are rendered from. Every kind of notification is
+// opt-in: a caregiver without preferences receives no email.
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// Kind names a kind of notification email
+type Kind string
+
+const (
+	// KindWeeklySummary is the weekly summary of every kid's stars in the family
+	KindWeeklySummary Kind = "weekly_summary"
+
+	// KindPendingApprovals reminds of family deletion requests waiting for confirmation
+	KindPendingApprovals Kind = "pending_approvals"
+
+	// KindLargeSpend is sent when a kid spends at least the caregiver's threshold at once
+	KindLargeSpend Kind = "large_spend"
+
+	// KindUpcomingBirthday is sent ahead of a kid's birthday
+	KindUpcomingBirthday Kind = "upcoming_birthday"
+)
+
+// Kinds lists every kind of notification
+var Kinds = []Kind{KindWeeklySummary, KindPendingApprovals, KindLargeSpend, KindUpcomingBirthday}
+
+// DefaultLargeSpendThreshold is the smallest spend reported as large unless the caregiver chose another
+const DefaultLargeSpendThreshold = 20
+
+// Preferences are the notifications a caregiver opted in to
+type Preferences struct {
+	CaregiverID         int        `json:"-" db:"caregiver_id"` // Internal ID, the API takes the caregiver's public ID from the path
+	WeeklySummary       bool       `json:"weekly_summary" db:"weekly_summary"`
+	PendingApprovals    bool       `json:"pending_approvals" db:"pending_approvals"`
+	LargeSpends         bool       `json:"large_spends" db:"large_spends"`
+	LargeSpendThreshold int        `json:"large_spend_threshold" db:"large_spend_threshold"` // Smallest spend, in stars, reported as large
+	UpcomingBirthdays   bool       `json:"upcoming_birthdays" db:"upcoming_birthdays"`
+	UpdatedAt           *time.Time `json:"updated_at,omitempty" db:"updated_at"` // Absent until the caregiver saved preferences
+}
+
+// DefaultPreferences returns the preferences of a caregiver who has not opted in to anything
+func DefaultPreferences(caregiverID int) *Preferences {
+	return &Preferences{
+		CaregiverID:         caregiverID,
+		LargeSpendThreshold: DefaultLargeSpendThreshold,
+	}
+}
+
+// Validate checks if the Preferences data meets business requirements
+func (p *Preferences) Validate() error {
+	if p.CaregiverID <= 0 {
+		return fmt.Errorf("caregiver_id must be greater than 0")
+	}
+	if p.LargeSpendThreshold < transaction.MinStarsAmount || p.LargeSpendThreshold > transaction.MaxStarsAmount {
+		return fmt.Errorf("large_spend_threshold must be between %d and %d stars", transaction.MinStarsAmount, transaction.MaxStarsAmount)
+	}
+	return nil
+}
+
+// Enabled reports whether the caregiver opted in to the kind of notification
+func (p *Preferences) Enabled(kind Kind) bool {
+	switch kind {
+	case KindWeeklySummary:
+		return p.WeeklySummary
+	case KindPendingApprovals:
+		return p.PendingApprovals
+	case KindLargeSpend:
+		return p.LargeSpends
+	case KindUpcomingBirthday:
+		return p.UpcomingBirthdays
+	}
+	return false
+}
+
+// Recipient is a caregiver who opted in to a kind of notification
+type Recipient struct {
+	CaregiverID         int    `db:"caregiver_id"`
+	CaregiverPublicID   string `db:"caregiver_public_id"` // Shown in the preferences footer
+	FamilyID            int    `db:"family_id"`
+	Name                string `db:"name"`
+	Email               string `db:"email"`
+	LargeSpendThreshold int    `db:"large_spend_threshold"`
+}
+
+// KidSummary is a kid's stars over a period, as reported to caregivers
+type KidSummary struct {
+	KidID       int       `db:"kid_id"`
+	KidPublicID string    `db:"kid_public_id"`
+	FamilyID    int       `db:"family_id"`
+	Name        string    `db:"name"`
+	Birthdate   time.Time `db:"birthdate"`
+	Earned      int       `db:"earned"`  // Stars earned in the period
+	Spent       int       `db:"spent"`   // Stars spent in the period
+	Balance     int       `db:"balance"` // Stars at the end of the period
+}
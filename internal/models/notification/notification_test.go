@@ -0,0 +1,46 @@
+package notification
+
+import "testing"
+
+func TestPreferencesValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences Preferences
+		expectError bool
+	}{
+		{"defaults", *DefaultPreferences(1), false},
+		{"all enabled", Preferences{CaregiverID: 1, WeeklySummary: true, LargeSpends: true, LargeSpendThreshold: 100}, false},
+		{"missing caregiver", Preferences{LargeSpendThreshold: 20}, true},
+		{"zero threshold", Preferences{CaregiverID: 1, LargeSpendThreshold: 0}, true},
+		{"threshold above the largest transaction", Preferences{CaregiverID: 1, LargeSpendThreshold: 101}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.preferences.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestPreferencesEnabled(t *testing.T) {
+	p := Preferences{CaregiverID: 1, WeeklySummary: true, UpcomingBirthdays: true}
+
+	expected := map[Kind]bool{
+		KindWeeklySummary:    true,
+		KindPendingApprovals: false,
+		KindLargeSpend:       false,
+		KindUpcomingBirthday: true,
+		Kind("unknown"):      false,
+	}
+	for kind, enabled := range expected {
+		if p.Enabled(kind) != enabled {
+			t.Errorf("expected %s enabled %v, got %v", kind, enabled, p.Enabled(kind))
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -17,6 +18,12 @@ const (
 	
 	// MaxStarsAmount defines the maximum stars amount for a single transaction
 	MaxStarsAmount = 100
+	
+	// MaxTags defines the maximum number of tags on a transaction
+	MaxTags = 10
+	
+	// MaxTagLength defines the maximum allowed tag length
+	MaxTagLength = 30
 )
 
 // TransactionType represents the type of star transaction
@@ -32,11 +39,16 @@ const (
 
 // Transaction represents a star transaction in the system
 type Transaction struct {
-	ID          int             `json:"id" db:"id"`
-	KidID       int             `json:"kid_id" db:"kid_id" validate:"required,min=1"`
+	ID          int             `json:"-" db:"id"`                                                  // Internal identifier, never exposed
+	PublicID    string          `json:"id" db:"public_id" validate:"omitempty,uuid"`                // Identifier used by the API
+	KidID       int             `json:"-" db:"kid_id" validate:"required,min=1"`                    // Internal identifier of the kid
+	KidPublicID string          `json:"kid_id" db:"kid_public_id" validate:"omitempty,uuid"`        // Public identifier of the kid
 	Type        TransactionType `json:"type" db:"type" validate:"required,oneof=earn spend"`
 	Amount      int             `json:"amount" db:"amount" validate:"required,min=1,max=100"`
 	Description string          `json:"description" db:"description" validate:"required,max=255"`
+	CategoryID  *int            `json:"category_id,omitempty" db:"category_id" validate:"omitempty,min=1"` // Category of the kid's family, uncategorized when nil
+	Tags        []string        `json:"tags" db:"tags" validate:"max=10"`                                  // Free-form lowercase labels
+	Balance     *int            `json:"balance,omitempty" db:"balance"`                                    // Kid's balance right after this transaction, set in listings
 	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at,omitempty" db:"updated_at"`
 }
@@ -47,8 +59,10 @@ func init() {
 	validate = validator.New()
 }
 
-// Validate validates the transaction fields
+// Validate validates the transaction fields. Tags are normalized first (see NormalizeTags).
 func (t *Transaction) Validate() error {
+	t.Tags = NormalizeTags(t.Tags)
+	
 	if err := validate.Struct(t); err != nil {
 		validationErrors := err.(validator.ValidationErrors)
 		for _, fieldErr := range validationErrors {
@@ -73,6 +87,9 @@ func (t *Transaction) Validate() error {
 				if fieldErr.Field() == "Description" {
 					return fmt.Errorf("description cannot exceed %d characters", MaxDescriptionLength)
 				}
+				if fieldErr.Field() == "Tags" {
+					return fmt.Errorf("a transaction cannot have more than %d tags", MaxTags)
+				}
 				return fmt.Errorf("%s cannot exceed %s", getFieldName(fieldErr.Field()), fieldErr.Param())
 			case "oneof":
 				return fmt.Errorf("type must be either 'earn' or 'spend'")
@@ -84,6 +101,11 @@ func (t *Transaction) Validate() error {
 	if err := ValidateTransactionType(string(t.Type)); err != nil {
 		return err
 	}
+	for _, tag := range t.Tags {
+		if err := ValidateTag(tag); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -110,6 +132,34 @@ func ValidateAmount(amount int) error {
 	return nil
 }
 
+// NormalizeTags trims and lowercases tags, dropping empty and repeated ones.
+// Always returns a non-nil slice, so transactions without tags list none.
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// ValidateTag validates a normalized tag: at most MaxTagLength characters and no commas,
+// which separate tags in query strings
+func ValidateTag(tag string) error {
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return fmt.Errorf("tag %q cannot exceed %d characters", tag, MaxTagLength)
+	}
+	if strings.Contains(tag, ",") {
+		return fmt.Errorf("tag %q cannot contain commas", tag)
+	}
+	return nil
+}
+
 // GetValidTransactionTypes returns the list of valid transaction types
 func GetValidTransactionTypes() []string {
 	return []string{string(TransactionTypeEarn), string(TransactionTypeSpend)}
@@ -136,6 +186,10 @@ func getFieldName(field string) string {
 		return "amount"
 	case "Description":
 		return "description"
+	case "CategoryID":
+		return "category_id"
+	case "Tags":
+		return "tags"
 	default:
 		return field
 	}
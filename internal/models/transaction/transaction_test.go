@@ -140,4 +140,40 @@ func TestTransactionTypeMethods(t *testing.T) {
 	if !spendTransaction.IsSpendTransaction() {
 		t.Error("expected spend transaction to return true for IsSpendTransaction()")
 	}
-}
\ No newline at end of file
+}
+
+func TestTransactionTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		tags         []string
+		expectedTags []string
+		expectError  bool
+	}{
+		{"no tags", nil, []string{}, false},
+		{"normalized", []string{" Homework ", "math", "HOMEWORK", ""}, []string{"homework", "math"}, false},
+		{"longest tag", []string{strings.Repeat("a", MaxTagLength)}, []string{strings.Repeat("a", MaxTagLength)}, false},
+		{"tag too long", []string{strings.Repeat("a", MaxTagLength+1)}, nil, true},
+		{"comma", []string{"math,science"}, nil, true},
+		{"too many tags", []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := Transaction{KidID: 1, Type: TransactionTypeEarn, Amount: 5, Description: "Test", Tags: tt.tags}
+
+			err := transaction.Validate()
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if strings.Join(transaction.Tags, "|") != strings.Join(tt.expectedTags, "|") || transaction.Tags == nil {
+				t.Errorf("expected tags %q, got %q", tt.expectedTags, transaction.Tags)
+			}
+		})
+	}
+}
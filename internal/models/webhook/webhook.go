@@ -0,0 +1,224 @@
+// Package webhook provides the Webhook model, a family's subscription to domain events
+// at a URL, the Delivery of one event to it, and the HMAC signature that lets the
+// receiver verify a delivery came from us.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+)
+
+// MaxURLLength is the longest accepted webhook URL
+const MaxURLLength = 2048
+
+// Headers of a delivery request
+const (
+	SignatureHeader = "X-Astras-Signature" // t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+	EventHeader     = "X-Astras-Event"     // Event type, e.g. stars.earned
+	DeliveryHeader  = "X-Astras-Delivery"  // Delivery ID, the same for every retry
+)
+
+// EventTypes lists the events a webhook can subscribe to: transaction and kid events
+var EventTypes = []event.Type{
+	event.StarsEarned,
+	event.StarsSpent,
+	event.TransactionUpdated,
+	event.TransactionDeleted,
+	event.KidCreated,
+	event.KidUpdated,
+	event.KidDeleted,
+	event.KidRestored,
+}
+
+// Subscribable reports whether webhooks can subscribe to the event type
+func Subscribable(t event.Type) bool {
+	for _, subscribable := range EventTypes {
+		if t == subscribable {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is a family's subscription to events at a URL
+type Webhook struct {
+	ID                  int        `json:"id" db:"id"`
+	FamilyID            int        `json:"-" db:"family_id"` // Internal ID of the family
+	URL                 string     `json:"url" db:"url"`
+	EventTypes          []string   `json:"event_types" db:"event_types"`
+	Secret              string     `json:"-" db:"secret"` // Signing key, only returned when the webhook is created
+	Active              bool       `json:"active" db:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"` // Failed attempts since the last success
+	DisabledAt          *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`         // When repeated failures disabled the webhook
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// Validate checks if the Webhook data meets business requirements
+func (w *Webhook) Validate() error {
+	w.URL = strings.TrimSpace(w.URL)
+
+	if w.URL == "" {
+		return errors.New("url is required and cannot be empty")
+	}
+	if len(w.URL) > MaxURLLength {
+		return fmt.Errorf("url cannot exceed %d characters", MaxURLLength)
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("url must be an absolute https URL")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("url cannot point to localhost")
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if err := CheckAddress(ip); err != nil {
+			return fmt.Errorf("url cannot point to %w", err)
+		}
+	}
+
+	if len(w.EventTypes) == 0 {
+		return errors.New("event_types must list at least one event type")
+	}
+	for _, t := range w.EventTypes {
+		if !Subscribable(event.Type(t)) {
+			return fmt.Errorf("event type %q cannot be subscribed to", t)
+		}
+	}
+
+	return nil
+}
+
+// blockedPrefixes are the non-public IPv4 ranges the netip predicates do not cover
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This network"
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+}
+
+// CheckAddress rejects addresses a delivery must not reach: loopback, private and
+// link-local ones (including the cloud metadata service at 169.254.169.254), multicast
+// and unspecified. Hostnames are checked by the dispatcher once they are resolved.
+func CheckAddress(ip netip.Addr) error {
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("non-public address %s", ip)
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("non-public address %s", ip)
+		}
+	}
+	return nil
+}
+
+// Subscribed reports whether the webhook receives events of the type
+func (w *Webhook) Subscribed(t event.Type) bool {
+	for _, subscribed := range w.EventTypes {
+		if event.Type(subscribed) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a delivery
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries wait for their first attempt or a retry
+	DeliveryPending DeliveryStatus = "pending"
+
+	// DeliverySucceeded deliveries were accepted with a 2xx response
+	DeliverySucceeded DeliveryStatus = "succeeded"
+
+	// DeliveryFailed deliveries ran out of attempts
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Delivery is one event sent, or to be sent, to one webhook
+type Delivery struct {
+	ID             int64          `json:"id" db:"id"`
+	WebhookID      int            `json:"webhook_id" db:"webhook_id"`
+	EventID        int64          `json:"event_id" db:"event_id"` // Outbox ID of the event
+	EventType      string         `json:"event_type" db:"event_type"`
+	Body           string         `json:"-" db:"body"` // JSON posted, the same for every attempt
+	Status         DeliveryStatus `json:"status" db:"status"`
+	Attempts       int            `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastStatusCode *int           `json:"last_status_code,omitempty" db:"last_status_code"` // HTTP status of the last attempt, absent when there was no response
+	LastError      *string        `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// Attempt is the outcome of one delivery attempt
+type Attempt struct {
+	StatusCode    int        // HTTP status, 0 when no response was received
+	Error         string     // Why the attempt failed, empty when it succeeded
+	NextAttemptAt *time.Time // When to retry a failed attempt, nil when the delivery gives up
+}
+
+// Succeeded reports whether the receiver accepted the delivery
+func (a Attempt) Succeeded() bool {
+	return a.Error == ""
+}
+
+// Sign returns the signature header value of a body sent at the given time
+func Sign(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + signature(secret, timestamp, body)
+}
+
+// Verify checks a signature header value against the body, rejecting signatures older
+// than tolerance to prevent replays. Receivers written in Go can use it directly.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now ...time.Time) error {
+	var timestamp, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			sig = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || sig == "" {
+		return errors.New("malformed signature")
+	}
+
+	at := time.Now()
+	if len(now) > 0 {
+		at = now[0]
+	}
+	if age := at.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return errors.New("signature timestamp is outside the tolerance")
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, timestamp, body))) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// signature is the hex HMAC-SHA256 of "<timestamp>.<body>"
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
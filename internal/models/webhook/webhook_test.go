@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		webhook     Webhook
+		expectError bool
+	}{
+		{"valid", Webhook{URL: " https://example.com/hooks ", EventTypes: []string{"stars.earned"}}, false},
+		{"public address", Webhook{URL: "https://93.184.216.34:8443/hooks", EventTypes: []string{"kid.created", "stars.spent"}}, false},
+		{"http", Webhook{URL: "http://example.com/hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"localhost", Webhook{URL: "https://localhost:8080", EventTypes: []string{"stars.earned"}}, true},
+		{"localhost subdomain", Webhook{URL: "https://api.LOCALHOST./hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"loopback", Webhook{URL: "https://127.0.0.1/hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"ipv6 loopback", Webhook{URL: "https://[::1]/hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"private", Webhook{URL: "https://10.0.0.5/hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"private 192.168", Webhook{URL: "https://192.168.1.1/hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"metadata service", Webhook{URL: "https://169.254.169.254/latest/meta-data", EventTypes: []string{"stars.earned"}}, true},
+		{"ipv4-mapped private", Webhook{URL: "https://[::ffff:172.16.0.1]/hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"unspecified", Webhook{URL: "https://0.0.0.0/hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"empty url", Webhook{URL: " ", EventTypes: []string{"stars.earned"}}, true},
+		{"relative url", Webhook{URL: "/hooks", EventTypes: []string{"stars.earned"}}, true},
+		{"ftp url", Webhook{URL: "ftp://example.com", EventTypes: []string{"stars.earned"}}, true},
+		{"no event types", Webhook{URL: "https://example.com"}, true},
+		{"unknown event type", Webhook{URL: "https://example.com", EventTypes: []string{"stars.stolen"}}, true},
+		{"caregiver event type", Webhook{URL: "https://example.com", EventTypes: []string{"caregiver.created"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.webhook.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":1,"type":"stars.earned"}`)
+	sentAt := time.Unix(1700000000, 0)
+	header := Sign("whsec_test", sentAt, body)
+
+	tests := []struct {
+		name        string
+		secret      string
+		header      string
+		body        []byte
+		now         time.Time
+		expectError bool
+	}{
+		{"valid", "whsec_test", header, body, sentAt.Add(time.Minute), false},
+		{"wrong secret", "whsec_other", header, body, sentAt, true},
+		{"tampered body", "whsec_test", header, []byte(`{"id":1,"type":"stars.spent"}`), sentAt, true},
+		{"too old", "whsec_test", header, body, sentAt.Add(10 * time.Minute), true},
+		{"malformed", "whsec_test", "v1=abc", body, sentAt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, 5*time.Minute, tt.now)
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package notifications
+
+import (
+	"text/template"
+
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+)
+
+// deletionTemplate is the email carrying the token that confirms a family data deletion.
+// Every caregiver of the family gets it whatever their preferences, so it has no
+// preferences footer.
+var deletionTemplate = template.Must(template.New("deletion").Funcs(templateFuncs).
+	ParseFS(templateFiles, "templates/deletion.tmpl"))
+
+// deletionEmail is the data of the deletion template
+type deletionEmail struct {
+	Caregiver *caregiver.Caregiver
+	Family    *family.Family
+	Deletion  *family.DeletionRequest
+	Token     string
+}
+
+// DeletionMessage returns the email sending the token that confirms a deletion request
+// to a caregiver of the family
+func DeletionMessage(c *caregiver.Caregiver, f *family.Family, d *family.DeletionRequest, token string) (Message, error) {
+	subject, body, err := execute(deletionTemplate, deletionEmail{Caregiver: c, Family: f, Deletion: d, Token: token})
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{To: c.Email, Subject: subject, Body: body}, nil
+}
@@ -0,0 +1,29 @@
+package notifications
+
+import (
+	"text/template"
+
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+	"github.com/lukasz/astras-mono-api/internal/models/invitation"
+)
+
+// invitationTemplate is the email carrying an invitation's token. Invitees have not
+// opted in to anything, so it has no preferences footer.
+var invitationTemplate = template.Must(template.New("invitation").Funcs(templateFuncs).
+	ParseFS(templateFiles, "templates/invitation.tmpl"))
+
+// invitationEmail is the data of the invitation template
+type invitationEmail struct {
+	Invitation *invitation.Invitation
+	Family     *family.Family
+	Token      string
+}
+
+// InvitationMessage returns the email sending the token that accepts the invitation to its invitee
+func InvitationMessage(i *invitation.Invitation, f *family.Family, token string) (Message, error) {
+	subject, body, err := execute(invitationTemplate, invitationEmail{Invitation: i, Family: f, Token: token})
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{To: i.Email, Subject: subject, Body: body}, nil
+}
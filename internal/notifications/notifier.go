@@ -0,0 +1,328 @@
+// Package notifications emails caregivers about their family: a weekly summary of
+// every kid's stars, reminders of deletion requests waiting for confirmation, spends
+// above the caregiver's threshold and upcoming birthdays. Each kind is opt-in (see
+// notification.Preferences) and sent at most once per caregiver and subject, so the
+// scheduled jobs and the outbox relay can safely run again.
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+	"github.com/lukasz/astras-mono-api/internal/models/kid"
+	"github.com/lukasz/astras-mono-api/internal/models/notification"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+	"github.com/lukasz/astras-mono-api/internal/reports"
+)
+
+// DefaultBirthdayLeadDays is how many days ahead birthdays are announced
+const DefaultBirthdayLeadDays = 7
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// templateFuncs are the helpers available to the email templates
+var templateFuncs = template.FuncMap{
+	"date":     func(t time.Time) string { return t.Format("Monday, 2 January 2006") },
+	"datetime": func(t time.Time) string { return t.UTC().Format("2 January 2006 15:04 MST") },
+	"signed":   func(n int) string { return fmt.Sprintf("%+d", n) },
+	"stars": func(n int) string {
+		if n == 1 || n == -1 {
+			return "star"
+		}
+		return "stars"
+	},
+}
+
+// templates holds the subject and body templates of each kind of notification
+var templates = map[notification.Kind]*template.Template{}
+
+func init() {
+	for _, kind := range notification.Kinds {
+		templates[kind] = template.Must(template.New(string(kind)).Funcs(templateFuncs).
+			ParseFS(templateFiles, "templates/footer.tmpl", "templates/"+string(kind)+".tmpl"))
+	}
+}
+
+// Data of the email templates; each has the Recipient the footer refers to
+type (
+	weeklySummary struct {
+		Recipient *notification.Recipient
+		From, To  time.Time // First and last day of the week
+		Kids      []weeklyKid
+	}
+
+	weeklyKid struct {
+		*notification.KidSummary
+		Report *reports.Report // Top chore and change since the week before, nil without a reporter
+	}
+
+	pendingApproval struct {
+		Recipient *notification.Recipient
+		Deletion  *family.DeletionRequest
+	}
+
+	largeSpend struct {
+		Recipient   *notification.Recipient
+		Kid         *notification.KidSummary
+		Transaction *transaction.Transaction
+	}
+
+	upcomingBirthday struct {
+		Recipient *notification.Recipient
+		Kid       *notification.KidSummary
+		Birthday  time.Time
+		Days      int // Days until the birthday, 0 on the day
+		Age       int // Age the kid turns
+	}
+)
+
+// Render returns the subject and body of a notification email
+func Render(kind notification.Kind, data any) (string, string, error) {
+	t, ok := templates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("unknown notification kind: %s", kind)
+	}
+	return execute(t, data)
+}
+
+// execute renders the subject and body templates of an email
+func execute(t *template.Template, data any) (string, string, error) {
+	var subject, body bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s subject: %w", t.Name(), err)
+	}
+	if err := t.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s body: %w", t.Name(), err)
+	}
+
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()) + "\n", nil
+}
+
+// Notifier sends the notification emails
+type Notifier struct {
+	repo     interfaces.NotificationRepository
+	sender   Sender
+	reporter *reports.Reporter
+	now      func() time.Time
+}
+
+// NewNotifier creates a notifier reading preferences and data from repo and sending with sender.
+// With a reporter, weekly summaries also name each kid's top chore and compare the
+// week to the one before.
+func NewNotifier(repo interfaces.NotificationRepository, sender Sender, reporter ...*reports.Reporter) *Notifier {
+	n := &Notifier{
+		repo:   repo,
+		sender: sender,
+		now:    time.Now,
+	}
+	if len(reporter) > 0 {
+		n.reporter = reporter[0]
+	}
+	return n
+}
+
+// SendWeeklySummaries emails the stars each kid earned and spent in the seven days
+// before today to the caregivers of the family. Returns how many emails were sent.
+func (n *Notifier) SendWeeklySummaries(ctx context.Context) (int, error) {
+	until := startOfDay(n.now())
+	from := until.AddDate(0, 0, -7)
+
+	recipients, err := n.repo.Recipients(ctx, notification.KindWeeklySummary)
+	if err != nil {
+		return 0, err
+	}
+	if len(recipients) == 0 {
+		return 0, nil
+	}
+
+	summaries, err := n.repo.KidSummaries(ctx, from, until)
+	if err != nil {
+		return 0, err
+	}
+	kids := map[int][]weeklyKid{}
+	for _, s := range summaries {
+		k := weeklyKid{KidSummary: s}
+		if n.reporter != nil {
+			if k.Report, err = n.reporter.Range(ctx, s.KidID, from, until, 1); err != nil {
+				return 0, err
+			}
+		}
+		kids[s.FamilyID] = append(kids[s.FamilyID], k)
+	}
+
+	year, week := from.ISOWeek()
+	key := fmt.Sprintf("%d-W%02d", year, week)
+
+	var sent int
+	var errs []error
+	for _, r := range recipients {
+		data := weeklySummary{Recipient: r, From: from, To: until.AddDate(0, 0, -1), Kids: kids[r.FamilyID]}
+		ok, err := n.notify(ctx, r, notification.KindWeeklySummary, key, data)
+		if err != nil {
+			errs = append(errs, err)
+		} else if ok {
+			sent++
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// SendPendingApprovals reminds the caregivers of a family of its deletion requests
+// that wait for confirmation, once per request. Returns how many emails were sent.
+func (n *Notifier) SendPendingApprovals(ctx context.Context) (int, error) {
+	deletions, err := n.repo.PendingDeletions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int
+	var errs []error
+	for _, deletion := range deletions {
+		recipients, err := n.repo.Recipients(ctx, notification.KindPendingApprovals, deletion.FamilyID)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, r := range recipients {
+			data := pendingApproval{Recipient: r, Deletion: deletion}
+			ok, err := n.notify(ctx, r, notification.KindPendingApprovals, fmt.Sprintf("deletion-%d", deletion.ID), data)
+			if err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				sent++
+			}
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// SendBirthdayReminders tells the caregivers of a family about the birthdays of its
+// kids within the given number of days, once per birthday. Returns how many emails were sent.
+func (n *Notifier) SendBirthdayReminders(ctx context.Context, within int) (int, error) {
+	now := n.now()
+	today := startOfDay(now)
+
+	recipients, err := n.repo.Recipients(ctx, notification.KindUpcomingBirthday)
+	if err != nil {
+		return 0, err
+	}
+	if len(recipients) == 0 {
+		return 0, nil
+	}
+
+	summaries, err := n.repo.KidSummaries(ctx, now, now)
+	if err != nil {
+		return 0, err
+	}
+	caregivers := map[int][]*notification.Recipient{}
+	for _, r := range recipients {
+		caregivers[r.FamilyID] = append(caregivers[r.FamilyID], r)
+	}
+
+	var sent int
+	var errs []error
+	for _, s := range summaries {
+		k := &kid.Kid{Birthdate: s.Birthdate}
+		days := k.DaysUntilBirthday(now)
+		if days > within {
+			continue
+		}
+		birthday := today.AddDate(0, 0, days)
+
+		for _, r := range caregivers[s.FamilyID] {
+			data := upcomingBirthday{Recipient: r, Kid: s, Birthday: birthday, Days: days, Age: k.Age(birthday)}
+			key := fmt.Sprintf("kid-%d-%s", s.KidID, birthday.Format(time.DateOnly))
+			ok, err := n.notify(ctx, r, notification.KindUpcomingBirthday, key, data)
+			if err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				sent++
+			}
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// Publish is the outbox.Sink of the notifier: it emails the caregivers of a family when
+// a kid spends at least their threshold at once. Other events are ignored. A failed
+// email fails the event, so the relay retries it; caregivers already told are skipped.
+func (n *Notifier) Publish(ctx context.Context, e *event.Event) error {
+	if e.Type != event.StarsSpent {
+		return nil
+	}
+
+	var t transaction.Transaction
+	if err := json.Unmarshal(e.Payload, &t); err != nil {
+		return fmt.Errorf("failed to decode transaction of event %d: %w", e.ID, err)
+	}
+
+	now := n.now()
+	summaries, err := n.repo.KidSummaries(ctx, now, now, t.KidPublicID)
+	if err != nil {
+		return err
+	}
+	if len(summaries) == 0 {
+		return nil // The kid is not in a family or has been deleted since
+	}
+	summary := summaries[0]
+
+	recipients, err := n.repo.Recipients(ctx, notification.KindLargeSpend, summary.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, r := range recipients {
+		if t.Amount < r.LargeSpendThreshold {
+			continue
+		}
+		data := largeSpend{Recipient: r, Kid: summary, Transaction: &t}
+		if _, err := n.notify(ctx, r, notification.KindLargeSpend, fmt.Sprintf("transaction-%d", e.EntityID), data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// notify sends one email unless the recipient was already notified about key, and
+// reports whether it was sent
+func (n *Notifier) notify(ctx context.Context, r *notification.Recipient, kind notification.Kind, key string, data any) (bool, error) {
+	subject, body, err := Render(kind, data)
+	if err != nil {
+		return false, err
+	}
+
+	first, err := n.repo.MarkSent(ctx, r.CaregiverID, kind, key)
+	if err != nil || !first {
+		return false, err
+	}
+
+	if err := n.sender.Send(ctx, Message{To: r.Email, Subject: subject, Body: body}); err != nil {
+		if unmarkErr := n.repo.UnmarkSent(ctx, r.CaregiverID, kind, key); unmarkErr != nil {
+			return false, errors.Join(err, unmarkErr)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// startOfDay returns midnight of the day of t, in its location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,340 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+	"github.com/lukasz/astras-mono-api/internal/models/invitation"
+	"github.com/lukasz/astras-mono-api/internal/models/notification"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+	"github.com/lukasz/astras-mono-api/internal/reports"
+)
+
+// memoryNotifications holds the caregivers of families 1 and 2 and their kids in memory
+type memoryNotifications struct {
+	interfaces.NotificationRepository
+	recipients map[notification.Kind][]*notification.Recipient
+	kids       []*notification.KidSummary
+	deletions  []*family.DeletionRequest
+	sent       map[string]bool
+}
+
+func (r *memoryNotifications) Recipients(ctx context.Context, kind notification.Kind, familyID ...int) ([]*notification.Recipient, error) {
+	var recipients []*notification.Recipient
+	for _, recipient := range r.recipients[kind] {
+		if len(familyID) == 0 || recipient.FamilyID == familyID[0] {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients, nil
+}
+
+func (r *memoryNotifications) KidSummaries(ctx context.Context, from, to time.Time, kidIDs ...string) ([]*notification.KidSummary, error) {
+	var summaries []*notification.KidSummary
+	for _, k := range r.kids {
+		if len(kidIDs) == 0 || k.KidPublicID == kidIDs[0] {
+			summaries = append(summaries, k)
+		}
+	}
+	return summaries, nil
+}
+
+func (r *memoryNotifications) PendingDeletions(ctx context.Context) ([]*family.DeletionRequest, error) {
+	return r.deletions, nil
+}
+
+func (r *memoryNotifications) MarkSent(ctx context.Context, caregiverID int, kind notification.Kind, key string) (bool, error) {
+	if r.sent == nil {
+		r.sent = map[string]bool{}
+	}
+	id := fmt.Sprintf("%d/%s/%s", caregiverID, kind, key)
+	if r.sent[id] {
+		return false, nil
+	}
+	r.sent[id] = true
+	return true, nil
+}
+
+func (r *memoryNotifications) UnmarkSent(ctx context.Context, caregiverID int, kind notification.Kind, key string) error {
+	delete(r.sent, fmt.Sprintf("%d/%s/%s", caregiverID, kind, key))
+	return nil
+}
+
+// failingSender fails every email
+type failingSender struct{}
+
+func (failingSender) Send(ctx context.Context, m Message) error {
+	return errors.New("smtp server unavailable")
+}
+
+func newRepository() *memoryNotifications {
+	sarah := &notification.Recipient{CaregiverID: 1, CaregiverPublicID: "0e4f6a8c-1b3d-4f5a-8c7e-9d0b1a2c3e4f", FamilyID: 1, Name: "Sarah", Email: "sarah@example.com", LargeSpendThreshold: 10}
+	mike := &notification.Recipient{CaregiverID: 2, CaregiverPublicID: "7a9c1e3f-5b7d-4e9a-b1c3-e5f7a9b1c3d5", FamilyID: 2, Name: "Mike", Email: "mike@example.com", LargeSpendThreshold: 50}
+
+	return &memoryNotifications{
+		recipients: map[notification.Kind][]*notification.Recipient{
+			notification.KindWeeklySummary:    {sarah, mike},
+			notification.KindPendingApprovals: {sarah},
+			notification.KindLargeSpend:       {sarah, mike},
+			notification.KindUpcomingBirthday: {sarah},
+		},
+		kids: []*notification.KidSummary{
+			{KidID: 1, KidPublicID: "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b", FamilyID: 1, Name: "Alice", Birthdate: time.Date(2015, 3, 15, 0, 0, 0, 0, time.UTC), Earned: 15, Spent: 1, Balance: 42},
+			{KidID: 2, KidPublicID: "0b9e4c1a-2f3d-4a5b-8c6d-7e8f9a0b1c2d", FamilyID: 2, Name: "Bob", Birthdate: time.Date(2012, 7, 22, 0, 0, 0, 0, time.UTC), Earned: 0, Spent: 3, Balance: 7},
+		},
+	}
+}
+
+func newTestNotifier(repo *memoryNotifications, sender Sender) *Notifier {
+	n := NewNotifier(repo, sender)
+	n.now = func() time.Time { return time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC) }
+	return n
+}
+
+func TestSendWeeklySummaries(t *testing.T) {
+	repo := newRepository()
+	sender := &MemorySender{}
+	notifier := newTestNotifier(repo, sender)
+
+	sent, err := notifier.SendWeeklySummaries(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if sent != 2 {
+		t.Fatalf("expected 2 summaries sent, got %d", sent)
+	}
+
+	messages := sender.Messages()
+	if messages[0].To != "sarah@example.com" || !strings.Contains(messages[0].Body, "Alice: earned 15, spent 1, balance 42 stars") {
+		t.Errorf("expected Alice's week in Sarah's summary, got %s", messages[0].Body)
+	}
+	if strings.Contains(messages[0].Body, "Bob") {
+		t.Errorf("expected only the caregiver's own family, got %s", messages[0].Body)
+	}
+	if !strings.Contains(messages[0].Body, "PUT /caregivers/0e4f6a8c-1b3d-4f5a-8c7e-9d0b1a2c3e4f/notifications") {
+		t.Errorf("expected Sarah's public ID in the preferences footer, got %s", messages[0].Body)
+	}
+	expectedSubject := "Your family's stars for Tuesday, 3 March 2026 – Monday, 9 March 2026"
+	if messages[0].Subject != expectedSubject {
+		t.Errorf("expected subject %q, got %q", expectedSubject, messages[0].Subject)
+	}
+
+	// Running the job again in the same week sends nothing
+	sent, err = notifier.SendWeeklySummaries(context.Background())
+	if err != nil || sent != 0 {
+		t.Errorf("expected no summaries sent twice, got %d (%v)", sent, err)
+	}
+}
+
+// memoryTransactions is a reports.Source of in-memory transactions
+type memoryTransactions []*transaction.Transaction
+
+func (m memoryTransactions) Find(ctx context.Context, filter interfaces.TransactionFilter) ([]*transaction.Transaction, error) {
+	var found []*transaction.Transaction
+	for _, t := range m {
+		if t.KidID == filter.KidID {
+			found = append(found, t)
+		}
+	}
+	return found, nil
+}
+
+func TestSendWeeklySummariesWithReports(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 18, 0, 0, 0, time.UTC) }
+	source := memoryTransactions{
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 5, Description: "Dishes", CreatedAt: day(4)},
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 5, Description: "dishes", CreatedAt: day(6)},
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 5, Description: "Homework", CreatedAt: day(7)},
+		{KidID: 1, Type: transaction.TransactionTypeSpend, Amount: 1, Description: "Candy", CreatedAt: day(8)},
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 10, Description: "Homework", CreatedAt: day(1)},
+	}
+
+	sender := &MemorySender{}
+	notifier := newTestNotifier(newRepository(), sender)
+	notifier.reporter = reports.NewReporter(source)
+
+	if _, err := notifier.SendWeeklySummaries(context.Background()); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	expected := "Alice: earned 15, spent 1, balance 42 stars, top chore: Dishes (+5 earned, +1 spent vs the week before)"
+	if body := sender.Messages()[0].Body; !strings.Contains(body, expected) {
+		t.Errorf("expected %q in the summary, got %s", expected, body)
+	}
+	if body := sender.Messages()[1].Body; !strings.Contains(body, "Bob: earned 0, spent 3, balance 7 stars (+0 earned, +0 spent vs the week before)") {
+		t.Errorf("expected no top chore for Bob, got %s", body)
+	}
+}
+
+func TestSendBirthdayReminders(t *testing.T) {
+	tests := []struct {
+		name          string
+		within        int
+		expectedSent  int
+		expectedTitle string
+	}{
+		{"within the lead time", 7, 1, "Alice turns 11 in 5 days"},
+		{"too early", 3, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &MemorySender{}
+			sent, err := newTestNotifier(newRepository(), sender).SendBirthdayReminders(context.Background(), tt.within)
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if sent != tt.expectedSent {
+				t.Fatalf("expected %d reminders sent, got %d", tt.expectedSent, sent)
+			}
+			if sent > 0 && sender.Messages()[0].Subject != tt.expectedTitle {
+				t.Errorf("expected subject %q, got %q", tt.expectedTitle, sender.Messages()[0].Subject)
+			}
+		})
+	}
+}
+
+func TestSendPendingApprovals(t *testing.T) {
+	repo := newRepository()
+	repo.deletions = []*family.DeletionRequest{
+		{ID: 3, FamilyID: 1, Mode: family.DeletionModeAnonymize, Kids: 1, Caregivers: 1, Transactions: 12,
+			RequestedAt: time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC), ExpiresAt: time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC)},
+	}
+	sender := &MemorySender{}
+
+	sent, err := newTestNotifier(repo, sender).SendPendingApprovals(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("expected 1 reminder sent, got %d", sent)
+	}
+	if body := sender.Messages()[0].Body; !strings.Contains(body, "anonymize your family's data") || !strings.Contains(body, "11 March 2026 08:00 UTC") {
+		t.Errorf("expected the request and its deadline, got %s", body)
+	}
+}
+
+func TestPublishLargeSpend(t *testing.T) {
+	tests := []struct {
+		name         string
+		eventType    event.Type
+		kidID        string
+		amount       int
+		expectedSent []string
+	}{
+		{"above the threshold", event.StarsSpent, "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b", 12, []string{"sarah@example.com"}},
+		{"below the threshold", event.StarsSpent, "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b", 9, nil},
+		{"other family's threshold", event.StarsSpent, "0b9e4c1a-2f3d-4a5b-8c6d-7e8f9a0b1c2d", 12, nil},
+		{"earning is not reported", event.StarsEarned, "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b", 50, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _ := json.Marshal(map[string]any{"id": "00000000-0000-4000-9000-000000000008", "kid_id": tt.kidID, "type": "spend", "amount": tt.amount, "description": "LEGO set"})
+			sender := &MemorySender{}
+			notifier := newTestNotifier(newRepository(), sender)
+
+			e := &event.Event{ID: 1, Type: tt.eventType, Entity: "transaction", EntityID: 8, Payload: payload}
+			if err := notifier.Publish(context.Background(), e); err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			var sentTo []string
+			for _, m := range sender.Messages() {
+				sentTo = append(sentTo, m.To)
+			}
+			if strings.Join(sentTo, ",") != strings.Join(tt.expectedSent, ",") {
+				t.Errorf("expected emails to %v, got %v", tt.expectedSent, sentTo)
+			}
+		})
+	}
+}
+
+func TestFailedSendIsRetried(t *testing.T) {
+	repo := newRepository()
+	payload, _ := json.Marshal(map[string]any{"id": "00000000-0000-4000-9000-000000000008", "kid_id": "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b", "type": "spend", "amount": 20, "description": "LEGO set"})
+	e := &event.Event{ID: 1, Type: event.StarsSpent, Entity: "transaction", EntityID: 8, Payload: payload}
+
+	if err := newTestNotifier(repo, failingSender{}).Publish(context.Background(), e); err == nil {
+		t.Fatalf("expected the failed email to fail the event")
+	}
+
+	sender := &MemorySender{}
+	if err := newTestNotifier(repo, sender).Publish(context.Background(), e); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if len(sender.Messages()) != 1 {
+		t.Errorf("expected the email to be sent on retry, got %d", len(sender.Messages()))
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "emails")
+	sender := NewFileSender(dir, "Astras <no-reply@astras.local>")
+
+	err := sender.Send(context.Background(), Message{To: "sarah@example.com", Subject: "Zoë\nturns 8", Body: "Hi Sarah,\n\nhello"})
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 email file, got %d", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	email := string(data)
+	if !strings.Contains(email, "To: sarah@example.com\r\n") || !strings.Contains(email, "\r\n\r\nHi Sarah,\r\n\r\nhello") {
+		t.Errorf("expected headers and body, got %q", email)
+	}
+	if !strings.Contains(email, "Subject: =?utf-8?q?Zo=C3=AB_turns_8?=\r\n") {
+		t.Errorf("expected an encoded one-line subject, got %q", email)
+	}
+}
+
+func TestDeletionMessage(t *testing.T) {
+	f := &family.Family{ID: 1, PublicID: "5d8c3a2e-7f1b-4c6d-9e0a-2b4c6d8e0f1a", Name: "The Johnsons"}
+	c := &caregiver.Caregiver{ID: 2, Name: "Sarah Johnson", Email: "sarah@example.com"}
+	d := &family.DeletionRequest{ID: 7, FamilyID: 1, Mode: family.DeletionModeAnonymize, Kids: 2, Caregivers: 1, Transactions: 10,
+		ExpiresAt: time.Date(2026, 3, 17, 9, 30, 0, 0, time.UTC)}
+
+	m, err := DeletionMessage(c, f, d, "0123abcd")
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if m.To != "sarah@example.com" || m.Subject != "Confirm the deletion of The Johnsons on Astras" {
+		t.Errorf("expected the confirmation to Sarah, got %q to %s", m.Subject, m.To)
+	}
+	if !strings.Contains(m.Body, "/families/5d8c3a2e-7f1b-4c6d-9e0a-2b4c6d8e0f1a/deletions/7/confirm") || !strings.Contains(m.Body, "\n0123abcd\n") ||
+		!strings.Contains(m.Body, "17 March 2026 09:30 UTC") || !strings.Contains(m.Body, "to anonymize the data") {
+		t.Errorf("expected the confirm resource, token, deadline and mode, got %s", m.Body)
+	}
+}
+
+func TestInvitationMessage(t *testing.T) {
+	f := &family.Family{ID: 1, PublicID: "5d8c3a2e-7f1b-4c6d-9e0a-2b4c6d8e0f1a", Name: "The Johnsons"}
+	i := &invitation.Invitation{ID: 4, FamilyID: 1, Email: "grace@example.com", Name: "Grace Wilson", Relationship: "grandparent",
+		ExpiresAt: time.Date(2026, 3, 17, 9, 30, 0, 0, time.UTC)}
+
+	m, err := InvitationMessage(i, f, "0123abcd")
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if m.To != "grace@example.com" || m.Subject != "You are invited to The Johnsons on Astras" {
+		t.Errorf("expected the invitation to Grace, got %q to %s", m.Subject, m.To)
+	}
+	if !strings.Contains(m.Body, "/families/5d8c3a2e-7f1b-4c6d-9e0a-2b4c6d8e0f1a/invitations/4/accept") || !strings.Contains(m.Body, "\n0123abcd\n") ||
+		!strings.Contains(m.Body, "17 March 2026 09:30 UTC") {
+		t.Errorf("expected the accept resource, token and deadline, got %s", m.Body)
+	}
+}
@@ -0,0 +1,170 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is one email
+type Message struct {
+	To      string
+	Subject string
+	Body    string // Plain text
+}
+
+// Sender sends emails
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// SMTPSender sends emails through an SMTP server
+type SMTPSender struct {
+	addr     string
+	from     string // From header, e.g. "Astras <no-reply@example.com>"
+	envelope string // Address of from, the SMTP envelope sender
+	auth     smtp.Auth
+}
+
+// NewSMTPSender creates a sender using the SMTP server at host:port. The username and
+// password are optional; servers requiring them must offer STARTTLS.
+func NewSMTPSender(host string, port int, from, username, password string) *SMTPSender {
+	s := &SMTPSender{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		from:     from,
+		envelope: from,
+	}
+	if address, err := mail.ParseAddress(from); err == nil {
+		s.envelope = address.Address
+	}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+// Send delivers the message to the SMTP server
+func (s *SMTPSender) Send(ctx context.Context, m Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := smtp.SendMail(s.addr, s.auth, s.envelope, []string{m.To}, format(s.from, m, time.Now())); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", m.To, err)
+	}
+	return nil
+}
+
+// FileSender writes emails to .eml files in a directory instead of sending them,
+// for local development
+type FileSender struct {
+	dir  string
+	from string
+	mu   sync.Mutex
+	n    int
+}
+
+// NewFileSender creates a sender writing emails to dir, which is created when missing
+func NewFileSender(dir, from string) *FileSender {
+	return &FileSender{dir: dir, from: from}
+}
+
+// Send writes the message to a new file named after the time and recipient
+func (s *FileSender) Send(ctx context.Context, m Message) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create email directory: %w", err)
+	}
+
+	s.mu.Lock()
+	s.n++
+	n := s.n
+	s.mu.Unlock()
+
+	now := time.Now()
+	to := strings.NewReplacer("@", "_at_", "/", "_", string(filepath.Separator), "_").Replace(m.To)
+	name := filepath.Join(s.dir, fmt.Sprintf("%s-%03d-%s.eml", now.UTC().Format("20060102T150405"), n, to))
+	if err := os.WriteFile(name, format(s.from, m, now), 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
+
+// MemorySender keeps sent emails in memory, for tests
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send stores the message
+func (s *MemorySender) Send(ctx context.Context, m Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+// Messages returns the messages sent so far, in order
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// NewSenderFromEnv creates the sender chosen by NOTIFY_SENDER: smtp, configured by
+// SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME and SMTP_PASSWORD, or file (the
+// default), writing to NOTIFY_DIR (default tmp/emails). Emails are sent from NOTIFY_FROM.
+func NewSenderFromEnv() (Sender, error) {
+	from := getEnv("NOTIFY_FROM", "Astras <no-reply@astras.local>")
+
+	switch sender := getEnv("NOTIFY_SENDER", "file"); sender {
+	case "file":
+		return NewFileSender(getEnv("NOTIFY_DIR", filepath.Join("tmp", "emails")), from), nil
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required with NOTIFY_SENDER=smtp")
+		}
+		port, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+		}
+		return NewSMTPSender(host, port, from, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")), nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFY_SENDER %q, use smtp or file", sender)
+	}
+}
+
+// getEnv returns the environment variable or the fallback when it is not set
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// headerValue keeps names in headers, e.g. a kid's name in the subject, on one line
+var headerValue = strings.NewReplacer("\r", " ", "\n", " ")
+
+// format encodes the message as a plain text RFC 5322 email
+func format(from string, m Message, at time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue.Replace(m.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
@@ -0,0 +1,296 @@
+// Package openapi generates OpenAPI 3.1 documents from the routes registered by
+// the services, so the published API contract is derived from the code rather
+// than maintained by hand.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+const (
+	// Version is the OpenAPI specification version of the generated documents
+	Version = "3.1.0"
+
+	// APIVersion is the version of the Astras API described by the documents
+	APIVersion = "1.0.0"
+
+	// Path is where every service serves its OpenAPI document
+	Path = "/openapi.json"
+)
+
+// SpecRoute serves the OpenAPI document of a service; services list it with their routes
+var SpecRoute = handler.Route{
+	Method:  http.MethodGet,
+	Path:    Path,
+	Summary: "OpenAPI document describing this service",
+	Response: &schema.Schema{
+		Type: "object",
+	},
+}
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI string              `json:"openapi"`
+	Info    Info                `json:"info"`
+	Servers []Server            `json:"servers,omitempty"`
+	Paths   map[string]PathItem `json:"paths"`
+}
+
+// Info holds the metadata of the API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Server is a base URL the paths of the document are relative to
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations of a path
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+}
+
+// Parameter describes a path, query or header parameter of an operation
+type Parameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *schema.Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the request body of an operation
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// MediaType holds the schema of a body in one content type
+type MediaType struct {
+	Schema *schema.Schema `json:"schema,omitempty"`
+}
+
+// Response describes one response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header describes a response header
+type Header struct {
+	Description string         `json:"description,omitempty"`
+	Schema      *schema.Schema `json:"schema,omitempty"`
+}
+
+// New generates the OpenAPI document describing the given routes. When an API
+// version is given, the document describes the routes as served under its prefix.
+func New(title string, routes []handler.Route, version ...string) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: APIVersion},
+		Paths:   make(map[string]PathItem),
+	}
+
+	var apiVersion handler.Version
+	if len(version) > 0 {
+		apiVersion, _ = handler.LookupVersion(version[0])
+		doc.Info.Version = apiVersion.Name
+		doc.Servers = []Server{{URL: "/" + apiVersion.Name}}
+	}
+
+	for _, route := range routes {
+		item, ok := doc.Paths[route.Path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = newOperation(route, apiVersion)
+	}
+
+	return doc
+}
+
+// Serve returns the OpenAPI document of the given routes as an HTTP response
+func Serve(title string, routes []handler.Route, version ...string) (handler.HTTPResponse, error) {
+	body, err := json.Marshal(New(title, routes, version...))
+	if err != nil {
+		return handler.HTTPResponse{}, err
+	}
+
+	return handler.HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
+
+// newOperation describes a route as an OpenAPI operation in the given API version
+func newOperation(route handler.Route, version handler.Version) *Operation {
+	op := &Operation{
+		OperationID: operationID(route),
+		Summary:     route.Summary,
+		Responses:   make(map[string]Response),
+		Deprecated:  version.Deprecated(),
+	}
+
+	declared := make(map[string]bool)
+	for _, param := range route.Params {
+		declared[param.In+":"+param.Name] = true
+		op.Parameters = append(op.Parameters, Parameter(param))
+	}
+	// Path parameters must always be documented, default to strings when not declared
+	for _, name := range pathParameterNames(route.Path) {
+		if !declared["path:"+name] {
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &schema.Schema{Type: "string"}})
+		}
+	}
+
+	if route.Body != nil {
+		contentType := "application/json"
+		if route.Method == http.MethodPatch {
+			contentType = "application/merge-patch+json"
+		}
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{contentType: {Schema: route.Body}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	headers := etagHeader(route, status)
+	for name := range version.Headers() {
+		if headers == nil {
+			headers = make(map[string]Header)
+		}
+		headers[name] = Header{Description: "Retirement of the API version", Schema: &schema.Schema{Type: "string"}}
+	}
+	op.Responses[strconv.Itoa(status)] = Response{
+		Description: http.StatusText(status),
+		Headers:     headers,
+		Content:     responseContent(route, version.Name),
+	}
+
+	errorResponse := func(status int) {
+		op.Responses[strconv.Itoa(status)] = Response{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{"application/json": {Schema: handler.ErrorSchema}},
+		}
+	}
+	if route.Body != nil || len(route.Params) > 0 {
+		errorResponse(http.StatusBadRequest)
+	}
+	if len(pathParameterNames(route.Path)) > 0 {
+		errorResponse(http.StatusNotFound)
+	}
+	for _, param := range route.Params {
+		switch param.Name {
+		case handler.IfMatchParam.Name:
+			errorResponse(http.StatusPreconditionFailed)
+			errorResponse(http.StatusPreconditionRequired)
+		case handler.IfNoneMatchParam.Name:
+			op.Responses[strconv.Itoa(http.StatusNotModified)] = Response{Description: http.StatusText(http.StatusNotModified)}
+		}
+	}
+
+	return op
+}
+
+// responseContent documents the body of successful responses in every media type
+// of the route. Non-JSON types such as text/csv are documented as plain strings.
+func responseContent(route handler.Route, version string) map[string]MediaType {
+	if len(route.ContentTypes) == 0 {
+		return map[string]MediaType{"application/json": {Schema: route.ResponseFor(version)}}
+	}
+
+	content := make(map[string]MediaType, len(route.ContentTypes))
+	for _, contentType := range route.ContentTypes {
+		if strings.Contains(contentType, "json") {
+			content[contentType] = MediaType{Schema: route.ResponseFor(version)}
+		} else {
+			content[contentType] = MediaType{Schema: &schema.Schema{Type: "string"}}
+		}
+	}
+	return content
+}
+
+// etagHeader documents the ETag header returned with the current version of a
+// resource by creates, conditional reads and conditional writes
+func etagHeader(route handler.Route, status int) map[string]Header {
+	returnsVersion := status == http.StatusCreated
+	for _, param := range route.Params {
+		switch param.Name {
+		case handler.IfNoneMatchParam.Name:
+			returnsVersion = true
+		case handler.IfMatchParam.Name:
+			returnsVersion = returnsVersion || route.Body != nil
+		}
+	}
+	if !returnsVersion {
+		return nil
+	}
+	return map[string]Header{"ETag": {Description: "Version of the returned resource", Schema: &schema.Schema{Type: "string"}}}
+}
+
+// operationID derives a unique operation ID from the method and path,
+// e.g. GET /kids/{id} becomes getKidsById
+func operationID(route handler.Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, segment := range strings.Split(strings.Trim(route.Path, "/"), "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// pathParameterNames returns the {name} parameters of a path template
+func pathParameterNames(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.Trim(segment, "{}"))
+		}
+	}
+	return names
+}
+
+// Methods returns the sorted "METHOD path" keys of all operations in the document
+func (d *Document) Methods() []string {
+	var keys []string
+	for path, item := range d.Paths {
+		for method := range item {
+			keys = append(keys, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,280 @@
+package openapi_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/openapi"
+	"github.com/lukasz/astras-mono-api/internal/services/audit"
+	"github.com/lukasz/astras-mono-api/internal/services/caregivers"
+	"github.com/lukasz/astras-mono-api/internal/services/families"
+	"github.com/lukasz/astras-mono-api/internal/services/kids"
+	"github.com/lukasz/astras-mono-api/internal/services/migrations"
+	"github.com/lukasz/astras-mono-api/internal/services/stars"
+)
+
+var services = []struct {
+	name             string
+	routes           []handler.Route
+	templateFunction string // Function in template.yaml, empty when not deployed with SAM
+	versioned        bool   // Whether the service is also served under the API version prefixes
+}{
+	{kids.ServiceName, kids.Routes, "KidFunction", true},
+	{caregivers.ServiceName, caregivers.Routes, "CaregiverFunction", true},
+	{stars.ServiceName, stars.Routes, "StarFunction", true},
+	{families.ServiceName, families.Routes, "FamilyFunction", true},
+	{audit.ServiceName, audit.Routes, "AuditFunction", true},
+	{migrations.ServiceName, migrations.Routes, "", false},
+}
+
+// TestSpecMatchesRoutes fails when the generated document and the routes a
+// service registers disagree, or when the deployment descriptors drift from them
+func TestSpecMatchesRoutes(t *testing.T) {
+	for _, svc := range services {
+		t.Run(svc.name, func(t *testing.T) {
+			doc := openapi.New(svc.name, svc.routes)
+			routes := routeKeys(svc.routes)
+
+			if got := doc.Methods(); !reflect.DeepEqual(got, routes) {
+				t.Errorf("expected operations %v, got %v", routes, got)
+			}
+
+			for _, route := range svc.routes {
+				op := doc.Paths[route.Path][strings.ToLower(route.Method)]
+				if op == nil {
+					continue
+				}
+
+				for _, name := range pathParameters(route.Path) {
+					if !hasParameter(op, "path", name) {
+						t.Errorf("%s %s: path parameter %q is not documented", route.Method, route.Path, name)
+					}
+				}
+				if (route.Body != nil) != (op.RequestBody != nil) {
+					t.Errorf("%s %s: expected request body %v, got %v", route.Method, route.Path, route.Body != nil, op.RequestBody != nil)
+				}
+				if route.Response == nil {
+					t.Errorf("%s %s: successful response is not documented", route.Method, route.Path)
+				}
+			}
+
+			// Versioned paths reach the service through one proxy event per version
+			events := routes
+			if svc.versioned {
+				events = append([]string(nil), routes...)
+				for _, version := range handler.Versions {
+					events = append(events, "ANY /"+version.Name+"/{proxy+}")
+				}
+				sort.Strings(events)
+			}
+
+			if svc.templateFunction != "" {
+				if got := templateRoutes(t, "../../template.yaml", svc.templateFunction); !reflect.DeepEqual(got, events) {
+					t.Errorf("template.yaml: expected events %v, got %v", events, got)
+				}
+			}
+
+			serverless := "../../services/" + svc.name + "/serverless.yml"
+			if got := serverlessRoutes(t, serverless); !reflect.DeepEqual(got, events) {
+				t.Errorf("%s: expected events %v, got %v", serverless, events, got)
+			}
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	response, err := openapi.Serve(kids.ServiceName, kids.Routes)
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, response.StatusCode)
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			RequestBody struct {
+				Content map[string]struct {
+					Schema struct {
+						Required   []string                  `json:"required"`
+						Properties map[string]map[string]any `json:"properties"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(response.Body), &doc); err != nil {
+		t.Fatalf("document is not valid JSON: %v", err)
+	}
+
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("expected openapi %s, got %s", openapi.Version, doc.OpenAPI)
+	}
+
+	// Constraints of the request fields come from the validate tags of the model
+	create := doc.Paths["/kids"]["post"].RequestBody.Content["application/json"].Schema
+	if !reflect.DeepEqual(create.Required, []string{"name"}) {
+		t.Errorf("expected required [name], got %v", create.Required)
+	}
+	if create.Properties["name"]["minLength"] != float64(2) || create.Properties["name"]["maxLength"] != float64(255) {
+		t.Errorf("expected name length 2..255, got %v", create.Properties["name"])
+	}
+	if create.Properties["birthdate"]["format"] != "date" {
+		t.Errorf("expected birthdate format date, got %v", create.Properties["birthdate"])
+	}
+
+	patch := doc.Paths["/kids/{id}"]["patch"].RequestBody.Content["application/merge-patch+json"].Schema
+	if len(patch.Required) != 0 {
+		t.Errorf("expected no required members in merge patch, got %v", patch.Required)
+	}
+	if !reflect.DeepEqual(patch.Properties["name"]["type"], []any{"string", "null"}) {
+		t.Errorf("expected nullable name in merge patch, got %v", patch.Properties["name"]["type"])
+	}
+}
+
+func TestNewVersion(t *testing.T) {
+	saved := handler.DefaultSchedule
+	defer func() { handler.DefaultSchedule = saved }()
+	handler.DefaultSchedule = handler.Schedule{
+		Versions: []handler.Version{
+			{
+				Name:        "v1",
+				Deprecation: time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+				Sunset:      time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{Name: "v2"},
+		},
+		Now: func() time.Time { return time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC) },
+	}
+
+	v1 := openapi.New(kids.ServiceName, kids.Routes, "v1")
+	v2 := openapi.New(kids.ServiceName, kids.Routes, "v2")
+
+	for _, tt := range []struct {
+		doc        *openapi.Document
+		server     string
+		deprecated bool
+		age        bool
+	}{
+		{v1, "/v1", true, true},
+		{v2, "/v2", false, false},
+	} {
+		if len(tt.doc.Servers) != 1 || tt.doc.Servers[0].URL != tt.server {
+			t.Errorf("expected server %s, got %v", tt.server, tt.doc.Servers)
+		}
+
+		op := tt.doc.Paths["/kids/{id}"]["get"]
+		if op.Deprecated != tt.deprecated {
+			t.Errorf("%s: expected deprecated %v, got %v", tt.server, tt.deprecated, op.Deprecated)
+		}
+		if _, ok := op.Responses["200"].Headers["Sunset"]; ok != tt.deprecated {
+			t.Errorf("%s: expected Sunset header documented %v, got %v", tt.server, tt.deprecated, ok)
+		}
+
+		// v2 drops the age computed by Kid.MarshalJSON
+		data := op.Responses["200"].Content["application/json"].Schema.Properties["data"]
+		if _, ok := data.Properties["age"]; ok != tt.age {
+			t.Errorf("%s: expected age member %v, got %v", tt.server, tt.age, ok)
+		}
+	}
+}
+
+// routeKeys returns the sorted "METHOD path" keys of the routes
+func routeKeys(routes []handler.Route) []string {
+	var keys []string
+	for _, route := range routes {
+		keys = append(keys, route.Method+" "+route.Path)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func pathParameters(path string) []string {
+	return regexp.MustCompile(`\{(\w+)\}`).FindAllString(path, -1)
+}
+
+func hasParameter(op *openapi.Operation, in, name string) bool {
+	name = strings.Trim(name, "{}")
+	for _, param := range op.Parameters {
+		if param.In == in && param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	templateFunction = regexp.MustCompile(`^  (\w+):$`)
+	templatePath     = regexp.MustCompile(`^\s+Path: (\S+)$`)
+	templateMethod   = regexp.MustCompile(`^\s+Method: (\S+)$`)
+	serverlessPath   = regexp.MustCompile(`^\s+path: (/\S*)$`)
+	serverlessMethod = regexp.MustCompile(`^\s+method: (\S+)$`)
+)
+
+// templateRoutes reads the Api events of a function in the SAM template
+func templateRoutes(t *testing.T, file, function string) []string {
+	var keys []string
+	var current, path string
+	scanLines(t, file, func(line string) {
+		if m := templateFunction.FindStringSubmatch(line); m != nil {
+			current = m[1]
+		}
+		if current != function {
+			return
+		}
+		if m := templatePath.FindStringSubmatch(line); m != nil {
+			path = m[1]
+		}
+		if m := templateMethod.FindStringSubmatch(line); m != nil {
+			keys = append(keys, strings.ToUpper(m[1])+" "+path)
+		}
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// serverlessRoutes reads the httpApi events of a Serverless Framework service
+func serverlessRoutes(t *testing.T, file string) []string {
+	var keys []string
+	var path string
+	scanLines(t, file, func(line string) {
+		if m := serverlessPath.FindStringSubmatch(line); m != nil {
+			path = m[1]
+		}
+		if m := serverlessMethod.FindStringSubmatch(line); m != nil {
+			method := strings.ToUpper(strings.Trim(m[1], `'"`))
+			if method == "*" {
+				method = "ANY"
+			}
+			keys = append(keys, method+" "+path)
+		}
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+func scanLines(t *testing.T, file string, fn func(line string)) {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", file, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read %s: %v", file, err)
+	}
+}
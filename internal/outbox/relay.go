@@ -0,0 +1,93 @@
+// Package outbox publishes the domain events the repositories write to the outbox
+// table. A Relay polls the pending events and hands them to a Sink in order; an event
+// is marked published only after the sink accepted it, so every event is delivered at
+// least once and subscribers must tolerate duplicates (use Event.ID to deduplicate).
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+)
+
+// Defaults of a Relay
+const (
+	DefaultBatchSize   = 100
+	DefaultInterval    = 5 * time.Second
+	DefaultLease       = 30 * time.Minute // Covers a full batch of webhook sink timeouts
+	DefaultMaxAttempts = 720              // An hour of retries at the default interval
+)
+
+// Sink receives published events, e.g. a log, a webhook or a message queue
+type Sink interface {
+	// Publish delivers one event; an error makes the relay retry it later
+	Publish(ctx context.Context, e *event.Event) error
+}
+
+// Relay moves pending events from the outbox to a sink
+type Relay struct {
+	repo    interfaces.OutboxRepository
+	sink    Sink
+	options interfaces.DispatchOptions
+}
+
+// NewRelay creates a relay publishing the events of repo to sink. The optional
+// batchSize limits how many events one Publish pass handles (DefaultBatchSize).
+// Events are leased for DefaultLease and parked after DefaultMaxAttempts failures.
+func NewRelay(repo interfaces.OutboxRepository, sink Sink, batchSize ...int) *Relay {
+	relay := &Relay{repo: repo, sink: sink, options: interfaces.DispatchOptions{
+		Limit:       DefaultBatchSize,
+		Lease:       DefaultLease,
+		MaxAttempts: DefaultMaxAttempts,
+	}}
+	if len(batchSize) > 0 && batchSize[0] > 0 {
+		relay.options.Limit = batchSize[0]
+	}
+	return relay
+}
+
+// SetMaxAttempts changes the failed attempts after which an event is parked, 0 to
+// retry failing events forever
+func (r *Relay) SetMaxAttempts(maxAttempts int) *Relay {
+	r.options.MaxAttempts = maxAttempts
+	return r
+}
+
+// Publish publishes pending events until the outbox is empty or the sink fails,
+// and returns how many events were published. A failed event stays pending until
+// it runs out of attempts.
+func (r *Relay) Publish(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := r.repo.Dispatch(ctx, r.options, r.sink.Publish)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		// A short batch means the outbox is drained
+		if n < r.options.Limit {
+			return total, nil
+		}
+	}
+}
+
+// Run publishes pending events every interval until ctx is cancelled. Failures are
+// reported to onError, if given, and retried on the next tick.
+func (r *Relay) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Publish(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+)
+
+// memoryOutbox is an outbox of pending events kept in memory
+type memoryOutbox struct {
+	pending  []*event.Event
+	attempts map[int64]int // Failed attempts by event ID
+	parked   []*event.Event
+}
+
+func (o *memoryOutbox) Dispatch(ctx context.Context, options interfaces.DispatchOptions, publish func(ctx context.Context, e *event.Event) error) (int, error) {
+	published := 0
+	for published < options.Limit && published < len(o.pending) {
+		if err := publish(ctx, o.pending[published]); err != nil {
+			failed := o.pending[published]
+			o.pending = o.pending[published:]
+			if o.attempts == nil {
+				o.attempts = map[int64]int{}
+			}
+			o.attempts[failed.ID]++
+			if options.MaxAttempts > 0 && o.attempts[failed.ID] >= options.MaxAttempts {
+				o.parked = append(o.parked, failed)
+				o.pending = o.pending[1:]
+			}
+			return published, err
+		}
+		published++
+	}
+	o.pending = o.pending[published:]
+	return published, nil
+}
+
+// flakySink fails the first publish of the given event
+type flakySink struct {
+	MemorySink
+	failID int64
+	failed bool
+}
+
+func (s *flakySink) Publish(ctx context.Context, e *event.Event) error {
+	if e.ID == s.failID && !s.failed {
+		s.failed = true
+		return errors.New("sink unavailable")
+	}
+	return s.MemorySink.Publish(ctx, e)
+}
+
+func pendingEvents(n int) []*event.Event {
+	events := make([]*event.Event, n)
+	for i := range events {
+		events[i] = &event.Event{ID: int64(i + 1), Type: event.StarsEarned, Entity: "transaction", EntityID: i + 1}
+	}
+	return events
+}
+
+func TestRelayPublish(t *testing.T) {
+	tests := []struct {
+		name              string
+		events            int
+		batchSize         int
+		failID            int64
+		expectedFirstRun  int
+		expectedPublished int
+	}{
+		{"drains several batches", 5, 2, 0, 5, 5},
+		{"failure keeps the event pending", 5, 2, 3, 2, 5},
+		{"empty outbox", 0, 2, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryOutbox{pending: pendingEvents(tt.events)}
+			sink := &flakySink{failID: tt.failID}
+			relay := NewRelay(repo, sink, tt.batchSize)
+
+			n, err := relay.Publish(context.Background())
+			if (err != nil) != (tt.failID != 0) {
+				t.Fatalf("expected error %v, got %v", tt.failID != 0, err)
+			}
+			if n != tt.expectedFirstRun {
+				t.Errorf("expected %d events published, got %d", tt.expectedFirstRun, n)
+			}
+
+			// The next pass retries the failed event
+			if _, err := relay.Publish(context.Background()); err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			published := sink.Events()
+			if len(published) != tt.expectedPublished {
+				t.Fatalf("expected %d events published, got %d", tt.expectedPublished, len(published))
+			}
+			for i, e := range published {
+				if e.ID != int64(i+1) {
+					t.Errorf("expected event %d at position %d, got %d", i+1, i, e.ID)
+				}
+			}
+		})
+	}
+}
+
+// brokenSink always fails to publish the given event
+type brokenSink struct {
+	MemorySink
+	failID int64
+}
+
+func (s *brokenSink) Publish(ctx context.Context, e *event.Event) error {
+	if e.ID == s.failID {
+		return errors.New("payload rejected")
+	}
+	return s.MemorySink.Publish(ctx, e)
+}
+
+func TestRelayParksFailingEvent(t *testing.T) {
+	repo := &memoryOutbox{pending: pendingEvents(3)}
+	sink := &brokenSink{failID: 2}
+	relay := NewRelay(repo, sink, 10).SetMaxAttempts(3)
+
+	for i := 0; i < 3; i++ {
+		if _, err := relay.Publish(context.Background()); err == nil {
+			t.Fatalf("expected attempt %d to fail", i+1)
+		}
+	}
+	if len(repo.parked) != 1 || repo.parked[0].ID != 2 {
+		t.Fatalf("expected event 2 to be parked, got %v", repo.parked)
+	}
+
+	// Later events are no longer held up
+	if _, err := relay.Publish(context.Background()); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	published := sink.Events()
+	if len(published) != 2 || published[0].ID != 1 || published[1].ID != 3 {
+		t.Errorf("expected events 1 and 3 published, got %v", published)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		expectError bool
+	}{
+		{"accepted", http.StatusAccepted, false},
+		{"server error", http.StatusBadGateway, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var eventID, eventType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				eventID, eventType = r.Header.Get("Event-ID"), r.Header.Get("Event-Type")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewWebhookSink(server.URL).Publish(context.Background(), &event.Event{ID: 42, Type: event.KidCreated})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if eventID != "42" || eventType != "kid.created" {
+				t.Errorf("expected headers 42 and kid.created, got %q and %q", eventID, eventType)
+			}
+		})
+	}
+}
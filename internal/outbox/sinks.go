@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+)
+
+// LogSink writes every event as one JSON line, e.g. to stdout for a log pipeline
+type LogSink struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewLogSink creates a sink writing JSON lines to out
+func NewLogSink(out io.Writer) *LogSink {
+	return &LogSink{out: out}
+}
+
+// Publish writes the event as a JSON line
+func (s *LogSink) Publish(ctx context.Context, e *event.Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.out.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// DefaultWebhookTimeout bounds a webhook delivery
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookSink POSTs every event as JSON to a URL. Any 2xx response accepts the event.
+// The Event-ID and Event-Type headers let the receiver route and deduplicate events.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink posting to url. The optional client replaces the
+// default one with DefaultWebhookTimeout.
+func NewWebhookSink(url string, client ...*http.Client) *WebhookSink {
+	sink := &WebhookSink{url: url, client: &http.Client{Timeout: DefaultWebhookTimeout}}
+	if len(client) > 0 && client[0] != nil {
+		sink.client = client[0]
+	}
+	return sink
+}
+
+// Publish posts the event to the webhook URL
+func (s *WebhookSink) Publish(ctx context.Context, e *event.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Event-ID", strconv.FormatInt(e.ID, 10))
+	req.Header.Set("Event-Type", string(e.Type))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post event: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MemorySink keeps published events in memory, for tests
+type MemorySink struct {
+	mu     sync.Mutex
+	events []event.Event
+}
+
+// Publish stores a copy of the event
+func (s *MemorySink) Publish(ctx context.Context, e *event.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, *e)
+	return nil
+}
+
+// Events returns the events published so far, in publish order
+func (s *MemorySink) Events() []event.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]event.Event(nil), s.events...)
+}
+
+// Sinks publishes every event to each of its sinks in order. When one fails, the event
+// is retried on all of them, so the earlier ones may receive it twice.
+type Sinks []Sink
+
+// Publish publishes the event to every sink, stopping at the first failure
+func (s Sinks) Publish(ctx context.Context, e *event.Event) error {
+	for _, sink := range s {
+		if err := sink.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+// Package reports computes star reports of a kid over a period: the stars earned and
+// spent, a per-day series, a comparison with the period before and the descriptions
+// that recur most, e.g. the top chore. Reports are built from the kid's transactions,
+// so the API and the notification emails describe a week the same way.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// Timezones are resolved without relying on the zoneinfo of the host, e.g. in Lambda
+	_ "time/tzdata"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// Limits of the number of descriptions listed per transaction type
+const (
+	DefaultTop = 3
+	MaxTop     = 20
+)
+
+// Period is the length of a report
+type Period string
+
+const (
+	// PeriodWeek reports run from Monday to Sunday
+	PeriodWeek Period = "week"
+
+	// PeriodMonth reports cover a calendar month
+	PeriodMonth Period = "month"
+)
+
+// ParsePeriod validates a period name
+func ParsePeriod(value string) (Period, error) {
+	switch p := Period(strings.ToLower(strings.TrimSpace(value))); p {
+	case PeriodWeek, PeriodMonth:
+		return p, nil
+	default:
+		return "", fmt.Errorf("period must be either '%s' or '%s'", PeriodWeek, PeriodMonth)
+	}
+}
+
+// Range returns the start and end (exclusive) of the period containing at, in the
+// location of at
+func (p Period) Range(at time.Time) (time.Time, time.Time) {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	if p == PeriodMonth {
+		from := day.AddDate(0, 0, 1-day.Day())
+		return from, from.AddDate(0, 1, 0)
+	}
+
+	// time.Weekday starts on Sunday, weeks start on Monday
+	from := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// Totals are the stars earned and spent in a period
+type Totals struct {
+	Earned       int `json:"earned"`
+	Spent        int `json:"spent"`
+	Net          int `json:"net"`          // Earned minus spent
+	Transactions int `json:"transactions"` // Number of transactions
+}
+
+// Day is one day of a report, in its timezone
+type Day struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Earned int    `json:"earned"`
+	Spent  int    `json:"spent"`
+}
+
+// Description is a transaction description recurring in a period, e.g. a chore
+type Description struct {
+	Description string `json:"description"`
+	Count       int    `json:"count"` // Number of transactions with it
+	Stars       int    `json:"stars"` // Stars earned or spent with it
+}
+
+// Report summarizes the stars of a kid over a period
+type Report struct {
+	KidID       int       `json:"-"`                // Internal ID of the kid
+	KidPublicID string    `json:"kid_id,omitempty"` // Public ID of the kid, set by the API
+	Period      Period    `json:"period,omitempty"` // Empty for custom ranges
+	Timezone    string    `json:"timezone"`         // Location the days are counted in
+	From        time.Time `json:"from"`             // Start of the period
+	To          time.Time `json:"to"`               // End of the period (exclusive)
+	Totals
+	PreviousFrom time.Time     `json:"previous_from"` // Start of the period before, which ends at From
+	Previous     Totals        `json:"previous"`
+	Change       Totals        `json:"change"` // Totals minus Previous
+	Days         []Day         `json:"days"`   // Every day of the period, also those without transactions
+	TopEarned    []Description `json:"top_earned"`
+	TopSpent     []Description `json:"top_spent"`
+}
+
+// TopChore returns the description the kid earned stars with most often, "" when none
+func (r *Report) TopChore() string {
+	if len(r.TopEarned) == 0 {
+		return ""
+	}
+	return r.TopEarned[0].Description
+}
+
+// Build computes the report of a kid over [from, to) from its transactions. Those in
+// [previousFrom, from) make up the previous period; the others are ignored. Days are
+// counted in the location of from, and up to top descriptions are listed per type.
+func Build(kidID int, previousFrom, from, to time.Time, transactions []*transaction.Transaction, top int) *Report {
+	loc := from.Location()
+	r := &Report{
+		KidID:        kidID,
+		Timezone:     loc.String(),
+		From:         from,
+		To:           to,
+		PreviousFrom: previousFrom,
+		Days:         []Day{},
+		TopEarned:    []Description{},
+		TopSpent:     []Description{},
+	}
+
+	days := map[string]*Day{}
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		r.Days = append(r.Days, Day{Date: day.Format(time.DateOnly)})
+	}
+	for i := range r.Days {
+		days[r.Days[i].Date] = &r.Days[i]
+	}
+
+	earned, spent := &descriptions{}, &descriptions{}
+	for _, t := range transactions {
+		switch {
+		case !t.CreatedAt.Before(from) && t.CreatedAt.Before(to):
+			r.Totals.add(t)
+			day := days[t.CreatedAt.In(loc).Format(time.DateOnly)]
+			if t.Type == transaction.TransactionTypeEarn {
+				day.Earned += t.Amount
+				earned.add(t)
+			} else {
+				day.Spent += t.Amount
+				spent.add(t)
+			}
+		case !t.CreatedAt.Before(previousFrom) && t.CreatedAt.Before(from):
+			r.Previous.add(t)
+		}
+	}
+
+	r.Change = Totals{
+		Earned:       r.Earned - r.Previous.Earned,
+		Spent:        r.Spent - r.Previous.Spent,
+		Net:          r.Net - r.Previous.Net,
+		Transactions: r.Transactions - r.Previous.Transactions,
+	}
+	r.TopEarned = earned.top(top)
+	r.TopSpent = spent.top(top)
+
+	return r
+}
+
+// add counts a transaction in the totals
+func (t *Totals) add(tx *transaction.Transaction) {
+	t.Transactions++
+	if tx.Type == transaction.TransactionTypeEarn {
+		t.Earned += tx.Amount
+		t.Net += tx.Amount
+	} else {
+		t.Spent += tx.Amount
+		t.Net -= tx.Amount
+	}
+}
+
+// descriptions groups transactions by description, ignoring case and surrounding
+// spaces; the first spelling seen is reported
+type descriptions struct {
+	byKey map[string]*Description
+	order []*Description
+}
+
+// add counts a transaction under its description
+func (d *descriptions) add(t *transaction.Transaction) {
+	if d.byKey == nil {
+		d.byKey = map[string]*Description{}
+	}
+	key := strings.ToLower(strings.TrimSpace(t.Description))
+	description, ok := d.byKey[key]
+	if !ok {
+		description = &Description{Description: strings.TrimSpace(t.Description)}
+		d.byKey[key] = description
+		d.order = append(d.order, description)
+	}
+	description.Count++
+	description.Stars += t.Amount
+}
+
+// top returns up to n descriptions, the most frequent first, then the most stars
+func (d *descriptions) top(n int) []Description {
+	sorted := append([]*Description(nil), d.order...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Stars > sorted[j].Stars
+	})
+
+	top := []Description{}
+	for i := 0; i < len(sorted) && i < n; i++ {
+		top = append(top, *sorted[i])
+	}
+	return top
+}
+
+// Source reads the transactions reports are built from; interfaces.TransactionRepository
+// implements it
+type Source interface {
+	Find(ctx context.Context, filter interfaces.TransactionFilter) ([]*transaction.Transaction, error)
+}
+
+// Reporter builds reports from the transactions of a Source
+type Reporter struct {
+	source Source
+}
+
+// NewReporter creates a reporter reading transactions from source
+func NewReporter(source Source) *Reporter {
+	return &Reporter{source: source}
+}
+
+// Period returns the report of the week or month containing at, compared to the one
+// before. Days are counted in the location of at.
+func (r *Reporter) Period(ctx context.Context, kidID int, period Period, at time.Time, top int) (*Report, error) {
+	from, to := period.Range(at)
+	previousFrom, _ := period.Range(from.AddDate(0, 0, -1))
+
+	report, err := r.build(ctx, kidID, previousFrom, from, to, top)
+	if err != nil {
+		return nil, err
+	}
+	report.Period = period
+	return report, nil
+}
+
+// Range returns the report of [from, to), compared to as long a period before from.
+// Days are counted in the location of from.
+func (r *Reporter) Range(ctx context.Context, kidID int, from, to time.Time, top int) (*Report, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	return r.build(ctx, kidID, from.Add(-to.Sub(from)), from, to, top)
+}
+
+// build reads the transactions of both periods and builds the report
+func (r *Reporter) build(ctx context.Context, kidID int, previousFrom, from, to time.Time, top int) (*Report, error) {
+	transactions, err := r.source.Find(ctx, interfaces.TransactionFilter{
+		KidID: kidID,
+		From:  &previousFrom,
+		To:    &to,
+		Sort:  "created_at,id",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions for report: %w", err)
+	}
+
+	return Build(kidID, previousFrom, from, to, transactions, top), nil
+}
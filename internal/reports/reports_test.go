@@ -0,0 +1,190 @@
+package reports
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// memorySource returns its transactions within the filter's range
+type memorySource struct {
+	transactions []*transaction.Transaction
+	filter       interfaces.TransactionFilter
+}
+
+func (s *memorySource) Find(ctx context.Context, filter interfaces.TransactionFilter) ([]*transaction.Transaction, error) {
+	s.filter = filter
+	var found []*transaction.Transaction
+	for _, t := range s.transactions {
+		if !t.CreatedAt.Before(*filter.From) && t.CreatedAt.Before(*filter.To) {
+			found = append(found, t)
+		}
+	}
+	return found, nil
+}
+
+func TestPeriodRange(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		period       Period
+		at           time.Time
+		expectedFrom string
+		expectedTo   string
+	}{
+		{"week from wednesday", PeriodWeek, time.Date(2026, 3, 11, 15, 0, 0, 0, time.UTC), "2026-03-09", "2026-03-16"},
+		{"week from monday", PeriodWeek, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), "2026-03-09", "2026-03-16"},
+		{"week from sunday", PeriodWeek, time.Date(2026, 3, 15, 23, 59, 0, 0, time.UTC), "2026-03-09", "2026-03-16"},
+		{"month", PeriodMonth, time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC), "2026-02-01", "2026-03-01"},
+		{"month across a year", PeriodMonth, time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC), "2025-12-01", "2026-01-01"},
+		{"week across daylight saving time", PeriodWeek, time.Date(2026, 3, 29, 12, 0, 0, 0, warsaw), "2026-03-23", "2026-03-30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := tt.period.Range(tt.at)
+			if got := from.Format(time.DateOnly); got != tt.expectedFrom || from.Hour() != 0 {
+				t.Errorf("expected from %s at midnight, got %s", tt.expectedFrom, from)
+			}
+			if got := to.Format(time.DateOnly); got != tt.expectedTo || to.Hour() != 0 {
+				t.Errorf("expected to %s at midnight, got %s", tt.expectedTo, to)
+			}
+		})
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      Period
+		expectedError bool
+	}{
+		{"week", PeriodWeek, false},
+		{" Month ", PeriodMonth, false},
+		{"year", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePeriod(tt.value)
+		if (err != nil) != tt.expectedError {
+			t.Errorf("ParsePeriod(%q): expected error %v, got %v", tt.value, tt.expectedError, err)
+		}
+		if got != tt.expected {
+			t.Errorf("ParsePeriod(%q): expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestReporterPeriod(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+
+	source := &memorySource{transactions: []*transaction.Transaction{
+		// Week before: Monday 2 to Sunday 8 March
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 4, Description: "Homework", CreatedAt: at(3, 12)},
+		// Monday 9 March, 02:00 UTC is still Sunday evening in New York
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 3, Description: "Dishes", CreatedAt: at(9, 2)},
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 3, Description: "Dishes", CreatedAt: at(9, 18)},
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 10, Description: "Homework", CreatedAt: at(10, 18)},
+		{KidID: 1, Type: transaction.TransactionTypeEarn, Amount: 2, Description: " dishes", CreatedAt: at(11, 18)},
+		{KidID: 1, Type: transaction.TransactionTypeSpend, Amount: 6, Description: "Toy", CreatedAt: at(13, 18)},
+	}}
+
+	tests := []struct {
+		name              string
+		at                time.Time
+		top               int
+		expectedTotals    Totals
+		expectedPrevious  Totals
+		expectedFirstDay  Day
+		expectedTopEarned []Description
+	}{
+		{
+			name:              "week in UTC",
+			at:                at(12, 9),
+			top:               DefaultTop,
+			expectedTotals:    Totals{Earned: 18, Spent: 6, Net: 12, Transactions: 5},
+			expectedPrevious:  Totals{Earned: 4, Net: 4, Transactions: 1},
+			expectedFirstDay:  Day{Date: "2026-03-09", Earned: 6},
+			expectedTopEarned: []Description{{"Dishes", 3, 8}, {"Homework", 1, 10}},
+		},
+		{
+			name:              "week in New York",
+			at:                at(12, 9).In(newYork),
+			top:               1,
+			expectedTotals:    Totals{Earned: 15, Spent: 6, Net: 9, Transactions: 4},
+			expectedPrevious:  Totals{Earned: 7, Net: 7, Transactions: 2},
+			expectedFirstDay:  Day{Date: "2026-03-09", Earned: 3},
+			expectedTopEarned: []Description{{"Dishes", 2, 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := NewReporter(source).Period(context.Background(), 1, PeriodWeek, tt.at, tt.top)
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			if source.filter.KidID != 1 || !source.filter.From.Equal(report.PreviousFrom) || !source.filter.To.Equal(report.To) {
+				t.Errorf("expected transactions of kid 1 from %s to %s, got %+v", report.PreviousFrom, report.To, source.filter)
+			}
+			if report.Totals != tt.expectedTotals {
+				t.Errorf("expected totals %+v, got %+v", tt.expectedTotals, report.Totals)
+			}
+			if report.Previous != tt.expectedPrevious {
+				t.Errorf("expected previous totals %+v, got %+v", tt.expectedPrevious, report.Previous)
+			}
+			if expected := tt.expectedTotals.Earned - tt.expectedPrevious.Earned; report.Change.Earned != expected {
+				t.Errorf("expected earned change %d, got %d", expected, report.Change.Earned)
+			}
+			if len(report.Days) != 7 || report.Days[0] != tt.expectedFirstDay {
+				t.Errorf("expected 7 days starting with %+v, got %+v", tt.expectedFirstDay, report.Days)
+			}
+			if len(report.TopEarned) != len(tt.expectedTopEarned) {
+				t.Fatalf("expected top earned %+v, got %+v", tt.expectedTopEarned, report.TopEarned)
+			}
+			for i, expected := range tt.expectedTopEarned {
+				if report.TopEarned[i] != expected {
+					t.Errorf("expected top earned %+v, got %+v", tt.expectedTopEarned, report.TopEarned)
+				}
+			}
+			if report.TopChore() != "Dishes" {
+				t.Errorf("expected top chore Dishes, got %q", report.TopChore())
+			}
+			if report.Period != PeriodWeek || report.Timezone != tt.at.Location().String() {
+				t.Errorf("expected a week in %s, got %s in %s", tt.at.Location(), report.Period, report.Timezone)
+			}
+		})
+	}
+}
+
+func TestReporterRange(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := NewReporter(&memorySource{}).Range(context.Background(), 1, from, from, DefaultTop); err == nil {
+		t.Errorf("expected an error for an empty range")
+	}
+
+	report, err := NewReporter(&memorySource{}).Range(context.Background(), 1, from, from.AddDate(0, 0, 3), DefaultTop)
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if expected := from.AddDate(0, 0, -3); !report.PreviousFrom.Equal(expected) {
+		t.Errorf("expected previous period from %s, got %s", expected, report.PreviousFrom)
+	}
+	if len(report.Days) != 3 || report.TopChore() != "" || len(report.TopSpent) != 0 {
+		t.Errorf("expected 3 empty days and no descriptions, got %+v", report)
+	}
+}
@@ -0,0 +1,222 @@
+// Package schema derives JSON Schemas from Go types.
+// Property types come from the Go field types and json tags, and constraints
+// from the validate tags used by the models, so the documented contract stays
+// in sync with the code.
+package schema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema (draft 2020-12, as used by OpenAPI 3.1)
+// needed to describe the API payloads.
+type Schema struct {
+	Type                 any                `json:"type,omitempty"` // Type name, or a list of names for nullable values
+	Format               string             `json:"format,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// Extender is implemented by types whose JSON form differs from their fields,
+// such as models with a custom MarshalJSON adding computed members.
+type Extender interface {
+	ExtendJSONSchema(s *Schema)
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	extenderType = reflect.TypeOf((*Extender)(nil)).Elem()
+)
+
+// Generate returns the schema of the JSON form of v. Fields also take the
+// constraints of the field with the same JSON name in the given models, so
+// request types inherit the rules of the models they build, while their own
+// validate tags add to them. Required members are only taken from v itself.
+func Generate(v any, models ...any) *Schema {
+	rules := make(map[string]string)
+	for _, model := range models {
+		t := reflect.TypeOf(model)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		for _, field := range fields(t) {
+			if tag := field.Tag.Get("validate"); tag != "" {
+				rules[jsonName(field)] = tag
+			}
+		}
+	}
+
+	return generate(reflect.TypeOf(v), rules)
+}
+
+// Nullable returns a copy of an object schema without required members whose
+// properties also accept null, describing a JSON Merge Patch of the object
+func Nullable(s *Schema) *Schema {
+	patch := *s
+	patch.Required = nil
+	patch.Properties = make(map[string]*Schema, len(s.Properties))
+	for name, property := range s.Properties {
+		nullable := *property
+		if typeName, ok := property.Type.(string); ok {
+			nullable.Type = []string{typeName, "null"}
+		}
+		if nullable.Enum != nil {
+			nullable.Enum = append(append([]any{}, property.Enum...), nil)
+		}
+		patch.Properties[name] = &nullable
+	}
+	return &patch
+}
+
+// Types returns the type names allowed by the schema
+func (s *Schema) Types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+// generate builds the schema of a type, applying the rules of its top-level fields
+func generate(t reflect.Type, rules map[string]string) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.String:
+		s = &Schema{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s = &Schema{Type: "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s = &Schema{Type: "number"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		s = &Schema{Type: "array", Items: generate(t.Elem(), nil)}
+	case t.Kind() == reflect.Map:
+		s = &Schema{Type: "object"}
+	case t.Kind() == reflect.Struct:
+		// Payloads are closed: members the type does not declare are rejected
+		closed := false
+		s = &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: &closed}
+		for _, field := range fields(t) {
+			name := jsonName(field)
+			property := generate(field.Type, nil)
+
+			tag := field.Tag.Get("validate")
+			if isRequired(tag) {
+				s.Required = append(s.Required, name)
+			}
+			applyRules(property, rules[name])
+			applyRules(property, tag)
+
+			s.Properties[name] = property
+		}
+	default:
+		s = &Schema{}
+	}
+
+	if reflect.PointerTo(t).Implements(extenderType) {
+		reflect.New(t).Interface().(Extender).ExtendJSONSchema(s)
+	}
+
+	return s
+}
+
+// fields returns the exported struct fields that appear in the JSON form of t,
+// including the fields of embedded structs
+func fields(t reflect.Type) []reflect.StructField {
+	var result []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			result = append(result, fields(field.Type)...)
+			continue
+		}
+		result = append(result, field)
+	}
+	return result
+}
+
+// jsonName returns the name of a struct field in JSON
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// isRequired reports whether a validate tag marks the field as required
+func isRequired(tag string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRules translates validate tag rules into schema constraints
+func applyRules(s *Schema, tag string) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			switch {
+			case s.Type == "string" && name == "min":
+				s.MinLength = &n
+			case s.Type == "string":
+				s.MaxLength = &n
+			case s.Type == "array" && name == "min":
+				s.MinItems = &n
+			case s.Type == "array":
+				s.MaxItems = &n
+			case name == "min":
+				s.Minimum = &n
+			default:
+				s.Maximum = &n
+			}
+		case "oneof":
+			s.Enum = nil
+			for _, value := range strings.Fields(param) {
+				s.Enum = append(s.Enum, value)
+			}
+		case "email":
+			s.Format = "email"
+		case "uuid":
+			s.Format = "uuid"
+		case "datetime":
+			if param == "2006-01-02" {
+				s.Format = "date"
+			} else {
+				s.Format = "date-time"
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testModel struct {
+	Name      string    `json:"name" validate:"required,min=2,max=5"`
+	Kind      string    `json:"kind" validate:"required,oneof=earn spend"`
+	Amount    int       `json:"amount" validate:"required,min=1,max=100"`
+	Email     string    `json:"email" validate:"required,email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testRequest struct {
+	Name   string   `json:"name,omitempty" validate:"required"`
+	Kind   string   `json:"kind,omitempty"`
+	Amount int      `json:"amount,omitempty"`
+	Email  string   `json:"email,omitempty"`
+	Date   string   `json:"date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	KidID  string   `json:"kid_id,omitempty" validate:"omitempty,uuid"`
+	Tags   []string `json:"tags,omitempty" validate:"max=2"`
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate(testRequest{}, testModel{})
+
+	if !reflect.DeepEqual(s.Required, []string{"name"}) {
+		t.Errorf("expected only the request's own required members, got %v", s.Required)
+	}
+	if s.AdditionalProperties == nil || *s.AdditionalProperties {
+		t.Error("expected object schema to reject unknown members")
+	}
+
+	name := s.Properties["name"]
+	if name.Type != "string" || name.MinLength == nil || *name.MinLength != 2 || name.MaxLength == nil || *name.MaxLength != 5 {
+		t.Errorf("expected string of length 2..5 from the model, got %+v", name)
+	}
+	if amount := s.Properties["amount"]; amount.Type != "integer" || *amount.Minimum != 1 || *amount.Maximum != 100 {
+		t.Errorf("expected integer 1..100 from the model, got %+v", amount)
+	}
+	if kind := s.Properties["kind"]; !reflect.DeepEqual(kind.Enum, []any{"earn", "spend"}) {
+		t.Errorf("expected enum [earn spend], got %v", kind.Enum)
+	}
+	if email := s.Properties["email"]; email.Format != "email" {
+		t.Errorf("expected email format, got %q", email.Format)
+	}
+	if date := s.Properties["date"]; date.Format != "date" {
+		t.Errorf("expected date format, got %q", date.Format)
+	}
+	if tags := s.Properties["tags"]; tags.Type != "array" || tags.Items.Type != "string" || tags.MaxItems == nil || *tags.MaxItems != 2 {
+		t.Errorf("expected array of at most 2 strings, got %+v", tags)
+	}
+	if created := Generate(testModel{}).Properties["created_at"]; created.Format != "date-time" {
+		t.Errorf("expected time.Time to be a date-time string, got %+v", created)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := Generate(testRequest{}, testModel{})
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []FieldError
+	}{
+		{"valid", `{"name":"Alice","kind":"earn","amount":5,"email":"a@example.com","date":"2020-01-31","tags":["a"]}`, nil},
+		{"only required", `{"name":"Bob"}`, nil},
+		{"malformed", `{"name":`, []FieldError{{Message: "must be valid JSON"}}},
+		{"trailing data", `{"name":"Bob"} {}`, []FieldError{{Message: "must contain a single JSON value"}}},
+		{"not an object", `["Bob"]`, []FieldError{{Message: "must be an object"}}},
+		{"missing required", `{}`, []FieldError{{Field: "name", Message: "is required"}}},
+		{"unknown member", `{"name":"Bob","amout":5}`, []FieldError{{Field: "amout", Message: "is not a known field"}}},
+		{"string for integer", `{"name":"Bob","amount":"5"}`, []FieldError{{Field: "amount", Message: "must be an integer"}}},
+		{"fraction for integer", `{"name":"Bob","amount":1.5}`, []FieldError{{Field: "amount", Message: "must be an integer"}}},
+		{"below minimum", `{"name":"Bob","amount":0}`, []FieldError{{Field: "amount", Message: "must be at least 1"}}},
+		{"above maximum", `{"name":"Bob","amount":101}`, []FieldError{{Field: "amount", Message: "must be at most 100"}}},
+		{"too short", `{"name":"B"}`, []FieldError{{Field: "name", Message: "must be at least 2 characters long"}}},
+		{"too long", `{"name":"Bobbyy"}`, []FieldError{{Field: "name", Message: "must be at most 5 characters long"}}},
+		{"not in enum", `{"name":"Bob","kind":"steal"}`, []FieldError{{Field: "kind", Message: "must be one of: earn, spend"}}},
+		{"bad email", `{"name":"Bob","email":"bob"}`, []FieldError{{Field: "email", Message: "must be a valid email address"}}},
+		{"bad date", `{"name":"Bob","date":"31/01/2020"}`, []FieldError{{Field: "date", Message: "must be a date in YYYY-MM-DD format"}}},
+		{"uuid", `{"name":"Bob","kid_id":"6F1C2B7E-3D4A-4E5F-8A9B-0C1D2E3F4A5B"}`, nil},
+		{"bad uuid", `{"name":"Bob","kid_id":"1"}`, []FieldError{{Field: "kid_id", Message: "must be a UUID"}}},
+		{"null member", `{"name":null}`, []FieldError{{Field: "name", Message: "must not be null"}}},
+		{"bad array item", `{"name":"Bob","tags":["a",1]}`, []FieldError{{Field: "tags[1]", Message: "must be a string"}}},
+		{"too many items", `{"name":"Bob","tags":["a","b","c"]}`, []FieldError{{Field: "tags", Message: "must contain at most 2 items"}}},
+		{"several errors", `{"amount":"5","kind":"x"}`, []FieldError{
+			{Field: "name", Message: "is required"},
+			{Field: "amount", Message: "must be an integer"},
+			{Field: "kind", Message: "must be one of: earn, spend"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := s.Validate([]byte(tt.body)); !reflect.DeepEqual(errs, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, errs)
+			}
+		})
+	}
+}
+
+func TestNullable(t *testing.T) {
+	s := Nullable(Generate(testRequest{}, testModel{}))
+
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{"empty patch", `{}`, true},
+		{"remove member", `{"kind":null}`, true},
+		{"replace member", `{"kind":"spend"}`, true},
+		{"constraints still apply", `{"kind":"steal"}`, false},
+		{"unknown member", `{"amout":null}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := s.Validate([]byte(tt.body))
+			if (len(errs) == 0) != tt.valid {
+				t.Errorf("expected valid %v, got errors %v", tt.valid, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes why the value of one member of a JSON document is invalid
+type FieldError struct {
+	Field   string `json:"field"`   // Path of the member, e.g. name or items[0].amount; empty for the document itself
+	Message string `json:"message"` // Human-readable reason
+}
+
+// Error returns the field and the reason
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + " " + e.Message
+}
+
+// Validate checks a JSON document against the schema and returns every
+// violation found, or nil when the document is valid. Malformed JSON is
+// reported as a single error for the whole document.
+func (s *Schema) Validate(data []byte) []FieldError {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []FieldError{{Message: "must be valid JSON"}}
+	}
+	if decoder.More() {
+		return []FieldError{{Message: "must contain a single JSON value"}}
+	}
+
+	return s.validate("", value, nil)
+}
+
+// validate checks a decoded value and appends the violations to errs
+func (s *Schema) validate(field string, value any, errs []FieldError) []FieldError {
+	fail := func(format string, args ...any) []FieldError {
+		return append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		if s.allows("null") || len(s.Types()) == 0 {
+			return errs
+		}
+		return fail("must not be null")
+	}
+
+	if types := s.Types(); len(types) > 0 && !s.allows(typeOf(value)) {
+		name := types[0]
+		if name == "integer" || name == "array" || name == "object" {
+			return fail("must be an %s", name)
+		}
+		return fail("must be a %s", name)
+	}
+
+	if len(s.Enum) > 0 && !contains(s.Enum, value) {
+		var options []string
+		for _, option := range s.Enum {
+			if option != nil {
+				options = append(options, fmt.Sprint(option))
+			}
+		}
+		return fail("must be one of: %s", strings.Join(options, ", "))
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			return fail("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fail("must be at most %d characters long", *s.MaxLength)
+		}
+		if message := checkFormat(s.Format, v); message != "" {
+			return fail("%s", message)
+		}
+
+	case json.Number:
+		n, err := v.Float64()
+		if err != nil {
+			return fail("must be a number")
+		}
+		if s.Minimum != nil && n < float64(*s.Minimum) {
+			return fail("must be at least %d", *s.Minimum)
+		}
+		if s.Maximum != nil && n > float64(*s.Maximum) {
+			return fail("must be at most %d", *s.Maximum)
+		}
+
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fail("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fail("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				errs = s.Items.validate(fmt.Sprintf("%s[%d]", field, i), item, errs)
+			}
+		}
+
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, FieldError{Field: join(field, name), Message: "is required"})
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					errs = append(errs, FieldError{Field: join(field, name), Message: "is not a known field"})
+				}
+				continue
+			}
+			errs = property.validate(join(field, name), v[name], errs)
+		}
+	}
+
+	return errs
+}
+
+// allows reports whether the schema accepts values of the named JSON type
+func (s *Schema) allows(typeName string) bool {
+	for _, t := range s.Types() {
+		if t == typeName || (t == "number" && typeName == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type name of a decoded value
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "null"
+}
+
+// contains reports whether the enum lists the value
+func contains(enum []any, value any) bool {
+	for _, option := range enum {
+		if option != nil && fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFormat returns why a string does not match a format, or "" when it does
+func checkFormat(format, value string) string {
+	switch format {
+	case "email":
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			return "must be a valid email address"
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 timestamp"
+		}
+	case "uuid":
+		if !IsUUID(value) {
+			return "must be a UUID"
+		}
+	}
+	return ""
+}
+
+// IsUUID reports whether s is a UUID in its canonical 8-4-4-4-12 hex form
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i, c := range s {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// join appends a member name to a field path
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
@@ -0,0 +1,151 @@
+// Package audit implements the Audit Service handlers.
+// The service answers who changed what and when: it lists the audit events the
+// database records for every change to a kid, caregiver or transaction.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+	"github.com/lukasz/astras-mono-api/internal/openapi"
+)
+
+// ServiceName identifies the Audit Service in responses and logs
+const ServiceName = "audit-service"
+
+// Limits of the number of events returned by one request
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Routes lists the API Gateway routes served by the Audit Service (see template.yaml)
+var Routes = []handler.Route{
+	{
+		Method:  http.MethodGet,
+		Path:    "/audit-events",
+		Summary: "List audit events, newest first",
+		Params: []handler.Param{
+			handler.QueryParam("entity", "string", "Only changes to this kind of record (kid, caregiver or transaction)"),
+			handler.UUIDQueryParam("entity_id", "Only changes to the record with this public ID, requires entity"),
+			handler.QueryParam("actor", "string", "Only changes made by this authenticated actor"),
+			handler.QueryParam("from", "string", "Only changes made at or after this RFC 3339 timestamp or YYYY-MM-DD date"),
+			handler.QueryParam("to", "string", "Only changes made before this RFC 3339 timestamp, or on or before this YYYY-MM-DD date"),
+			handler.QueryParam("limit", "integer", fmt.Sprintf("Maximum number of events (default %d, at most %d)", DefaultLimit, MaxLimit)),
+		},
+		Response: handler.ResponseSchema([]audit.Event{}),
+	},
+	openapi.SpecRoute,
+}
+
+// AuditHandler serves the audit log
+type AuditHandler struct {
+	repo interfaces.AuditRepository
+}
+
+// NewAuditHandler creates a new audit handler with database repository
+func NewAuditHandler(repo interfaces.AuditRepository) *AuditHandler {
+	return &AuditHandler{
+		repo: repo,
+	}
+}
+
+// List retrieves the audit events matching the query string
+func (h *AuditHandler) List(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	filter, err := parseAuditFilter(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	events, err := h.repo.Find(ctx, filter)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get audit events: %w", err)
+	}
+
+	eventList := make([]audit.Event, len(events))
+	for i, e := range events {
+		eventList[i] = *e
+	}
+
+	return handler.Response{
+		Message: "Audit events retrieved successfully",
+		Service: ServiceName,
+		Data:    eventList,
+	}, nil
+}
+
+// parseAuditFilter builds a repository filter from the list endpoint query string
+func parseAuditFilter(request handler.HTTPRequest) (interfaces.AuditFilter, error) {
+	filter := interfaces.AuditFilter{
+		Entity: handler.QueryString(request, "entity"),
+		Actor:  handler.QueryString(request, "actor"),
+		Limit:  DefaultLimit,
+	}
+
+	if filter.Entity != "" {
+		if err := audit.ValidateEntity(filter.Entity); err != nil {
+			return interfaces.AuditFilter{}, err
+		}
+	}
+
+	entityID, err := handler.QueryUUID(request, "entity_id")
+	if err != nil {
+		return interfaces.AuditFilter{}, err
+	}
+	if entityID != "" {
+		if filter.Entity == "" {
+			return interfaces.AuditFilter{}, fmt.Errorf("entity_id requires entity")
+		}
+		filter.EntityID = entityID
+	}
+
+	limit, err := handler.QueryInt(request, "limit")
+	if err != nil {
+		return interfaces.AuditFilter{}, err
+	}
+	if limit != nil {
+		if *limit <= 0 || *limit > MaxLimit {
+			return interfaces.AuditFilter{}, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		filter.Limit = *limit
+	}
+
+	filter.From, filter.To, err = handler.QueryDateRange(request)
+	if err != nil {
+		return interfaces.AuditFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// Handle is the main entry point for audit service requests
+func (h *AuditHandler) Handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	return handler.ServeVersion(ctx, request, Routes, handler.DefaultSchedule, h.handle)
+}
+
+// handle serves a request in the API version resolved by Handle
+func (h *AuditHandler) handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	// Serve the API contract generated from the routes
+	if request.Path == openapi.Path {
+		return openapi.Serve(ServiceName, Routes, request.Version)
+	}
+
+	if err := handler.ValidateRequest(request, Routes); err != nil {
+		return handler.ErrorResponse(err), nil
+	}
+
+	// The audit log is written by the database only
+	if request.HTTPMethod != http.MethodGet {
+		return handler.ErrorResponse(handler.NewError(http.StatusMethodNotAllowed, "the audit log is read-only")), nil
+	}
+
+	response, err := h.List(ctx, request)
+	if err != nil {
+		return handler.ErrorResponse(err), nil
+	}
+	return handler.Respond(http.StatusOK, response), nil
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/audit"
+)
+
+const kidPublicID = "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"
+
+// auditRepository records the filter of the last Find
+type auditRepository struct {
+	filter *interfaces.AuditFilter
+}
+
+func (r *auditRepository) Find(ctx context.Context, filter interfaces.AuditFilter) ([]*audit.Event, error) {
+	r.filter = &filter
+	publicID := kidPublicID
+	return []*audit.Event{{ID: 1, Actor: "user-42", Action: audit.ActionUpdate, Entity: audit.EntityKid, EntityID: 3, PublicID: &publicID}}, nil
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          map[string]string
+		expectedStatus int
+		expectedFilter interfaces.AuditFilter
+	}{
+		{"default limit", map[string]string{}, http.StatusOK, interfaces.AuditFilter{Limit: DefaultLimit}},
+		{"history of a record", map[string]string{"entity": "kid", "entity_id": kidPublicID, "actor": "user-42", "limit": "10"}, http.StatusOK,
+			interfaces.AuditFilter{Entity: "kid", EntityID: kidPublicID, Actor: "user-42", Limit: 10}},
+		{"unknown entity", map[string]string{"entity": "family"}, http.StatusBadRequest, interfaces.AuditFilter{}},
+		{"sequential entity_id", map[string]string{"entity": "kid", "entity_id": "3"}, http.StatusBadRequest, interfaces.AuditFilter{}},
+		{"entity_id without entity", map[string]string{"entity_id": kidPublicID}, http.StatusBadRequest, interfaces.AuditFilter{}},
+		{"limit too large", map[string]string{"limit": "5000"}, http.StatusBadRequest, interfaces.AuditFilter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &auditRepository{}
+			response, err := NewAuditHandler(repo).Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod:            http.MethodGet,
+				Path:                  "/v2/audit-events",
+				QueryStringParameters: tt.query,
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				if repo.filter != nil {
+					t.Errorf("expected no query for a rejected request")
+				}
+				return
+			}
+			if repo.filter == nil || *repo.filter != tt.expectedFilter {
+				t.Errorf("expected filter %+v, got %+v", tt.expectedFilter, repo.filter)
+			}
+
+			var body struct {
+				Data []map[string]any `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(body.Data) != 1 || body.Data[0]["entity_id"] != kidPublicID {
+				t.Errorf("expected entity_id %s, got %v", kidPublicID, body.Data)
+			}
+		})
+	}
+}
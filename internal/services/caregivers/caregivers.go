@@ -0,0 +1,672 @@
+// Package caregivers implements the Caregiver Service handlers.
+// The service manages caregivers/guardians in the Astras system, providing
+// full CRUD operations and validation endpoints through a RESTful API interface.
+package caregivers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/notification"
+	"github.com/lukasz/astras-mono-api/internal/openapi"
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+// ServiceName identifies the Caregiver Service in responses and logs
+const ServiceName = "caregiver-service"
+
+var (
+	caregiverRequestSchema = schema.Generate(CaregiverRequest{}, caregiver.Caregiver{})
+	caregiverIDParam       = handler.UUIDPathParam("id", "Caregiver ID")
+
+	// restoreResource is the route template of the restore endpoint
+	restoreResource = "/caregivers/{id}/restore"
+
+	// notificationsResource is the route template of the notification preferences
+	notificationsResource = "/caregivers/{id}/notifications"
+)
+
+// Routes lists the API Gateway routes served by the Caregiver Service (see template.yaml)
+var Routes = []handler.Route{
+	{
+		Method:  http.MethodGet,
+		Path:    "/caregivers",
+		Summary: "List caregivers",
+		Params: []handler.Param{
+			handler.QueryParam("relationship", "string", "Only caregivers with this relationship"),
+			handler.QueryParam("email", "string", "Only the caregiver with this email address"),
+			handler.QueryParam("deleted", "boolean", "List soft-deleted caregivers instead, e.g. to restore one"),
+			handler.QueryParam("sort", "string", "Comma-separated sort fields (id, name, email, relationship, created_at, updated_at), prefix with - for descending"),
+		},
+		Response: handler.ResponseSchema([]caregiver.Caregiver{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/caregivers",
+		Summary:  "Create a caregiver; it stays invited until it accepts an invitation to a family",
+		Body:     caregiverRequestSchema,
+		Status:   http.StatusCreated,
+		Response: handler.ResponseSchema(caregiver.Caregiver{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/caregivers/{id}",
+		Summary:  "Get a caregiver",
+		Params:   []handler.Param{caregiverIDParam, handler.IfNoneMatchParam},
+		Response: handler.ResponseSchema(caregiver.Caregiver{}),
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/caregivers/{id}",
+		Summary:  "Replace a caregiver",
+		Params:   []handler.Param{caregiverIDParam, handler.IfMatchParam},
+		Body:     caregiverRequestSchema,
+		Response: handler.ResponseSchema(caregiver.Caregiver{}),
+	},
+	{
+		Method:   http.MethodPatch,
+		Path:     "/caregivers/{id}",
+		Summary:  "Partially update a caregiver with a JSON Merge Patch",
+		Params:   []handler.Param{caregiverIDParam, handler.IfMatchParam},
+		Body:     schema.Nullable(caregiverRequestSchema),
+		Response: handler.ResponseSchema(caregiver.Caregiver{}),
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     "/caregivers/{id}",
+		Summary:  "Delete a caregiver, keeping it restorable until it is purged",
+		Params:   []handler.Param{caregiverIDParam, handler.IfMatchParam},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     restoreResource,
+		Summary:  "Restore a deleted caregiver",
+		Params:   []handler.Param{caregiverIDParam},
+		Response: handler.ResponseSchema(caregiver.Caregiver{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     notificationsResource,
+		Summary:  "Get the emails a caregiver opted in to",
+		Params:   []handler.Param{caregiverIDParam},
+		Response: handler.ResponseSchema(notification.Preferences{}),
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     notificationsResource,
+		Summary:  "Choose the emails a caregiver receives",
+		Params:   []handler.Param{caregiverIDParam},
+		Body:     schema.Generate(NotificationsRequest{}),
+		Response: handler.ResponseSchema(notification.Preferences{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/validate/email",
+		Summary:  "Validate an email address",
+		Body:     schema.Generate(ValidationRequest{}),
+		Response: handler.ResponseSchema(ValidationResponse{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/validate/relationship",
+		Summary:  "Validate a relationship type",
+		Body:     schema.Generate(ValidationRequest{}),
+		Response: handler.ResponseSchema(ValidationResponse{}),
+	},
+	openapi.SpecRoute,
+}
+
+// CaregiverRequest represents the payload for creating or updating a caregiver.
+// Used for parsing JSON requests in POST, PUT and PATCH operations.
+type CaregiverRequest struct {
+	Name         string `json:"name,omitempty" validate:"required"`         // Caregiver's name
+	Email        string `json:"email,omitempty" validate:"required"`        // Contact email address
+	Relationship string `json:"relationship,omitempty" validate:"required"` // Relationship to child
+}
+
+// NotificationsRequest represents the payload choosing the emails a caregiver receives.
+// Omitted kinds are not sent.
+type NotificationsRequest struct {
+	WeeklySummary       bool `json:"weekly_summary"`                  // Weekly summary of every kid's stars
+	PendingApprovals    bool `json:"pending_approvals"`               // Reminders of deletion requests waiting for confirmation
+	LargeSpends         bool `json:"large_spends"`                    // A kid spent at least large_spend_threshold stars at once
+	LargeSpendThreshold int  `json:"large_spend_threshold,omitempty"` // Defaults to 20 stars
+	UpcomingBirthdays   bool `json:"upcoming_birthdays"`              // A kid's birthday is a week away
+}
+
+// ValidationRequest represents the payload for validation endpoints.
+// Used for validating individual fields from frontend.
+type ValidationRequest struct {
+	Email        string `json:"email,omitempty"`        // Email to validate
+	Relationship string `json:"relationship,omitempty"` // Relationship to validate
+}
+
+// ValidationResponse represents the response from validation endpoints.
+type ValidationResponse struct {
+	Valid   bool     `json:"valid"`             // Whether the value is valid
+	Message string   `json:"message,omitempty"` // Error message if invalid
+	Options []string `json:"options,omitempty"` // Valid options (for relationships)
+}
+
+// ToCaregiver converts a CaregiverRequest to a Caregiver model with generated fields.
+// Sets timestamps and can accept an optional ID for updates.
+func (cr *CaregiverRequest) ToCaregiver(id ...int) (*caregiver.Caregiver, error) {
+	caregiverModel := &caregiver.Caregiver{
+		Name:         strings.TrimSpace(cr.Name),
+		Email:        strings.TrimSpace(cr.Email),
+		Relationship: caregiver.RelationshipType(strings.TrimSpace(cr.Relationship)),
+		CreatedAt:    time.Now(),
+	}
+
+	if len(id) > 0 && id[0] > 0 {
+		caregiverModel.ID = id[0]
+		caregiverModel.UpdatedAt = time.Now()
+	}
+
+	if err := caregiverModel.Validate(); err != nil {
+		return nil, err
+	}
+
+	return caregiverModel, nil
+}
+
+// CaregiverHandler implements the handler.Handler interface for caregiver-specific operations.
+// This struct contains all the business logic for managing caregivers in the system.
+type CaregiverHandler struct {
+	repo          interfaces.CaregiverRepository
+	notifications interfaces.NotificationRepository
+}
+
+// NewCaregiverHandler creates a new caregiver handler with database repositories
+func NewCaregiverHandler(repo interfaces.CaregiverRepository, notifications interfaces.NotificationRepository) *CaregiverHandler {
+	return &CaregiverHandler{
+		repo:          repo,
+		notifications: notifications,
+	}
+}
+
+// GetAll retrieves and returns a list of caregivers in the system.
+// Supports optional filtering by ?relationship= and ?email= and ordering by ?sort=
+// (e.g. ?sort=name).
+func (h *CaregiverHandler) GetAll(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	filter, err := parseCaregiverFilter(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	caregivers, err := h.repo.Find(ctx, filter)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get all caregivers: %w", err)
+	}
+
+	// Convert from []*caregiver.Caregiver to []caregiver.Caregiver for JSON response
+	caregiverList := make([]caregiver.Caregiver, len(caregivers))
+	for i, c := range caregivers {
+		caregiverList[i] = *c
+	}
+
+	return handler.Response{
+		Message: "Caregivers retrieved successfully",
+		Service: "caregiver-service",
+		Data:    caregiverList,
+	}, nil
+}
+
+// parseCaregiverFilter builds a repository filter from the list endpoint query string
+func parseCaregiverFilter(request handler.HTTPRequest) (interfaces.CaregiverFilter, error) {
+	filter := interfaces.CaregiverFilter{
+		Email: handler.QueryString(request, "email"),
+		Sort:  handler.QueryString(request, "sort"),
+	}
+
+	if relationship := handler.QueryString(request, "relationship"); relationship != "" {
+		if err := caregiver.ValidateRelationship(relationship); err != nil {
+			return interfaces.CaregiverFilter{}, err
+		}
+		filter.Relationship = caregiver.RelationshipType(strings.ToLower(relationship))
+	}
+
+	deleted, err := handler.QueryBool(request, "deleted")
+	if err != nil {
+		return interfaces.CaregiverFilter{}, err
+	}
+	filter.Deleted = deleted
+
+	return filter, nil
+}
+
+// GetByID retrieves a specific caregiver by their unique identifier.
+// Extracts the caregiver ID from the URL path parameters and queries the database.
+func (h *CaregiverHandler) GetByID(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.caregiverID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	caregiverModel, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get caregiver: %w", err)
+	}
+
+	etag := handler.ETag(caregiverModel.UpdatedAt)
+	if handler.NotModified(request, etag) {
+		return handler.Response{
+			StatusCode: http.StatusNotModified,
+			Headers:    map[string]string{"ETag": etag},
+		}, nil
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Caregiver %s retrieved successfully", publicID),
+		Service: "caregiver-service",
+		Data:    *caregiverModel,
+		Headers: map[string]string{"ETag": etag},
+	}, nil
+}
+
+// Create processes a request to add a new caregiver to the system.
+// Parses the request body JSON and validates the caregiver data before creation.
+// Returns the newly created caregiver data with a generated ID.
+func (h *CaregiverHandler) Create(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	var caregiverRequest CaregiverRequest
+	// Parse and validate the incoming JSON request body
+	if err := handler.DecodeJSON(request.Body, &caregiverRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	// Convert request to model and validate
+	caregiverModel, err := caregiverRequest.ToCaregiver()
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	// Save to database
+	createdCaregiver, err := h.repo.Create(ctx, caregiverModel)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to create caregiver: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Caregiver %s created successfully", createdCaregiver.Name),
+		Service: "caregiver-service",
+		Data:    *createdCaregiver,
+		Headers: map[string]string{"ETag": handler.ETag(createdCaregiver.UpdatedAt)},
+	}, nil
+}
+
+// Update modifies an existing caregiver's information in the system.
+// Takes the caregiver ID from URL parameters and new data from request body.
+// Returns the updated caregiver data after successful modification.
+func (h *CaregiverHandler) Update(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.caregiverID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var caregiverRequest CaregiverRequest
+	// Parse and validate the incoming JSON update data
+	if err := handler.DecodeJSON(request.Body, &caregiverRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	// Convert request to model with existing ID and validate
+	caregiverModel, err := caregiverRequest.ToCaregiver(id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	// Update in database
+	updatedCaregiver, err := h.repo.Update(ctx, caregiverModel, ifMatch...)
+	if err != nil {
+		return handler.Response{}, versionError(err, "failed to update caregiver")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Caregiver %s updated successfully", publicID),
+		Service: "caregiver-service",
+		Data:    *updatedCaregiver,
+		Headers: map[string]string{"ETag": handler.ETag(updatedCaregiver.UpdatedAt)},
+	}, nil
+}
+
+// Patch partially modifies an existing caregiver using JSON Merge Patch semantics.
+// The patch is applied to the stored caregiver, so omitted fields keep their current
+// values; the merged result is then validated like a full update.
+func (h *CaregiverHandler) Patch(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.caregiverID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	stored, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get caregiver: %w", err)
+	}
+
+	base := CaregiverRequest{
+		Name:         stored.Name,
+		Email:        stored.Email,
+		Relationship: string(stored.Relationship),
+	}
+
+	var caregiverRequest CaregiverRequest
+	if err := handler.ApplyMergePatch(base, request.Body, &caregiverRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	// Convert merged request to model with existing ID and validate
+	caregiverModel, err := caregiverRequest.ToCaregiver(id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	updatedCaregiver, err := h.repo.Update(ctx, caregiverModel, ifMatch...)
+	if err != nil {
+		return handler.Response{}, versionError(err, "failed to update caregiver")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Caregiver %s updated successfully", publicID),
+		Service: "caregiver-service",
+		Data:    *updatedCaregiver,
+		Headers: map[string]string{"ETag": handler.ETag(updatedCaregiver.UpdatedAt)},
+	}, nil
+}
+
+// Delete soft deletes a caregiver by their unique identifier.
+// The caregiver is kept until restored or purged.
+// Returns a confirmation message upon successful removal.
+func (h *CaregiverHandler) Delete(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.caregiverID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Delete from database
+	if err := h.repo.Delete(ctx, id, ifMatch...); err != nil {
+		return handler.Response{}, versionError(err, "failed to delete caregiver")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Caregiver %s deleted successfully", publicID),
+		Service: "caregiver-service",
+	}, nil
+}
+
+// Restore brings back a soft-deleted caregiver
+func (h *CaregiverHandler) Restore(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.caregiverID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	restoredCaregiver, err := h.repo.Restore(ctx, id)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotDeleted) {
+			return handler.Response{}, handler.NewError(http.StatusConflict, fmt.Sprintf("caregiver %s is not deleted", publicID))
+		}
+		return handler.Response{}, fmt.Errorf("failed to restore caregiver: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Caregiver %s restored successfully", publicID),
+		Service: "caregiver-service",
+		Data:    *restoredCaregiver,
+		Headers: map[string]string{"ETag": handler.ETag(restoredCaregiver.UpdatedAt)},
+	}, nil
+}
+
+// GetNotifications returns the emails a caregiver opted in to
+func (h *CaregiverHandler) GetNotifications(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.caregiverID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	preferences, err := h.notifications.GetPreferences(ctx, id)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Notification preferences of caregiver %s retrieved successfully", publicID),
+		Service: ServiceName,
+		Data:    *preferences,
+	}, nil
+}
+
+// SetNotifications chooses the emails a caregiver receives
+func (h *CaregiverHandler) SetNotifications(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.caregiverID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var notificationsRequest NotificationsRequest
+	if err := handler.DecodeJSON(request.Body, &notificationsRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	preferences := &notification.Preferences{
+		CaregiverID:         id,
+		WeeklySummary:       notificationsRequest.WeeklySummary,
+		PendingApprovals:    notificationsRequest.PendingApprovals,
+		LargeSpends:         notificationsRequest.LargeSpends,
+		LargeSpendThreshold: notificationsRequest.LargeSpendThreshold,
+		UpcomingBirthdays:   notificationsRequest.UpcomingBirthdays,
+	}
+	if preferences.LargeSpendThreshold == 0 {
+		preferences.LargeSpendThreshold = notification.DefaultLargeSpendThreshold
+	}
+	if err := preferences.Validate(); err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	saved, err := h.notifications.SetPreferences(ctx, preferences)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Notification preferences of caregiver %s saved successfully", publicID),
+		Service: ServiceName,
+		Data:    *saved,
+	}, nil
+}
+
+// ValidateEmail handles email validation requests from frontend.
+// POST /validate/email with {"email": "test@example.com"}
+func (h *CaregiverHandler) ValidateEmail(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	var validationReq ValidationRequest
+	if err := handler.DecodeJSON(request.Body, &validationReq); err != nil {
+		return handler.Response{}, err
+	}
+
+	err := caregiver.ValidateEmail(validationReq.Email)
+	response := ValidationResponse{
+		Valid: err == nil,
+	}
+
+	if err != nil {
+		response.Message = err.Error()
+	}
+
+	return handler.Response{
+		Message: "Email validation completed",
+		Service: "caregiver-service",
+		Data:    response,
+	}, nil
+}
+
+// ValidateRelationship handles relationship validation requests from frontend.
+// POST /validate/relationship with {"relationship": "parent"}
+func (h *CaregiverHandler) ValidateRelationship(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	var validationReq ValidationRequest
+	if err := handler.DecodeJSON(request.Body, &validationReq); err != nil {
+		return handler.Response{}, err
+	}
+
+	err := caregiver.ValidateRelationship(validationReq.Relationship)
+	response := ValidationResponse{
+		Valid:   err == nil,
+		Options: caregiver.GetValidRelationships(),
+	}
+
+	if err != nil {
+		response.Message = err.Error()
+	}
+
+	return handler.Response{
+		Message: "Relationship validation completed",
+		Service: "caregiver-service",
+		Data:    response,
+	}, nil
+}
+
+// caregiverID resolves the public ID in the {id} path parameter to the caregiver's internal ID
+func (h *CaregiverHandler) caregiverID(ctx context.Context, request handler.HTTPRequest) (int, string, error) {
+	publicID := request.PathParameters["id"]
+	if !handler.IsUUID(publicID) {
+		return 0, "", fmt.Errorf("invalid caregiver ID: %s", publicID)
+	}
+	publicID = strings.ToLower(publicID)
+
+	id, err := h.repo.ResolveID(ctx, publicID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return 0, "", handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return 0, "", fmt.Errorf("failed to get caregiver: %w", err)
+	}
+
+	return id, publicID, nil
+}
+
+// versionError reports a failed If-Match precondition as 412 Precondition Failed
+// and wraps any other repository error with the given context.
+func versionError(err error, action string) error {
+	if errors.Is(err, interfaces.ErrVersionConflict) {
+		return handler.NewError(http.StatusPreconditionFailed, "caregiver has been modified, fetch the latest version and retry")
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+// Handle is the entry point for all HTTP requests to the Caregiver Service.
+// It handles both CRUD operations and validation endpoints in every API version.
+func (h *CaregiverHandler) Handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	return handler.ServeVersion(ctx, request, Routes, handler.DefaultSchedule, h.handle)
+}
+
+// handle serves a request in the API version resolved by Handle
+func (h *CaregiverHandler) handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	// Serve the API contract generated from the routes
+	if request.Path == openapi.Path {
+		return openapi.Serve(ServiceName, Routes, request.Version)
+	}
+
+	// Reject malformed bodies with field-level errors before any handler logic
+	if err := handler.ValidateRequest(request, Routes); err != nil {
+		return handler.ErrorResponse(err), nil
+	}
+
+	// Handle validation endpoints
+	if strings.HasPrefix(request.Path, "/validate/") {
+		var response handler.Response
+		var err error
+
+		switch {
+		case strings.Contains(request.Path, "/validate/email"):
+			response, err = h.ValidateEmail(ctx, request)
+		case strings.Contains(request.Path, "/validate/relationship"):
+			response, err = h.ValidateRelationship(ctx, request)
+		default:
+			return handler.HTTPResponse{
+				StatusCode: 404,
+				Headers: map[string]string{
+					"Content-Type":                "application/json",
+					"Access-Control-Allow-Origin": "*",
+				},
+				Body: `{"message": "validation endpoint not found", "service": "caregiver-service"}`,
+			}, nil
+		}
+
+		if err != nil {
+			return handler.HTTPResponse{
+				StatusCode: 400,
+				Headers: map[string]string{
+					"Content-Type":                "application/json",
+					"Access-Control-Allow-Origin": "*",
+				},
+				Body: fmt.Sprintf(`{"message": "%s", "service": "caregiver-service"}`, err.Error()),
+			}, nil
+		}
+
+		responseJSON, _ := json.Marshal(response)
+		return handler.HTTPResponse{
+			StatusCode: 200,
+			Headers: map[string]string{
+				"Content-Type":                "application/json",
+				"Access-Control-Allow-Origin": "*",
+			},
+			Body: string(responseJSON),
+		}, nil
+	}
+
+	if request.Resource == restoreResource {
+		response, err := h.Restore(ctx, request)
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return handler.Respond(http.StatusOK, response), nil
+	}
+
+	if request.Resource == notificationsResource {
+		var response handler.Response
+		var err error
+		if request.HTTPMethod == http.MethodPut {
+			response, err = h.SetNotifications(ctx, request)
+		} else {
+			response, err = h.GetNotifications(ctx, request)
+		}
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return handler.Respond(http.StatusOK, response), nil
+	}
+
+	// Handle standard CRUD operations
+	return handler.HandleRequest(ctx, request, h)
+}
@@ -0,0 +1,227 @@
+package caregivers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+)
+
+// storedVersion is the last modification time of the caregiver held by patchRepository
+var storedVersion = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// storedPublicID is the public ID of the caregiver held by patchRepository, whose internal ID is 1
+const storedPublicID = "3a9e7c1b-5d2f-4b8a-8c6e-0f1a2b3c4d5e"
+
+// patchRepository holds a single caregiver and records the last update it was given.
+// Writes naming a version other than storedVersion fail with ErrVersionConflict.
+type patchRepository struct {
+	interfaces.CaregiverRepository
+	updated *caregiver.Caregiver
+	deleted bool
+}
+
+func (r *patchRepository) ResolveID(ctx context.Context, publicID string) (int, error) {
+	if publicID != storedPublicID {
+		return 0, fmt.Errorf("caregiver with id %s %w", publicID, interfaces.ErrNotFound)
+	}
+	return 1, nil
+}
+
+func (r *patchRepository) GetByID(ctx context.Context, id int) (*caregiver.Caregiver, error) {
+	return &caregiver.Caregiver{
+		ID:           id,
+		Name:         "Jane Doe",
+		Email:        "jane@example.com",
+		Relationship: caregiver.RelationshipParent,
+		UpdatedAt:    storedVersion,
+	}, nil
+}
+
+func (r *patchRepository) Update(ctx context.Context, c *caregiver.Caregiver, ifMatch ...time.Time) (*caregiver.Caregiver, error) {
+	if !matchesStoredVersion(ifMatch) {
+		return nil, interfaces.ErrVersionConflict
+	}
+	r.updated = c
+	return c, nil
+}
+
+func (r *patchRepository) Delete(ctx context.Context, id int, ifMatch ...time.Time) error {
+	if !matchesStoredVersion(ifMatch) {
+		return interfaces.ErrVersionConflict
+	}
+	r.deleted = true
+	return nil
+}
+
+// matchesStoredVersion reports whether an If-Match precondition allows overwriting storedVersion
+func matchesStoredVersion(ifMatch []time.Time) bool {
+	if len(ifMatch) == 0 {
+		return true
+	}
+	for _, version := range ifMatch {
+		if version.Equal(storedVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPatchCaregiver(t *testing.T) {
+	tests := []struct {
+		name                 string
+		body                 string
+		expectedStatus       int
+		expectedName         string
+		expectedEmail        string
+		expectedRelationship caregiver.RelationshipType
+	}{
+		{"empty patch keeps stored caregiver", `{}`, http.StatusOK, "Jane Doe", "jane@example.com", caregiver.RelationshipParent},
+		{"email replaced", `{"email":"jane.doe@example.com"}`, http.StatusOK, "Jane Doe", "jane.doe@example.com", caregiver.RelationshipParent},
+		{"several members replaced", `{"name":" Jane Smith ","relationship":"Guardian"}`, http.StatusOK, "Jane Smith", "jane@example.com", caregiver.RelationshipGuardian},
+		{"null name cleared", `{"name":null}`, http.StatusBadRequest, "", "", ""},
+		{"null email cleared", `{"email":null}`, http.StatusBadRequest, "", "", ""},
+		{"invalid relationship", `{"relationship":"neighbour"}`, http.StatusBadRequest, "", "", ""},
+		{"non-object patch", `"Jane"`, http.StatusBadRequest, "", "", ""},
+		{"invalid JSON", `{"email":`, http.StatusBadRequest, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &patchRepository{}
+
+			response, err := handler.HandleRequest(context.Background(), handler.HTTPRequest{
+				HTTPMethod:     http.MethodPatch,
+				PathParameters: map[string]string{"id": storedPublicID},
+				Headers:        map[string]string{"If-Match": handler.ETag(storedVersion)},
+				Body:           tt.body,
+			}, NewCaregiverHandler(repo, nil))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				if repo.updated != nil {
+					t.Errorf("expected no update, got %+v", repo.updated)
+				}
+				return
+			}
+
+			if repo.updated == nil {
+				t.Fatal("expected the caregiver to be updated")
+			}
+			if repo.updated.ID != 1 {
+				t.Errorf("expected ID 1, got %d", repo.updated.ID)
+			}
+			if repo.updated.Name != tt.expectedName || repo.updated.Email != tt.expectedEmail {
+				t.Errorf("expected %s <%s>, got %s <%s>", tt.expectedName, tt.expectedEmail, repo.updated.Name, repo.updated.Email)
+			}
+			if repo.updated.Relationship != tt.expectedRelationship {
+				t.Errorf("expected relationship %s, got %s", tt.expectedRelationship, repo.updated.Relationship)
+			}
+		})
+	}
+}
+
+func TestCaregiverPreconditions(t *testing.T) {
+	current := handler.ETag(storedVersion)
+	stale := handler.ETag(storedVersion.Add(-time.Minute))
+	update := `{"name":"Jane Doe","email":"jane@example.com","relationship":"guardian"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		headers        map[string]string
+		body           string
+		expectedStatus int
+	}{
+		{"patch without If-Match", http.MethodPatch, nil, `{"name":"Jane Smith"}`, http.StatusPreconditionRequired},
+		{"patch with stale If-Match", http.MethodPatch, map[string]string{"If-Match": stale}, `{"name":"Jane Smith"}`, http.StatusPreconditionFailed},
+		{"patch with weak If-Match", http.MethodPatch, map[string]string{"If-Match": "W/" + current}, `{"name":"Jane Smith"}`, http.StatusPreconditionFailed},
+		{"patch with current If-Match", http.MethodPatch, map[string]string{"if-match": current}, `{"name":"Jane Smith"}`, http.StatusOK},
+		{"put without If-Match", http.MethodPut, nil, update, http.StatusPreconditionRequired},
+		{"put with stale If-Match", http.MethodPut, map[string]string{"If-Match": stale}, update, http.StatusPreconditionFailed},
+		{"put with current If-Match", http.MethodPut, map[string]string{"If-Match": current}, update, http.StatusOK},
+		{"delete without If-Match", http.MethodDelete, nil, "", http.StatusPreconditionRequired},
+		{"delete with stale If-Match", http.MethodDelete, map[string]string{"If-Match": stale}, "", http.StatusPreconditionFailed},
+		{"get with current If-None-Match", http.MethodGet, map[string]string{"If-None-Match": current}, "", http.StatusNotModified},
+		{"get with any If-None-Match", http.MethodGet, map[string]string{"If-None-Match": "*"}, "", http.StatusNotModified},
+		{"get with stale If-None-Match", http.MethodGet, map[string]string{"If-None-Match": stale}, "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &patchRepository{}
+
+			response, err := handler.HandleRequest(context.Background(), handler.HTTPRequest{
+				HTTPMethod:     tt.method,
+				PathParameters: map[string]string{"id": storedPublicID},
+				Headers:        tt.headers,
+				Body:           tt.body,
+			}, NewCaregiverHandler(repo, nil))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+
+			if tt.expectedStatus >= http.StatusBadRequest && (repo.updated != nil || repo.deleted) {
+				t.Error("expected a failed precondition to leave the caregiver unchanged")
+			}
+			if tt.method == http.MethodGet && response.Headers["ETag"] != current {
+				t.Errorf("expected ETag %s, got %s", current, response.Headers["ETag"])
+			}
+			if tt.expectedStatus == http.StatusNotModified && response.Body != "" {
+				t.Errorf("expected an empty body, got %s", response.Body)
+			}
+		})
+	}
+}
+
+func TestCaregiverPathID(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{"stored public ID", storedPublicID, http.StatusOK},
+		{"upper-case public ID", strings.ToUpper(storedPublicID), http.StatusOK},
+		{"internal ID", "1", http.StatusBadRequest},
+		{"malformed UUID", storedPublicID[:35], http.StatusBadRequest},
+		{"unknown public ID", "00000000-0000-4000-8000-000000000000", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodPatch} {
+			t.Run(tt.name+" "+method, func(t *testing.T) {
+				repo := &patchRepository{}
+
+				response, err := handler.HandleRequest(context.Background(), handler.HTTPRequest{
+					HTTPMethod:     method,
+					PathParameters: map[string]string{"id": tt.id},
+					Headers:        map[string]string{"If-Match": handler.ETag(storedVersion)},
+					Body:           `{}`,
+				}, NewCaregiverHandler(repo, nil))
+				if err != nil {
+					t.Fatalf("expected no error but got: %v", err)
+				}
+				if response.StatusCode != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+				}
+				if tt.expectedStatus != http.StatusOK && repo.updated != nil {
+					t.Errorf("expected no update, got %+v", repo.updated)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package families
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/category"
+)
+
+// CategoryRequest represents the payload creating or renaming a category
+type CategoryRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=50"` // Unique within the family, ignoring case
+}
+
+// ListCategories returns the categories of the family, ordered by name
+func (h *FamilyHandler) ListCategories(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	categories, err := h.categories.GetByFamily(ctx, familyID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	categoryList := make([]category.Category, len(categories))
+	for i, c := range categories {
+		categoryList[i] = *c
+	}
+
+	return handler.Response{
+		Message: "Categories retrieved successfully",
+		Service: ServiceName,
+		Data:    categoryList,
+	}, nil
+}
+
+// CreateCategory adds a category transactions of the family can be filed under
+func (h *FamilyHandler) CreateCategory(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var categoryRequest CategoryRequest
+	if err := handler.DecodeJSON(request.Body, &categoryRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	c := &category.Category{FamilyID: familyID, Name: categoryRequest.Name}
+	if err := c.Validate(); err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	created, err := h.categories.Create(ctx, c)
+	if err != nil {
+		return handler.Response{}, categoryError("create", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Category %s created successfully", created.Name),
+		Service: ServiceName,
+		Data:    *created,
+	}, nil
+}
+
+// GetCategory returns a category of the family
+func (h *FamilyHandler) GetCategory(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.categoryPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	c, err := h.categories.GetByID(ctx, familyID, id)
+	if err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Category %d retrieved successfully", id),
+		Service: ServiceName,
+		Data:    *c,
+	}, nil
+}
+
+// UpdateCategory renames a category; its transactions keep it
+func (h *FamilyHandler) UpdateCategory(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.categoryPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var categoryRequest CategoryRequest
+	if err := handler.DecodeJSON(request.Body, &categoryRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	c := &category.Category{ID: id, FamilyID: familyID, Name: categoryRequest.Name}
+	if err := c.Validate(); err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	updated, err := h.categories.Update(ctx, c)
+	if err != nil {
+		return handler.Response{}, categoryError("update", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Category %d updated successfully", id),
+		Service: ServiceName,
+		Data:    *updated,
+	}, nil
+}
+
+// DeleteCategory removes a category; its transactions become uncategorized
+func (h *FamilyHandler) DeleteCategory(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.categoryPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	if err := h.categories.Delete(ctx, familyID, id); err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Category %d deleted successfully", id),
+		Service: ServiceName,
+	}, nil
+}
+
+// categoryError maps a failed category write to its HTTP status
+func categoryError(action string, err error) error {
+	switch {
+	case errors.Is(err, interfaces.ErrCategoryExists):
+		return handler.NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, interfaces.ErrNotFound):
+		return handler.NewError(http.StatusNotFound, err.Error())
+	}
+	return fmt.Errorf("failed to %s category: %w", action, err)
+}
+
+// categoryPathIDs resolves the family and category IDs of a category resource
+func (h *FamilyHandler) categoryPathIDs(ctx context.Context, request handler.HTTPRequest) (int, int, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := pathID(request, "category_id", "category")
+	if err != nil {
+		return 0, 0, err
+	}
+	return familyID, id, nil
+}
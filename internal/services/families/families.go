@@ -0,0 +1,815 @@
+// Package families implements the Family Service handlers.
+// The service groups kids and caregivers into families and lets a family take
+// its personal data with it (export) or have it erased (deletion workflow, confirmed
+// with a token emailed to its caregivers), invites caregivers by email, manages the
+// categories its transactions are filed under and the webhooks that push a family's
+// events to its own systems, and searches a family's records.
+package families
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/category"
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+	"github.com/lukasz/astras-mono-api/internal/models/invitation"
+	"github.com/lukasz/astras-mono-api/internal/models/webhook"
+	"github.com/lukasz/astras-mono-api/internal/notifications"
+	"github.com/lukasz/astras-mono-api/internal/openapi"
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+// ServiceName identifies the Family Service in responses and logs
+const ServiceName = "family-service"
+
+// Resources served besides the family itself
+const (
+	kidResource         = "/families/{id}/kids/{kid_id}"
+	caregiverResource   = "/families/{id}/caregivers/{caregiver_id}"
+	exportResource      = "/families/{id}/export"
+	deletionsResource   = "/families/{id}/deletions"
+	deletionResource    = "/families/{id}/deletions/{deletion_id}"
+	confirmResource     = "/families/{id}/deletions/{deletion_id}/confirm"
+	webhooksResource    = "/families/{id}/webhooks"
+	webhookResource     = "/families/{id}/webhooks/{webhook_id}"
+	deliveriesResource  = "/families/{id}/webhooks/{webhook_id}/deliveries"
+	invitationsResource = "/families/{id}/invitations"
+	invitationResource  = "/families/{id}/invitations/{invitation_id}"
+	acceptResource      = "/families/{id}/invitations/{invitation_id}/accept"
+	revokeResource      = "/families/{id}/caregivers/{caregiver_id}/revoke"
+	categoriesResource  = "/families/{id}/categories"
+	categoryResource    = "/families/{id}/categories/{category_id}"
+	searchResource      = "/search"
+)
+
+var (
+	familyRequestSchema   = schema.Generate(FamilyRequest{}, family.Family{})
+	familyIDParam         = handler.UUIDPathParam("id", "Family ID")
+	deletionIDParam       = handler.PathParam("deletion_id", "integer", "Deletion request ID")
+	webhookIDParam        = handler.PathParam("webhook_id", "integer", "Webhook ID")
+	webhookRequestSchema  = schema.Generate(WebhookRequest{})
+	invitationIDParam     = handler.PathParam("invitation_id", "integer", "Invitation ID")
+	categoryIDParam       = handler.PathParam("category_id", "integer", "Category ID")
+	categoryRequestSchema = schema.Generate(CategoryRequest{})
+)
+
+// Routes lists the API Gateway routes served by the Family Service (see template.yaml)
+var Routes = []handler.Route{
+	{
+		Method:   http.MethodGet,
+		Path:     "/families",
+		Summary:  "List families",
+		Response: handler.ResponseSchema([]family.Family{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/families",
+		Summary:  "Create a family",
+		Body:     familyRequestSchema,
+		Status:   http.StatusCreated,
+		Response: handler.ResponseSchema(family.Family{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/families/{id}",
+		Summary:  "Get a family",
+		Params:   []handler.Param{familyIDParam, handler.IfNoneMatchParam},
+		Response: handler.ResponseSchema(family.Family{}),
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/families/{id}",
+		Summary:  "Rename a family",
+		Params:   []handler.Param{familyIDParam, handler.IfMatchParam},
+		Body:     familyRequestSchema,
+		Response: handler.ResponseSchema(family.Family{}),
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     kidResource,
+		Summary:  "Add a kid to the family",
+		Params:   []handler.Param{familyIDParam, handler.UUIDPathParam("kid_id", "Kid ID")},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     kidResource,
+		Summary:  "Remove a kid from the family",
+		Params:   []handler.Param{familyIDParam, handler.UUIDPathParam("kid_id", "Kid ID")},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     caregiverResource,
+		Summary:  "Add a caregiver to the family",
+		Params:   []handler.Param{familyIDParam, handler.UUIDPathParam("caregiver_id", "Caregiver ID")},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     caregiverResource,
+		Summary:  "Remove a caregiver from the family",
+		Params:   []handler.Param{familyIDParam, handler.UUIDPathParam("caregiver_id", "Caregiver ID")},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     exportResource,
+		Summary:  "Export all personal data of the family: kids, caregivers and the transaction ledger",
+		Params:   []handler.Param{familyIDParam},
+		Response: handler.ResponseSchema(ExportResponse{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     deletionsResource,
+		Summary:  "Request erasure of the family's personal data, to be confirmed with the token emailed to its caregivers",
+		Params:   []handler.Param{familyIDParam},
+		Body:     schema.Generate(DeletionRequest{}),
+		Status:   http.StatusAccepted,
+		Response: handler.ResponseSchema(family.DeletionRequest{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     deletionResource,
+		Summary:  "Get the audit record of a deletion request",
+		Params:   []handler.Param{familyIDParam, deletionIDParam},
+		Response: handler.ResponseSchema(family.DeletionRequest{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     confirmResource,
+		Summary:  "Confirm a deletion request, erasing the family's personal data",
+		Params:   []handler.Param{familyIDParam, deletionIDParam},
+		Body:     schema.Generate(ConfirmationRequest{}),
+		Response: handler.ResponseSchema(family.DeletionRequest{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     webhooksResource,
+		Summary:  "List the family's webhooks",
+		Params:   []handler.Param{familyIDParam},
+		Response: handler.ResponseSchema([]webhook.Webhook{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     webhooksResource,
+		Summary:  "Subscribe a URL to the family's events; the returned secret signs every delivery",
+		Params:   []handler.Param{familyIDParam},
+		Body:     webhookRequestSchema,
+		Status:   http.StatusCreated,
+		Response: handler.ResponseSchema(CreatedWebhook{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     webhookResource,
+		Summary:  "Get a webhook",
+		Params:   []handler.Param{familyIDParam, webhookIDParam},
+		Response: handler.ResponseSchema(webhook.Webhook{}),
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     webhookResource,
+		Summary:  "Change, pause or resume a webhook",
+		Params:   []handler.Param{familyIDParam, webhookIDParam},
+		Body:     webhookRequestSchema,
+		Response: handler.ResponseSchema(webhook.Webhook{}),
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     webhookResource,
+		Summary:  "Delete a webhook and its delivery log",
+		Params:   []handler.Param{familyIDParam, webhookIDParam},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    deliveriesResource,
+		Summary: "List the deliveries of a webhook, newest first",
+		Params: []handler.Param{
+			familyIDParam,
+			webhookIDParam,
+			handler.QueryParam("limit", "integer", fmt.Sprintf("Maximum number of deliveries (default %d, at most %d)", DefaultDeliveryLimit, MaxDeliveryLimit)),
+		},
+		Response: handler.ResponseSchema([]webhook.Delivery{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     invitationsResource,
+		Summary:  "List the family's caregiver invitations, newest first",
+		Params:   []handler.Param{familyIDParam},
+		Response: handler.ResponseSchema([]invitation.Invitation{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     invitationsResource,
+		Summary:  "Invite an email address to the family; the token accepting the invitation is emailed to it",
+		Params:   []handler.Param{familyIDParam},
+		Body:     schema.Generate(InvitationRequest{}),
+		Status:   http.StatusCreated,
+		Response: handler.ResponseSchema(invitation.Invitation{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     invitationResource,
+		Summary:  "Get an invitation",
+		Params:   []handler.Param{familyIDParam, invitationIDParam},
+		Response: handler.ResponseSchema(invitation.Invitation{}),
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     invitationResource,
+		Summary:  "Revoke a pending invitation",
+		Params:   []handler.Param{familyIDParam, invitationIDParam},
+		Response: handler.ResponseSchema(invitation.Invitation{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     acceptResource,
+		Summary:  "Accept an invitation with the emailed token, joining the family as an active caregiver",
+		Params:   []handler.Param{familyIDParam, invitationIDParam},
+		Body:     schema.Generate(AcceptanceRequest{}),
+		Response: handler.ResponseSchema(AcceptedInvitation{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     revokeResource,
+		Summary:  "Revoke a caregiver's access to the family",
+		Params:   []handler.Param{familyIDParam, handler.UUIDPathParam("caregiver_id", "Caregiver ID")},
+		Response: handler.ResponseSchema(caregiver.Caregiver{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     categoriesResource,
+		Summary:  "List the family's transaction categories, ordered by name",
+		Params:   []handler.Param{familyIDParam},
+		Response: handler.ResponseSchema([]category.Category{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     categoriesResource,
+		Summary:  "Create a transaction category",
+		Params:   []handler.Param{familyIDParam},
+		Body:     categoryRequestSchema,
+		Status:   http.StatusCreated,
+		Response: handler.ResponseSchema(category.Category{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     categoryResource,
+		Summary:  "Get a transaction category",
+		Params:   []handler.Param{familyIDParam, categoryIDParam},
+		Response: handler.ResponseSchema(category.Category{}),
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     categoryResource,
+		Summary:  "Rename a transaction category",
+		Params:   []handler.Param{familyIDParam, categoryIDParam},
+		Body:     categoryRequestSchema,
+		Response: handler.ResponseSchema(category.Category{}),
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     categoryResource,
+		Summary:  "Delete a transaction category, leaving its transactions uncategorized",
+		Params:   []handler.Param{familyIDParam, categoryIDParam},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    searchResource,
+		Summary: "Search the family's transaction descriptions and kid and caregiver names, best matches first",
+		Params: []handler.Param{
+			{Name: "q", In: "query", Description: `Words to find; "quoted phrases", or, and -excluded words are supported`, Required: true, Schema: &schema.Schema{Type: "string"}},
+			{Name: "family_id", In: "query", Description: "Family of the caller, the only one searched", Required: true, Schema: &schema.Schema{Type: "string", Format: "uuid"}},
+			handler.QueryParam("limit", "integer", fmt.Sprintf("Maximum number of results (default %d, at most %d)", DefaultSearchLimit, MaxSearchLimit)),
+			handler.QueryParam("offset", "integer", "Number of results to skip"),
+		},
+		Response: handler.ResponseSchema(interfaces.SearchResults{}),
+	},
+	openapi.SpecRoute,
+}
+
+// FamilyRequest represents the payload for creating or renaming a family
+type FamilyRequest struct {
+	Name string `json:"name,omitempty" validate:"required"` // Family name
+}
+
+// DeletionRequest represents the payload requesting erasure of a family's personal data
+type DeletionRequest struct {
+	Mode string `json:"mode" validate:"required,oneof=delete anonymize"` // delete removes every record, anonymize keeps the ledger without personal data
+}
+
+// ConfirmationRequest represents the payload confirming a deletion request
+type ConfirmationRequest struct {
+	ConfirmationToken string `json:"confirmation_token" validate:"required"` // Token emailed to the family's caregivers when the deletion was requested
+}
+
+// ExportResponse bundles all personal data held about a family
+type ExportResponse struct {
+	ExportedAt time.Time `json:"exported_at"`
+	interfaces.FamilyExport
+}
+
+// FamilyHandler implements the handler.Handler interface for family operations
+type FamilyHandler struct {
+	repo        interfaces.FamilyRepository
+	webhooks    interfaces.WebhookRepository
+	invitations interfaces.InvitationRepository
+	categories  interfaces.CategoryRepository
+	sender      notifications.Sender // Emails invitation and deletion confirmation tokens
+}
+
+// NewFamilyHandler creates a new family handler with database repositories and the
+// sender of the emails carrying invitation and confirmation tokens
+func NewFamilyHandler(repo interfaces.FamilyRepository, webhooks interfaces.WebhookRepository, invitations interfaces.InvitationRepository, categories interfaces.CategoryRepository, sender notifications.Sender) *FamilyHandler {
+	return &FamilyHandler{
+		repo:        repo,
+		webhooks:    webhooks,
+		invitations: invitations,
+		categories:  categories,
+		sender:      sender,
+	}
+}
+
+// GetAll retrieves and returns a list of families in the system
+func (h *FamilyHandler) GetAll(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	families, err := h.repo.GetAll(ctx)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get all families: %w", err)
+	}
+
+	familyList := make([]family.Family, len(families))
+	for i, f := range families {
+		familyList[i] = *f
+	}
+
+	return handler.Response{
+		Message: "Families retrieved successfully",
+		Service: ServiceName,
+		Data:    familyList,
+	}, nil
+}
+
+// GetByID retrieves a specific family by its unique identifier
+func (h *FamilyHandler) GetByID(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	f, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get family: %w", err)
+	}
+
+	etag := handler.ETag(f.UpdatedAt)
+	if handler.NotModified(request, etag) {
+		return handler.Response{
+			StatusCode: http.StatusNotModified,
+			Headers:    map[string]string{"ETag": etag},
+		}, nil
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Family %s retrieved successfully", f.PublicID),
+		Service: ServiceName,
+		Data:    *f,
+		Headers: map[string]string{"ETag": etag},
+	}, nil
+}
+
+// Create processes a request to add a new family to the system
+func (h *FamilyHandler) Create(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	var familyRequest FamilyRequest
+	if err := handler.DecodeJSON(request.Body, &familyRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	f := &family.Family{Name: familyRequest.Name}
+	if err := f.Validate(); err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	created, err := h.repo.Create(ctx, f)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to create family: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Family %s created successfully", created.Name),
+		Service: ServiceName,
+		Data:    *created,
+		Headers: map[string]string{"ETag": handler.ETag(created.UpdatedAt)},
+	}, nil
+}
+
+// Update renames an existing family
+func (h *FamilyHandler) Update(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var familyRequest FamilyRequest
+	if err := handler.DecodeJSON(request.Body, &familyRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	f := &family.Family{ID: id, Name: familyRequest.Name}
+	if err := f.Validate(); err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	updated, err := h.repo.Update(ctx, f, ifMatch...)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrVersionConflict) {
+			return handler.Response{}, handler.NewError(http.StatusPreconditionFailed, "family has been modified, fetch the latest version and retry")
+		}
+		return handler.Response{}, fmt.Errorf("failed to update family: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Family %s updated successfully", updated.PublicID),
+		Service: ServiceName,
+		Data:    *updated,
+		Headers: map[string]string{"ETag": handler.ETag(updated.UpdatedAt)},
+	}, nil
+}
+
+// Patch is not supported, families only have a name (see Update)
+func (h *FamilyHandler) Patch(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	return handler.Response{}, handler.NewError(http.StatusMethodNotAllowed, "families are renamed with PUT")
+}
+
+// Delete is not supported; erasing a family goes through the confirmed deletion workflow
+func (h *FamilyHandler) Delete(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	return handler.Response{}, handler.NewError(http.StatusMethodNotAllowed, "request a deletion with POST /families/{id}/deletions")
+}
+
+// SetMember adds a kid or caregiver to the family with PUT, or removes it with DELETE
+func (h *FamilyHandler) SetMember(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, familyPublicID, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	entity, param, set := "kid", "kid_id", h.repo.SetKidFamily
+	if request.Resource == caregiverResource {
+		entity, param, set = "caregiver", "caregiver_id", h.repo.SetCaregiverFamily
+	}
+	memberID, err := pathPublicID(request, param, entity)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	message := fmt.Sprintf("%s %s added to family %s", strings.ToUpper(entity[:1])+entity[1:], memberID, familyPublicID)
+	target := familyID
+	if request.HTTPMethod == http.MethodDelete {
+		message = fmt.Sprintf("%s %s removed from family %s", strings.ToUpper(entity[:1])+entity[1:], memberID, familyPublicID)
+		target = 0
+	}
+
+	if err := set(ctx, memberID, target); err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to update family membership: %w", err)
+	}
+
+	return handler.Response{
+		Message: message,
+		Service: ServiceName,
+	}, nil
+}
+
+// Export returns all personal data held about the family as one JSON document,
+// served as a download
+func (h *FamilyHandler) Export(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	export, err := h.repo.Export(ctx, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to export family: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Family %s exported successfully", publicID),
+		Service: ServiceName,
+		Data:    ExportResponse{ExportedAt: time.Now().UTC(), FamilyExport: *export},
+		Headers: map[string]string{
+			"Content-Disposition":           fmt.Sprintf(`attachment; filename="family-%s-export.json"`, publicID),
+			"Access-Control-Expose-Headers": "Content-Disposition",
+			"Cache-Control":                 "no-store",
+		},
+	}, nil
+}
+
+// RequestDeletion starts the deletion workflow: it records a pending, audited request
+// counting what would be erased and emails a single-use confirmation token to the
+// family's caregivers. The token is never returned, so knowing the family's URL is not
+// enough to erase it. Nothing is erased until the token is sent to the confirm resource
+// within family.DeletionTTL.
+func (h *FamilyHandler) RequestDeletion(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var deletionRequest DeletionRequest
+	if err := handler.DecodeJSON(request.Body, &deletionRequest); err != nil {
+		return handler.Response{}, err
+	}
+	if err := family.ValidateDeletionMode(deletionRequest.Mode); err != nil {
+		return handler.Response{}, err
+	}
+
+	f, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+	caregivers, err := h.repo.GetCaregivers(ctx, id)
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if len(caregivers) == 0 {
+		return handler.Response{}, handler.NewError(http.StatusConflict, "family has no active caregivers to send the confirmation token to")
+	}
+
+	token, err := newConfirmationToken()
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	pending, err := h.repo.RequestDeletion(ctx, &family.DeletionRequest{
+		FamilyID:  id,
+		Mode:      family.DeletionMode(deletionRequest.Mode),
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(family.DeletionTTL),
+	})
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to request deletion: %w", err)
+	}
+
+	// A request nobody got the token of cannot be confirmed and simply expires
+	var errs []error
+	for _, c := range caregivers {
+		message, err := notifications.DeletionMessage(c, f, pending, token)
+		if err == nil {
+			err = h.sender.Send(ctx, message)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return handler.Response{}, fmt.Errorf("failed to send deletion confirmation: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Deletion of family %s requested, confirm it with the token emailed to its caregivers by %s", publicID, pending.ExpiresAt.UTC().Format(time.RFC3339)),
+		Service: ServiceName,
+		Data:    *pending,
+	}, nil
+}
+
+// GetDeletion returns the audit record of a deletion request
+func (h *FamilyHandler) GetDeletion(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+	id, err := pathID(request, "deletion_id", "deletion request")
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	deletion, err := h.repo.GetDeletion(ctx, familyID, id)
+	if err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Deletion request %d retrieved successfully", id),
+		Service: ServiceName,
+		Data:    *deletion,
+	}, nil
+}
+
+// ConfirmDeletion completes a pending deletion request with its confirmation token,
+// erasing or anonymizing the family's data as requested
+func (h *FamilyHandler) ConfirmDeletion(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, familyPublicID, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+	id, err := pathID(request, "deletion_id", "deletion request")
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var confirmation ConfirmationRequest
+	if err := handler.DecodeJSON(request.Body, &confirmation); err != nil {
+		return handler.Response{}, err
+	}
+
+	// Explain why a request cannot be confirmed; the repository re-checks atomically
+	deletion, err := h.repo.GetDeletion(ctx, familyID, id)
+	if err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+	switch {
+	case deletion.Status == family.DeletionCompleted:
+		return handler.Response{}, handler.NewError(http.StatusConflict, "deletion request has already been completed")
+	case deletion.Expired():
+		return handler.Response{}, handler.NewError(http.StatusGone, "deletion request has expired, request a new one")
+	case deletion.TokenHash != hashToken(confirmation.ConfirmationToken):
+		return handler.Response{}, handler.NewError(http.StatusForbidden, "invalid confirmation token")
+	}
+
+	completed, err := h.repo.CompleteDeletion(ctx, familyID, id, hashToken(confirmation.ConfirmationToken))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrDeletionNotPending) {
+			return handler.Response{}, handler.NewError(http.StatusConflict, "deletion request is no longer pending")
+		}
+		return handler.Response{}, fmt.Errorf("failed to delete family data: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Family %s data %s", familyPublicID, map[family.DeletionMode]string{
+			family.DeletionModeDelete:    "deleted",
+			family.DeletionModeAnonymize: "anonymized",
+		}[completed.Mode]),
+		Service: ServiceName,
+		Data:    *completed,
+	}, nil
+}
+
+// familyID resolves the public ID in the {id} path parameter to the family's internal ID
+func (h *FamilyHandler) familyID(ctx context.Context, request handler.HTTPRequest) (int, string, error) {
+	publicID, err := pathPublicID(request, "id", "family")
+	if err != nil {
+		return 0, "", err
+	}
+
+	id, err := h.repo.ResolveID(ctx, publicID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return 0, "", handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return 0, "", fmt.Errorf("failed to get family: %w", err)
+	}
+
+	return id, publicID, nil
+}
+
+// pathID parses a numeric path parameter
+func pathID(request handler.HTTPRequest, param, entity string) (int, error) {
+	value := request.PathParameters[param]
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s ID: %s", entity, value)
+	}
+	return id, nil
+}
+
+// pathPublicID parses a path parameter holding the public ID of a family, kid or caregiver
+func pathPublicID(request handler.HTTPRequest, param, entity string) (string, error) {
+	value := request.PathParameters[param]
+	if !handler.IsUUID(value) {
+		return "", fmt.Errorf("invalid %s ID: %s", entity, value)
+	}
+	return strings.ToLower(value), nil
+}
+
+// newConfirmationToken returns a random single-use token
+func newConfirmationToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// hashToken returns the SHA-256 of a confirmation token, as stored in the audit record
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Handle is the entry point for all HTTP requests to the Family Service in every API version
+func (h *FamilyHandler) Handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	return handler.ServeVersion(ctx, request, Routes, handler.DefaultSchedule, h.handle)
+}
+
+// handle serves a request in the API version resolved by Handle
+func (h *FamilyHandler) handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	// Serve the API contract generated from the routes
+	if request.Path == openapi.Path {
+		return openapi.Serve(ServiceName, Routes, request.Version)
+	}
+
+	// Reject malformed bodies with field-level errors before any handler logic
+	if err := handler.ValidateRequest(request, Routes); err != nil {
+		return handler.ErrorResponse(err), nil
+	}
+
+	var response handler.Response
+	var err error
+	statusCode := http.StatusOK
+	// Family sub-resources are told apart by the route template resolved by the trigger
+	switch request.Resource {
+	case kidResource, caregiverResource:
+		response, err = h.SetMember(ctx, request)
+	case exportResource:
+		response, err = h.Export(ctx, request)
+	case deletionsResource:
+		response, err = h.RequestDeletion(ctx, request)
+		statusCode = http.StatusAccepted
+	case deletionResource:
+		response, err = h.GetDeletion(ctx, request)
+	case confirmResource:
+		response, err = h.ConfirmDeletion(ctx, request)
+	case webhooksResource:
+		if request.HTTPMethod == http.MethodPost {
+			response, err = h.CreateWebhook(ctx, request)
+			statusCode = http.StatusCreated
+		} else {
+			response, err = h.ListWebhooks(ctx, request)
+		}
+	case webhookResource:
+		switch request.HTTPMethod {
+		case http.MethodPut:
+			response, err = h.UpdateWebhook(ctx, request)
+		case http.MethodDelete:
+			response, err = h.DeleteWebhook(ctx, request)
+		default:
+			response, err = h.GetWebhook(ctx, request)
+		}
+	case deliveriesResource:
+		response, err = h.WebhookDeliveries(ctx, request)
+	case invitationsResource:
+		if request.HTTPMethod == http.MethodPost {
+			response, err = h.Invite(ctx, request)
+			statusCode = http.StatusCreated
+		} else {
+			response, err = h.ListInvitations(ctx, request)
+		}
+	case invitationResource:
+		if request.HTTPMethod == http.MethodDelete {
+			response, err = h.RevokeInvitation(ctx, request)
+		} else {
+			response, err = h.GetInvitation(ctx, request)
+		}
+	case acceptResource:
+		response, err = h.AcceptInvitation(ctx, request)
+	case revokeResource:
+		response, err = h.RevokeCaregiver(ctx, request)
+	case categoriesResource:
+		if request.HTTPMethod == http.MethodPost {
+			response, err = h.CreateCategory(ctx, request)
+			statusCode = http.StatusCreated
+		} else {
+			response, err = h.ListCategories(ctx, request)
+		}
+	case categoryResource:
+		switch request.HTTPMethod {
+		case http.MethodPut:
+			response, err = h.UpdateCategory(ctx, request)
+		case http.MethodDelete:
+			response, err = h.DeleteCategory(ctx, request)
+		default:
+			response, err = h.GetCategory(ctx, request)
+		}
+	case searchResource:
+		response, err = h.Search(ctx, request)
+	default:
+		// Handle standard CRUD operations
+		return handler.HandleRequest(ctx, request, h)
+	}
+
+	if err != nil {
+		return handler.ErrorResponse(err), nil
+	}
+	return handler.Respond(statusCode, response), nil
+}
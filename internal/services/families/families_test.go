@@ -0,0 +1,588 @@
+package families
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/category"
+	"github.com/lukasz/astras-mono-api/internal/models/family"
+	"github.com/lukasz/astras-mono-api/internal/models/invitation"
+	"github.com/lukasz/astras-mono-api/internal/models/webhook"
+	"github.com/lukasz/astras-mono-api/internal/notifications"
+)
+
+// deletionRepository keeps one deletion request of family 1 in memory
+type deletionRepository struct {
+	interfaces.FamilyRepository
+	deletion   *family.DeletionRequest
+	completed  int
+	caregivers []*caregiver.Caregiver
+}
+
+// newDeletionRepository returns a repository of family 1 with two caregivers
+func newDeletionRepository() *deletionRepository {
+	return &deletionRepository{caregivers: []*caregiver.Caregiver{
+		{ID: 1, Name: "Sarah Johnson", Email: "sarah@example.com", Relationship: caregiver.RelationshipParent},
+		{ID: 2, Name: "Tom Johnson", Email: "tom@example.com", Relationship: caregiver.RelationshipParent},
+	}}
+}
+
+// familyUUID returns the public ID of the family with the given internal ID
+func familyUUID(id int) string {
+	return fmt.Sprintf("00000000-0000-4000-a000-%012d", id)
+}
+
+func (r *deletionRepository) ResolveID(ctx context.Context, publicID string) (int, error) {
+	if publicID != familyUUID(1) {
+		return 0, fmt.Errorf("family with id %s %w", publicID, interfaces.ErrNotFound)
+	}
+	return 1, nil
+}
+
+func (r *deletionRepository) GetByID(ctx context.Context, id int) (*family.Family, error) {
+	if id != 1 {
+		return nil, fmt.Errorf("family with id %d %w", id, interfaces.ErrNotFound)
+	}
+	return &family.Family{ID: 1, PublicID: familyUUID(1), Name: "The Johnsons"}, nil
+}
+
+func (r *deletionRepository) GetCaregivers(ctx context.Context, familyID int) ([]*caregiver.Caregiver, error) {
+	return r.caregivers, nil
+}
+
+func (r *deletionRepository) RequestDeletion(ctx context.Context, request *family.DeletionRequest) (*family.DeletionRequest, error) {
+	created := *request
+	created.ID, created.Status, created.RequestedAt = 7, family.DeletionPending, time.Now()
+	created.Kids, created.Caregivers, created.Transactions = 2, 1, 10
+	r.deletion = &created
+	return &created, nil
+}
+
+func (r *deletionRepository) GetDeletion(ctx context.Context, familyID, id int) (*family.DeletionRequest, error) {
+	if r.deletion == nil || familyID != r.deletion.FamilyID || id != r.deletion.ID {
+		return nil, fmt.Errorf("deletion request with id %d %w", id, interfaces.ErrNotFound)
+	}
+	deletion := *r.deletion
+	return &deletion, nil
+}
+
+func (r *deletionRepository) CompleteDeletion(ctx context.Context, familyID, id int, tokenHash string) (*family.DeletionRequest, error) {
+	if r.deletion.Status != family.DeletionPending || r.deletion.TokenHash != tokenHash {
+		return nil, interfaces.ErrDeletionNotPending
+	}
+	r.completed++
+	now := time.Now()
+	r.deletion.Status, r.deletion.CompletedAt = family.DeletionCompleted, &now
+	deletion := *r.deletion
+	return &deletion, nil
+}
+
+func (r *deletionRepository) Export(ctx context.Context, id int) (*interfaces.FamilyExport, error) {
+	return &interfaces.FamilyExport{Family: &family.Family{ID: id, PublicID: familyUUID(id), Name: "The Johnsons"}}, nil
+}
+
+func request(method, path, body string) handler.HTTPRequest {
+	return handler.HTTPRequest{HTTPMethod: method, Path: "/v2" + path, Body: body}
+}
+
+// emailedToken returns the confirmation token in the body of a deletion email
+func emailedToken(m notifications.Message) string {
+	for _, line := range strings.Split(m.Body, "\n") {
+		if len(line) == 64 && strings.Trim(line, "0123456789abcdef") == "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func TestDeletionWorkflow(t *testing.T) {
+	tests := []struct {
+		name           string
+		prepare        func(deletion *family.DeletionRequest)
+		token          func(token string) string
+		expectedStatus int
+		expectedErased int
+	}{
+		{"confirmed", func(*family.DeletionRequest) {}, func(token string) string { return token }, http.StatusOK, 1},
+		{"wrong token", func(*family.DeletionRequest) {}, func(string) string { return "guess" }, http.StatusForbidden, 0},
+		{"expired", func(d *family.DeletionRequest) { d.ExpiresAt = time.Now().Add(-time.Second) }, func(token string) string { return token }, http.StatusGone, 0},
+		{"already completed", func(d *family.DeletionRequest) { d.Status = family.DeletionCompleted }, func(token string) string { return token }, http.StatusConflict, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newDeletionRepository()
+			sender := &notifications.MemorySender{}
+			h := NewFamilyHandler(repo, nil, nil, nil, sender)
+
+			response, err := h.Handle(context.Background(), request(http.MethodPost, "/families/"+familyUUID(1)+"/deletions", `{"mode":"anonymize"}`))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != http.StatusAccepted {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, response.StatusCode, response.Body)
+			}
+
+			var body struct {
+				Data struct {
+					ID                int    `json:"id"`
+					Mode              string `json:"mode"`
+					Kids              int    `json:"kids"`
+					TokenHash         string `json:"token_hash"`
+					ConfirmationToken string `json:"confirmation_token"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("expected JSON body but got: %v", err)
+			}
+			if body.Data.ConfirmationToken != "" || body.Data.TokenHash != "" || body.Data.Mode != "anonymize" || body.Data.Kids != 2 {
+				t.Fatalf("expected pending anonymization without the token, got %s", response.Body)
+			}
+
+			messages := sender.Messages()
+			if len(messages) != 2 || messages[0].To != "sarah@example.com" || messages[1].To != "tom@example.com" {
+				t.Fatalf("expected the token emailed to both caregivers, got %+v", messages)
+			}
+			token := emailedToken(messages[0])
+			if token == "" || emailedToken(messages[1]) != token {
+				t.Fatalf("expected the same token in both emails, got %q", messages[0].Body)
+			}
+			if repo.deletion.TokenHash == token {
+				t.Errorf("expected the token to be stored hashed")
+			}
+
+			tt.prepare(repo.deletion)
+			response, err = h.Handle(context.Background(), request(http.MethodPost, "/families/"+familyUUID(1)+"/deletions/7/confirm",
+				`{"confirmation_token":"`+tt.token(token)+`"}`))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if repo.completed != tt.expectedErased {
+				t.Errorf("expected %d completed deletions, got %d", tt.expectedErased, repo.completed)
+			}
+		})
+	}
+}
+
+func TestRequestDeletionRejected(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		caregivers     bool
+		expectedStatus int
+	}{
+		{"invalid mode", "/families/" + familyUUID(1) + "/deletions", `{"mode":"shred"}`, true, http.StatusBadRequest},
+		{"sequential family ID", "/families/1/deletions", `{"mode":"delete"}`, true, http.StatusBadRequest},
+		{"unknown family", "/families/" + familyUUID(2) + "/deletions", `{"mode":"delete"}`, true, http.StatusNotFound},
+		{"no caregivers to email", "/families/" + familyUUID(1) + "/deletions", `{"mode":"delete"}`, false, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newDeletionRepository()
+			if !tt.caregivers {
+				repo.caregivers = nil
+			}
+			sender := &notifications.MemorySender{}
+
+			response, err := NewFamilyHandler(repo, nil, nil, nil, sender).Handle(context.Background(), request(http.MethodPost, tt.path, tt.body))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus || repo.deletion != nil || len(sender.Messages()) != 0 {
+				t.Errorf("expected status %d without a request or email, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	response, err := NewFamilyHandler(&deletionRepository{}, nil, nil, nil, nil).Handle(context.Background(), request(http.MethodGet, "/families/"+familyUUID(1)+"/export", ""))
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, response.StatusCode, response.Body)
+	}
+
+	expected := `attachment; filename="family-` + familyUUID(1) + `-export.json"`
+	if got := response.Headers["Content-Disposition"]; got != expected {
+		t.Errorf("expected Content-Disposition %q, got %q", expected, got)
+	}
+	if !strings.Contains(response.Body, `"exported_at"`) || !strings.Contains(response.Body, `"name":"The Johnsons"`) {
+		t.Errorf("expected the family in the export, got %s", response.Body)
+	}
+}
+
+// webhookRepository keeps the webhooks of family 1 in memory
+type webhookRepository struct {
+	interfaces.WebhookRepository
+	webhooks []*webhook.Webhook
+}
+
+func (r *webhookRepository) Create(ctx context.Context, w *webhook.Webhook) (*webhook.Webhook, error) {
+	if w.FamilyID != 1 {
+		return nil, fmt.Errorf("family with id %d %w", w.FamilyID, interfaces.ErrNotFound)
+	}
+	created := *w
+	created.ID, created.CreatedAt = len(r.webhooks)+1, time.Now()
+	r.webhooks = append(r.webhooks, &created)
+	return &created, nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, familyID, id int) (*webhook.Webhook, error) {
+	for _, w := range r.webhooks {
+		if w.FamilyID == familyID && w.ID == id {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("webhook with id %d %w", id, interfaces.ErrNotFound)
+}
+
+func TestCreateWebhook(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"created", "/families/" + familyUUID(1) + "/webhooks", `{"url":"https://example.com/hooks","event_types":["stars.earned","kid.created"]}`, http.StatusCreated},
+		{"unknown event type", "/families/" + familyUUID(1) + "/webhooks", `{"url":"https://example.com/hooks","event_types":["stars.stolen"]}`, http.StatusBadRequest},
+		{"not a URL", "/families/" + familyUUID(1) + "/webhooks", `{"url":"example.com","event_types":["stars.earned"]}`, http.StatusBadRequest},
+		{"no event types", "/families/" + familyUUID(1) + "/webhooks", `{"url":"https://example.com/hooks","event_types":[]}`, http.StatusBadRequest},
+		{"unknown family", "/families/" + familyUUID(2) + "/webhooks", `{"url":"https://example.com/hooks","event_types":["stars.earned"]}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &webhookRepository{}
+			response, err := NewFamilyHandler(&deletionRepository{}, repo, nil, nil, nil).Handle(context.Background(), request(http.MethodPost, tt.path, tt.body))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			var body struct {
+				Data CreatedWebhook `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("expected JSON response, got %s", response.Body)
+			}
+			if !strings.HasPrefix(body.Data.Secret, secretPrefix) || body.Data.Secret != repo.webhooks[0].Secret {
+				t.Errorf("expected the stored signing secret, got %q", body.Data.Secret)
+			}
+
+			// The secret is only returned when the webhook is created
+			response, _ = NewFamilyHandler(&deletionRepository{}, repo, nil, nil, nil).Handle(context.Background(), request(http.MethodGet, "/families/"+familyUUID(1)+"/webhooks/1", ""))
+			if response.StatusCode != http.StatusOK || strings.Contains(response.Body, body.Data.Secret) {
+				t.Errorf("expected the webhook without its secret, got %d: %s", response.StatusCode, response.Body)
+			}
+		})
+	}
+}
+
+// parentID is the public ID of the only active parent of family 1
+const parentID = "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"
+
+// invitationRepository keeps the invitations of family 1 in memory; caregiver parentID
+// is its only active parent
+type invitationRepository struct {
+	interfaces.InvitationRepository
+	invitation *invitation.Invitation
+	accepted   int
+}
+
+func (r *invitationRepository) Create(ctx context.Context, i *invitation.Invitation) (*invitation.Invitation, error) {
+	if *i.InvitedByPublicID != parentID {
+		return nil, interfaces.ErrInviterNotAllowed
+	}
+	created := *i
+	created.ID, created.Status, created.CreatedAt = 4, invitation.StatusPending, time.Now()
+	r.invitation = &created
+	return &created, nil
+}
+
+func (r *invitationRepository) GetByID(ctx context.Context, familyID, id int) (*invitation.Invitation, error) {
+	if r.invitation == nil || familyID != r.invitation.FamilyID || id != r.invitation.ID {
+		return nil, fmt.Errorf("invitation with id %d %w", id, interfaces.ErrNotFound)
+	}
+	i := *r.invitation
+	return &i, nil
+}
+
+func (r *invitationRepository) Accept(ctx context.Context, familyID, id int, tokenHash string) (*invitation.Invitation, *caregiver.Caregiver, error) {
+	if r.invitation.Status != invitation.StatusPending || r.invitation.TokenHash != tokenHash {
+		return nil, nil, interfaces.ErrInvitationNotPending
+	}
+	r.accepted++
+	r.invitation.Status = invitation.StatusAccepted
+	c := &caregiver.Caregiver{ID: 9, Name: r.invitation.Name, Email: r.invitation.Email, Relationship: r.invitation.Relationship, Status: caregiver.StatusActive}
+	i := *r.invitation
+	return &i, c, nil
+}
+
+func TestInvitationWorkflow(t *testing.T) {
+	tests := []struct {
+		name             string
+		prepare          func(i *invitation.Invitation)
+		token            func(token string) string
+		expectedStatus   int
+		expectedAccepted int
+	}{
+		{"accepted", func(*invitation.Invitation) {}, func(token string) string { return token }, http.StatusOK, 1},
+		{"wrong token", func(*invitation.Invitation) {}, func(string) string { return "guess" }, http.StatusForbidden, 0},
+		{"expired", func(i *invitation.Invitation) { i.ExpiresAt = time.Now().Add(-time.Second) }, func(token string) string { return token }, http.StatusGone, 0},
+		{"revoked", func(i *invitation.Invitation) { i.Status = invitation.StatusRevoked }, func(token string) string { return token }, http.StatusGone, 0},
+		{"already accepted", func(i *invitation.Invitation) { i.Status = invitation.StatusAccepted }, func(token string) string { return token }, http.StatusConflict, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &invitationRepository{}
+			sender := &notifications.MemorySender{}
+			h := NewFamilyHandler(&deletionRepository{}, nil, repo, nil, sender)
+
+			response, err := h.Handle(context.Background(), request(http.MethodPost, "/families/"+familyUUID(1)+"/invitations",
+				`{"email":"Grace@example.com","name":"Grace Wilson","relationship":"grandparent","invited_by":"`+parentID+`"}`))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != http.StatusCreated {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, response.StatusCode, response.Body)
+			}
+
+			// The token only reaches the invited address
+			messages := sender.Messages()
+			if len(messages) != 1 || messages[0].To != "grace@example.com" {
+				t.Fatalf("expected one email to grace@example.com, got %v", messages)
+			}
+			token := regexp.MustCompile(`[0-9a-f]{64}`).FindString(messages[0].Body)
+			if token == "" || strings.Contains(response.Body, token) || repo.invitation.TokenHash != hashToken(token) {
+				t.Fatalf("expected the hashed token to be emailed only, got %s", response.Body)
+			}
+			if !strings.Contains(messages[0].Subject, "The Johnsons") {
+				t.Errorf("expected the family in the subject, got %q", messages[0].Subject)
+			}
+
+			tt.prepare(repo.invitation)
+			response, err = h.Handle(context.Background(), request(http.MethodPost, "/families/"+familyUUID(1)+"/invitations/4/accept",
+				`{"token":"`+tt.token(token)+`"}`))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if repo.accepted != tt.expectedAccepted {
+				t.Errorf("expected %d accepted invitations, got %d", tt.expectedAccepted, repo.accepted)
+			}
+			if tt.expectedAccepted > 0 && !strings.Contains(response.Body, `"status":"active"`) {
+				t.Errorf("expected an active caregiver, got %s", response.Body)
+			}
+		})
+	}
+}
+
+func TestInviteRequiresParent(t *testing.T) {
+	sender := &notifications.MemorySender{}
+	h := NewFamilyHandler(&deletionRepository{}, nil, &invitationRepository{}, nil, sender)
+
+	response, err := h.Handle(context.Background(), request(http.MethodPost, "/families/"+familyUUID(1)+"/invitations",
+		`{"email":"grace@example.com","name":"Grace Wilson","relationship":"grandparent","invited_by":"0d9e8f7a-6b5c-4d3e-9f2a-1b0c9d8e7f6a"}`))
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if response.StatusCode != http.StatusForbidden || len(sender.Messages()) != 0 {
+		t.Errorf("expected status %d without an email, got %d: %s", http.StatusForbidden, response.StatusCode, response.Body)
+	}
+}
+
+// categoryRepository keeps the categories of family 1 in memory
+type categoryRepository struct {
+	interfaces.CategoryRepository
+	categories []*category.Category
+}
+
+func (r *categoryRepository) Create(ctx context.Context, c *category.Category) (*category.Category, error) {
+	if c.FamilyID != 1 {
+		return nil, fmt.Errorf("family with id %d %w", c.FamilyID, interfaces.ErrNotFound)
+	}
+	for _, existing := range r.categories {
+		if strings.EqualFold(existing.Name, c.Name) {
+			return nil, interfaces.ErrCategoryExists
+		}
+	}
+	created := *c
+	created.ID, created.CreatedAt = len(r.categories)+1, time.Now()
+	r.categories = append(r.categories, &created)
+	return &created, nil
+}
+
+func TestCreateCategory(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"created", "/families/" + familyUUID(1) + "/categories", `{"name":"Homework"}`, http.StatusCreated},
+		{"same name ignoring case", "/families/" + familyUUID(1) + "/categories", `{"name":"chores"}`, http.StatusConflict},
+		{"blank name", "/families/" + familyUUID(1) + "/categories", `{"name":"   "}`, http.StatusBadRequest},
+		{"name too long", "/families/" + familyUUID(1) + "/categories", `{"name":"` + strings.Repeat("a", category.MaxNameLength+1) + `"}`, http.StatusBadRequest},
+		{"unknown family", "/families/" + familyUUID(2) + "/categories", `{"name":"Homework"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &categoryRepository{categories: []*category.Category{{ID: 1, FamilyID: 1, Name: "Chores"}}}
+			response, err := NewFamilyHandler(&deletionRepository{}, nil, nil, repo, nil).Handle(context.Background(), request(http.MethodPost, tt.path, tt.body))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus == http.StatusCreated && len(repo.categories) != 2 {
+				t.Errorf("expected the category to be stored, got %d categories", len(repo.categories))
+			}
+		})
+	}
+}
+
+// searchRepository records the search filter and finds one kid in family 1
+type searchRepository struct {
+	deletionRepository
+	filter interfaces.SearchFilter
+}
+
+func (r *searchRepository) Search(ctx context.Context, filter interfaces.SearchFilter) (*interfaces.SearchResults, error) {
+	r.filter = filter
+	if _, err := r.GetByID(ctx, filter.FamilyID); err != nil {
+		return nil, err
+	}
+	kidID := "2b8d4e6f-1a3c-4b5d-9e7f-0a2c4e6a8b0d"
+	return &interfaces.SearchResults{Total: 1, Results: []*interfaces.SearchResult{
+		{Kind: interfaces.SearchKid, ID: kidID, KidID: &kidID, Text: "Emma", Highlight: "<mark>Emma</mark>"},
+	}}, nil
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          map[string]string
+		expectedStatus int
+		expectedFilter interfaces.SearchFilter
+	}{
+		{"default page", map[string]string{"q": " cleaned the garage ", "family_id": familyUUID(1)}, http.StatusOK, interfaces.SearchFilter{FamilyID: 1, Query: "cleaned the garage", Limit: DefaultSearchLimit}},
+		{"second page", map[string]string{"q": "emma", "family_id": familyUUID(1), "limit": "5", "offset": "5"}, http.StatusOK, interfaces.SearchFilter{FamilyID: 1, Query: "emma", Limit: 5, Offset: 5}},
+		{"missing query", map[string]string{"family_id": familyUUID(1)}, http.StatusBadRequest, interfaces.SearchFilter{}},
+		{"blank query", map[string]string{"q": "  ", "family_id": familyUUID(1)}, http.StatusBadRequest, interfaces.SearchFilter{}},
+		{"missing family", map[string]string{"q": "emma"}, http.StatusBadRequest, interfaces.SearchFilter{}},
+		{"limit too large", map[string]string{"q": "emma", "family_id": familyUUID(1), "limit": "1000"}, http.StatusBadRequest, interfaces.SearchFilter{}},
+		{"negative offset", map[string]string{"q": "emma", "family_id": familyUUID(1), "offset": "-1"}, http.StatusBadRequest, interfaces.SearchFilter{}},
+		{"unknown family", map[string]string{"q": "emma", "family_id": familyUUID(2)}, http.StatusNotFound, interfaces.SearchFilter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &searchRepository{}
+			response, err := NewFamilyHandler(repo, nil, nil, nil, nil).Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod:            http.MethodGet,
+				Path:                  "/v2/search",
+				QueryStringParameters: tt.query,
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if repo.filter != tt.expectedFilter {
+				t.Errorf("expected filter %+v, got %+v", tt.expectedFilter, repo.filter)
+			}
+
+			var body struct {
+				Data interfaces.SearchResults `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("expected JSON response, got %s", response.Body)
+			}
+			if body.Data.Total != 1 || body.Data.Results[0].Highlight != "<mark>Emma</mark>" {
+				t.Errorf("expected the highlighted kid, got %s", response.Body)
+			}
+		})
+	}
+}
+
+// memberRepository records the kid moved between families; kid memberKidID exists
+type memberRepository struct {
+	deletionRepository
+	kidID  string
+	family int
+}
+
+// memberKidID is the public ID of the kid memberRepository knows
+const memberKidID = "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+
+func (r *memberRepository) SetKidFamily(ctx context.Context, kidID string, familyID int) error {
+	if kidID != memberKidID {
+		return fmt.Errorf("kid with id %s %w", kidID, interfaces.ErrNotFound)
+	}
+	r.kidID, r.family = kidID, familyID
+	return nil
+}
+
+func TestSetMember(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		kidID          string
+		expectedStatus int
+		expectedFamily int
+	}{
+		{"add kid", http.MethodPut, memberKidID, http.StatusOK, 1},
+		{"uppercase public ID", http.MethodPut, strings.ToUpper(memberKidID), http.StatusOK, 1},
+		{"remove kid", http.MethodDelete, memberKidID, http.StatusOK, 0},
+		{"sequential ID", http.MethodPut, "1", http.StatusBadRequest, 0},
+		{"unknown kid", http.MethodPut, "00000000-0000-4000-8000-000000000000", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memberRepository{family: -1}
+			response, err := NewFamilyHandler(repo, nil, nil, nil, nil).Handle(context.Background(),
+				request(tt.method, "/families/"+familyUUID(1)+"/kids/"+tt.kidID, ""))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus == http.StatusOK && (repo.kidID != memberKidID || repo.family != tt.expectedFamily) {
+				t.Errorf("expected kid %s in family %d, got kid %s in family %d", memberKidID, tt.expectedFamily, repo.kidID, repo.family)
+			}
+		})
+	}
+}
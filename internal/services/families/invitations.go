@@ -0,0 +1,261 @@
+package families
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/caregiver"
+	"github.com/lukasz/astras-mono-api/internal/models/invitation"
+	"github.com/lukasz/astras-mono-api/internal/notifications"
+)
+
+// InvitationRequest represents the payload inviting an email address to the family
+type InvitationRequest struct {
+	Email        string `json:"email" validate:"required,email"`                                                       // Address the invitation is emailed to
+	Name         string `json:"name" validate:"required,min=2,max=100"`                                                // Name of the caregiver to create
+	Relationship string `json:"relationship" validate:"required,oneof=parent guardian grandparent relative caregiver"` // Relationship of the caregiver to the kids
+	InvitedBy    string `json:"invited_by" validate:"required,uuid"`                                                   // Public ID of the active parent or guardian of the family sending the invitation
+}
+
+// AcceptanceRequest represents the payload accepting an invitation
+type AcceptanceRequest struct {
+	Token string `json:"token" validate:"required"` // Token emailed to the invitee
+}
+
+// AcceptedInvitation is an accepted invitation with the caregiver it created or linked
+type AcceptedInvitation struct {
+	Invitation invitation.Invitation `json:"invitation"`
+	Caregiver  caregiver.Caregiver   `json:"caregiver"`
+}
+
+// ListInvitations returns the invitations of the family, newest first
+func (h *FamilyHandler) ListInvitations(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	invitations, err := h.invitations.GetByFamily(ctx, familyID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to get invitations: %w", err)
+	}
+
+	invitationList := make([]invitation.Invitation, len(invitations))
+	for i, inv := range invitations {
+		invitationList[i] = *inv
+	}
+
+	return handler.Response{
+		Message: "Invitations retrieved successfully",
+		Service: ServiceName,
+		Data:    invitationList,
+	}, nil
+}
+
+// Invite records a pending invitation of an email address to the family and emails
+// the invitee the single-use token that accepts it within invitation.TTL. The token is
+// never returned to the inviter, so accepting proves the invitee owns the address.
+func (h *FamilyHandler) Invite(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var invitationRequest InvitationRequest
+	if err := handler.DecodeJSON(request.Body, &invitationRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	f, err := h.repo.GetByID(ctx, familyID)
+	if err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+
+	token, err := newConfirmationToken()
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	inv := &invitation.Invitation{
+		FamilyID:          familyID,
+		Email:             invitationRequest.Email,
+		Name:              invitationRequest.Name,
+		Relationship:      caregiver.RelationshipType(invitationRequest.Relationship),
+		InvitedByPublicID: &invitationRequest.InvitedBy,
+		TokenHash:         hashToken(token),
+		ExpiresAt:         time.Now().Add(invitation.TTL),
+	}
+	if err := inv.Validate(); err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	created, err := h.invitations.Create(ctx, inv)
+	if err != nil {
+		switch {
+		case errors.Is(err, interfaces.ErrInviterNotAllowed):
+			return handler.Response{}, handler.NewError(http.StatusForbidden, err.Error())
+		case errors.Is(err, interfaces.ErrAlreadyInvited):
+			return handler.Response{}, handler.NewError(http.StatusConflict, err.Error()+", revoke the pending invitation to send a new one")
+		case errors.Is(err, interfaces.ErrNotFound):
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	// An invitation nobody received cannot be accepted, so it does not stay pending
+	message, err := notifications.InvitationMessage(created, f, token)
+	if err == nil {
+		err = h.sender.Send(ctx, message)
+	}
+	if err != nil {
+		if _, revokeErr := h.invitations.Revoke(ctx, familyID, created.ID); revokeErr != nil {
+			err = errors.Join(err, revokeErr)
+		}
+		return handler.Response{}, fmt.Errorf("failed to send invitation: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Invitation sent to %s, it expires at %s", created.Email, created.ExpiresAt.UTC().Format(time.RFC3339)),
+		Service: ServiceName,
+		Data:    *created,
+	}, nil
+}
+
+// GetInvitation returns an invitation of the family
+func (h *FamilyHandler) GetInvitation(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.invitationPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	inv, err := h.invitations.GetByID(ctx, familyID, id)
+	if err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Invitation %d retrieved successfully", id),
+		Service: ServiceName,
+		Data:    *inv,
+	}, nil
+}
+
+// RevokeInvitation withdraws a pending invitation, so its token can no longer be used
+func (h *FamilyHandler) RevokeInvitation(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.invitationPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	revoked, err := h.invitations.Revoke(ctx, familyID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, interfaces.ErrInvitationNotPending):
+			return handler.Response{}, handler.NewError(http.StatusConflict, "invitation has already been accepted or revoked")
+		case errors.Is(err, interfaces.ErrNotFound):
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Invitation %d revoked successfully", id),
+		Service: ServiceName,
+		Data:    *revoked,
+	}, nil
+}
+
+// AcceptInvitation accepts a pending invitation with the token emailed to the invitee,
+// creating the caregiver, or linking the caregiver with the invited email, as an active
+// caregiver of the family
+func (h *FamilyHandler) AcceptInvitation(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.invitationPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var acceptance AcceptanceRequest
+	if err := handler.DecodeJSON(request.Body, &acceptance); err != nil {
+		return handler.Response{}, err
+	}
+
+	// Explain why an invitation cannot be accepted; the repository re-checks atomically
+	inv, err := h.invitations.GetByID(ctx, familyID, id)
+	if err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+	switch {
+	case inv.Status == invitation.StatusAccepted:
+		return handler.Response{}, handler.NewError(http.StatusConflict, "invitation has already been accepted")
+	case inv.Status == invitation.StatusRevoked:
+		return handler.Response{}, handler.NewError(http.StatusGone, "invitation has been revoked")
+	case inv.Expired():
+		return handler.Response{}, handler.NewError(http.StatusGone, "invitation has expired, ask for a new one")
+	case inv.TokenHash != hashToken(acceptance.Token):
+		return handler.Response{}, handler.NewError(http.StatusForbidden, "invalid invitation token")
+	}
+
+	accepted, c, err := h.invitations.Accept(ctx, familyID, id, hashToken(acceptance.Token))
+	if err != nil {
+		switch {
+		case errors.Is(err, interfaces.ErrInvitationNotPending):
+			return handler.Response{}, handler.NewError(http.StatusConflict, "invitation is no longer pending")
+		case errors.Is(err, interfaces.ErrCaregiverInOtherFamily):
+			return handler.Response{}, handler.NewError(http.StatusConflict, err.Error()+", ask that family to remove you first")
+		}
+		return handler.Response{}, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Caregiver %s joined the family", c.PublicID),
+		Service: ServiceName,
+		Data:    AcceptedInvitation{Invitation: *accepted, Caregiver: *c},
+	}, nil
+}
+
+// RevokeCaregiver takes a caregiver's access to the family away
+func (h *FamilyHandler) RevokeCaregiver(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+	caregiverID, err := pathPublicID(request, "caregiver_id", "caregiver")
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	c, err := h.invitations.RevokeCaregiver(ctx, familyID, caregiverID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to revoke caregiver: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Caregiver %s revoked from the family", caregiverID),
+		Service: ServiceName,
+		Data:    *c,
+	}, nil
+}
+
+// invitationPathIDs resolves the family and invitation IDs of an invitation resource
+func (h *FamilyHandler) invitationPathIDs(ctx context.Context, request handler.HTTPRequest) (int, int, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := pathID(request, "invitation_id", "invitation")
+	if err != nil {
+		return 0, 0, err
+	}
+	return familyID, id, nil
+}
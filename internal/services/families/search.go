@@ -0,0 +1,87 @@
+package families
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+)
+
+// Limits of the number of search results returned by one request
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// MaxSearchQueryLength is the longest accepted search query
+const MaxSearchQueryLength = 200
+
+// Search finds the transactions, kids and caregivers of the family (?family_id=)
+// matching ?q=, best matches first, ?limit= results at a time from ?offset=
+func (h *FamilyHandler) Search(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	filter := interfaces.SearchFilter{
+		Query: strings.TrimSpace(handler.QueryString(request, "q")),
+		Limit: DefaultSearchLimit,
+	}
+	if filter.Query == "" {
+		return handler.Response{}, fmt.Errorf("q is required")
+	}
+	if utf8.RuneCountInString(filter.Query) > MaxSearchQueryLength {
+		return handler.Response{}, fmt.Errorf("q cannot exceed %d characters", MaxSearchQueryLength)
+	}
+
+	familyPublicID, err := handler.QueryUUID(request, "family_id")
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if familyPublicID == "" {
+		return handler.Response{}, fmt.Errorf("family_id is required")
+	}
+	if filter.FamilyID, err = h.repo.ResolveID(ctx, familyPublicID); err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to get family: %w", err)
+	}
+
+	limit, err := handler.QueryInt(request, "limit")
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if limit != nil {
+		if *limit <= 0 || *limit > MaxSearchLimit {
+			return handler.Response{}, fmt.Errorf("limit must be between 1 and %d", MaxSearchLimit)
+		}
+		filter.Limit = *limit
+	}
+
+	offset, err := handler.QueryInt(request, "offset")
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if offset != nil {
+		if *offset < 0 {
+			return handler.Response{}, fmt.Errorf("offset cannot be negative")
+		}
+		filter.Offset = *offset
+	}
+
+	results, err := h.repo.Search(ctx, filter)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to search: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Found %d results", results.Total),
+		Service: ServiceName,
+		Data:    *results,
+	}, nil
+}
@@ -0,0 +1,246 @@
+package families
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/webhook"
+)
+
+// Limits of the number of deliveries returned by one request
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 500
+)
+
+// secretPrefix marks webhook signing secrets, making leaked ones easy to recognize
+const secretPrefix = "whsec_"
+
+// WebhookRequest represents the payload for creating or changing a webhook
+type WebhookRequest struct {
+	URL        string   `json:"url" validate:"required,max=2048"`      // http or https URL receiving the events
+	EventTypes []string `json:"event_types" validate:"required,min=1"` // Events to receive, e.g. stars.earned
+	Active     *bool    `json:"active,omitempty"`                      // Pause (false) or resume (true) deliveries, defaults to true
+}
+
+// CreatedWebhook is a newly created webhook with the secret its deliveries are signed
+// with. The secret is only ever returned here.
+type CreatedWebhook struct {
+	webhook.Webhook
+	Secret string `json:"secret"`
+}
+
+// webhook builds the webhook of a family described by the request
+func (r WebhookRequest) webhook(familyID int) *webhook.Webhook {
+	w := &webhook.Webhook{
+		FamilyID:   familyID,
+		URL:        r.URL,
+		EventTypes: r.EventTypes,
+		Active:     true,
+	}
+	if r.Active != nil {
+		w.Active = *r.Active
+	}
+	return w
+}
+
+// ListWebhooks returns the webhooks of the family
+func (h *FamilyHandler) ListWebhooks(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	webhooks, err := h.webhooks.GetByFamily(ctx, familyID)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	webhookList := make([]webhook.Webhook, len(webhooks))
+	for i, w := range webhooks {
+		webhookList[i] = *w
+	}
+
+	return handler.Response{
+		Message: "Webhooks retrieved successfully",
+		Service: ServiceName,
+		Data:    webhookList,
+	}, nil
+}
+
+// CreateWebhook subscribes a URL to the family's events and returns the secret that
+// signs every delivery
+func (h *FamilyHandler) CreateWebhook(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var webhookRequest WebhookRequest
+	if err := handler.DecodeJSON(request.Body, &webhookRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	w := webhookRequest.webhook(familyID)
+	if err := w.Validate(); err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	w.Secret, err = newWebhookSecret()
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	created, err := h.webhooks.Create(ctx, w)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Webhook %d created successfully", created.ID),
+		Service: ServiceName,
+		Data:    CreatedWebhook{Webhook: *created, Secret: created.Secret},
+	}, nil
+}
+
+// GetWebhook returns a webhook of the family
+func (h *FamilyHandler) GetWebhook(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.webhookPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	w, err := h.webhooks.GetByID(ctx, familyID, id)
+	if err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Webhook %d retrieved successfully", id),
+		Service: ServiceName,
+		Data:    *w,
+	}, nil
+}
+
+// UpdateWebhook changes the URL and event types of a webhook, or pauses or resumes it.
+// Resuming a webhook disabled by repeated failures resets its failure count.
+func (h *FamilyHandler) UpdateWebhook(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.webhookPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var webhookRequest WebhookRequest
+	if err := handler.DecodeJSON(request.Body, &webhookRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	w := webhookRequest.webhook(familyID)
+	w.ID = id
+	if err := w.Validate(); err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	updated, err := h.webhooks.Update(ctx, w)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Webhook %d updated successfully", id),
+		Service: ServiceName,
+		Data:    *updated,
+	}, nil
+}
+
+// DeleteWebhook removes a webhook of the family together with its delivery log
+func (h *FamilyHandler) DeleteWebhook(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.webhookPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	if err := h.webhooks.Delete(ctx, familyID, id); err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Webhook %d deleted successfully", id),
+		Service: ServiceName,
+	}, nil
+}
+
+// WebhookDeliveries returns the delivery log of a webhook, newest first
+func (h *FamilyHandler) WebhookDeliveries(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	familyID, id, err := h.webhookPathIDs(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	limit := DefaultDeliveryLimit
+	requested, err := handler.QueryInt(request, "limit")
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if requested != nil {
+		if *requested <= 0 || *requested > MaxDeliveryLimit {
+			return handler.Response{}, fmt.Errorf("limit must be between 1 and %d", MaxDeliveryLimit)
+		}
+		limit = *requested
+	}
+
+	// Deliveries are looked up by webhook, make sure it belongs to the family
+	if _, err := h.webhooks.GetByID(ctx, familyID, id); err != nil {
+		return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+	}
+
+	deliveries, err := h.webhooks.Deliveries(ctx, id, limit)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	deliveryList := make([]webhook.Delivery, len(deliveries))
+	for i, d := range deliveries {
+		deliveryList[i] = *d
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Deliveries of webhook %d retrieved successfully", id),
+		Service: ServiceName,
+		Data:    deliveryList,
+	}, nil
+}
+
+// webhookPathIDs resolves the family and webhook IDs of a webhook resource
+func (h *FamilyHandler) webhookPathIDs(ctx context.Context, request handler.HTTPRequest) (int, int, error) {
+	familyID, _, err := h.familyID(ctx, request)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := pathID(request, "webhook_id", "webhook")
+	if err != nil {
+		return 0, 0, err
+	}
+	return familyID, id, nil
+}
+
+// newWebhookSecret returns a random signing secret
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,511 @@
+// Package kids implements the Kid Service handlers.
+// The service manages children/kids in the Astras system, providing
+// full CRUD operations through a RESTful API interface.
+package kids
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/kid"
+	"github.com/lukasz/astras-mono-api/internal/openapi"
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+// ServiceName identifies the Kid Service in responses and logs
+const ServiceName = "kid-service"
+
+var (
+	kidRequestSchema = schema.Generate(KidRequest{}, kid.Kid{})
+	kidIDParam       = handler.UUIDPathParam("id", "Kid ID")
+
+	// restoreResource is the route template of the restore endpoint
+	restoreResource = "/kids/{id}/restore"
+
+	// v2 returns kids without the computed age (see KidV2)
+	kidV2Response     = map[string]*schema.Schema{"v2": handler.ResponseSchema(KidV2{}, kid.Kid{})}
+	kidListV2Response = map[string]*schema.Schema{"v2": handler.ResponseSchema([]KidV2{}, kid.Kid{})}
+)
+
+// Routes lists the API Gateway routes served by the Kid Service (see template.yaml)
+var Routes = []handler.Route{
+	{
+		Method:  http.MethodGet,
+		Path:    "/kids",
+		Summary: "List kids",
+		Params: []handler.Param{
+			handler.QueryParam("min_age", "integer", "Only kids at least this old"),
+			handler.QueryParam("max_age", "integer", "Only kids at most this old"),
+			handler.QueryParam("deleted", "boolean", "List soft-deleted kids instead, e.g. to restore one"),
+			handler.QueryParam("sort", "string", "Comma-separated sort fields (id, name, birthdate, created_at, updated_at), prefix with - for descending"),
+		},
+		Response:          handler.ResponseSchema([]kid.Kid{}),
+		ResponseByVersion: kidListV2Response,
+	},
+	{
+		Method:            http.MethodPost,
+		Path:              "/kids",
+		Summary:           "Create a kid",
+		Body:              kidRequestSchema,
+		Status:            http.StatusCreated,
+		Response:          handler.ResponseSchema(kid.Kid{}),
+		ResponseByVersion: kidV2Response,
+	},
+	{
+		Method:            http.MethodGet,
+		Path:              "/kids/{id}",
+		Summary:           "Get a kid",
+		Params:            []handler.Param{kidIDParam, handler.IfNoneMatchParam},
+		Response:          handler.ResponseSchema(kid.Kid{}),
+		ResponseByVersion: kidV2Response,
+	},
+	{
+		Method:            http.MethodPut,
+		Path:              "/kids/{id}",
+		Summary:           "Replace a kid",
+		Params:            []handler.Param{kidIDParam, handler.IfMatchParam},
+		Body:              kidRequestSchema,
+		Response:          handler.ResponseSchema(kid.Kid{}),
+		ResponseByVersion: kidV2Response,
+	},
+	{
+		Method:            http.MethodPatch,
+		Path:              "/kids/{id}",
+		Summary:           "Partially update a kid with a JSON Merge Patch",
+		Params:            []handler.Param{kidIDParam, handler.IfMatchParam},
+		Body:              schema.Nullable(kidRequestSchema),
+		Response:          handler.ResponseSchema(kid.Kid{}),
+		ResponseByVersion: kidV2Response,
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     "/kids/{id}",
+		Summary:  "Delete a kid, keeping it restorable until it is purged",
+		Params:   []handler.Param{kidIDParam, handler.IfMatchParam},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:            http.MethodPost,
+		Path:              restoreResource,
+		Summary:           "Restore a deleted kid",
+		Params:            []handler.Param{kidIDParam},
+		Response:          handler.ResponseSchema(kid.Kid{}),
+		ResponseByVersion: kidV2Response,
+	},
+	openapi.SpecRoute,
+}
+
+// KidRequest represents the payload for creating or updating a kid.
+// Used for parsing JSON requests in POST, PUT and PATCH operations.
+type KidRequest struct {
+	Name      string `json:"name,omitempty" validate:"required"`                           // Child's name
+	Age       int    `json:"age,omitempty" validate:"min=0,max=18"`                        // Child's age
+	Birthdate string `json:"birthdate,omitempty" validate:"omitempty,datetime=2006-01-02"` // Exact date of birth (YYYY-MM-DD), takes precedence over age
+}
+
+// ToKid converts a KidRequest to a Kid model with generated fields.
+// Uses the exact birthdate when given, otherwise converts age to an approximate
+// birthdate, and sets timestamps. Accepts an optional ID for updates.
+func (kr *KidRequest) ToKid(id ...int) (*kid.Kid, error) {
+	// Convert age to approximate birthdate (assuming birthday hasn't occurred this year)
+	now := time.Now()
+	birthdate := time.Date(now.Year()-kr.Age, now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if kr.Birthdate != "" {
+		parsed, err := time.Parse(kid.BirthdateFormat, strings.TrimSpace(kr.Birthdate))
+		if err != nil {
+			return nil, fmt.Errorf("birthdate must be in YYYY-MM-DD format")
+		}
+		birthdate = parsed
+	}
+
+	kidModel := &kid.Kid{
+		Name:      strings.TrimSpace(kr.Name),
+		Birthdate: birthdate,
+		CreatedAt: time.Now(),
+	}
+
+	if len(id) > 0 && id[0] > 0 {
+		kidModel.ID = id[0]
+		kidModel.UpdatedAt = time.Now()
+	}
+
+	if err := kidModel.Validate(); err != nil {
+		return nil, err
+	}
+
+	return kidModel, nil
+}
+
+// KidV2 is the v2 representation of a kid. The age that v1 computes in
+// Kid.MarshalJSON changes without the record changing, so v2 leaves it to the
+// client and returns the birthdate as a plain date.
+type KidV2 struct {
+	ID        string     `json:"id" validate:"uuid"`                       // Public identifier
+	Name      string     `json:"name"`                                     // Full name of the child
+	Birthdate string     `json:"birthdate" validate:"datetime=2006-01-02"` // Date of birth (YYYY-MM-DD)
+	CreatedAt time.Time  `json:"created_at"`                               // Record creation timestamp
+	UpdatedAt time.Time  `json:"updated_at"`                               // Last update timestamp
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`                     // Set while the kid is soft deleted
+}
+
+// kidData returns a kid in the response shape of the API version serving the request
+func kidData(request handler.HTTPRequest, k *kid.Kid) any {
+	if request.Version != "v2" {
+		return *k
+	}
+	return KidV2{
+		ID:        k.PublicID,
+		Name:      k.Name,
+		Birthdate: k.FormatBirthdate(),
+		CreatedAt: k.CreatedAt,
+		UpdatedAt: k.UpdatedAt,
+		DeletedAt: k.DeletedAt,
+	}
+}
+
+// KidHandler implements the handler.Handler interface for kid-specific operations.
+// This struct contains all the business logic for managing kids in the system.
+type KidHandler struct {
+	repo interfaces.KidRepository
+}
+
+// NewKidHandler creates a new kid handler with database repository
+func NewKidHandler(repo interfaces.KidRepository) *KidHandler {
+	return &KidHandler{
+		repo: repo,
+	}
+}
+
+// GetAll retrieves and returns a list of kids in the system.
+// Supports optional filtering by ?min_age= and ?max_age= and ordering by ?sort=
+// (e.g. ?sort=-created_at,name).
+func (h *KidHandler) GetAll(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	filter, err := parseKidFilter(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	kids, err := h.repo.Find(ctx, filter)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get all kids: %w", err)
+	}
+
+	// Convert to the response shape of the requested API version
+	kidList := make([]any, len(kids))
+	for i, k := range kids {
+		kidList[i] = kidData(request, k)
+	}
+
+	return handler.Response{
+		Message: "Kids retrieved successfully",
+		Service: "kid-service",
+		Data:    kidList,
+	}, nil
+}
+
+// parseKidFilter builds a repository filter from the list endpoint query string
+func parseKidFilter(request handler.HTTPRequest) (interfaces.KidFilter, error) {
+	minAge, err := handler.QueryInt(request, "min_age")
+	if err != nil {
+		return interfaces.KidFilter{}, err
+	}
+
+	maxAge, err := handler.QueryInt(request, "max_age")
+	if err != nil {
+		return interfaces.KidFilter{}, err
+	}
+
+	if minAge != nil && maxAge != nil && *minAge > *maxAge {
+		return interfaces.KidFilter{}, fmt.Errorf("min_age cannot be greater than max_age")
+	}
+
+	deleted, err := handler.QueryBool(request, "deleted")
+	if err != nil {
+		return interfaces.KidFilter{}, err
+	}
+
+	return interfaces.KidFilter{
+		MinAge:  minAge,
+		MaxAge:  maxAge,
+		Deleted: deleted,
+		Sort:    handler.QueryString(request, "sort"),
+	}, nil
+}
+
+// GetByID retrieves a specific kid by their unique identifier.
+// Extracts the kid ID from the URL path parameters and queries the database.
+func (h *KidHandler) GetByID(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.kidID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	kidModel, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get kid: %w", err)
+	}
+
+	etag := handler.ETag(kidModel.UpdatedAt)
+	if handler.NotModified(request, etag) {
+		return handler.Response{
+			StatusCode: http.StatusNotModified,
+			Headers:    map[string]string{"ETag": etag},
+		}, nil
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s retrieved successfully", publicID),
+		Service: "kid-service",
+		Data:    kidData(request, kidModel),
+		Headers: map[string]string{"ETag": etag},
+	}, nil
+}
+
+// Create processes a request to add a new kid to the system.
+// Parses the request body JSON and validates the kid data before creation.
+// Returns the newly created kid data with a generated ID.
+func (h *KidHandler) Create(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	var kidRequest KidRequest
+	// Parse and validate the incoming JSON request body
+	if err := handler.DecodeJSON(request.Body, &kidRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	// Convert request to model and validate
+	kidModel, err := kidRequest.ToKid()
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	// Save to database
+	createdKid, err := h.repo.Create(ctx, kidModel)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to create kid: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s created successfully", createdKid.Name),
+		Service: "kid-service",
+		Data:    kidData(request, createdKid),
+		Headers: map[string]string{"ETag": handler.ETag(createdKid.UpdatedAt)},
+	}, nil
+}
+
+// Update modifies an existing kid's information in the system.
+// Takes the kid ID from URL parameters and new data from request body.
+// Returns the updated kid data after successful modification.
+func (h *KidHandler) Update(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.kidID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var kidRequest KidRequest
+	// Parse and validate the incoming JSON update data
+	if err := handler.DecodeJSON(request.Body, &kidRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	// Convert request to model with existing ID and validate
+	kidModel, err := kidRequest.ToKid(id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	// Update in database
+	updatedKid, err := h.repo.Update(ctx, kidModel, ifMatch...)
+	if err != nil {
+		return handler.Response{}, versionError(err, "failed to update kid")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s updated successfully", publicID),
+		Service: "kid-service",
+		Data:    kidData(request, updatedKid),
+		Headers: map[string]string{"ETag": handler.ETag(updatedKid.UpdatedAt)},
+	}, nil
+}
+
+// Patch partially modifies an existing kid using JSON Merge Patch semantics.
+// The patch is applied to the stored kid, so omitted fields keep their current
+// values; the merged result is then validated like a full update.
+func (h *KidHandler) Patch(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.kidID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := handler.DecodeJSON(request.Body, &members); err != nil {
+		return handler.Response{}, err
+	}
+
+	storedKid, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get kid: %w", err)
+	}
+
+	base := KidRequest{
+		Name:      storedKid.Name,
+		Birthdate: storedKid.FormatBirthdate(),
+	}
+
+	// Age and birthdate describe the same thing, so patching age replaces the stored birthdate
+	_, patchesAge := members["age"]
+	if patchesAge {
+		base.Birthdate = ""
+	}
+
+	var kidRequest KidRequest
+	if err := handler.ApplyMergePatch(base, request.Body, &kidRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	if kidRequest.Birthdate == "" && !patchesAge {
+		return handler.Response{}, fmt.Errorf("validation failed: birthdate is required")
+	}
+
+	// Convert merged request to model with existing ID and validate
+	kidModel, err := kidRequest.ToKid(id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	updatedKid, err := h.repo.Update(ctx, kidModel, ifMatch...)
+	if err != nil {
+		return handler.Response{}, versionError(err, "failed to update kid")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s updated successfully", publicID),
+		Service: "kid-service",
+		Data:    kidData(request, updatedKid),
+		Headers: map[string]string{"ETag": handler.ETag(updatedKid.UpdatedAt)},
+	}, nil
+}
+
+// Delete soft deletes a kid by their unique identifier.
+// The kid and their transaction history are kept until restored or purged.
+// Returns a confirmation message upon successful removal.
+func (h *KidHandler) Delete(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.kidID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Delete from database
+	if err := h.repo.Delete(ctx, id, ifMatch...); err != nil {
+		return handler.Response{}, versionError(err, "failed to delete kid")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s deleted successfully", publicID),
+		Service: "kid-service",
+	}, nil
+}
+
+// Restore brings back a soft-deleted kid, together with their transaction history
+func (h *KidHandler) Restore(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.kidID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	restoredKid, err := h.repo.Restore(ctx, id)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotDeleted) {
+			return handler.Response{}, handler.NewError(http.StatusConflict, fmt.Sprintf("kid %s is not deleted", publicID))
+		}
+		return handler.Response{}, fmt.Errorf("failed to restore kid: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s restored successfully", publicID),
+		Service: "kid-service",
+		Data:    kidData(request, restoredKid),
+		Headers: map[string]string{"ETag": handler.ETag(restoredKid.UpdatedAt)},
+	}, nil
+}
+
+// kidID resolves the public ID in the {id} path parameter to the kid's internal ID
+func (h *KidHandler) kidID(ctx context.Context, request handler.HTTPRequest) (int, string, error) {
+	publicID := request.PathParameters["id"]
+	if !handler.IsUUID(publicID) {
+		return 0, "", fmt.Errorf("invalid kid ID: %s", publicID)
+	}
+	publicID = strings.ToLower(publicID)
+
+	id, err := h.repo.ResolveID(ctx, publicID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return 0, "", handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return 0, "", fmt.Errorf("failed to get kid: %w", err)
+	}
+
+	return id, publicID, nil
+}
+
+// versionError reports a failed If-Match precondition as 412 Precondition Failed
+// and wraps any other repository error with the given context.
+func versionError(err error, action string) error {
+	if errors.Is(err, interfaces.ErrVersionConflict) {
+		return handler.NewError(http.StatusPreconditionFailed, "kid has been modified, fetch the latest version and retry")
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+// Handle is the entry point for all HTTP requests to the Kid Service.
+// It resolves the API version and delegates to the shared CRUD router.
+func (h *KidHandler) Handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	return handler.ServeVersion(ctx, request, Routes, handler.DefaultSchedule, h.handle)
+}
+
+// handle serves a request in the API version resolved by Handle
+func (h *KidHandler) handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	// Serve the API contract generated from the routes
+	if request.Path == openapi.Path {
+		return openapi.Serve(ServiceName, Routes, request.Version)
+	}
+
+	// Reject malformed bodies with field-level errors before any handler logic
+	if err := handler.ValidateRequest(request, Routes); err != nil {
+		return handler.ErrorResponse(err), nil
+	}
+
+	if request.Resource == restoreResource {
+		response, err := h.Restore(ctx, request)
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return handler.Respond(http.StatusOK, response), nil
+	}
+
+	return handler.HandleRequest(ctx, request, h)
+}
@@ -0,0 +1,230 @@
+package kids
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/kid"
+)
+
+var (
+	// storedBirthdate is the birthdate of the kid held by patchRepository
+	storedBirthdate = time.Date(2015, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	// storedVersion is the last modification time of the kid held by patchRepository
+	storedVersion = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// storedPublicID is the public ID of the kid held by patchRepository, whose internal ID is 1
+const storedPublicID = "6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"
+
+// patchRepository holds a single kid and records the last update it was given.
+// Writes naming a version other than storedVersion fail with ErrVersionConflict.
+type patchRepository struct {
+	interfaces.KidRepository
+	updated *kid.Kid
+	deleted bool
+}
+
+func (r *patchRepository) ResolveID(ctx context.Context, publicID string) (int, error) {
+	if publicID != storedPublicID {
+		return 0, fmt.Errorf("kid with id %s %w", publicID, interfaces.ErrNotFound)
+	}
+	return 1, nil
+}
+
+func (r *patchRepository) GetByID(ctx context.Context, id int) (*kid.Kid, error) {
+	return &kid.Kid{ID: id, Name: "Alice", Birthdate: storedBirthdate, UpdatedAt: storedVersion}, nil
+}
+
+func (r *patchRepository) Update(ctx context.Context, k *kid.Kid, ifMatch ...time.Time) (*kid.Kid, error) {
+	if !matchesStoredVersion(ifMatch) {
+		return nil, interfaces.ErrVersionConflict
+	}
+	r.updated = k
+	return k, nil
+}
+
+func (r *patchRepository) Delete(ctx context.Context, id int, ifMatch ...time.Time) error {
+	if !matchesStoredVersion(ifMatch) {
+		return interfaces.ErrVersionConflict
+	}
+	r.deleted = true
+	return nil
+}
+
+// matchesStoredVersion reports whether an If-Match precondition allows overwriting storedVersion
+func matchesStoredVersion(ifMatch []time.Time) bool {
+	if len(ifMatch) == 0 {
+		return true
+	}
+	for _, version := range ifMatch {
+		if version.Equal(storedVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPatchKid(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name              string
+		body              string
+		expectedStatus    int
+		expectedName      string
+		expectedBirthdate string
+	}{
+		{"empty patch keeps stored kid", `{}`, http.StatusOK, "Alice", "2015-06-01"},
+		{"name replaced", `{"name":"Zoe"}`, http.StatusOK, "Zoe", "2015-06-01"},
+		{"birthdate replaced", `{"birthdate":"2016-02-29"}`, http.StatusOK, "Alice", "2016-02-29"},
+		{"age replaces birthdate", `{"age":7}`, http.StatusOK, "Alice", now.AddDate(-7, 0, 0).Format(kid.BirthdateFormat)},
+		{"null birthdate with age", `{"birthdate":null,"age":7}`, http.StatusOK, "Alice", now.AddDate(-7, 0, 0).Format(kid.BirthdateFormat)},
+		{"null name cleared", `{"name":null}`, http.StatusBadRequest, "", ""},
+		{"null birthdate cleared", `{"birthdate":null}`, http.StatusBadRequest, "", ""},
+		{"invalid birthdate", `{"birthdate":"01/06/2015"}`, http.StatusBadRequest, "", ""},
+		{"non-object patch", `["name"]`, http.StatusBadRequest, "", ""},
+		{"invalid JSON", `{"name":`, http.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &patchRepository{}
+
+			response, err := handler.HandleRequest(context.Background(), handler.HTTPRequest{
+				HTTPMethod:     http.MethodPatch,
+				PathParameters: map[string]string{"id": storedPublicID},
+				Headers:        map[string]string{"If-Match": handler.ETag(storedVersion)},
+				Body:           tt.body,
+			}, NewKidHandler(repo))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				if repo.updated != nil {
+					t.Errorf("expected no update, got %+v", repo.updated)
+				}
+				return
+			}
+
+			if repo.updated == nil {
+				t.Fatal("expected the kid to be updated")
+			}
+			if repo.updated.ID != 1 {
+				t.Errorf("expected ID 1, got %d", repo.updated.ID)
+			}
+			if repo.updated.Name != tt.expectedName {
+				t.Errorf("expected name %q, got %q", tt.expectedName, repo.updated.Name)
+			}
+			if birthdate := repo.updated.FormatBirthdate(); birthdate != tt.expectedBirthdate {
+				t.Errorf("expected birthdate %s, got %s", tt.expectedBirthdate, birthdate)
+			}
+		})
+	}
+}
+
+func TestKidPreconditions(t *testing.T) {
+	current := handler.ETag(storedVersion)
+	stale := handler.ETag(storedVersion.Add(-time.Minute))
+
+	tests := []struct {
+		name           string
+		method         string
+		headers        map[string]string
+		body           string
+		expectedStatus int
+	}{
+		{"patch without If-Match", http.MethodPatch, nil, `{"name":"Zoe"}`, http.StatusPreconditionRequired},
+		{"patch with stale If-Match", http.MethodPatch, map[string]string{"If-Match": stale}, `{"name":"Zoe"}`, http.StatusPreconditionFailed},
+		{"patch with weak If-Match", http.MethodPatch, map[string]string{"If-Match": "W/" + current}, `{"name":"Zoe"}`, http.StatusPreconditionFailed},
+		{"patch with current If-Match", http.MethodPatch, map[string]string{"if-match": current}, `{"name":"Zoe"}`, http.StatusOK},
+		{"patch with any version", http.MethodPatch, map[string]string{"If-Match": "*"}, `{"name":"Zoe"}`, http.StatusOK},
+		{"put without If-Match", http.MethodPut, nil, `{"name":"Zoe","birthdate":"2015-06-01"}`, http.StatusPreconditionRequired},
+		{"put with stale If-Match", http.MethodPut, map[string]string{"If-Match": stale}, `{"name":"Zoe","birthdate":"2015-06-01"}`, http.StatusPreconditionFailed},
+		{"delete without If-Match", http.MethodDelete, nil, "", http.StatusPreconditionRequired},
+		{"delete with stale If-Match", http.MethodDelete, map[string]string{"If-Match": stale}, "", http.StatusPreconditionFailed},
+		{"delete with current If-Match", http.MethodDelete, map[string]string{"If-Match": current}, "", http.StatusOK},
+		{"get with current If-None-Match", http.MethodGet, map[string]string{"If-None-Match": current}, "", http.StatusNotModified},
+		{"get with weak If-None-Match", http.MethodGet, map[string]string{"If-None-Match": "W/" + current}, "", http.StatusNotModified},
+		{"get with stale If-None-Match", http.MethodGet, map[string]string{"If-None-Match": stale}, "", http.StatusOK},
+		{"get without If-None-Match", http.MethodGet, nil, "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &patchRepository{}
+
+			response, err := handler.HandleRequest(context.Background(), handler.HTTPRequest{
+				HTTPMethod:     tt.method,
+				PathParameters: map[string]string{"id": storedPublicID},
+				Headers:        tt.headers,
+				Body:           tt.body,
+			}, NewKidHandler(repo))
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+
+			if tt.expectedStatus >= http.StatusBadRequest && (repo.updated != nil || repo.deleted) {
+				t.Error("expected a failed precondition to leave the kid unchanged")
+			}
+			if tt.method == http.MethodGet && response.Headers["ETag"] != current {
+				t.Errorf("expected ETag %s, got %s", current, response.Headers["ETag"])
+			}
+			if tt.expectedStatus == http.StatusNotModified && response.Body != "" {
+				t.Errorf("expected an empty body, got %s", response.Body)
+			}
+		})
+	}
+}
+
+func TestKidPathID(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{"stored public ID", storedPublicID, http.StatusOK},
+		{"upper-case public ID", strings.ToUpper(storedPublicID), http.StatusOK},
+		{"internal ID", "1", http.StatusBadRequest},
+		{"malformed UUID", storedPublicID[:35], http.StatusBadRequest},
+		{"unknown public ID", "00000000-0000-4000-8000-000000000000", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodPatch} {
+			t.Run(tt.name+" "+method, func(t *testing.T) {
+				repo := &patchRepository{}
+
+				response, err := handler.HandleRequest(context.Background(), handler.HTTPRequest{
+					HTTPMethod:     method,
+					PathParameters: map[string]string{"id": tt.id},
+					Headers:        map[string]string{"If-Match": handler.ETag(storedVersion)},
+					Body:           `{}`,
+				}, NewKidHandler(repo))
+				if err != nil {
+					t.Fatalf("expected no error but got: %v", err)
+				}
+				if response.StatusCode != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+				}
+				if tt.expectedStatus != http.StatusOK && repo.updated != nil {
+					t.Errorf("expected no update, got %+v", repo.updated)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package stars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/reports"
+)
+
+const (
+	// AnalyticsPath is the resource returning star series for charts
+	AnalyticsPath = "/transactions/analytics"
+
+	// MaxSeriesBuckets is the largest number of buckets returned at once
+	MaxSeriesBuckets = 400
+)
+
+// Series is a star series of a kid or a family, one point per bucket
+type Series struct {
+	KidID    string                    `json:"kid_id,omitempty"`    // Public ID of the kid
+	FamilyID string                    `json:"family_id,omitempty"` // Public ID of the family
+	Interval interfaces.SeriesInterval `json:"interval"`
+	Timezone string                    `json:"timezone"`
+	From     time.Time                 `json:"from"`
+	To       time.Time                 `json:"to"` // End of the range (exclusive)
+	Points   []*interfaces.SeriesPoint `json:"points"`
+}
+
+// Analytics returns the stars earned and spent per day, week or month with the
+// balance after each, for one kid (?kid_id=) or every kid of a family (?family_id=).
+// Buckets start at midnight in ?tz= (UTC by default); without ?from= the range ends
+// with the current bucket and covers 30 days, 12 weeks or 12 months.
+func (h *TransactionHandler) Analytics(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	kidPublicID, err := handler.QueryUUID(request, "kid_id")
+	if err != nil {
+		return handler.Response{}, err
+	}
+	familyPublicID, err := handler.QueryUUID(request, "family_id")
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if (kidPublicID == "") == (familyPublicID == "") {
+		return handler.Response{}, fmt.Errorf("either kid_id or family_id is required")
+	}
+
+	series := Series{KidID: kidPublicID, FamilyID: familyPublicID, Interval: interfaces.SeriesDay}
+	kidID, familyID := 0, 0
+	if familyPublicID != "" {
+		familyID, err = h.repo.ResolveFamilyID(ctx, familyPublicID)
+	} else {
+		kidID, _, err = h.queryKidID(ctx, request)
+	}
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, err
+	}
+
+	switch interval := interfaces.SeriesInterval(strings.ToLower(handler.QueryString(request, "interval"))); interval {
+	case interfaces.SeriesDay, interfaces.SeriesWeek, interfaces.SeriesMonth:
+		series.Interval = interval
+	case "":
+	default:
+		return handler.Response{}, fmt.Errorf("invalid interval: %s (expected day, week or month)", interval)
+	}
+
+	loc := time.UTC
+	if value := handler.QueryString(request, "tz"); value != "" {
+		if loc, err = time.LoadLocation(value); err != nil {
+			return handler.Response{}, fmt.Errorf("invalid tz: %s", value)
+		}
+	}
+	series.Timezone = loc.String()
+
+	from, to, err := handler.QueryDateRange(request, loc)
+	if err != nil {
+		return handler.Response{}, err
+	}
+	for _, t := range []*time.Time{from, to} {
+		if t != nil {
+			*t = t.In(loc)
+		}
+	}
+	series.From, series.To = defaultSeriesRange(series.Interval, from, to, time.Now().In(loc))
+	if !series.From.Before(series.To) {
+		return handler.Response{}, fmt.Errorf("from must be before to")
+	}
+	if buckets := seriesBuckets(series.Interval, series.From, series.To); buckets > MaxSeriesBuckets {
+		return handler.Response{}, fmt.Errorf("the range spans %d %ss, at most %d are returned at once", buckets, series.Interval, MaxSeriesBuckets)
+	}
+
+	series.Points, err = h.repo.GetSeries(ctx, interfaces.SeriesFilter{
+		KidID:    kidID,
+		FamilyID: familyID,
+		Interval: series.Interval,
+		Location: loc,
+		From:     series.From,
+		To:       series.To,
+	})
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return handler.Response{}, handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return handler.Response{}, fmt.Errorf("failed to get transaction series: %w", err)
+	}
+	for _, p := range series.Points {
+		p.Start = p.Start.In(loc)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Retrieved %d %s buckets", len(series.Points), series.Interval),
+		Service: ServiceName,
+		Data:    series,
+	}, nil
+}
+
+// defaultSeriesRange fills in a missing end with the end of the bucket containing now,
+// and a missing start with 30 days, 12 weeks or 12 months before the end's bucket
+func defaultSeriesRange(interval interfaces.SeriesInterval, from, to *time.Time, now time.Time) (time.Time, time.Time) {
+	var end time.Time
+	if to != nil {
+		end = *to
+	} else {
+		_, end = bucketRange(interval, now)
+	}
+	if from != nil {
+		return *from, end
+	}
+
+	start, _ := bucketRange(interval, end.Add(-time.Nanosecond))
+	switch interval {
+	case interfaces.SeriesWeek:
+		return start.AddDate(0, 0, -7*11), end
+	case interfaces.SeriesMonth:
+		return start.AddDate(0, -11, 0), end
+	default:
+		return start.AddDate(0, 0, -29), end
+	}
+}
+
+// bucketRange returns the start and end of the bucket containing at, in its location
+func bucketRange(interval interfaces.SeriesInterval, at time.Time) (time.Time, time.Time) {
+	switch interval {
+	case interfaces.SeriesWeek:
+		return reports.PeriodWeek.Range(at)
+	case interfaces.SeriesMonth:
+		return reports.PeriodMonth.Range(at)
+	default:
+		day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+		return day, day.AddDate(0, 0, 1)
+	}
+}
+
+// seriesBuckets counts the buckets overlapping [from, to)
+func seriesBuckets(interval interfaces.SeriesInterval, from, to time.Time) int {
+	count := 0
+	for start, _ := bucketRange(interval, from); start.Before(to) && count <= MaxSeriesBuckets; count++ {
+		_, start = bucketRange(interval, start)
+	}
+	return count
+}
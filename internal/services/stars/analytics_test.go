@@ -0,0 +1,107 @@
+package stars
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+)
+
+// seriesRepository records the series filter and returns one empty point per day
+type seriesRepository struct {
+	publicIDRepository
+	filter interfaces.SeriesFilter
+}
+
+func (r *seriesRepository) GetSeries(ctx context.Context, filter interfaces.SeriesFilter) ([]*interfaces.SeriesPoint, error) {
+	r.filter = filter
+	if filter.FamilyID == 404 {
+		return nil, fmt.Errorf("family with id %d %w", filter.FamilyID, interfaces.ErrNotFound)
+	}
+	return []*interfaces.SeriesPoint{{Start: filter.From.UTC()}}, nil
+}
+
+func TestAnalytics(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            map[string]string
+		expectedStatus   int
+		expectedInterval interfaces.SeriesInterval
+		expectedFrom     string
+		expectedTo       string
+	}{
+		{"days of a kid", map[string]string{"kid_id": kidUUID(1), "from": "2025-01-01", "to": "2025-01-31"}, http.StatusOK, interfaces.SeriesDay, "2025-01-01T00:00:00Z", "2025-02-01T00:00:00Z"},
+		{"weeks of a family in a timezone", map[string]string{"family_id": familyUUID(2), "interval": "week", "tz": "Europe/Warsaw", "from": "2025-01-06", "to": "2025-03-30"}, http.StatusOK, interfaces.SeriesWeek, "2025-01-06T00:00:00+01:00", "2025-03-31T00:00:00+02:00"},
+		{"default months before to", map[string]string{"kid_id": kidUUID(1), "interval": "month", "to": "2025-06-15"}, http.StatusOK, interfaces.SeriesMonth, "2024-07-01T00:00:00Z", "2025-06-16T00:00:00Z"},
+		{"default days before to", map[string]string{"kid_id": kidUUID(1), "to": "2025-03-31"}, http.StatusOK, interfaces.SeriesDay, "2025-03-02T00:00:00Z", "2025-04-01T00:00:00Z"},
+		{"missing kid and family", map[string]string{}, http.StatusBadRequest, "", "", ""},
+		{"both kid and family", map[string]string{"kid_id": kidUUID(1), "family_id": familyUUID(2)}, http.StatusBadRequest, "", "", ""},
+		{"unknown interval", map[string]string{"kid_id": kidUUID(1), "interval": "hour"}, http.StatusBadRequest, "", "", ""},
+		{"unknown timezone", map[string]string{"kid_id": kidUUID(1), "tz": "Mars/Olympus"}, http.StatusBadRequest, "", "", ""},
+		{"too many days", map[string]string{"kid_id": kidUUID(1), "from": "2020-01-01", "to": "2025-01-01"}, http.StatusBadRequest, "", "", ""},
+		{"unknown family", map[string]string{"family_id": familyUUID(404)}, http.StatusNotFound, "", "", ""},
+		{"sequential family id", map[string]string{"family_id": "2"}, http.StatusBadRequest, "", "", ""},
+		{"unknown kid", map[string]string{"kid_id": "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"}, http.StatusNotFound, "", "", ""},
+		{"sequential kid id", map[string]string{"kid_id": "1"}, http.StatusBadRequest, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &seriesRepository{}
+			h := NewTransactionHandler(repo)
+
+			response, err := h.Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod:            http.MethodGet,
+				Path:                  "/v2" + AnalyticsPath,
+				QueryStringParameters: tt.query,
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if repo.filter.Interval != tt.expectedInterval {
+				t.Errorf("expected interval %s, got %s", tt.expectedInterval, repo.filter.Interval)
+			}
+			if got := repo.filter.From.Format(time.RFC3339); got != tt.expectedFrom {
+				t.Errorf("expected from %s, got %s", tt.expectedFrom, got)
+			}
+			if got := repo.filter.To.Format(time.RFC3339); got != tt.expectedTo {
+				t.Errorf("expected to %s, got %s", tt.expectedTo, got)
+			}
+		})
+	}
+}
+
+func TestDefaultSeriesRange(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		interval     interfaces.SeriesInterval
+		expectedFrom string
+		expectedTo   string
+	}{
+		{interfaces.SeriesDay, "2025-02-11", "2025-03-13"},
+		{interfaces.SeriesWeek, "2024-12-23", "2025-03-17"},
+		{interfaces.SeriesMonth, "2024-04-01", "2025-04-01"},
+	}
+
+	for _, tt := range tests {
+		from, to := defaultSeriesRange(tt.interval, nil, nil, now)
+		if from.Format(time.DateOnly) != tt.expectedFrom || to.Format(time.DateOnly) != tt.expectedTo {
+			t.Errorf("%s: expected %s to %s, got %s to %s", tt.interval, tt.expectedFrom, tt.expectedTo, from, to)
+		}
+		if buckets := seriesBuckets(tt.interval, from, to); buckets != 30 && buckets != 12 {
+			t.Errorf("%s: expected 30 days or 12 weeks or months, got %d buckets", tt.interval, buckets)
+		}
+	}
+}
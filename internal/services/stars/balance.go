@@ -0,0 +1,65 @@
+package stars
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+)
+
+// BalancePath is the resource returning the balance of a kid, now or at a point in time
+const BalancePath = "/transactions/balance"
+
+// Balance is the star balance of a kid at a point in time
+type Balance struct {
+	KidID   string    `json:"kid_id"` // Public ID of the kid
+	At      time.Time `json:"at"`     // Transactions created before this time are counted
+	Balance int       `json:"balance"`
+}
+
+// Balance returns the star balance of a kid now, or at ?at=. A date means the end of
+// that day in the ?tz= timezone (UTC by default), e.g. at=2025-01-01 answers "how many
+// stars did they have on January 1st".
+func (h *TransactionHandler) Balance(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	kidID, kidPublicID, err := h.queryKidID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if kidPublicID == "" {
+		return handler.Response{}, fmt.Errorf("kid_id is required")
+	}
+
+	loc := time.UTC
+	if value := handler.QueryString(request, "tz"); value != "" {
+		if loc, err = time.LoadLocation(value); err != nil {
+			return handler.Response{}, fmt.Errorf("invalid tz: %s", value)
+		}
+	}
+
+	at, dateOnly, err := handler.QueryTime(request, "at", loc)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	balance := Balance{KidID: kidPublicID}
+	if at == nil {
+		balance.At = time.Now().In(loc)
+		balance.Balance, err = h.repo.GetKidBalance(ctx, kidID)
+	} else {
+		balance.At = *at
+		if dateOnly {
+			balance.At = at.AddDate(0, 0, 1)
+		}
+		balance.Balance, err = h.repo.GetKidBalanceAt(ctx, kidID, balance.At)
+	}
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s had %d stars at %s", kidPublicID, balance.Balance, balance.At.Format(time.RFC3339)),
+		Service: ServiceName,
+		Data:    balance,
+	}, nil
+}
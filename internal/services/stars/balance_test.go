@@ -0,0 +1,74 @@
+package stars
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+)
+
+// balanceRepository records the point in time a balance is asked for
+type balanceRepository struct {
+	publicIDRepository
+	at *time.Time
+}
+
+func (r *balanceRepository) GetKidBalance(ctx context.Context, kidID int) (int, error) {
+	return 12, nil
+}
+
+func (r *balanceRepository) GetKidBalanceAt(ctx context.Context, kidID int, at time.Time) (int, error) {
+	r.at = &at
+	return 7, nil
+}
+
+func TestBalance(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          map[string]string
+		expectedStatus int
+		expectedAt     string // Empty for the current balance
+	}{
+		{"now", map[string]string{"kid_id": kidUUID(1)}, http.StatusOK, ""},
+		{"end of a day", map[string]string{"kid_id": kidUUID(1), "at": "2025-01-01"}, http.StatusOK, "2025-01-02T00:00:00Z"},
+		{"end of a day in a timezone", map[string]string{"kid_id": kidUUID(1), "at": "2025-01-01", "tz": "Europe/Warsaw"}, http.StatusOK, "2025-01-02T00:00:00+01:00"},
+		{"timestamp", map[string]string{"kid_id": kidUUID(1), "at": "2025-01-01T12:30:00Z"}, http.StatusOK, "2025-01-01T12:30:00Z"},
+		{"missing kid", map[string]string{"at": "2025-01-01"}, http.StatusBadRequest, ""},
+		{"invalid at", map[string]string{"kid_id": kidUUID(1), "at": "yesterday"}, http.StatusBadRequest, ""},
+		{"unknown timezone", map[string]string{"kid_id": kidUUID(1), "tz": "Mars/Olympus"}, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &balanceRepository{}
+			h := NewTransactionHandler(repo)
+
+			response, err := h.Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod:            http.MethodGet,
+				Path:                  "/v2" + BalancePath,
+				QueryStringParameters: tt.query,
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if tt.expectedAt == "" {
+				if repo.at != nil {
+					t.Errorf("expected the current balance, got the balance at %s", repo.at)
+				}
+				return
+			}
+			if repo.at == nil || repo.at.Format(time.RFC3339) != tt.expectedAt {
+				t.Errorf("expected the balance at %s, got %v", tt.expectedAt, repo.at)
+			}
+		})
+	}
+}
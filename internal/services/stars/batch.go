@@ -0,0 +1,139 @@
+package stars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+const (
+	// BatchPath is the resource creating many transactions at once
+	BatchPath = "/transactions/batch"
+
+	// MaxBatchSize is the largest number of transactions accepted in one batch
+	MaxBatchSize = 100
+
+	// BatchModeAtomic creates every item or none of them
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort creates the valid items and reports the others
+	BatchModeBestEffort = "best_effort"
+)
+
+// BatchRequest represents the payload for creating many transactions at once,
+// e.g. awarding the same stars to every kid of a group
+type BatchRequest struct {
+	Mode  string               `json:"mode,omitempty" validate:"omitempty,oneof=atomic best_effort"` // atomic (default) or best_effort
+	Items []TransactionRequest `json:"items" validate:"required,min=1,max=100"`                      // Transactions to create
+}
+
+// BatchItemResult reports the outcome of one item of a batch, in request order
+type BatchItemResult struct {
+	Index       int                      `json:"index"`                 // Position of the item in the request
+	Status      int                      `json:"status"`                // 201 created, 400 invalid, 424 not created because another item failed
+	Transaction *transaction.Transaction `json:"transaction,omitempty"` // Created transaction
+	Error       string                   `json:"error,omitempty"`       // Why the item failed
+}
+
+// batchRequestSchema only checks the structure of the items. Business rules are
+// checked per item, so best-effort batches can report invalid items individually.
+var batchRequestSchema = func() *schema.Schema {
+	s := schema.Generate(BatchRequest{})
+	s.Properties["items"].Items = schema.Generate(TransactionRequest{})
+	return s
+}()
+
+// CreateBatch processes a request to add many star transactions in one database
+// transaction. Every item is validated with Transaction.Validate. In atomic mode
+// any failing item rejects the whole batch with 400 Bad Request; in best-effort
+// mode the valid items are created and the response is 207 Multi-Status when
+// some items failed. Either way the response lists the result of every item.
+func (h *TransactionHandler) CreateBatch(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	var batchRequest BatchRequest
+	if err := handler.DecodeJSON(request.Body, &batchRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	atomic := batchRequest.Mode != BatchModeBestEffort
+	if len(batchRequest.Items) == 0 || len(batchRequest.Items) > MaxBatchSize {
+		return handler.Response{}, fmt.Errorf("batch must contain between 1 and %d items", MaxBatchSize)
+	}
+
+	results := make([]BatchItemResult, len(batchRequest.Items))
+	var models []*transaction.Transaction
+	var indexes []int
+	kidIDs := make(map[string]int)
+	for i, item := range batchRequest.Items {
+		results[i] = BatchItemResult{Index: i, Status: http.StatusFailedDependency}
+
+		kidID, ok := kidIDs[item.KidID]
+		if !ok {
+			var err error
+			if kidID, err = h.kidID(ctx, item.KidID); err != nil {
+				results[i].Status = http.StatusBadRequest
+				results[i].Error = err.Error()
+				continue
+			}
+			kidIDs[item.KidID] = kidID
+		}
+
+		model, err := item.ToTransaction(kidID)
+		if err != nil {
+			results[i].Status = http.StatusBadRequest
+			results[i].Error = fmt.Sprintf("validation failed: %v", err)
+			continue
+		}
+		models = append(models, model)
+		indexes = append(indexes, i)
+	}
+
+	invalid := len(models) < len(results)
+	if !(invalid && atomic) && len(models) > 0 {
+		created, err := h.repo.CreateBatch(ctx, models, atomic)
+		if err != nil && !errors.Is(err, interfaces.ErrBatchRolledBack) {
+			return handler.Response{}, fmt.Errorf("failed to create transactions: %w", err)
+		}
+
+		for j, result := range created {
+			i := indexes[j]
+			switch {
+			case result.Err != nil:
+				results[i].Status = http.StatusBadRequest
+				results[i].Error = result.Err.Error()
+				invalid = true
+			case result.Transaction != nil:
+				results[i].Status = http.StatusCreated
+				results[i].Transaction = result.Transaction
+			}
+		}
+	}
+
+	createdCount := 0
+	for _, result := range results {
+		if result.Status == http.StatusCreated {
+			createdCount++
+		}
+	}
+
+	response := handler.Response{
+		Message: fmt.Sprintf("%d of %d transactions created", createdCount, len(results)),
+		Service: "star-service",
+		Data:    results,
+	}
+	switch {
+	case !invalid:
+		response.StatusCode = http.StatusCreated
+	case atomic:
+		response.Message = "Batch rejected, no transactions created"
+		response.StatusCode = http.StatusBadRequest
+	default:
+		response.StatusCode = http.StatusMultiStatus
+	}
+
+	return response, nil
+}
@@ -0,0 +1,116 @@
+package stars
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// batchRepository creates batches in memory, failing items of kid 404 like a
+// missing foreign key would
+type batchRepository struct {
+	publicIDRepository
+	calls int
+}
+
+func (r *batchRepository) CreateBatch(ctx context.Context, transactions []*transaction.Transaction, atomic bool) ([]interfaces.BatchResult, error) {
+	r.calls++
+	results := make([]interfaces.BatchResult, len(transactions))
+	failed := false
+	for i, t := range transactions {
+		if t.KidID == 404 {
+			results[i].Err = errors.New("kid with id 404 not found")
+			failed = true
+			continue
+		}
+		created := *t
+		created.ID = i + 1
+		results[i].Transaction = &created
+	}
+	if failed && atomic {
+		for i := range results {
+			results[i].Transaction = nil
+		}
+		return results, interfaces.ErrBatchRolledBack
+	}
+	return results, nil
+}
+
+func TestCreateBatch(t *testing.T) {
+	const (
+		valid    = `{"kid_id":"00000000-0000-4000-8000-000000000001","type":"earn","amount":5,"description":"Cleaned up"}`
+		invalid  = `{"kid_id":"00000000-0000-4000-8000-000000000002","type":"earn","amount":500,"description":"Too generous"}`
+		missing  = `{"kid_id":"00000000-0000-4000-8000-000000000404","type":"earn","amount":5,"description":"Unknown kid"}`
+		unknown  = `{"kid_id":"6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b","type":"earn","amount":5,"description":"Never existed"}`
+		sequence = `{"kid_id":1,"type":"earn","amount":5,"description":"Sequential kid"}`
+	)
+
+	tests := []struct {
+		name             string
+		body             string
+		expectedStatus   int
+		expectedStatuses []int
+		expectedCalls    int
+	}{
+		{"all valid", `{"items":[` + valid + `,` + valid + `]}`, http.StatusCreated, []int{201, 201}, 1},
+		{"atomic with invalid item", `{"items":[` + valid + `,` + invalid + `]}`, http.StatusBadRequest, []int{424, 400}, 0},
+		{"atomic with failing insert", `{"mode":"atomic","items":[` + valid + `,` + missing + `]}`, http.StatusBadRequest, []int{424, 400}, 1},
+		{"best effort", `{"mode":"best_effort","items":[` + valid + `,` + invalid + `,` + missing + `]}`, http.StatusMultiStatus, []int{201, 400, 400}, 1},
+		{"best effort with unresolved kid", `{"mode":"best_effort","items":[` + valid + `,` + unknown + `]}`, http.StatusMultiStatus, []int{201, 400}, 1},
+		{"atomic with unresolved kid", `{"items":[` + valid + `,` + unknown + `]}`, http.StatusBadRequest, []int{424, 400}, 0},
+		{"sequential kid id", `{"items":[` + sequence + `]}`, http.StatusBadRequest, nil, 0},
+		{"best effort all invalid", `{"mode":"best_effort","items":[` + invalid + `]}`, http.StatusMultiStatus, []int{400}, 0},
+		{"empty batch", `{"items":[]}`, http.StatusBadRequest, nil, 0},
+		{"unknown mode", `{"mode":"yolo","items":[` + valid + `]}`, http.StatusBadRequest, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &batchRepository{}
+			h := NewTransactionHandler(repo)
+
+			response, err := h.Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod: http.MethodPost,
+				Path:       "/v2" + BatchPath,
+				Body:       tt.body,
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if repo.calls != tt.expectedCalls {
+				t.Errorf("expected %d repository calls, got %d", tt.expectedCalls, repo.calls)
+			}
+			if tt.expectedStatuses == nil {
+				return
+			}
+
+			var body struct {
+				Data []BatchItemResult `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("expected JSON body, got %q", response.Body)
+			}
+			if len(body.Data) != len(tt.expectedStatuses) {
+				t.Fatalf("expected %d results, got %d", len(tt.expectedStatuses), len(body.Data))
+			}
+			for i, status := range tt.expectedStatuses {
+				result := body.Data[i]
+				if result.Index != i || result.Status != status {
+					t.Errorf("item %d: expected status %d, got %d at index %d (%s)", i, status, result.Status, result.Index, result.Error)
+				}
+				if (result.Transaction != nil) != (status == http.StatusCreated) {
+					t.Errorf("item %d: expected transaction only when created, got %v", i, result.Transaction)
+				}
+			}
+		})
+	}
+}
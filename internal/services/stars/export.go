@@ -0,0 +1,226 @@
+package stars
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+const (
+	// ExportPath is the resource exporting the star history of a kid
+	ExportPath = "/transactions/export"
+
+	// ExportFormatCSV exports one comma-separated row per transaction after a header row
+	ExportFormatCSV = "csv"
+	// ExportFormatNDJSON exports one JSON object per line
+	ExportFormatNDJSON = "ndjson"
+
+	// exportPageSize is the number of transactions read from the database at a time
+	exportPageSize = 500
+)
+
+// LedgerEntry is one exported transaction with the running totals after it
+type LedgerEntry struct {
+	ID          string    `json:"id"`          // Public ID of the transaction
+	CreatedAt   time.Time `json:"created_at"`  // When the transaction happened
+	Type        string    `json:"type"`        // earn or spend
+	Amount      int       `json:"amount"`      // Stars earned or spent
+	Description string    `json:"description"` // What the stars were for
+	Earned      int       `json:"earned"`      // Stars earned so far within the exported range
+	Spent       int       `json:"spent"`       // Stars spent so far within the exported range
+	Balance     int       `json:"balance"`     // Balance after the transaction, including everything before the range
+}
+
+// ledgerColumns is the CSV header row, in the order of LedgerEntry
+var ledgerColumns = []string{"id", "created_at", "type", "amount", "description", "earned", "spent", "balance"}
+
+// Export streams the star history of a kid as CSV or NDJSON, oldest first, with
+// running earned, spent and balance columns. Supports ?from= and ?to= like the
+// list endpoint; the balance also counts the transactions before the range.
+// The format comes from ?format=, or from the Accept header when absent.
+// Transactions are read a page at a time, so long histories never sit in memory.
+func (h *TransactionHandler) Export(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	kidID, kidPublicID, err := h.queryKidID(ctx, request)
+	if err != nil {
+		return handler.HTTPResponse{}, err
+	}
+	if kidPublicID == "" {
+		return handler.HTTPResponse{}, fmt.Errorf("kid_id is required")
+	}
+
+	format, err := exportFormat(request)
+	if err != nil {
+		return handler.HTTPResponse{}, err
+	}
+
+	from, to, err := handler.QueryDateRange(request)
+	if err != nil {
+		return handler.HTTPResponse{}, err
+	}
+	filter := interfaces.TransactionFilter{KidID: kidID, From: from, To: to}
+
+	opening := 0
+	if from != nil {
+		opening, err = h.repo.GetBalance(ctx, interfaces.TransactionFilter{KidID: kidID, To: from})
+		if err != nil {
+			return handler.HTTPResponse{}, fmt.Errorf("failed to get opening balance: %w", err)
+		}
+	}
+
+	// Read the first page now, so database errors still get an error status
+	page, err := h.repo.FindAfter(ctx, filter, interfaces.Cursor{}, exportPageSize)
+	if err != nil {
+		return handler.HTTPResponse{}, fmt.Errorf("failed to export transactions: %w", err)
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == ExportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	return handler.HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                  contentType,
+			"Content-Disposition":           fmt.Sprintf(`attachment; filename="%s"`, exportFilename(kidPublicID, from, to, format)),
+			"Access-Control-Allow-Origin":   "*",
+			"Access-Control-Expose-Headers": "Content-Disposition",
+			"Cache-Control":                 "no-store",
+		},
+		Stream: func(w io.Writer) error {
+			return h.writeLedger(ctx, w, format, filter, page, opening)
+		},
+	}, nil
+}
+
+// writeLedger writes the ledger page by page, starting with the first page
+// already read and continuing after the last transaction of each page
+func (h *TransactionHandler) writeLedger(ctx context.Context, w io.Writer, format string, filter interfaces.TransactionFilter, page []*transaction.Transaction, opening int) error {
+	buffered := bufio.NewWriter(w)
+	write := ndjsonWriter(buffered)
+	if format == ExportFormatCSV {
+		var err error
+		if write, err = csvWriter(buffered); err != nil {
+			return err
+		}
+	}
+
+	entry := LedgerEntry{Balance: opening}
+	for len(page) > 0 {
+		for _, t := range page {
+			entry.ID, entry.CreatedAt, entry.Type, entry.Amount, entry.Description = t.PublicID, t.CreatedAt, string(t.Type), t.Amount, t.Description
+			if t.IsEarnTransaction() {
+				entry.Earned += t.Amount
+				entry.Balance += t.Amount
+			} else {
+				entry.Spent += t.Amount
+				entry.Balance -= t.Amount
+			}
+			if err := write(entry); err != nil {
+				return err
+			}
+		}
+
+		// Hand the finished page to the client before reading the next one
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+		if len(page) < exportPageSize {
+			break
+		}
+
+		last := page[len(page)-1]
+		var err error
+		page, err = h.repo.FindAfter(ctx, filter, interfaces.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}, exportPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to export transactions: %w", err)
+		}
+	}
+
+	return buffered.Flush()
+}
+
+// csvWriter writes the header row right away, so an empty ledger still exports its
+// columns, and returns a function writing ledger entries as CSV rows
+func csvWriter(w io.Writer) (func(LedgerEntry) error, error) {
+	out := csv.NewWriter(w)
+	out.Write(ledgerColumns)
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return nil, err
+	}
+
+	return func(entry LedgerEntry) error {
+		out.Write([]string{
+			entry.ID,
+			entry.CreatedAt.Format(time.RFC3339),
+			entry.Type,
+			strconv.Itoa(entry.Amount),
+			spreadsheetSafe(entry.Description),
+			strconv.Itoa(entry.Earned),
+			strconv.Itoa(entry.Spent),
+			strconv.Itoa(entry.Balance),
+		})
+		out.Flush()
+		return out.Error()
+	}, nil
+}
+
+// ndjsonWriter writes ledger entries as one JSON object per line
+func ndjsonWriter(w io.Writer) func(LedgerEntry) error {
+	encoder := json.NewEncoder(w)
+	return func(entry LedgerEntry) error {
+		return encoder.Encode(entry)
+	}
+}
+
+// spreadsheetSafe keeps spreadsheet applications from evaluating a description
+// as a formula when the exported file is opened
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// exportFormat reads the requested export format from ?format= or the Accept header
+func exportFormat(request handler.HTTPRequest) (string, error) {
+	switch format := strings.ToLower(handler.QueryString(request, "format")); format {
+	case ExportFormatCSV, ExportFormatNDJSON:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("invalid format: %s (expected csv or ndjson)", format)
+	}
+
+	accept := handler.Header(request, "Accept")
+	if strings.Contains(accept, "application/x-ndjson") || strings.Contains(accept, "application/jsonl") {
+		return ExportFormatNDJSON, nil
+	}
+	return ExportFormatCSV, nil
+}
+
+// exportFilename names the downloaded file after the kid and the exported range,
+// e.g. kid-6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b-stars-from-2025-01-01-to-2025-03-31.csv
+func exportFilename(kidID string, from, to *time.Time, format string) string {
+	name := fmt.Sprintf("kid-%s-stars", kidID)
+	if from != nil {
+		name += "-from-" + from.Format(time.DateOnly)
+	}
+	if to != nil {
+		// The range ends before to, so name the last day it includes
+		name += "-to-" + to.Add(-time.Nanosecond).Format(time.DateOnly)
+	}
+	return name + "." + format
+}
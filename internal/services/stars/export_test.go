@@ -0,0 +1,241 @@
+package stars
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// ledgerRepository pages through an in-memory ledger like FindAfter does,
+// recording every page requested
+type ledgerRepository struct {
+	publicIDRepository
+	ledger  []*transaction.Transaction
+	opening int
+	pages   int
+}
+
+func (r *ledgerRepository) FindAfter(ctx context.Context, filter interfaces.TransactionFilter, after interfaces.Cursor, limit int) ([]*transaction.Transaction, error) {
+	r.pages++
+	var page []*transaction.Transaction
+	for _, t := range r.ledger {
+		if t.ID > after.ID && len(page) < limit {
+			page = append(page, t)
+		}
+	}
+	return page, nil
+}
+
+func (r *ledgerRepository) GetBalance(ctx context.Context, filter interfaces.TransactionFilter) (int, error) {
+	return r.opening, nil
+}
+
+func newLedger(n int) []*transaction.Transaction {
+	ledger := make([]*transaction.Transaction, n)
+	start := time.Date(2025, time.January, 1, 8, 0, 0, 0, time.UTC)
+	for i := range ledger {
+		ledger[i] = &transaction.Transaction{
+			ID:          i + 1,
+			PublicID:    transactionUUID(i + 1),
+			KidID:       1,
+			Type:        transaction.TransactionTypeEarn,
+			Amount:      2,
+			Description: "Homework",
+			CreatedAt:   start.Add(time.Duration(i) * time.Hour),
+		}
+	}
+	return ledger
+}
+
+func TestExport(t *testing.T) {
+	ledger := newLedger(2)
+	ledger = append(ledger, &transaction.Transaction{
+		ID:          3,
+		PublicID:    transactionUUID(3),
+		KidID:       1,
+		Type:        transaction.TransactionTypeSpend,
+		Amount:      3,
+		Description: "=HYPERLINK(\"http://example.com\")",
+		CreatedAt:   time.Date(2025, time.January, 2, 8, 0, 0, 0, time.UTC),
+	})
+
+	tests := []struct {
+		name                string
+		query               map[string]string
+		accept              string
+		opening             int
+		expectedStatus      int
+		expectedType        string
+		expectedFilename    string
+		expectedLastBalance int
+	}{
+		{"csv by default", map[string]string{"kid_id": kidUUID(1)}, "", 0, http.StatusOK, "text/csv; charset=utf-8", "kid-" + kidUUID(1) + "-stars.csv", 1},
+		{"ndjson from accept header", map[string]string{"kid_id": kidUUID(1)}, "application/x-ndjson", 0, http.StatusOK, "application/x-ndjson", "kid-" + kidUUID(1) + "-stars.ndjson", 1},
+		{"format overrides accept header", map[string]string{"kid_id": kidUUID(1), "format": "csv"}, "application/x-ndjson", 0, http.StatusOK, "text/csv; charset=utf-8", "kid-" + kidUUID(1) + "-stars.csv", 1},
+		{"range includes opening balance", map[string]string{"kid_id": kidUUID(1), "from": "2025-01-01", "to": "2025-01-31"}, "", 10, http.StatusOK, "text/csv; charset=utf-8", "kid-" + kidUUID(1) + "-stars-from-2025-01-01-to-2025-01-31.csv", 11},
+		{"missing kid", map[string]string{}, "", 0, http.StatusBadRequest, "", "", 0},
+		{"unknown format", map[string]string{"kid_id": kidUUID(1), "format": "xlsx"}, "", 0, http.StatusBadRequest, "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTransactionHandler(&ledgerRepository{ledger: ledger, opening: tt.opening})
+
+			request := handler.HTTPRequest{
+				HTTPMethod:            http.MethodGet,
+				Path:                  ExportPath,
+				QueryStringParameters: tt.query,
+			}
+			if tt.accept != "" {
+				request.Headers = map[string]string{"Accept": tt.accept}
+			}
+
+			response, err := h.Handle(context.Background(), request)
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if got := response.Headers["Content-Type"]; got != tt.expectedType {
+				t.Errorf("expected content type %q, got %q", tt.expectedType, got)
+			}
+			if got, expected := response.Headers["Content-Disposition"], `attachment; filename="`+tt.expectedFilename+`"`; got != expected {
+				t.Errorf("expected content disposition %q, got %q", expected, got)
+			}
+
+			response, err = response.Buffered()
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(response.Body), "\n")
+
+			if strings.HasPrefix(tt.expectedType, "text/csv") {
+				if len(lines) != 4 || lines[0] != "id,created_at,type,amount,description,earned,spent,balance" {
+					t.Fatalf("expected header and 3 rows, got %q", response.Body)
+				}
+				expected := transactionUUID(3) + `,2025-01-02T08:00:00Z,spend,3,"'=HYPERLINK(""http://example.com"")",4,3,` + strconv.Itoa(tt.expectedLastBalance)
+				if lines[3] != expected {
+					t.Errorf("expected last row %q, got %q", expected, lines[3])
+				}
+				return
+			}
+
+			if len(lines) != 3 {
+				t.Fatalf("expected 3 lines, got %q", response.Body)
+			}
+			var entry LedgerEntry
+			if err := json.Unmarshal([]byte(lines[2]), &entry); err != nil {
+				t.Fatalf("expected JSON line, got %q", lines[2])
+			}
+			if entry.ID != transactionUUID(3) || entry.Earned != 4 || entry.Spent != 3 || entry.Balance != tt.expectedLastBalance {
+				t.Errorf("expected running totals 4/3/%d, got %+v", tt.expectedLastBalance, entry)
+			}
+		})
+	}
+}
+
+func TestExportEmptyLedger(t *testing.T) {
+	tests := []struct {
+		format       string
+		expectedBody string
+	}{
+		{ExportFormatCSV, "id,created_at,type,amount,description,earned,spent,balance\n"},
+		{ExportFormatNDJSON, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			h := NewTransactionHandler(&ledgerRepository{})
+
+			response, err := h.Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod:            http.MethodGet,
+				Path:                  ExportPath,
+				QueryStringParameters: map[string]string{"kid_id": kidUUID(1), "format": tt.format},
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, response.StatusCode, response.Body)
+			}
+
+			response, err = response.Buffered()
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.Body != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, response.Body)
+			}
+		})
+	}
+}
+
+func TestExportPages(t *testing.T) {
+	repo := &ledgerRepository{ledger: newLedger(exportPageSize*2 + 1)}
+	h := NewTransactionHandler(repo)
+
+	response, err := h.Handle(context.Background(), handler.HTTPRequest{
+		HTTPMethod:            http.MethodGet,
+		Path:                  "/v2" + ExportPath,
+		QueryStringParameters: map[string]string{"kid_id": kidUUID(1), "format": "ndjson"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if repo.pages != 1 {
+		t.Errorf("expected only the first page before streaming, got %d pages", repo.pages)
+	}
+
+	response, err = response.Buffered()
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if repo.pages != 3 {
+		t.Errorf("expected 3 pages, got %d", repo.pages)
+	}
+
+	lines := strings.Split(strings.TrimSpace(response.Body), "\n")
+	if len(lines) != len(repo.ledger) {
+		t.Fatalf("expected %d lines, got %d", len(repo.ledger), len(lines))
+	}
+	var last LedgerEntry
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatalf("expected JSON line, got %q", lines[len(lines)-1])
+	}
+	if expected := 2 * len(repo.ledger); last.Balance != expected {
+		t.Errorf("expected balance %d, got %d", expected, last.Balance)
+	}
+}
+
+func TestSpreadsheetSafe(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"Homework", "Homework"},
+		{"=1+1", "'=1+1"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := spreadsheetSafe(tt.value); got != tt.expected {
+			t.Errorf("spreadsheetSafe(%q): expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package stars
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/reports"
+)
+
+// ReportPath is the resource summarizing the stars of a kid over a week or month
+const ReportPath = "/transactions/report"
+
+// Report returns the star report of a kid for the week or month containing ?date=
+// (today by default), with days counted in the ?tz= timezone (UTC by default)
+func (h *TransactionHandler) Report(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	kidID, kidPublicID, err := h.queryKidID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if kidPublicID == "" {
+		return handler.Response{}, fmt.Errorf("kid_id is required")
+	}
+
+	period := reports.PeriodWeek
+	if value := handler.QueryString(request, "period"); value != "" {
+		if period, err = reports.ParsePeriod(value); err != nil {
+			return handler.Response{}, err
+		}
+	}
+
+	loc := time.UTC
+	if value := handler.QueryString(request, "tz"); value != "" {
+		if loc, err = time.LoadLocation(value); err != nil {
+			return handler.Response{}, fmt.Errorf("invalid tz: %s", value)
+		}
+	}
+
+	at := time.Now().In(loc)
+	if value := handler.QueryString(request, "date"); value != "" {
+		if at, err = time.ParseInLocation(time.DateOnly, value, loc); err != nil {
+			return handler.Response{}, fmt.Errorf("invalid date: %s (expected YYYY-MM-DD)", value)
+		}
+	}
+
+	top := reports.DefaultTop
+	value, err := handler.QueryInt(request, "top")
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if value != nil {
+		if *value < 0 || *value > reports.MaxTop {
+			return handler.Response{}, fmt.Errorf("top must be between 0 and %d", reports.MaxTop)
+		}
+		top = *value
+	}
+
+	report, err := reports.NewReporter(h.repo).Period(ctx, kidID, period, at, top)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to build report: %w", err)
+	}
+	report.KidPublicID = kidPublicID
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s earned %d stars and spent %d from %s to %s", kidPublicID, report.Earned, report.Spent,
+			report.From.Format(time.DateOnly), report.To.AddDate(0, 0, -1).Format(time.DateOnly)),
+		Service: ServiceName,
+		Data:    report,
+	}, nil
+}
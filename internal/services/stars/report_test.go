@@ -0,0 +1,86 @@
+package stars
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+	"github.com/lukasz/astras-mono-api/internal/reports"
+)
+
+// Find returns the transactions of the ledger within the filter's range
+func (r *ledgerRepository) Find(ctx context.Context, filter interfaces.TransactionFilter) ([]*transaction.Transaction, error) {
+	var found []*transaction.Transaction
+	for _, t := range r.ledger {
+		if !t.CreatedAt.Before(*filter.From) && t.CreatedAt.Before(*filter.To) {
+			found = append(found, t)
+		}
+	}
+	return found, nil
+}
+
+func TestReport(t *testing.T) {
+	// Three transactions of 2 stars from 08:00 to 10:00 UTC on Wednesday 1 January 2025
+	ledger := newLedger(3)
+
+	tests := []struct {
+		name           string
+		query          map[string]string
+		expectedStatus int
+		expectedFrom   string
+		expectedEarned int
+		expectedDays   int
+	}{
+		{"week", map[string]string{"kid_id": kidUUID(1), "date": "2025-01-01"}, http.StatusOK, "2024-12-30T00:00:00Z", 6, 7},
+		{"month", map[string]string{"kid_id": kidUUID(1), "period": "month", "date": "2025-01-31"}, http.StatusOK, "2025-01-01T00:00:00Z", 6, 31},
+		{"timezone", map[string]string{"kid_id": kidUUID(1), "date": "2025-01-01", "tz": "Asia/Tokyo"}, http.StatusOK, "2024-12-30T00:00:00+09:00", 6, 7},
+		{"previous week", map[string]string{"kid_id": kidUUID(1), "date": "2024-12-29"}, http.StatusOK, "2024-12-23T00:00:00Z", 0, 7},
+		{"missing kid", map[string]string{"date": "2025-01-01"}, http.StatusBadRequest, "", 0, 0},
+		{"unknown period", map[string]string{"kid_id": kidUUID(1), "period": "year"}, http.StatusBadRequest, "", 0, 0},
+		{"unknown timezone", map[string]string{"kid_id": kidUUID(1), "tz": "Mars/Olympus"}, http.StatusBadRequest, "", 0, 0},
+		{"invalid date", map[string]string{"kid_id": kidUUID(1), "date": "01/01/2025"}, http.StatusBadRequest, "", 0, 0},
+		{"too many top descriptions", map[string]string{"kid_id": kidUUID(1), "top": "21"}, http.StatusBadRequest, "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTransactionHandler(&ledgerRepository{ledger: ledger})
+
+			response, err := h.Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod:            http.MethodGet,
+				Path:                  "/v2" + ReportPath,
+				QueryStringParameters: tt.query,
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data reports.Report `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("expected a JSON report, got %s", response.Body)
+			}
+			report := body.Data
+			if got := report.From.Format("2006-01-02T15:04:05Z07:00"); got != tt.expectedFrom {
+				t.Errorf("expected report from %s, got %s", tt.expectedFrom, got)
+			}
+			if report.Earned != tt.expectedEarned || len(report.Days) != tt.expectedDays {
+				t.Errorf("expected %d stars earned over %d days, got %d over %d", tt.expectedEarned, tt.expectedDays, report.Earned, len(report.Days))
+			}
+			if tt.expectedEarned > 0 && report.TopChore() != "Homework" {
+				t.Errorf("expected top chore Homework, got %q", report.TopChore())
+			}
+		})
+	}
+}
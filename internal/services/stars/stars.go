@@ -0,0 +1,730 @@
+// Package stars implements the Star Service handlers.
+// The service manages star transactions in the Astras system,
+// allowing kids to earn and spend stars through various activities.
+package stars
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+	"github.com/lukasz/astras-mono-api/internal/openapi"
+	"github.com/lukasz/astras-mono-api/internal/reports"
+	"github.com/lukasz/astras-mono-api/internal/schema"
+)
+
+// ServiceName identifies the Star Service in responses and logs
+const ServiceName = "star-service"
+
+var (
+	transactionRequestSchema = schema.Generate(TransactionRequest{}, transaction.Transaction{})
+	transactionIDParam       = handler.UUIDPathParam("id", "Transaction ID")
+)
+
+// Routes lists the API Gateway routes served by the Star Service (see template.yaml)
+var Routes = []handler.Route{
+	{
+		Method:  http.MethodGet,
+		Path:    "/transactions",
+		Summary: "List transactions, each with its kid's balance after it",
+		Params: []handler.Param{
+			handler.UUIDQueryParam("kid_id", "Only transactions of this kid"),
+			handler.QueryParam("type", "string", "Only transactions of this type (earn or spend)"),
+			handler.QueryParam("category_id", "integer", "Only transactions in this category"),
+			handler.QueryParam("tag", "string", "Only transactions with this tag"),
+			handler.QueryParam("from", "string", "Only transactions created at or after this RFC 3339 timestamp or YYYY-MM-DD date"),
+			handler.QueryParam("to", "string", "Only transactions created before this RFC 3339 timestamp, or on or before this YYYY-MM-DD date"),
+			handler.QueryParam("sort", "string", "Comma-separated sort fields (id, kid_id, type, amount, created_at, updated_at), prefix with - for descending"),
+		},
+		Response: handler.ResponseSchema([]transaction.Transaction{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/transactions",
+		Summary:  "Create a transaction",
+		Body:     transactionRequestSchema,
+		Status:   http.StatusCreated,
+		Response: handler.ResponseSchema(transaction.Transaction{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     BatchPath,
+		Summary:  "Create many transactions in one database transaction, atomically or best-effort",
+		Body:     batchRequestSchema,
+		Status:   http.StatusCreated,
+		Response: handler.ResponseSchema([]BatchItemResult{}),
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    ExportPath,
+		Summary: "Export the star history of a kid as CSV or NDJSON with running balances",
+		Params: []handler.Param{
+			{Name: "kid_id", In: "query", Description: "Kid whose transactions are exported", Required: true, Schema: &schema.Schema{Type: "string", Format: "uuid"}},
+			handler.QueryParam("from", "string", "Only transactions created at or after this RFC 3339 timestamp or YYYY-MM-DD date"),
+			handler.QueryParam("to", "string", "Only transactions created before this RFC 3339 timestamp, or on or before this YYYY-MM-DD date"),
+			handler.QueryParam("format", "string", "csv (default) or ndjson, overrides the Accept header"),
+		},
+		Response:     schema.Generate(LedgerEntry{}),
+		ContentTypes: []string{"text/csv", "application/x-ndjson"},
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    ReportPath,
+		Summary: "Summarize the stars of a kid over a week or month, compared to the period before",
+		Params: []handler.Param{
+			{Name: "kid_id", In: "query", Description: "Kid the report is about", Required: true, Schema: &schema.Schema{Type: "string", Format: "uuid"}},
+			handler.QueryParam("period", "string", "week (Monday to Sunday, default) or month"),
+			handler.QueryParam("date", "string", "YYYY-MM-DD date within the period, today by default"),
+			handler.QueryParam("tz", "string", "IANA timezone the days are counted in, UTC by default"),
+			handler.QueryParam("top", "integer", "Number of top descriptions listed per type, 3 by default, at most 20"),
+		},
+		Response: handler.ResponseSchema(reports.Report{}),
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    AnalyticsPath,
+		Summary: "Stars earned, spent and the balance per day, week or month, for charts",
+		Params: []handler.Param{
+			handler.UUIDQueryParam("kid_id", "Kid the series is about, required unless family_id is given"),
+			handler.UUIDQueryParam("family_id", "Family whose kids are added up, required unless kid_id is given"),
+			handler.QueryParam("interval", "string", "day (default), week (Monday to Sunday) or month"),
+			handler.QueryParam("tz", "string", "IANA timezone the buckets start at midnight in, UTC by default"),
+			handler.QueryParam("from", "string", "Start of the range as an RFC 3339 timestamp or YYYY-MM-DD date, 30 days, 12 weeks or 12 months before to by default"),
+			handler.QueryParam("to", "string", "End of the range as an RFC 3339 timestamp, or last YYYY-MM-DD date, the end of the current bucket by default"),
+		},
+		Response: handler.ResponseSchema(Series{}),
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    StatsPath,
+		Summary: "All-time star totals of a kid, broken down by category",
+		Params: []handler.Param{
+			{Name: "kid_id", In: "query", Description: "Kid the totals are about", Required: true, Schema: &schema.Schema{Type: "string", Format: "uuid"}},
+		},
+		Response: handler.ResponseSchema(interfaces.TransactionStats{}),
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    BalancePath,
+		Summary: "Star balance of a kid, now or at a point in time",
+		Params: []handler.Param{
+			{Name: "kid_id", In: "query", Description: "Kid the balance is about", Required: true, Schema: &schema.Schema{Type: "string", Format: "uuid"}},
+			handler.QueryParam("at", "string", "RFC 3339 timestamp or YYYY-MM-DD date (end of that day) to get the balance at, now by default"),
+			handler.QueryParam("tz", "string", "IANA timezone of a date-only at (default UTC)"),
+		},
+		Response: handler.ResponseSchema(Balance{}),
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/transactions/{id}",
+		Summary:  "Get a transaction",
+		Params:   []handler.Param{transactionIDParam, handler.IfNoneMatchParam},
+		Response: handler.ResponseSchema(transaction.Transaction{}),
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/transactions/{id}",
+		Summary:  "Replace a transaction",
+		Params:   []handler.Param{transactionIDParam, handler.IfMatchParam},
+		Body:     transactionRequestSchema,
+		Response: handler.ResponseSchema(transaction.Transaction{}),
+	},
+	{
+		Method:   http.MethodPatch,
+		Path:     "/transactions/{id}",
+		Summary:  "Partially update a transaction with a JSON Merge Patch",
+		Params:   []handler.Param{transactionIDParam, handler.IfMatchParam},
+		Body:     schema.Nullable(transactionRequestSchema),
+		Response: handler.ResponseSchema(transaction.Transaction{}),
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     "/transactions/{id}",
+		Summary:  "Delete a transaction",
+		Params:   []handler.Param{transactionIDParam, handler.IfMatchParam},
+		Response: handler.ResponseSchema(nil),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/validate/type",
+		Summary:  "Validate a transaction type",
+		Body:     schema.Generate(ValidationRequest{}),
+		Response: schema.Generate(ValidationResponse{}),
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/validate/amount",
+		Summary:  "Validate a star amount",
+		Body:     schema.Generate(ValidationRequest{}),
+		Response: schema.Generate(ValidationResponse{}),
+	},
+	openapi.SpecRoute,
+}
+
+// TransactionRequest represents the payload for creating or updating a transaction.
+// Used for parsing JSON requests in POST, PUT and PATCH operations.
+type TransactionRequest struct {
+	KidID       string   `json:"kid_id,omitempty" validate:"required,uuid"` // Public ID of the kid
+	Type        string   `json:"type,omitempty" validate:"required"`
+	Amount      int      `json:"amount,omitempty" validate:"required"`
+	Description string   `json:"description,omitempty" validate:"required"`
+	CategoryID  *int     `json:"category_id,omitempty"` // Category of the kid's family, see /families/{id}/categories
+	Tags        []string `json:"tags,omitempty"`        // Free-form labels, stored lowercase
+}
+
+// ValidationRequest represents requests to validation endpoints
+type ValidationRequest struct {
+	Type   string `json:"type,omitempty"`
+	Amount int    `json:"amount,omitempty"`
+}
+
+// ValidationResponse represents the response from validation endpoints
+type ValidationResponse struct {
+	Valid   bool   `json:"valid"`
+	Message string `json:"message,omitempty"`
+}
+
+// ToTransaction converts a TransactionRequest to a Transaction model with generated fields.
+// kidID is the internal ID the request's kid_id resolved to (see TransactionHandler.kidID).
+// Sets timestamps and can accept an optional ID for updates.
+func (tr *TransactionRequest) ToTransaction(kidID int, id ...int) (*transaction.Transaction, error) {
+	transactionModel := &transaction.Transaction{
+		KidID:       kidID,
+		KidPublicID: strings.ToLower(tr.KidID),
+		Type:        transaction.TransactionType(strings.TrimSpace(strings.ToLower(tr.Type))),
+		Amount:      tr.Amount,
+		Description: strings.TrimSpace(tr.Description),
+		CategoryID:  tr.CategoryID,
+		Tags:        tr.Tags,
+		CreatedAt:   time.Now(),
+	}
+
+	if len(id) > 0 && id[0] > 0 {
+		transactionModel.ID = id[0]
+		transactionModel.UpdatedAt = time.Now()
+	}
+
+	if err := transactionModel.Validate(); err != nil {
+		return nil, err
+	}
+
+	return transactionModel, nil
+}
+
+// TransactionHandler implements the handler.Handler interface for star transaction operations.
+// This struct contains all the business logic for managing star transactions in the system.
+type TransactionHandler struct {
+	repo interfaces.TransactionRepository
+}
+
+// NewTransactionHandler creates a new transaction handler with database repository
+func NewTransactionHandler(repo interfaces.TransactionRepository) *TransactionHandler {
+	return &TransactionHandler{
+		repo: repo,
+	}
+}
+
+// GetAll retrieves and returns a list of star transactions in the system.
+// Supports optional filtering by ?kid_id=, ?type=, ?category_id=, ?tag=, ?from= and ?to=
+// and ordering by ?sort=
+// (e.g. ?sort=-created_at). Each transaction carries its kid's running balance, counting
+// the kid's whole ledger rather than only the filtered transactions.
+func (h *TransactionHandler) GetAll(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	filter, err := h.parseTransactionFilter(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	transactions, err := h.repo.Find(ctx, filter)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get all transactions: %w", err)
+	}
+
+	// Convert from []*transaction.Transaction to []transaction.Transaction for JSON response
+	transactionList := make([]transaction.Transaction, len(transactions))
+	for i, t := range transactions {
+		transactionList[i] = *t
+	}
+
+	return handler.Response{
+		Message: "Transactions retrieved successfully",
+		Service: "star-service",
+		Data:    transactionList,
+	}, nil
+}
+
+// parseTransactionFilter builds a repository filter from the list endpoint query string
+func (h *TransactionHandler) parseTransactionFilter(ctx context.Context, request handler.HTTPRequest) (interfaces.TransactionFilter, error) {
+	filter := interfaces.TransactionFilter{
+		Sort: handler.QueryString(request, "sort"),
+	}
+
+	kidID, _, err := h.queryKidID(ctx, request)
+	if err != nil {
+		return interfaces.TransactionFilter{}, err
+	}
+	filter.KidID = kidID
+
+	categoryID, err := handler.QueryInt(request, "category_id")
+	if err != nil {
+		return interfaces.TransactionFilter{}, err
+	}
+	if categoryID != nil {
+		if *categoryID <= 0 {
+			return interfaces.TransactionFilter{}, fmt.Errorf("category_id must be greater than 0")
+		}
+		filter.CategoryID = *categoryID
+	}
+
+	if tag := handler.QueryString(request, "tag"); tag != "" {
+		filter.Tag = strings.ToLower(tag)
+	}
+
+	if transactionType := handler.QueryString(request, "type"); transactionType != "" {
+		if err := transaction.ValidateTransactionType(transactionType); err != nil {
+			return interfaces.TransactionFilter{}, err
+		}
+		filter.Type = transaction.TransactionType(strings.ToLower(transactionType))
+	}
+
+	filter.From, filter.To, err = handler.QueryDateRange(request)
+	if err != nil {
+		return interfaces.TransactionFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// GetByID retrieves a specific star transaction by its unique identifier.
+// Extracts the transaction ID from the URL path parameters and queries the database.
+func (h *TransactionHandler) GetByID(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.transactionID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	transactionModel, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	etag := handler.ETag(transactionModel.UpdatedAt)
+	if handler.NotModified(request, etag) {
+		return handler.Response{
+			StatusCode: http.StatusNotModified,
+			Headers:    map[string]string{"ETag": etag},
+		}, nil
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Transaction %s retrieved successfully", publicID),
+		Service: "star-service",
+		Data:    *transactionModel,
+		Headers: map[string]string{"ETag": etag},
+	}, nil
+}
+
+// Create processes a request to add a new star transaction to the system.
+// Parses the request body JSON and validates the transaction data before creation.
+// Returns the newly created transaction data with a generated ID.
+func (h *TransactionHandler) Create(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	var transactionRequest TransactionRequest
+	// Parse and validate the incoming JSON request body
+	if err := handler.DecodeJSON(request.Body, &transactionRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	kidID, err := h.kidID(ctx, transactionRequest.KidID)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Convert request to model and validate
+	transactionModel, err := transactionRequest.ToTransaction(kidID)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	// Save to database
+	createdTransaction, err := h.repo.Create(ctx, transactionModel)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Transaction created successfully: %s %d stars", createdTransaction.Type, createdTransaction.Amount),
+		Service: "star-service",
+		Data:    *createdTransaction,
+		Headers: map[string]string{"ETag": handler.ETag(createdTransaction.UpdatedAt)},
+	}, nil
+}
+
+// Update modifies an existing star transaction's information in the system.
+// Takes the transaction ID from URL parameters and new data from request body.
+// Returns the updated transaction data after successful modification.
+func (h *TransactionHandler) Update(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.transactionID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	var transactionRequest TransactionRequest
+	// Parse and validate the incoming JSON update data
+	if err := handler.DecodeJSON(request.Body, &transactionRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	kidID, err := h.kidID(ctx, transactionRequest.KidID)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Convert request to model with existing ID and validate
+	transactionModel, err := transactionRequest.ToTransaction(kidID, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	// Update in database
+	updatedTransaction, err := h.repo.Update(ctx, transactionModel, ifMatch...)
+	if err != nil {
+		return handler.Response{}, versionError(err, "failed to update transaction")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Transaction %s updated successfully", publicID),
+		Service: "star-service",
+		Data:    *updatedTransaction,
+		Headers: map[string]string{"ETag": handler.ETag(updatedTransaction.UpdatedAt)},
+	}, nil
+}
+
+// Patch partially modifies an existing star transaction using JSON Merge Patch semantics.
+// The patch is applied to the stored transaction, so omitted fields keep their current
+// values; the merged result is then validated like a full update.
+func (h *TransactionHandler) Patch(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.transactionID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	stored, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	base := TransactionRequest{
+		KidID:       stored.KidPublicID,
+		Type:        string(stored.Type),
+		Amount:      stored.Amount,
+		Description: stored.Description,
+		CategoryID:  stored.CategoryID,
+		Tags:        stored.Tags,
+	}
+
+	var transactionRequest TransactionRequest
+	if err := handler.ApplyMergePatch(base, request.Body, &transactionRequest); err != nil {
+		return handler.Response{}, err
+	}
+
+	kidID, err := h.kidID(ctx, transactionRequest.KidID)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Convert merged request to model with existing ID and validate
+	transactionModel, err := transactionRequest.ToTransaction(kidID, id)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("validation failed: %v", err)
+	}
+
+	updatedTransaction, err := h.repo.Update(ctx, transactionModel, ifMatch...)
+	if err != nil {
+		return handler.Response{}, versionError(err, "failed to update transaction")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Transaction %s updated successfully", publicID),
+		Service: "star-service",
+		Data:    *updatedTransaction,
+		Headers: map[string]string{"ETag": handler.ETag(updatedTransaction.UpdatedAt)},
+	}, nil
+}
+
+// Delete removes a star transaction from the system by its unique identifier.
+// Extracts the transaction ID from URL parameters and performs the deletion operation.
+// Returns a confirmation message upon successful removal.
+func (h *TransactionHandler) Delete(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	id, publicID, err := h.transactionID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Writes must name the version they were based on to avoid lost updates
+	ifMatch, err := handler.IfMatch(request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+
+	// Delete from database
+	if err := h.repo.Delete(ctx, id, ifMatch...); err != nil {
+		return handler.Response{}, versionError(err, "failed to delete transaction")
+	}
+
+	return handler.Response{
+		Message: fmt.Sprintf("Transaction %s deleted successfully", publicID),
+		Service: "star-service",
+	}, nil
+}
+
+// transactionID resolves the public ID in the {id} path parameter to the transaction's internal ID
+func (h *TransactionHandler) transactionID(ctx context.Context, request handler.HTTPRequest) (int, string, error) {
+	publicID := request.PathParameters["id"]
+	if !handler.IsUUID(publicID) {
+		return 0, "", fmt.Errorf("invalid transaction ID: %s", publicID)
+	}
+	publicID = strings.ToLower(publicID)
+
+	id, err := h.repo.ResolveID(ctx, publicID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			return 0, "", handler.NewError(http.StatusNotFound, err.Error())
+		}
+		return 0, "", fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return id, publicID, nil
+}
+
+// kidID resolves the public ID of a kid, as given in a request body, to the kid's internal ID
+func (h *TransactionHandler) kidID(ctx context.Context, publicID string) (int, error) {
+	if !handler.IsUUID(publicID) {
+		return 0, fmt.Errorf("invalid kid_id: %s", publicID)
+	}
+	return h.repo.ResolveKidID(ctx, strings.ToLower(publicID))
+}
+
+// queryKidID resolves the kid_id query-string parameter to the kid's internal ID.
+// Returns the normalized public ID as well, empty when the parameter is absent.
+func (h *TransactionHandler) queryKidID(ctx context.Context, request handler.HTTPRequest) (int, string, error) {
+	publicID, err := handler.QueryUUID(request, "kid_id")
+	if err != nil || publicID == "" {
+		return 0, "", err
+	}
+
+	id, err := h.repo.ResolveKidID(ctx, publicID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, publicID, nil
+}
+
+// HandleCustomRequest handles custom validation endpoints
+func (h *TransactionHandler) HandleCustomRequest(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	path := request.Path
+	method := request.HTTPMethod
+
+	headers := map[string]string{
+		"Content-Type":                 "application/json",
+		"Access-Control-Allow-Origin":  "*",
+		"Access-Control-Allow-Headers": "Content-Type, If-Match, If-None-Match",
+		"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+	}
+
+	if method == "OPTIONS" {
+		return handler.HTTPResponse{
+			StatusCode: 200,
+			Headers:    headers,
+		}, nil
+	}
+
+	switch {
+	case strings.HasSuffix(path, "/validate/type"):
+		return h.handleTypeValidation(request, headers)
+	case strings.HasSuffix(path, "/validate/amount"):
+		return h.handleAmountValidation(request, headers)
+	}
+
+	return handler.HTTPResponse{
+		StatusCode: 404,
+		Headers:    headers,
+		Body:       `{"error": "endpoint not found"}`,
+	}, nil
+}
+
+// handleTypeValidation validates transaction type
+func (h *TransactionHandler) handleTypeValidation(request handler.HTTPRequest, headers map[string]string) (handler.HTTPResponse, error) {
+	var req ValidationRequest
+	if err := handler.DecodeJSON(request.Body, &req); err != nil {
+		response := ValidationResponse{
+			Valid:   false,
+			Message: "Invalid JSON format",
+		}
+		body, _ := json.Marshal(response)
+		return handler.HTTPResponse{
+			StatusCode: 400,
+			Headers:    headers,
+			Body:       string(body),
+		}, nil
+	}
+
+	err := transaction.ValidateTransactionType(req.Type)
+	response := ValidationResponse{
+		Valid: err == nil,
+	}
+	if err != nil {
+		response.Message = err.Error()
+	}
+
+	body, _ := json.Marshal(response)
+	statusCode := 200
+	if !response.Valid {
+		statusCode = 400
+	}
+
+	return handler.HTTPResponse{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Body:       string(body),
+	}, nil
+}
+
+// handleAmountValidation validates transaction amount
+func (h *TransactionHandler) handleAmountValidation(request handler.HTTPRequest, headers map[string]string) (handler.HTTPResponse, error) {
+	var req ValidationRequest
+	if err := handler.DecodeJSON(request.Body, &req); err != nil {
+		response := ValidationResponse{
+			Valid:   false,
+			Message: "Invalid JSON format",
+		}
+		body, _ := json.Marshal(response)
+		return handler.HTTPResponse{
+			StatusCode: 400,
+			Headers:    headers,
+			Body:       string(body),
+		}, nil
+	}
+
+	err := transaction.ValidateAmount(req.Amount)
+	response := ValidationResponse{
+		Valid: err == nil,
+	}
+	if err != nil {
+		response.Message = err.Error()
+	}
+
+	body, _ := json.Marshal(response)
+	statusCode := 200
+	if !response.Valid {
+		statusCode = 400
+	}
+
+	return handler.HTTPResponse{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Body:       string(body),
+	}, nil
+}
+
+// versionError reports a failed If-Match precondition as 412 Precondition Failed
+// and wraps any other repository error with the given context.
+func versionError(err error, action string) error {
+	if errors.Is(err, interfaces.ErrVersionConflict) {
+		return handler.NewError(http.StatusPreconditionFailed, "transaction has been modified, fetch the latest version and retry")
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+// Handle is the entry point for all HTTP requests to the Star Service.
+// It handles both CRUD operations and validation endpoints in every API version.
+func (h *TransactionHandler) Handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	return handler.ServeVersion(ctx, request, Routes, handler.DefaultSchedule, h.handle)
+}
+
+// handle serves a request in the API version resolved by Handle
+func (h *TransactionHandler) handle(ctx context.Context, request handler.HTTPRequest) (handler.HTTPResponse, error) {
+	// Serve the API contract generated from the routes
+	if request.Path == openapi.Path {
+		return openapi.Serve(ServiceName, Routes, request.Version)
+	}
+
+	// Reject malformed bodies with field-level errors before any handler logic
+	if err := handler.ValidateRequest(request, Routes); err != nil {
+		return handler.ErrorResponse(err), nil
+	}
+
+	// Check for custom validation endpoints
+	if strings.Contains(request.Path, "/validate/") {
+		return h.HandleCustomRequest(ctx, request)
+	}
+
+	if request.HTTPMethod == http.MethodPost && strings.HasSuffix(request.Path, BatchPath) {
+		response, err := h.CreateBatch(ctx, request)
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return handler.Respond(http.StatusCreated, response), nil
+	}
+
+	if request.HTTPMethod == http.MethodGet && strings.HasSuffix(request.Path, ExportPath) {
+		response, err := h.Export(ctx, request)
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return response, nil
+	}
+
+	if request.HTTPMethod == http.MethodGet && strings.HasSuffix(request.Path, AnalyticsPath) {
+		response, err := h.Analytics(ctx, request)
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return handler.Respond(http.StatusOK, response), nil
+	}
+
+	if request.HTTPMethod == http.MethodGet && strings.HasSuffix(request.Path, BalancePath) {
+		response, err := h.Balance(ctx, request)
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return handler.Respond(http.StatusOK, response), nil
+	}
+
+	if request.HTTPMethod == http.MethodGet && strings.HasSuffix(request.Path, StatsPath) {
+		response, err := h.Stats(ctx, request)
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return handler.Respond(http.StatusOK, response), nil
+	}
+
+	if request.HTTPMethod == http.MethodGet && strings.HasSuffix(request.Path, ReportPath) {
+		response, err := h.Report(ctx, request)
+		if err != nil {
+			return handler.ErrorResponse(err), nil
+		}
+		return handler.Respond(http.StatusOK, response), nil
+	}
+
+	return handler.HandleRequest(ctx, request, h)
+}
@@ -0,0 +1,166 @@
+package stars
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+	"github.com/lukasz/astras-mono-api/internal/models/transaction"
+)
+
+// publicIDRepository resolves the public IDs built by kidUUID, transactionUUID and familyUUID
+// back to internal IDs; any other UUID is not found
+type publicIDRepository struct {
+	interfaces.TransactionRepository
+}
+
+// kidUUID returns the public ID of the kid with the given internal ID
+func kidUUID(id int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", id)
+}
+
+// familyUUID returns the public ID of the family with the given internal ID
+func familyUUID(id int) string {
+	return fmt.Sprintf("00000000-0000-4000-a000-%012d", id)
+}
+
+// transactionUUID returns the public ID of the transaction with the given internal ID
+func transactionUUID(id int) string {
+	return fmt.Sprintf("00000000-0000-4000-9000-%012d", id)
+}
+
+func (publicIDRepository) ResolveKidID(ctx context.Context, publicID string) (int, error) {
+	var id int
+	if _, err := fmt.Sscanf(publicID, "00000000-0000-4000-8000-%d", &id); err != nil {
+		return 0, fmt.Errorf("kid with id %s %w", publicID, interfaces.ErrNotFound)
+	}
+	return id, nil
+}
+
+func (publicIDRepository) ResolveID(ctx context.Context, publicID string) (int, error) {
+	var id int
+	if _, err := fmt.Sscanf(publicID, "00000000-0000-4000-9000-%d", &id); err != nil {
+		return 0, fmt.Errorf("transaction with id %s %w", publicID, interfaces.ErrNotFound)
+	}
+	return id, nil
+}
+
+func (publicIDRepository) ResolveFamilyID(ctx context.Context, publicID string) (int, error) {
+	var id int
+	if _, err := fmt.Sscanf(publicID, "00000000-0000-4000-a000-%d", &id); err != nil {
+		return 0, fmt.Errorf("family with id %s %w", publicID, interfaces.ErrNotFound)
+	}
+	return id, nil
+}
+
+// crudRepository keeps the last transaction it was given
+type crudRepository struct {
+	publicIDRepository
+	stored *transaction.Transaction
+}
+
+func (r *crudRepository) GetByID(ctx context.Context, id int) (*transaction.Transaction, error) {
+	return &transaction.Transaction{
+		ID:          id,
+		PublicID:    transactionUUID(id),
+		KidID:       1,
+		KidPublicID: kidUUID(1),
+		Type:        transaction.TransactionTypeEarn,
+		Amount:      2,
+		Description: "Homework",
+	}, nil
+}
+
+func (r *crudRepository) Create(ctx context.Context, t *transaction.Transaction) (*transaction.Transaction, error) {
+	r.stored = t
+	created := *t
+	created.ID = 5
+	created.PublicID = transactionUUID(5)
+	return &created, nil
+}
+
+func TestGetByPublicID(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{"public id", transactionUUID(3), http.StatusOK},
+		{"uppercase public id", strings.ToUpper(transactionUUID(3)), http.StatusOK},
+		{"sequential id", "3", http.StatusBadRequest},
+		{"unknown public id", "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTransactionHandler(&crudRepository{})
+
+			response, err := h.Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod: http.MethodGet,
+				Path:       "/v2/transactions/" + tt.id,
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data map[string]any `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body.Data["id"] != transactionUUID(3) || body.Data["kid_id"] != kidUUID(1) {
+				t.Errorf("expected public IDs %s and %s, got %v and %v", transactionUUID(3), kidUUID(1), body.Data["id"], body.Data["kid_id"])
+			}
+		})
+	}
+}
+
+func TestCreateResolvesKid(t *testing.T) {
+	tests := []struct {
+		name           string
+		kidID          string
+		expectedStatus int
+		expectedKidID  int
+	}{
+		{"public kid id", kidUUID(7), http.StatusCreated, 7},
+		{"sequential kid id", "7", http.StatusBadRequest, 0},
+		{"unknown kid", "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &crudRepository{}
+			h := NewTransactionHandler(repo)
+
+			response, err := h.Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod: http.MethodPost,
+				Path:       "/v2/transactions",
+				Body:       fmt.Sprintf(`{"kid_id":%q,"type":"earn","amount":5,"description":"Cleaned up"}`, tt.kidID),
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+			if repo.stored.KidID != tt.expectedKidID || repo.stored.KidPublicID != tt.kidID {
+				t.Errorf("expected kid %d (%s), got %d (%s)", tt.expectedKidID, tt.kidID, repo.stored.KidID, repo.stored.KidPublicID)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package stars
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lukasz/astras-mono-api/internal/handler"
+)
+
+// StatsPath is the resource returning the all-time star totals of a kid
+const StatsPath = "/transactions/stats"
+
+// Stats returns the all-time totals of a kid, broken down by category
+func (h *TransactionHandler) Stats(ctx context.Context, request handler.HTTPRequest) (handler.Response, error) {
+	kidID, kidPublicID, err := h.queryKidID(ctx, request)
+	if err != nil {
+		return handler.Response{}, err
+	}
+	if kidPublicID == "" {
+		return handler.Response{}, fmt.Errorf("kid_id is required")
+	}
+
+	stats, err := h.repo.GetKidTransactionStats(ctx, kidID)
+	if err != nil {
+		return handler.Response{}, fmt.Errorf("failed to get transaction stats: %w", err)
+	}
+	stats.KidPublicID = kidPublicID
+
+	return handler.Response{
+		Message: fmt.Sprintf("Kid %s has a balance of %d stars", kidPublicID, stats.Balance),
+		Service: ServiceName,
+		Data:    *stats,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package stars
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/handler"
+)
+
+// statsRepository returns fixed stats with one category and the uncategorized rest
+type statsRepository struct {
+	publicIDRepository
+}
+
+func (r *statsRepository) GetKidTransactionStats(ctx context.Context, kidID int) (*interfaces.TransactionStats, error) {
+	categoryID := 3
+	return &interfaces.TransactionStats{
+		KidID:       kidID,
+		TotalEarned: 12,
+		TotalSpent:  5,
+		Balance:     7,
+		Categories: []interfaces.CategoryStats{
+			{CategoryID: &categoryID, Name: "Chores", TotalEarned: 10, EarnCount: 2},
+			{TotalEarned: 2, TotalSpent: 5, EarnCount: 1, SpendCount: 1},
+		},
+	}, nil
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          map[string]string
+		expectedStatus int
+	}{
+		{"kid stats", map[string]string{"kid_id": kidUUID(1)}, http.StatusOK},
+		{"missing kid", map[string]string{}, http.StatusBadRequest},
+		{"invalid kid", map[string]string{"kid_id": "0"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTransactionHandler(&statsRepository{})
+
+			response, err := h.Handle(context.Background(), handler.HTTPRequest{
+				HTTPMethod:            http.MethodGet,
+				Path:                  "/v2" + StatsPath,
+				QueryStringParameters: tt.query,
+			})
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, response.StatusCode, response.Body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data interfaces.TransactionStats `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(body.Data.Categories) != 2 || body.Data.Categories[0].Name != "Chores" || body.Data.Categories[1].CategoryID != nil {
+				t.Errorf("expected Chores then uncategorized, got %+v", body.Data.Categories)
+			}
+		})
+	}
+}
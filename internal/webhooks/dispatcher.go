@@ -0,0 +1,189 @@
+// Package webhooks delivers domain events to the webhooks families subscribe.
+// Sink fans events out from the outbox relay into one pending delivery per
+// subscribed webhook; Dispatcher sends the due deliveries as signed JSON POSTs,
+// retries failures with exponential backoff and disables webhooks that keep failing.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+	"github.com/lukasz/astras-mono-api/internal/models/webhook"
+)
+
+// RetryPolicy says how often and how long failed deliveries are retried
+type RetryPolicy struct {
+	MaxAttempts  int           // Attempts per delivery before it fails for good
+	BaseDelay    time.Duration // Delay after the first failed attempt, doubled after every further one
+	MaxDelay     time.Duration // Longest delay between attempts
+	DisableAfter int           // Consecutive failures, across deliveries, that disable a webhook
+	Timeout      time.Duration // Time a receiver has to answer
+}
+
+// DefaultRetryPolicy retries a delivery for about four hours
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  10,
+	BaseDelay:    30 * time.Second,
+	MaxDelay:     6 * time.Hour,
+	DisableAfter: 50,
+	Timeout:      10 * time.Second,
+}
+
+// Backoff returns the delay before the next attempt after the given number of failed attempts
+func (p RetryPolicy) Backoff(failures int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Dispatcher sends due webhook deliveries
+type Dispatcher struct {
+	repo   interfaces.WebhookRepository
+	policy RetryPolicy
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewDispatcher creates a dispatcher sending the deliveries of repo. The optional client
+// replaces the default one, which times out after policy.Timeout, does not follow
+// redirects, so a receiver cannot bounce deliveries elsewhere, and only connects to
+// public addresses (see publicTransport).
+func NewDispatcher(repo interfaces.WebhookRepository, policy RetryPolicy, client ...*http.Client) *Dispatcher {
+	d := &Dispatcher{
+		repo:   repo,
+		policy: policy,
+		client: &http.Client{
+			Transport:     publicTransport(),
+			Timeout:       policy.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		now: time.Now,
+	}
+	if len(client) > 0 && client[0] != nil {
+		d.client = client[0]
+	}
+	return d
+}
+
+// publicTransport is an HTTP transport that refuses to connect to addresses rejected by
+// webhook.CheckAddress. The check runs on the resolved IP right before connecting, so a
+// public hostname cannot resolve, or be rebound, to an internal service. Proxies are not
+// used, as the transport would only see the proxy's address.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			return webhook.CheckAddress(addr.Addr())
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// Dispatch sends up to limit due deliveries and returns how many it attempted.
+// A delivery is claimed for twice the timeout, so another dispatcher only retries it
+// when this one crashed.
+func (d *Dispatcher) Dispatch(ctx context.Context, limit int) (int, error) {
+	due, err := d.repo.ClaimDue(ctx, limit, 2*d.policy.Timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, delivery := range due {
+		attempt := d.send(ctx, delivery)
+		if failures := delivery.Delivery.Attempts + 1; !attempt.Succeeded() && failures < d.policy.MaxAttempts {
+			next := d.now().Add(d.policy.Backoff(failures))
+			attempt.NextAttemptAt = &next
+		}
+
+		if _, err := d.repo.RecordAttempt(ctx, delivery, attempt, d.policy.DisableAfter); err != nil {
+			return i, err
+		}
+	}
+
+	return len(due), nil
+}
+
+// send posts one delivery. Any 2xx response accepts it.
+func (d *Dispatcher) send(ctx context.Context, due *interfaces.DueDelivery) webhook.Attempt {
+	body := []byte(due.Delivery.Body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, due.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return webhook.Attempt{Error: fmt.Sprintf("invalid webhook request: %v", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Astras-Webhooks/1.0")
+	req.Header.Set(webhook.EventHeader, due.Delivery.EventType)
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatInt(due.Delivery.ID, 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(due.Webhook.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return webhook.Attempt{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return webhook.Attempt{StatusCode: resp.StatusCode, Error: fmt.Sprintf("receiver answered %d", resp.StatusCode)}
+	}
+	return webhook.Attempt{StatusCode: resp.StatusCode}
+}
+
+// Sink is an outbox.Sink creating webhook deliveries of the events families can
+// subscribe to. Events of other types are accepted without deliveries.
+type Sink struct {
+	repo interfaces.WebhookRepository
+}
+
+// NewSink creates a sink enqueuing deliveries in repo
+func NewSink(repo interfaces.WebhookRepository) *Sink {
+	return &Sink{repo: repo}
+}
+
+// Publish enqueues a delivery of the event for each webhook of the family of its kid
+func (s *Sink) Publish(ctx context.Context, e *event.Event) error {
+	if !webhook.Subscribable(e.Type) {
+		return nil
+	}
+
+	// Payloads carry public IDs: the kid's own for kid events, its kid's for transactions
+	var record struct {
+		ID    string `json:"id"`
+		KidID string `json:"kid_id"`
+	}
+	if err := json.Unmarshal(e.Payload, &record); err != nil {
+		return fmt.Errorf("failed to decode %s of event %d: %w", e.Entity, e.ID, err)
+	}
+	kidID := record.ID
+	if e.Entity == "transaction" {
+		kidID = record.KidID
+	}
+
+	_, err := s.repo.Enqueue(ctx, e, kidID)
+	return err
+}
@@ -0,0 +1,234 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukasz/astras-mono-api/internal/database/interfaces"
+	"github.com/lukasz/astras-mono-api/internal/models/event"
+	"github.com/lukasz/astras-mono-api/internal/models/webhook"
+)
+
+// memoryWebhooks keeps one webhook and its deliveries in memory
+type memoryWebhooks struct {
+	interfaces.WebhookRepository
+	webhook    *webhook.Webhook
+	deliveries []*webhook.Delivery
+	enqueued   map[int]string // Public kid ID of each enqueued event, by event ID
+}
+
+func (r *memoryWebhooks) Enqueue(ctx context.Context, e *event.Event, kidID string) (int, error) {
+	if r.enqueued == nil {
+		r.enqueued = map[int]string{}
+	}
+	r.enqueued[int(e.ID)] = kidID
+	return 1, nil
+}
+
+func (r *memoryWebhooks) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*interfaces.DueDelivery, error) {
+	var due []*interfaces.DueDelivery
+	for _, d := range r.deliveries {
+		if len(due) < limit && r.webhook.Active && d.Status == webhook.DeliveryPending {
+			due = append(due, &interfaces.DueDelivery{Delivery: d, Webhook: r.webhook})
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryWebhooks) RecordAttempt(ctx context.Context, due *interfaces.DueDelivery, attempt webhook.Attempt, disableAfter int) (bool, error) {
+	d := due.Delivery
+	d.Attempts++
+	d.NextAttemptAt = attempt.NextAttemptAt
+	switch {
+	case attempt.Succeeded():
+		d.Status = webhook.DeliverySucceeded
+		r.webhook.ConsecutiveFailures = 0
+		return false, nil
+	case attempt.NextAttemptAt == nil:
+		d.Status = webhook.DeliveryFailed
+	}
+
+	r.webhook.ConsecutiveFailures++
+	if r.webhook.ConsecutiveFailures >= disableAfter {
+		r.webhook.Active = false
+		return true, nil
+	}
+	return false, nil
+}
+
+func TestDispatch(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour, DisableAfter: 2, Timeout: time.Second}
+
+	tests := []struct {
+		name             string
+		status           int
+		attempts         int
+		expectedStatus   webhook.DeliveryStatus
+		expectedNext     *time.Time
+		expectedActive   bool
+		expectedFailures int
+	}{
+		{"accepted", http.StatusNoContent, 0, webhook.DeliverySucceeded, nil, true, 0},
+		{"first failure is retried", http.StatusInternalServerError, 0, webhook.DeliveryPending, timePtr(now.Add(time.Minute)), true, 1},
+		{"later failures back off", http.StatusBadGateway, 1, webhook.DeliveryPending, timePtr(now.Add(2 * time.Minute)), true, 1},
+		{"last attempt gives up", http.StatusNotFound, 2, webhook.DeliveryFailed, nil, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"id":9,"type":"stars.earned"}`
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ := io.ReadAll(r.Body)
+				if err := webhook.Verify("whsec_test", r.Header.Get(webhook.SignatureHeader), received, time.Minute, now); err != nil {
+					t.Errorf("expected a valid signature, got %v", err)
+				}
+				if r.Header.Get(webhook.EventHeader) != "stars.earned" || r.Header.Get(webhook.DeliveryHeader) != "5" {
+					t.Errorf("expected event and delivery headers, got %v", r.Header)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			repo := &memoryWebhooks{
+				webhook: &webhook.Webhook{ID: 1, URL: server.URL, Secret: "whsec_test", Active: true},
+				deliveries: []*webhook.Delivery{
+					{ID: 5, WebhookID: 1, EventID: 9, EventType: "stars.earned", Body: body, Status: webhook.DeliveryPending, Attempts: tt.attempts},
+				},
+			}
+			dispatcher := NewDispatcher(repo, policy, server.Client())
+			dispatcher.now = func() time.Time { return now }
+
+			n, err := dispatcher.Dispatch(context.Background(), 10)
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if n != 1 {
+				t.Errorf("expected 1 delivery attempted, got %d", n)
+			}
+
+			d := repo.deliveries[0]
+			if d.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, d.Status)
+			}
+			if (d.NextAttemptAt == nil) != (tt.expectedNext == nil) || (d.NextAttemptAt != nil && !d.NextAttemptAt.Equal(*tt.expectedNext)) {
+				t.Errorf("expected next attempt at %v, got %v", tt.expectedNext, d.NextAttemptAt)
+			}
+			if repo.webhook.Active != tt.expectedActive || repo.webhook.ConsecutiveFailures != tt.expectedFailures {
+				t.Errorf("expected active %v with %d failures, got %v with %d",
+					tt.expectedActive, tt.expectedFailures, repo.webhook.Active, repo.webhook.ConsecutiveFailures)
+			}
+		})
+	}
+}
+
+func TestDispatchDisablesFailingWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := &memoryWebhooks{
+		webhook: &webhook.Webhook{ID: 1, URL: server.URL, Secret: "whsec_test", Active: true},
+		deliveries: []*webhook.Delivery{
+			{ID: 1, WebhookID: 1, EventType: "stars.earned", Body: "{}", Status: webhook.DeliveryPending},
+			{ID: 2, WebhookID: 1, EventType: "stars.spent", Body: "{}", Status: webhook.DeliveryPending},
+			{ID: 3, WebhookID: 1, EventType: "kid.updated", Body: "{}", Status: webhook.DeliveryPending},
+		},
+	}
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Minute, MaxDelay: time.Hour, DisableAfter: 3, Timeout: time.Second}
+
+	if _, err := NewDispatcher(repo, policy, server.Client()).Dispatch(context.Background(), 10); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if repo.webhook.Active {
+		t.Errorf("expected the webhook to be disabled after %d failures", policy.DisableAfter)
+	}
+
+	n, err := NewDispatcher(repo, policy, server.Client()).Dispatch(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected no deliveries to a disabled webhook, got %d", n)
+	}
+}
+
+func TestDispatchRefusesInternalAddresses(t *testing.T) {
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	due := &interfaces.DueDelivery{
+		Delivery: &webhook.Delivery{ID: 1, WebhookID: 1, EventType: "stars.earned", Body: "{}", Status: webhook.DeliveryPending},
+		Webhook:  &webhook.Webhook{ID: 1, URL: server.URL, Secret: "whsec_test", Active: true},
+	}
+	attempt := NewDispatcher(&memoryWebhooks{}, DefaultRetryPolicy).send(context.Background(), due)
+
+	if reached {
+		t.Errorf("expected the default client not to connect to %s", server.URL)
+	}
+	if attempt.Succeeded() || !strings.Contains(attempt.Error, "non-public address") {
+		t.Errorf("expected a failed attempt for a non-public address, got %q", attempt.Error)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if delay := DefaultRetryPolicy.Backoff(tt.failures); delay != tt.expected {
+			t.Errorf("expected backoff %v after %d failures, got %v", tt.expected, tt.failures, delay)
+		}
+	}
+}
+
+func TestSinkPublish(t *testing.T) {
+	const kidID = "6f1c2b7e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"
+	transaction, _ := json.Marshal(map[string]any{"id": "0b9e4c1a-2f3d-4a5b-8c6d-7e8f9a0b1c2d", "kid_id": kidID, "amount": 3})
+
+	tests := []struct {
+		name          string
+		event         *event.Event
+		expectedKidID string
+		expectedQueue bool
+	}{
+		{"transaction event uses its kid", &event.Event{ID: 1, Type: event.StarsEarned, Entity: "transaction", EntityID: 4, Payload: transaction}, kidID, true},
+		{"kid event uses the kid", &event.Event{ID: 2, Type: event.KidUpdated, Entity: "kid", EntityID: 7, Payload: []byte(`{"id":"` + kidID + `"}`)}, kidID, true},
+		{"caregiver events are not subscribable", &event.Event{ID: 3, Type: event.CaregiverCreated, Entity: "caregiver", EntityID: 2}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryWebhooks{}
+			if err := NewSink(repo).Publish(context.Background(), tt.event); err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			kidID, queued := repo.enqueued[int(tt.event.ID)]
+			if queued != tt.expectedQueue || kidID != tt.expectedKidID {
+				t.Errorf("expected enqueued %v for kid %q, got %v for kid %q", tt.expectedQueue, tt.expectedKidID, queued, kidID)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
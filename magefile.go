@@ -23,6 +23,8 @@ var services = []string{
 	"kid-service",
 	"caregiver-service",
 	"star-service",
+	"family-service",
+	"audit-service",
 	"migration-service",
 }
 
@@ -61,6 +63,16 @@ func (Build) Star() error {
 	return buildService("star-service")
 }
 
+// Build Family service
+func (Build) Family() error {
+	return buildService("family-service")
+}
+
+// Build Audit service
+func (Build) Audit() error {
+	return buildService("audit-service")
+}
+
 // Build Migration service
 func (Build) Migration() error {
 	return buildService("migration-service")
@@ -81,6 +93,16 @@ func (Build) StarLocal() error {
 	return buildServiceLocal("star-service")
 }
 
+// Build Family service for local development
+func (Build) FamilyLocal() error {
+	return buildServiceLocal("family-service")
+}
+
+// Build Audit service for local development
+func (Build) AuditLocal() error {
+	return buildServiceLocal("audit-service")
+}
+
 // Build Migration service for local development
 func (Build) MigrationLocal() error {
 	return buildServiceLocal("migration-service")
@@ -137,6 +159,17 @@ func buildServiceLocal(service string) error {
 	return sh.RunWithV(env, "go", "build", "-ldflags", "-s -w", "-o", outputPath, "./"+servicePath)
 }
 
+type Run mg.Namespace
+
+// Run all services on a single local HTTP server (port 3000, override with PORT)
+func (Run) Local() error {
+	fmt.Println("Starting local API server...")
+	env := map[string]string{
+		"MIGRATIONS_PATH": filepath.Join("database", "migrations"),
+	}
+	return sh.RunWithV(env, "go", "run", "./cmd/astras-local")
+}
+
 type Deploy mg.Namespace
 
 // Deploy all services
@@ -178,6 +211,18 @@ func (Deploy) Star() error {
 	return deployService("star-service")
 }
 
+// Deploy Family service
+func (Deploy) Family() error {
+	mg.Deps(Build.Family)
+	return deployService("family-service")
+}
+
+// Deploy Audit service
+func (Deploy) Audit() error {
+	mg.Deps(Build.Audit)
+	return deployService("audit-service")
+}
+
 // Deploy Migration service
 func (Deploy) Migration() error {
 	mg.Deps(Build.Migration)
@@ -362,10 +407,17 @@ func Default() {
 	fmt.Println("  mage build:caregiverLocal - Build caregiver service (for local development)")
 	fmt.Println("  mage build:star       - Build star service")
 	fmt.Println("  mage build:starLocal  - Build star service (for local development)")
+	fmt.Println("  mage build:family     - Build family service")
+	fmt.Println("  mage build:familyLocal - Build family service (for local development)")
+	fmt.Println("  mage build:audit      - Build audit service")
+	fmt.Println("  mage build:auditLocal - Build audit service (for local development)")
+	fmt.Println("  mage run:local        - Run all services on one local HTTP server")
 	fmt.Println("  mage deploy:all       - Deploy all services")
 	fmt.Println("  mage deploy:kid       - Deploy kid service")
 	fmt.Println("  mage deploy:caregiver - Deploy caregiver service")
 	fmt.Println("  mage deploy:star      - Deploy star service")
+	fmt.Println("  mage deploy:family    - Deploy family service")
+	fmt.Println("  mage deploy:audit     - Deploy audit service")
 	fmt.Println("  mage test:all         - Run all tests")
 	fmt.Println("  mage test:coverage    - Run tests with coverage")
 	fmt.Println("  mage clean:all        - Clean all artifacts")